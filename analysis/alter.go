@@ -0,0 +1,483 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"strings"
+
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/charset"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/terror"
+	"github.com/pingcap/parser/types"
+)
+
+// The errors returned by ClassifyAlter.
+var (
+	ErrAlterOperationNotSupported       = terror.ClassDDL.NewStd(mysql.ErrAlterOperationNotSupported)
+	ErrAlterOperationNotSupportedReason = terror.ClassDDL.NewStd(mysql.ErrAlterOperationNotSupportedReason)
+	ErrWrongUsage                       = terror.ClassDDL.NewStd(mysql.ErrWrongUsage)
+	ErrBadField                         = terror.ClassDDL.NewStd(mysql.ErrBadField)
+	ErrCantDropFieldOrKey               = terror.ClassDDL.NewStd(mysql.ErrCantDropFieldOrKey)
+)
+
+// AlterImpact is how an InnoDB table of MySQL 8.0 is altered.
+type AlterImpact struct {
+	// Algorithm is the fastest algorithm supported, which is AlgorithmTypeInstant,
+	// AlgorithmTypeInplace or AlgorithmTypeCopy.
+	Algorithm ast.AlgorithmType
+	// Rebuild is true if the table is rebuilt.
+	Rebuild bool
+	// Lock is the least restrictive lock permitted, which is LockTypeNone,
+	// LockTypeShared or LockTypeExclusive.
+	Lock ast.LockType
+	// Reason is why a faster algorithm or a less restrictive lock isn't supported,
+	// it's empty if there is no specific reason.
+	Reason string
+}
+
+// SpecImpact is the AlterImpact of a spec.
+type SpecImpact struct {
+	Spec *ast.AlterTableSpec
+	AlterImpact
+}
+
+// AlterReport is the result of ClassifyAlter. The embedded AlterImpact is of the
+// whole statement, which is the slowest algorithm and the most restrictive lock
+// of the specs.
+type AlterReport struct {
+	AlterImpact
+	// Specs are the impacts of the specs, except the ALGORITHM and LOCK options.
+	Specs []SpecImpact
+	// Errors are the errors returned by MySQL for the explicit ALGORITHM and LOCK
+	// options not supported by the specs.
+	Errors []error
+}
+
+var (
+	instantImpact = AlterImpact{Algorithm: ast.AlgorithmTypeInstant, Lock: ast.LockTypeNone}
+	inplaceImpact = AlterImpact{Algorithm: ast.AlgorithmTypeInplace, Lock: ast.LockTypeNone}
+	rebuildImpact = AlterImpact{Algorithm: ast.AlgorithmTypeInplace, Rebuild: true, Lock: ast.LockTypeNone}
+	copyImpact    = AlterImpact{Algorithm: ast.AlgorithmTypeCopy, Rebuild: true, Lock: ast.LockTypeShared}
+)
+
+// withReason returns the impact with the reason of the errcode.
+func (a AlterImpact) withReason(code uint16) AlterImpact {
+	a.Reason = mysql.MySQLErrName[code].Raw
+	return a
+}
+
+// merge returns the impact of both a and b, the reason is of the slower one.
+func (a AlterImpact) merge(b AlterImpact) AlterImpact {
+	if b.Reason != "" && (a.Reason == "" || b.Algorithm < a.Algorithm || b.Lock > a.Lock) {
+		a.Reason = b.Reason
+	}
+	if b.Algorithm < a.Algorithm {
+		// AlgorithmTypeCopy is the slowest.
+		a.Algorithm = b.Algorithm
+	}
+	if b.Lock > a.Lock {
+		a.Lock = b.Lock
+	}
+	a.Rebuild = a.Rebuild || b.Rebuild
+	return a
+}
+
+// ClassifyAlter classifies the specs of stmt, which alters table, by the algorithm,
+// the rebuild and the lock of MySQL 8.0 before 8.0.29, which only adds the last
+// columns instantly. It returns an error if a column altered is not in table.
+//
+// The impacts are of the InnoDB tables with the default settings, such as
+// foreign_key_checks=ON, and are conservative for the specs not supported by
+// InnoDB online DDL, which are classified as COPY.
+func ClassifyAlter(stmt *ast.AlterTableStmt, table *model.TableInfo) (*AlterReport, error) {
+	c := &alterClassifier{table: table}
+	var algorithm ast.AlgorithmType
+	lock := ast.LockTypeDefault
+	for _, spec := range stmt.Specs {
+		switch spec.Tp {
+		case ast.AlterTableAlgorithm:
+			algorithm = spec.Algorithm
+		case ast.AlterTableLock:
+			lock = spec.LockType
+		case ast.AlterTableAddConstraint:
+			if spec.Constraint != nil && spec.Constraint.Tp == ast.ConstraintPrimaryKey {
+				c.addsPrimaryKey = true
+			}
+		}
+	}
+	report := &AlterReport{AlterImpact: instantImpact}
+	for _, spec := range stmt.Specs {
+		if spec.Tp == ast.AlterTableAlgorithm || spec.Tp == ast.AlterTableLock {
+			continue
+		}
+		impact, err := c.classify(spec)
+		if err != nil {
+			return nil, err
+		}
+		report.Specs = append(report.Specs, SpecImpact{Spec: spec, AlterImpact: impact})
+		report.AlterImpact = report.merge(impact)
+	}
+	report.Errors = report.check(algorithm, lock)
+	return report, nil
+}
+
+// check returns the errors of the explicit algorithm and lock.
+func (r *AlterReport) check(algorithm ast.AlgorithmType, lock ast.LockType) []error {
+	var errs []error
+	notSupported := func(option string, reason string, try string) {
+		if reason == "" {
+			errs = append(errs, ErrAlterOperationNotSupported.GenWithStackByArgs(option, try))
+		} else {
+			errs = append(errs, ErrAlterOperationNotSupportedReason.GenWithStackByArgs(option, reason, try))
+		}
+	}
+	if algorithm != ast.AlgorithmTypeDefault && r.Algorithm < algorithm {
+		notSupported("ALGORITHM="+algorithm.String(), r.reason(func(i AlterImpact) bool { return i.Algorithm < algorithm }), "ALGORITHM="+r.Algorithm.String())
+	}
+	switch {
+	case lock == ast.LockTypeDefault:
+	case algorithm == ast.AlgorithmTypeInstant:
+		errs = append(errs, ErrWrongUsage.GenWithStackByArgs("ALGORITHM=INSTANT", "LOCK=NONE/SHARED/EXCLUSIVE"))
+	case lock == ast.LockTypeNone && algorithm == ast.AlgorithmTypeCopy:
+		notSupported("LOCK=NONE", mysql.MySQLErrName[mysql.ErrAlterOperationNotSupportedReasonCopy].Raw, "LOCK=SHARED")
+	case r.Lock > lock:
+		notSupported("LOCK="+lock.String(), r.reason(func(i AlterImpact) bool { return i.Lock > lock }), "LOCK="+r.Lock.String())
+	}
+	return errs
+}
+
+// reason returns the first reason of the specs matched by f.
+func (r *AlterReport) reason(f func(AlterImpact) bool) string {
+	for _, spec := range r.Specs {
+		if f(spec.AlterImpact) && spec.Reason != "" {
+			return spec.Reason
+		}
+	}
+	return ""
+}
+
+type alterClassifier struct {
+	table          *model.TableInfo
+	addsPrimaryKey bool
+}
+
+func (c *alterClassifier) classify(spec *ast.AlterTableSpec) (AlterImpact, error) {
+	switch spec.Tp {
+	case ast.AlterTableAddColumns:
+		return c.addColumns(spec), nil
+	case ast.AlterTableDropColumn:
+		col := c.column(spec.OldColumnName.Name.O)
+		if col == nil {
+			return AlterImpact{}, ErrCantDropFieldOrKey.GenWithStackByArgs(spec.OldColumnName.Name.O)
+		}
+		if col.IsGenerated() && !col.GeneratedStored {
+			return instantImpact, nil
+		}
+		return rebuildImpact, nil
+	case ast.AlterTableModifyColumn, ast.AlterTableChangeColumn:
+		return c.modifyColumn(spec)
+	case ast.AlterTableRenameColumn:
+		if c.column(spec.OldColumnName.Name.O) == nil {
+			return AlterImpact{}, ErrBadField.GenWithStackByArgs(spec.OldColumnName.Name.O, c.table.Name.O)
+		}
+		return inplaceImpact, nil
+	case ast.AlterTableAlterColumn, ast.AlterTableRenameTable:
+		return instantImpact, nil
+	case ast.AlterTableAddConstraint:
+		return c.addConstraint(spec.Constraint), nil
+	case ast.AlterTableDropPrimaryKey:
+		if c.addsPrimaryKey {
+			return rebuildImpact, nil
+		}
+		return copyImpact.withReason(mysql.ErrAlterOperationNotSupportedReasonNopk), nil
+	case ast.AlterTableDropIndex, ast.AlterTableDropForeignKey, ast.AlterTableRenameIndex,
+		ast.AlterTableIndexInvisible, ast.AlterTableAlterCheck, ast.AlterTableDropCheck:
+		return inplaceImpact, nil
+	case ast.AlterTableForce:
+		return rebuildImpact, nil
+	case ast.AlterTableOption:
+		impact := instantImpact
+		for _, opt := range spec.Options {
+			impact = impact.merge(c.tableOption(opt))
+		}
+		return impact, nil
+	case ast.AlterTableAddPartitions, ast.AlterTableDropPartition, ast.AlterTableTruncatePartition,
+		ast.AlterTableDiscardPartitionTablespace, ast.AlterTableImportPartitionTablespace:
+		impact := inplaceImpact
+		impact.Lock = ast.LockTypeShared
+		return impact.withReason(mysql.ErrAlterOperationNotSupportedReasonPartition), nil
+	case ast.AlterTableCoalescePartitions, ast.AlterTableReorganizePartition, ast.AlterTableRebuildPartition,
+		ast.AlterTablePartition, ast.AlterTableRemovePartitioning, ast.AlterTableExchangePartition:
+		return copyImpact.withReason(mysql.ErrAlterOperationNotSupportedReasonPartition), nil
+	}
+	return copyImpact, nil
+}
+
+// column returns the column of the table by name, or nil if it's not found.
+func (c *alterClassifier) column(name string) *model.ColumnInfo {
+	for _, col := range c.table.Columns {
+		if strings.EqualFold(col.Name.O, name) {
+			return col
+		}
+	}
+	return nil
+}
+
+// last reports whether pos appends a column after the last column.
+func (c *alterClassifier) last(pos *ast.ColumnPosition) bool {
+	switch {
+	case pos == nil || pos.Tp == ast.ColumnPositionNone:
+		return true
+	case pos.Tp == ast.ColumnPositionFirst:
+		return len(c.table.Columns) == 0
+	}
+	n := len(c.table.Columns)
+	return n > 0 && strings.EqualFold(c.table.Columns[n-1].Name.O, pos.RelativeColumn.Name.O)
+}
+
+// moved reports whether pos moves col from its position.
+func (c *alterClassifier) moved(col *model.ColumnInfo, pos *ast.ColumnPosition) bool {
+	if pos == nil || pos.Tp == ast.ColumnPositionNone {
+		return false
+	}
+	i := 0
+	for i < len(c.table.Columns) && c.table.Columns[i] != col {
+		i++
+	}
+	if pos.Tp == ast.ColumnPositionFirst {
+		return i != 0
+	}
+	return i == 0 || !strings.EqualFold(c.table.Columns[i-1].Name.O, pos.RelativeColumn.Name.O)
+}
+
+func (c *alterClassifier) addColumns(spec *ast.AlterTableSpec) AlterImpact {
+	impact := instantImpact
+	if !c.last(spec.Position) {
+		impact = rebuildImpact
+	}
+	for _, col := range spec.NewColumns {
+		for _, opt := range col.Options {
+			switch opt.Tp {
+			case ast.ColumnOptionAutoIncrement:
+				auto := rebuildImpact
+				auto.Lock = ast.LockTypeShared
+				impact = impact.merge(auto.withReason(mysql.ErrAlterOperationNotSupportedReasonAutoinc))
+			case ast.ColumnOptionGenerated:
+				if opt.Stored {
+					impact = impact.merge(copyImpact)
+				} else if impact.Algorithm == ast.AlgorithmTypeInplace {
+					// a virtual column is added in place without rebuild.
+					impact.Rebuild = false
+				}
+			}
+		}
+		impact = impact.merge(c.columnIndexes(col))
+	}
+	return impact
+}
+
+// columnIndexes returns the impact of the indexes defined by the column options.
+func (c *alterClassifier) columnIndexes(col *ast.ColumnDef) AlterImpact {
+	impact := instantImpact
+	for _, opt := range col.Options {
+		switch opt.Tp {
+		case ast.ColumnOptionPrimaryKey:
+			impact = impact.merge(c.addConstraint(&ast.Constraint{Tp: ast.ConstraintPrimaryKey}))
+		case ast.ColumnOptionUniqKey:
+			impact = impact.merge(c.addConstraint(&ast.Constraint{Tp: ast.ConstraintUniq}))
+		case ast.ColumnOptionReference:
+			impact = impact.merge(c.addConstraint(&ast.Constraint{Tp: ast.ConstraintForeignKey}))
+		case ast.ColumnOptionCheck:
+			impact = impact.merge(c.addConstraint(&ast.Constraint{Tp: ast.ConstraintCheck}))
+		}
+	}
+	return impact
+}
+
+func (c *alterClassifier) addConstraint(cons *ast.Constraint) AlterImpact {
+	switch cons.Tp {
+	case ast.ConstraintPrimaryKey:
+		return rebuildImpact
+	case ast.ConstraintFulltext:
+		impact := inplaceImpact
+		impact.Lock = ast.LockTypeShared
+		return impact.withReason(mysql.ErrAlterOperationNotSupportedReasonFts)
+	case ast.ConstraintSPATIAL:
+		impact := inplaceImpact
+		impact.Lock = ast.LockTypeShared
+		return impact
+	case ast.ConstraintForeignKey:
+		return copyImpact.withReason(mysql.ErrAlterOperationNotSupportedReasonFkCheck)
+	case ast.ConstraintCheck:
+		return copyImpact
+	}
+	return inplaceImpact
+}
+
+func (c *alterClassifier) modifyColumn(spec *ast.AlterTableSpec) (AlterImpact, error) {
+	def := spec.NewColumns[0]
+	name := def.Name.Name.O
+	if spec.Tp == ast.AlterTableChangeColumn {
+		name = spec.OldColumnName.Name.O
+	}
+	col := c.column(name)
+	if col == nil {
+		return AlterImpact{}, ErrBadField.GenWithStackByArgs(name, c.table.Name.O)
+	}
+	impact := instantImpact
+	if !strings.EqualFold(def.Name.Name.O, col.Name.O) {
+		impact = inplaceImpact
+	}
+	if c.moved(col, spec.Position) {
+		impact = impact.merge(rebuildImpact)
+	}
+	tp := *def.Tp
+	notNull, autoIncrement, stored := false, false, false
+	for _, opt := range def.Options {
+		switch opt.Tp {
+		case ast.ColumnOptionCollate:
+			tp.Collate = opt.StrValue
+		case ast.ColumnOptionNotNull, ast.ColumnOptionPrimaryKey:
+			notNull = true
+		case ast.ColumnOptionAutoIncrement:
+			autoIncrement = true
+		case ast.ColumnOptionGenerated:
+			stored = opt.Stored
+		}
+	}
+	impact = impact.merge(c.changeType(col, &tp))
+	if notNull != mysql.HasNotNullFlag(col.Flag) {
+		impact = impact.merge(rebuildImpact)
+	}
+	if autoIncrement && !mysql.HasAutoIncrementFlag(col.Flag) || stored != col.GeneratedStored {
+		impact = impact.merge(copyImpact)
+	}
+	return impact.merge(c.columnIndexes(def)), nil
+}
+
+// changeType returns the impact of changing the type of col to tp.
+func (c *alterClassifier) changeType(col *model.ColumnInfo, tp *types.FieldType) AlterImpact {
+	old := &col.FieldType
+	changed := copyImpact.withReason(mysql.ErrAlterOperationNotSupportedReasonColumnType)
+	if old.Tp != tp.Tp || mysql.HasUnsignedFlag(old.Flag) != mysql.HasUnsignedFlag(tp.Flag) {
+		return changed
+	}
+	// the binary flag of a string only means the binary collation of its charset.
+	if types.HasCharset(&types.FieldType{Tp: old.Tp}) {
+		oldCs, oldCo := c.charsetOf(old)
+		cs, co := c.charsetOf(tp)
+		if !strings.EqualFold(oldCs, cs) || !strings.EqualFold(oldCo, co) {
+			return changed
+		}
+	}
+	switch {
+	case old.Tp == mysql.TypeVarchar || old.Tp == mysql.TypeVarString:
+		oldCs, _ := c.charsetOf(old)
+		maxlen := 1
+		if info, err := charset.GetCharsetInfo(oldCs); err == nil {
+			maxlen = info.Maxlen
+		}
+		// the length of a VARCHAR of less than 256 bytes is stored in 1 byte, or 2 bytes otherwise.
+		oldBytes, bytes := old.Flen*maxlen, tp.Flen*maxlen
+		switch {
+		case bytes < oldBytes || oldBytes < 256 && bytes >= 256:
+			return changed
+		case bytes == oldBytes:
+			return instantImpact
+		}
+		return inplaceImpact
+	case old.Tp == mysql.TypeEnum || old.Tp == mysql.TypeSet:
+		if len(tp.Elems) < len(old.Elems) || elemsSize(old.Tp, len(old.Elems)) != elemsSize(tp.Tp, len(tp.Elems)) {
+			return changed
+		}
+		for i, elem := range old.Elems {
+			if tp.Elems[i] != elem {
+				return changed
+			}
+		}
+		return instantImpact
+	case mysql.IsIntegerType(old.Tp) || old.Tp == mysql.TypeYear:
+		if tp.Flen == old.Flen {
+			return instantImpact
+		}
+		// the length of an integer is only the display width.
+		return inplaceImpact
+	}
+	if tp.Flen != types.UnspecifiedLength && tp.Flen != old.Flen || tp.Decimal != types.UnspecifiedLength && tp.Decimal != old.Decimal {
+		return changed
+	}
+	return instantImpact
+}
+
+// charsetOf returns the charset and the collation of a string type, which are of
+// the table by default.
+func (c *alterClassifier) charsetOf(tp *types.FieldType) (string, string) {
+	cs, co := tp.Charset, tp.Collate
+	if cs == "" && co != "" {
+		if collation, err := charset.GetCollationByName(co); err == nil {
+			cs = collation.CharsetName
+		}
+	}
+	if cs == "" {
+		cs, co = c.table.Charset, c.table.Collate
+	}
+	if cs == "" {
+		cs = mysql.DefaultCharset
+	}
+	if co == "" {
+		co, _ = charset.GetDefaultCollation(cs)
+	}
+	return cs, co
+}
+
+func (c *alterClassifier) tableOption(opt *ast.TableOption) AlterImpact {
+	switch opt.Tp {
+	case ast.TableOptionEngine:
+		if strings.EqualFold(opt.StrValue, "InnoDB") {
+			return rebuildImpact
+		}
+		return copyImpact
+	case ast.TableOptionCharset, ast.TableOptionCollate:
+		if opt.UintValue == ast.TableOptionCharsetWithConvertTo {
+			return copyImpact.withReason(mysql.ErrAlterOperationNotSupportedReasonColumnType)
+		}
+		if strings.EqualFold(opt.StrValue, c.table.Charset) || strings.EqualFold(opt.StrValue, c.table.Collate) {
+			return inplaceImpact
+		}
+		return rebuildImpact
+	case ast.TableOptionRowFormat, ast.TableOptionKeyBlockSize:
+		return rebuildImpact
+	case ast.TableOptionComment, ast.TableOptionAutoIncrement, ast.TableOptionStatsPersistent,
+		ast.TableOptionStatsAutoRecalc, ast.TableOptionStatsSamplePages:
+		return inplaceImpact
+	}
+	return copyImpact
+}
+
+// elemsSize returns the storage size of an ENUM or a SET of n elements.
+func elemsSize(tp byte, n int) int {
+	if tp == mysql.TypeEnum {
+		if n < 256 {
+			return 1
+		}
+		return 2
+	}
+	size := (n + 7) / 8
+	if size > 4 {
+		size = 8
+	}
+	return size
+}
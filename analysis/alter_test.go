@@ -0,0 +1,183 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis_test
+
+import (
+	"fmt"
+	"regexp"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	. "github.com/pingcap/parser/analysis"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/mysql"
+)
+
+var _ = Suite(&testAlterSuite{})
+
+type testAlterSuite struct {
+}
+
+// alterTable returns the table of the columns defined by a CREATE TABLE statement.
+func alterTable(c *C, sql string) *model.TableInfo {
+	stmt, err := parser.New().ParseOneStmt(sql, "", "")
+	c.Assert(err, IsNil)
+	create := stmt.(*ast.CreateTableStmt)
+	table := &model.TableInfo{Name: create.Table.Name, Charset: "utf8mb4", Collate: "utf8mb4_bin"}
+	for i, def := range create.Cols {
+		col := &model.ColumnInfo{Name: def.Name.Name, Offset: i, FieldType: *def.Tp}
+		for _, opt := range def.Options {
+			switch opt.Tp {
+			case ast.ColumnOptionNotNull:
+				col.Flag |= mysql.NotNullFlag
+			case ast.ColumnOptionGenerated:
+				col.GeneratedExprString, col.GeneratedStored = "1", opt.Stored
+			}
+		}
+		table.Columns = append(table.Columns, col)
+	}
+	return table
+}
+
+func parseAlter(c *C, sql string) *ast.AlterTableStmt {
+	stmt, err := parser.New().ParseOneStmt(sql, "", "")
+	c.Assert(err, IsNil, Commentf("sql: %s", sql))
+	return stmt.(*ast.AlterTableStmt)
+}
+
+func classifyAlter(c *C, table *model.TableInfo, sql string) *AlterReport {
+	report, err := ClassifyAlter(parseAlter(c, sql), table)
+	c.Assert(err, IsNil, Commentf("sql: %s", sql))
+	return report
+}
+
+func (s *testAlterSuite) TestClassifyAlter(c *C) {
+	table := alterTable(c, "create table t (id int not null, a varchar(60), b varchar(300) charset latin1, c int, s enum('x', 'y'), v int as (c + 1) virtual)")
+	cases := []struct {
+		sql      string
+		expected string
+	}{
+		{"alter table t add column d int", "INSTANT false NONE"},
+		{"alter table t add column d int after v", "INSTANT false NONE"},
+		{"alter table t add column d int after a", "INPLACE true NONE"},
+		{"alter table t add column d int first", "INPLACE true NONE"},
+		{"alter table t add column d int auto_increment", "INPLACE true SHARED"},
+		{"alter table t add column d int as (c + 2) stored", "COPY true SHARED"},
+		{"alter table t add column (d int, e int)", "INSTANT false NONE"},
+		{"alter table t drop column a", "INPLACE true NONE"},
+		{"alter table t drop column v", "INSTANT false NONE"},
+		{"alter table t modify a varchar(63)", "INPLACE false NONE"},
+		{"alter table t modify a varchar(64)", "COPY true SHARED"},
+		{"alter table t modify a varchar(50)", "COPY true SHARED"},
+		{"alter table t modify b varchar(1000) charset latin1", "INPLACE false NONE"},
+		{"alter table t modify a varchar(60) charset latin1", "COPY true SHARED"},
+		{"alter table t modify a varchar(60) collate utf8mb4_general_ci", "COPY true SHARED"},
+		{"alter table t modify a varchar(60) collate utf8mb4_bin", "INSTANT false NONE"},
+		{"alter table t modify c bigint", "COPY true SHARED"},
+		{"alter table t modify c int(10)", "INPLACE false NONE"},
+		{"alter table t modify c int not null", "INPLACE true NONE"},
+		{"alter table t modify c int first", "INPLACE true NONE"},
+		{"alter table t modify a varchar(60) after id", "INSTANT false NONE"},
+		{"alter table t modify s enum('x', 'y', 'z')", "INSTANT false NONE"},
+		{"alter table t modify s enum('y', 'x')", "COPY true SHARED"},
+		{"alter table t change c d int", "INPLACE false NONE"},
+		{"alter table t rename column c to d", "INPLACE false NONE"},
+		{"alter table t alter column c set default 1", "INSTANT false NONE"},
+		{"alter table t add index i (c)", "INPLACE false NONE"},
+		{"alter table t add fulltext index i (a)", "INPLACE false SHARED"},
+		{"alter table t add primary key (id)", "INPLACE true NONE"},
+		{"alter table t drop primary key", "COPY true SHARED"},
+		{"alter table t drop primary key, add primary key (id, c)", "INPLACE true NONE"},
+		{"alter table t add foreign key (c) references p (id)", "COPY true SHARED"},
+		{"alter table t drop index i, rename index j to k", "INPLACE false NONE"},
+		{"alter table t engine = InnoDB", "INPLACE true NONE"},
+		{"alter table t engine = MyISAM", "COPY true SHARED"},
+		{"alter table t comment 'x', auto_increment = 10", "INPLACE false NONE"},
+		{"alter table t row_format = compact", "INPLACE true NONE"},
+		{"alter table t convert to charset latin1", "COPY true SHARED"},
+		{"alter table t default charset latin1", "INPLACE true NONE"},
+		{"alter table t rename to s", "INSTANT false NONE"},
+		{"alter table t force", "INPLACE true NONE"},
+		{"alter table t add column d int, modify c bigint", "COPY true SHARED"},
+	}
+	for _, ca := range cases {
+		report := classifyAlter(c, table, ca.sql)
+		actual := fmt.Sprintf("%s %v %s", report.Algorithm, report.Rebuild, report.Lock)
+		c.Assert(actual, Equals, ca.expected, Commentf("sql: %s", ca.sql))
+		c.Assert(report.Errors, IsNil, Commentf("sql: %s", ca.sql))
+	}
+
+	report := classifyAlter(c, table, "alter table t add column d int first, modify c bigint, algorithm = inplace")
+	c.Assert(report.Specs, HasLen, 2)
+	c.Assert(report.Specs[0].Algorithm, Equals, ast.AlgorithmTypeInplace)
+	c.Assert(report.Specs[1].Algorithm, Equals, ast.AlgorithmTypeCopy)
+	c.Assert(report.Specs[1].Reason, Equals, "Cannot change column type INPLACE")
+
+	_, err := ClassifyAlter(parseAlter(c, "alter table t modify x int"), table)
+	c.Assert(err, ErrorMatches, ".*Unknown column 'x' in 't'")
+	_, err = ClassifyAlter(parseAlter(c, "alter table t drop column x"), table)
+	c.Assert(err, ErrorMatches, ".*Can't DROP 'x'; check that column/key exists")
+}
+
+func (s *testAlterSuite) TestAlterOptions(c *C) {
+	table := alterTable(c, "create table t (id int, a varchar(60), c int)")
+	cases := []struct {
+		sql      string
+		expected []string
+	}{
+		{"alter table t add column d int, algorithm = instant", nil},
+		{"alter table t add column d int first, algorithm = inplace, lock = none", nil},
+		{"alter table t modify c bigint, algorithm = copy, lock = shared", nil},
+		{"alter table t add index i (c), lock = exclusive", nil},
+		{
+			"alter table t add column d int first, algorithm = instant",
+			[]string{"ALGORITHM=INSTANT is not supported for this operation. Try ALGORITHM=INPLACE."},
+		},
+		{
+			"alter table t modify a varchar(64), algorithm = inplace",
+			[]string{"ALGORITHM=INPLACE is not supported. Reason: Cannot change column type INPLACE. Try ALGORITHM=COPY."},
+		},
+		{
+			"alter table t drop primary key, algorithm = inplace, lock = none",
+			[]string{
+				"ALGORITHM=INPLACE is not supported. Reason: Dropping a primary key is not allowed without also adding a new primary key. Try ALGORITHM=COPY.",
+				"LOCK=NONE is not supported. Reason: Dropping a primary key is not allowed without also adding a new primary key. Try LOCK=SHARED.",
+			},
+		},
+		{
+			"alter table t add column d int auto_increment, lock = none",
+			[]string{"LOCK=NONE is not supported. Reason: Adding an auto-increment column requires a lock. Try LOCK=SHARED."},
+		},
+		{
+			"alter table t add column d int, algorithm = copy, lock = none",
+			[]string{"LOCK=NONE is not supported. Reason: COPY algorithm requires a lock. Try LOCK=SHARED."},
+		},
+		{
+			"alter table t add column d int, algorithm = instant, lock = none",
+			[]string{"Incorrect usage of ALGORITHM=INSTANT and LOCK=NONE/SHARED/EXCLUSIVE"},
+		},
+	}
+	for _, ca := range cases {
+		report := classifyAlter(c, table, ca.sql)
+		var errs []string
+		for _, err := range report.Errors {
+			errs = append(errs, err.Error())
+		}
+		c.Assert(errs, HasLen, len(ca.expected), Commentf("sql: %s, errors: %v", ca.sql, errs))
+		for i, err := range errs {
+			c.Assert(err, Matches, `\[ddl:\d+\]`+regexp.QuoteMeta(ca.expected[i]), Commentf("sql: %s", ca.sql))
+		}
+	}
+}
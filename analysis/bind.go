@@ -0,0 +1,838 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/terror"
+)
+
+var (
+	ErrBadTable      = terror.ClassOptimizer.NewStd(mysql.ErrBadTable)
+	ErrDupFieldName  = terror.ClassOptimizer.NewStd(mysql.ErrDupFieldName)
+	ErrNoSuchTable   = terror.ClassOptimizer.NewStd(mysql.ErrNoSuchTable)
+	ErrNoTablesUsed  = terror.ClassOptimizer.NewStd(mysql.ErrNoTablesUsed)
+	ErrNonUniq       = terror.ClassOptimizer.NewStd(mysql.ErrNonUniq)
+	ErrNonuniqTable  = terror.ClassOptimizer.NewStd(mysql.ErrNonuniqTable)
+	ErrUnknownTable  = terror.ClassOptimizer.NewStd(mysql.ErrUnknownTable)
+	ErrViewWrongList = terror.ClassOptimizer.NewStd(mysql.ErrViewWrongList)
+)
+
+// The clauses in the messages of ErrBadField and ErrNonUniq.
+const (
+	clauseField  = "field list"
+	clauseFrom   = "from clause"
+	clauseOn     = "on clause"
+	clauseWhere  = "where clause"
+	clauseGroup  = "group statement"
+	clauseHaving = "having clause"
+	clauseOrder  = "order clause"
+)
+
+// Bind resolves the column names in the statement against the tables provided by
+// the catalog. It sets ColumnNameExpr.Refer to the result field of the column, and
+// PositionExpr.Refer to the select field of the position. It returns the result
+// fields of the statement if it's a query.
+//
+// The names are resolved by the scoping rules of MySQL: the unqualified names refer
+// to the innermost query having them, the columns of USING and NATURAL joins are
+// coalesced, and GROUP BY, HAVING and ORDER BY may refer to the aliases of the
+// select fields. The unknown names are reported by ErrBadField, and the ambiguous
+// names are reported by ErrNonUniq.
+//
+// If the catalog has a method `CurrentDatabase() string`, such as catalog.Catalog,
+// it's the schema of the tables without a schema.
+func Bind(stmt ast.StmtNode, catalog Catalog) ([]*ast.ResultField, error) {
+	return newBinder(catalog).stmt(stmt, nil)
+}
+
+// bindColumn is a column of a table in the FROM clause.
+type bindColumn struct {
+	field *ast.ResultField
+	// coalesced is the column which the column is coalesced into by USING or NATURAL
+	// joins, it's nil if the column isn't coalesced or the column is the one kept.
+	coalesced *bindColumn
+}
+
+// root returns the column kept after the column is coalesced.
+func (c *bindColumn) root() *bindColumn {
+	for c.coalesced != nil {
+		c = c.coalesced
+	}
+	return c
+}
+
+// bindSource is a table in the FROM clause.
+type bindSource struct {
+	// name is the name to qualify the columns, which is the alias or the name of the table.
+	name    model.CIStr
+	schema  model.CIStr
+	columns []*bindColumn
+}
+
+// bindCTE is a common table expression.
+type bindCTE struct {
+	fields []*ast.ResultField
+}
+
+// bindScope is the scope of a query block.
+type bindScope struct {
+	parent  *bindScope
+	ctes    map[string]*bindCTE
+	sources []*bindSource
+	// columns are the columns selected by the wildcard.
+	columns []*bindColumn
+	// fields are the select fields and their result fields, which may be referred
+	// by GROUP BY, HAVING and ORDER BY.
+	fields  []*ast.SelectField
+	results []*ast.ResultField
+}
+
+func (s *bindScope) lookupCTE(name string) *bindCTE {
+	for ; s != nil; s = s.parent {
+		if c, ok := s.ctes[name]; ok {
+			return c
+		}
+	}
+	return nil
+}
+
+// aliasMode is how the names refer to the aliases of the select fields.
+type aliasMode int
+
+const (
+	// aliasNone is for the clauses which can't refer to the aliases, such as WHERE.
+	aliasNone aliasMode = iota
+	// aliasAfter is for GROUP BY and HAVING, which refer to the aliases if the
+	// names aren't the columns of the tables.
+	aliasAfter
+	// aliasBefore is for ORDER BY, which refers to the columns of the tables if the
+	// names aren't the aliases.
+	aliasBefore
+)
+
+type binder struct {
+	catalog   Catalog
+	currentDB model.CIStr
+	// infer is whether to infer the types of the expressions after they are bound.
+	infer bool
+}
+
+func newBinder(catalog Catalog) *binder {
+	b := &binder{catalog: catalog}
+	if c, ok := catalog.(interface{ CurrentDatabase() string }); ok {
+		b.currentDB = model.NewCIStr(c.CurrentDatabase())
+	}
+	return b
+}
+
+func (b *binder) stmt(node ast.StmtNode, parent *bindScope) ([]*ast.ResultField, error) {
+	switch x := node.(type) {
+	case *ast.SelectStmt, *ast.SetOprStmt:
+		return b.resultSet(x.(ast.ResultSetNode), parent)
+	case *ast.InsertStmt:
+		return nil, b.insert(x, parent)
+	case *ast.UpdateStmt:
+		return nil, b.update(x, parent)
+	case *ast.DeleteStmt:
+		return nil, b.delete(x, parent)
+	case *ast.ExplainStmt:
+		_, err := b.stmt(x.Stmt, parent)
+		return nil, err
+	}
+	return nil, nil
+}
+
+// resultSet binds a query and returns its result fields.
+func (b *binder) resultSet(node ast.Node, parent *bindScope) ([]*ast.ResultField, error) {
+	switch x := node.(type) {
+	case *ast.SelectStmt:
+		return b.selectStmt(x, parent)
+	case *ast.SetOprStmt:
+		sc := &bindScope{parent: parent}
+		if err := b.with(x.With, sc); err != nil {
+			return nil, err
+		}
+		fields, err := b.resultSet(x.SelectList, sc)
+		if err != nil {
+			return nil, err
+		}
+		// ORDER BY of a set operation refers to the result fields.
+		sc.sources = []*bindSource{{columns: bindColumns(fields)}}
+		if x.OrderBy != nil {
+			if err := b.byItems(x.OrderBy.Items, sc, clauseOrder, aliasNone); err != nil {
+				return nil, err
+			}
+		}
+		return fields, nil
+	case *ast.SetOprSelectList:
+		sc := &bindScope{parent: parent}
+		if err := b.with(x.With, sc); err != nil {
+			return nil, err
+		}
+		var fields []*ast.ResultField
+		for i, sel := range x.Selects {
+			selFields, err := b.resultSet(sel, sc)
+			if err != nil {
+				return nil, err
+			}
+			if i == 0 {
+				fields = selFields
+			} else if b.infer {
+				fields = mergeResults(fields, selFields)
+			}
+		}
+		return fields, nil
+	case *ast.SubqueryExpr:
+		return b.resultSet(x.Query, parent)
+	}
+	return nil, nil
+}
+
+// mergeResults returns the result fields of a set operation, whose types are merged
+// from the result fields of the queries.
+func mergeResults(fields, others []*ast.ResultField) []*ast.ResultField {
+	if len(fields) != len(others) {
+		return fields
+	}
+	merged := make([]*ast.ResultField, 0, len(fields))
+	for i, field := range fields {
+		f, col := *field, *field.Column
+		col.FieldType = *mergeFieldTypes(&field.Column.FieldType, &others[i].Column.FieldType)
+		if mysql.HasNotNullFlag(field.Column.Flag) && mysql.HasNotNullFlag(others[i].Column.Flag) {
+			col.Flag |= mysql.NotNullFlag
+		}
+		f.Column = &col
+		merged = append(merged, &f)
+	}
+	return merged
+}
+
+func bindColumns(fields []*ast.ResultField) []*bindColumn {
+	cols := make([]*bindColumn, 0, len(fields))
+	for _, field := range fields {
+		cols = append(cols, &bindColumn{field: field})
+	}
+	return cols
+}
+
+func (b *binder) selectStmt(sel *ast.SelectStmt, parent *bindScope) ([]*ast.ResultField, error) {
+	sc := &bindScope{parent: parent}
+	if err := b.with(sel.With, sc); err != nil {
+		return nil, err
+	}
+	if sel.From != nil {
+		cols, err := b.tableRefs(sel.From.TableRefs, sc)
+		if err != nil {
+			return nil, err
+		}
+		sc.columns = cols
+	}
+	for _, row := range sel.Lists {
+		if err := b.expr(row, sc, clauseField, aliasNone); err != nil {
+			return nil, err
+		}
+	}
+	if sel.Fields != nil {
+		for _, field := range sel.Fields.Fields {
+			if field.WildCard != nil {
+				cols, err := b.wildcard(field.WildCard, sc)
+				if err != nil {
+					return nil, err
+				}
+				for _, col := range cols {
+					sc.fields = append(sc.fields, field)
+					sc.results = append(sc.results, col.field)
+				}
+				continue
+			}
+			if err := b.expr(field.Expr, sc, clauseField, aliasNone); err != nil {
+				return nil, err
+			}
+			result := selectResult(field)
+			if _, ok := field.Expr.(*ast.ColumnNameExpr); b.infer && !ok {
+				result.Column.FieldType = *field.Expr.GetType()
+			}
+			sc.fields = append(sc.fields, field)
+			sc.results = append(sc.results, result)
+		}
+	}
+	if sel.Where != nil {
+		if err := b.expr(sel.Where, sc, clauseWhere, aliasNone); err != nil {
+			return nil, err
+		}
+	}
+	if sel.GroupBy != nil {
+		if err := b.byItems(sel.GroupBy.Items, sc, clauseGroup, aliasAfter); err != nil {
+			return nil, err
+		}
+	}
+	if sel.Having != nil {
+		if err := b.expr(sel.Having.Expr, sc, clauseHaving, aliasAfter); err != nil {
+			return nil, err
+		}
+	}
+	for i := range sel.WindowSpecs {
+		if err := b.expr(&sel.WindowSpecs[i], sc, clauseField, aliasNone); err != nil {
+			return nil, err
+		}
+	}
+	if sel.OrderBy != nil {
+		if err := b.byItems(sel.OrderBy.Items, sc, clauseOrder, aliasBefore); err != nil {
+			return nil, err
+		}
+	}
+	return sc.results, nil
+}
+
+// selectResult returns the result field of a select field which isn't a wildcard.
+func selectResult(field *ast.SelectField) *ast.ResultField {
+	var result ast.ResultField
+	if x, ok := field.Expr.(*ast.ColumnNameExpr); ok && x.Refer != nil {
+		result = *x.Refer
+	} else {
+		name := strings.TrimSpace(field.Text())
+		if name == "" {
+			name = restoreExpr(field.Expr)
+		}
+		result.ColumnAsName = model.NewCIStr(name)
+		result.Column = &model.ColumnInfo{Name: result.ColumnAsName}
+		result.Table, result.TableAsName, result.DBName, result.TableName = nil, model.CIStr{}, model.CIStr{}, nil
+	}
+	if field.AsName.L != "" {
+		result.ColumnAsName = field.AsName
+	}
+	result.Expr = field.Expr
+	return &result
+}
+
+// wildcard returns the columns selected by the wildcard.
+func (b *binder) wildcard(wildcard *ast.WildCardField, sc *bindScope) ([]*bindColumn, error) {
+	if wildcard.Table.L == "" {
+		if len(sc.sources) == 0 {
+			return nil, ErrNoTablesUsed.GenWithStackByArgs()
+		}
+		return sc.columns, nil
+	}
+	for _, src := range sc.sources {
+		if src.name.L == wildcard.Table.L && (wildcard.Schema.L == "" || src.schema.L == wildcard.Schema.L) {
+			return src.columns, nil
+		}
+	}
+	return nil, ErrBadTable.GenWithStackByArgs(wildcard.Table.O)
+}
+
+func (b *binder) with(with *ast.WithClause, sc *bindScope) error {
+	if with == nil {
+		return nil
+	}
+	if sc.ctes == nil {
+		sc.ctes = make(map[string]*bindCTE, len(with.CTEs))
+	}
+	for _, expr := range with.CTEs {
+		c := &bindCTE{}
+		if with.IsRecursive {
+			// the recursive CTE may refer to itself after its first query, whose
+			// result fields are the ones of the CTE.
+			if x, ok := expr.Query.Query.(*ast.SetOprStmt); ok && x.With == nil && x.SelectList.With == nil {
+				fields, err := b.resultSet(x.SelectList.Selects[0], sc)
+				if err != nil {
+					return err
+				}
+				if c.fields, err = cteFields(expr, fields); err != nil {
+					return err
+				}
+				sc.ctes[expr.Name.L] = c
+			}
+		}
+		fields, err := b.resultSet(expr.Query, sc)
+		if err != nil {
+			return err
+		}
+		if c.fields, err = cteFields(expr, fields); err != nil {
+			return err
+		}
+		sc.ctes[expr.Name.L] = c
+	}
+	return nil
+}
+
+// cteFields returns the result fields of the CTE, which are renamed by its column names.
+func cteFields(expr *ast.CommonTableExpression, fields []*ast.ResultField) ([]*ast.ResultField, error) {
+	if len(expr.ColNameList) == 0 {
+		return fields, nil
+	}
+	if len(expr.ColNameList) != len(fields) {
+		return nil, ErrViewWrongList.GenWithStackByArgs()
+	}
+	renamed := make([]*ast.ResultField, 0, len(fields))
+	for i, field := range fields {
+		f := *field
+		f.ColumnAsName = expr.ColNameList[i]
+		renamed = append(renamed, &f)
+	}
+	return renamed, nil
+}
+
+// tableRefs adds the tables to the scope, and returns the columns of them selected
+// by the wildcard.
+func (b *binder) tableRefs(node ast.ResultSetNode, sc *bindScope) ([]*bindColumn, error) {
+	switch x := node.(type) {
+	case *ast.Join:
+		return b.join(x, sc)
+	case *ast.TableSource:
+		switch src := x.Source.(type) {
+		case *ast.TableName:
+			return b.tableName(src, x.AsName, sc)
+		case *ast.SelectStmt, *ast.SetOprStmt:
+			// the derived table can't refer to the tables of the same FROM clause.
+			fields, err := b.resultSet(src, &bindScope{parent: sc.parent, ctes: sc.ctes})
+			if err != nil {
+				return nil, err
+			}
+			return b.addSource(sc, x.AsName, model.CIStr{}, derivedFields(x.AsName, fields))
+		default:
+			return b.tableRefs(src, sc)
+		}
+	case *ast.TableName:
+		return b.tableName(x, model.CIStr{}, sc)
+	}
+	return nil, nil
+}
+
+// derivedFields returns the result fields of the columns of a derived table.
+func derivedFields(alias model.CIStr, fields []*ast.ResultField) []*ast.ResultField {
+	derived := make([]*ast.ResultField, 0, len(fields))
+	for _, field := range fields {
+		f := *field
+		f.TableAsName = alias
+		derived = append(derived, &f)
+	}
+	return derived
+}
+
+func (b *binder) join(join *ast.Join, sc *bindScope) ([]*bindColumn, error) {
+	left, err := b.tableRefs(join.Left, sc)
+	if err != nil || join.Right == nil {
+		return left, err
+	}
+	right, err := b.tableRefs(join.Right, sc)
+	if err != nil {
+		return nil, err
+	}
+	var names []model.CIStr
+	if join.NaturalJoin {
+		for _, l := range left {
+			for _, r := range right {
+				if l.field.ColumnAsName.L == r.field.ColumnAsName.L {
+					names = append(names, l.field.ColumnAsName)
+					break
+				}
+			}
+		}
+	}
+	for _, name := range join.Using {
+		names = append(names, name.Name)
+	}
+	if b.infer {
+		// the columns of the inner table of the outer join are nullable.
+		switch join.Tp {
+		case ast.LeftJoin:
+			nullableColumns(right)
+		case ast.RightJoin:
+			nullableColumns(left)
+		}
+	}
+	cols := make([]*bindColumn, 0, len(left)+len(right))
+	used := make(map[*bindColumn]bool)
+	for _, name := range names {
+		l, err := findColumn(left, name)
+		if err != nil {
+			return nil, err
+		}
+		r, err := findColumn(right, name)
+		if err != nil {
+			return nil, err
+		}
+		// the column of the right table is kept by RIGHT JOIN.
+		if join.Tp == ast.RightJoin {
+			l.root().coalesced = r.root()
+			cols = append(cols, r)
+		} else {
+			r.root().coalesced = l.root()
+			cols = append(cols, l)
+		}
+		used[l], used[r] = true, true
+	}
+	for _, side := range [][]*bindColumn{left, right} {
+		for _, col := range side {
+			if !used[col] {
+				cols = append(cols, col)
+			}
+		}
+	}
+	if join.On != nil {
+		if err := b.expr(join.On.Expr, sc, clauseOn, aliasNone); err != nil {
+			return nil, err
+		}
+	}
+	return cols, nil
+}
+
+// nullableColumns makes the types of the columns nullable.
+func nullableColumns(cols []*bindColumn) {
+	for _, col := range cols {
+		f, column := *col.field, *col.field.Column
+		column.Flag &^= mysql.NotNullFlag
+		f.Column = &column
+		col.field = &f
+	}
+}
+
+// findColumn returns the column of a side of USING and NATURAL joins.
+func findColumn(cols []*bindColumn, name model.CIStr) (*bindColumn, error) {
+	var found *bindColumn
+	for _, col := range cols {
+		if col.field.ColumnAsName.L == name.L {
+			if found != nil {
+				return nil, ErrNonUniq.GenWithStackByArgs(name.O, clauseFrom)
+			}
+			found = col
+		}
+	}
+	if found == nil {
+		return nil, ErrBadField.GenWithStackByArgs(name.O, clauseFrom)
+	}
+	return found, nil
+}
+
+// tableName adds the base table or the CTE to the scope.
+func (b *binder) tableName(tn *ast.TableName, alias model.CIStr, sc *bindScope) ([]*bindColumn, error) {
+	name := alias
+	if name.L == "" {
+		name = tn.Name
+	}
+	if c := sc.lookupCTE(tn.Name.L); c != nil && tn.Schema.L == "" {
+		return b.addSource(sc, name, model.CIStr{}, derivedFields(name, c.fields))
+	}
+	schema := tn.Schema
+	if schema.L == "" {
+		schema = b.currentDB
+	}
+	var info *model.TableInfo
+	if b.catalog != nil {
+		info = b.catalog.TableByName(tn.Schema, tn.Name)
+	}
+	if info == nil {
+		return nil, ErrNoSuchTable.GenWithStackByArgs(schema.O, tn.Name.O)
+	}
+	tn.TableInfo = info
+	var fields []*ast.ResultField
+	for _, col := range info.Columns {
+		if col.Hidden {
+			continue
+		}
+		fields = append(fields, &ast.ResultField{
+			Column:       col,
+			ColumnAsName: col.Name,
+			Table:        info,
+			TableAsName:  name,
+			DBName:       schema,
+			TableName:    tn,
+		})
+	}
+	srcSchema := tn.Schema
+	if alias.L != "" {
+		srcSchema = model.CIStr{}
+	}
+	return b.addSource(sc, name, srcSchema, fields)
+}
+
+func (b *binder) addSource(sc *bindScope, name, schema model.CIStr, fields []*ast.ResultField) ([]*bindColumn, error) {
+	for _, src := range sc.sources {
+		if src.name.L == name.L && src.schema.L == schema.L && name.L != "" {
+			return nil, ErrNonuniqTable.GenWithStackByArgs(name.O)
+		}
+	}
+	cols := bindColumns(fields)
+	for i, col := range cols {
+		for _, other := range cols[:i] {
+			if other.field.ColumnAsName.L == col.field.ColumnAsName.L {
+				return nil, ErrDupFieldName.GenWithStackByArgs(col.field.ColumnAsName.O)
+			}
+		}
+	}
+	sc.sources = append(sc.sources, &bindSource{name: name, schema: schema, columns: cols})
+	return cols, nil
+}
+
+// byItems binds the items of GROUP BY and ORDER BY, whose positions refer to the
+// select fields.
+func (b *binder) byItems(items []*ast.ByItem, sc *bindScope, clause string, mode aliasMode) error {
+	for _, item := range items {
+		if pos, ok := item.Expr.(*ast.PositionExpr); ok && pos.P == nil {
+			if pos.N < 1 || pos.N > len(sc.results) {
+				return ErrBadField.GenWithStackByArgs(strconv.Itoa(pos.N), clause)
+			}
+			pos.Refer = sc.results[pos.N-1]
+			if b.infer {
+				pos.SetType(pos.Refer.Column.FieldType.Clone())
+			}
+			continue
+		}
+		if err := b.expr(item.Expr, sc, clause, mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expr binds the names in node, which is usually an expression.
+func (b *binder) expr(node ast.Node, sc *bindScope, clause string, mode aliasMode) error {
+	var err error
+	ast.Inspect(node, func(n ast.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch x := n.(type) {
+		case *ast.ColumnNameExpr:
+			x.Refer, err = b.column(x.Name, sc, clause, mode)
+			return false
+		case *ast.DefaultExpr:
+			if x.Name != nil {
+				var field *ast.ResultField
+				if field, err = b.column(x.Name, sc, clause, aliasNone); err == nil && b.infer {
+					x.SetType(field.Column.FieldType.Clone())
+				}
+			}
+		case *ast.SubqueryExpr:
+			var fields []*ast.ResultField
+			if fields, err = b.resultSet(x.Query, sc); err == nil && b.infer && len(fields) > 0 {
+				// the scalar subquery is NULL if it returns no row.
+				x.SetType(nullable(fields[0].Column.FieldType.Clone(), true))
+			}
+			return false
+		}
+		return true
+	})
+	if err == nil && b.infer {
+		node.Accept(&typeInferrer{})
+	}
+	return err
+}
+
+// column returns the result field which the name refers to.
+func (b *binder) column(name *ast.ColumnName, sc *bindScope, clause string, mode aliasMode) (*ast.ResultField, error) {
+	if mode == aliasBefore && name.Table.L == "" {
+		if field, err := alias(name, sc, clause); field != nil || err != nil {
+			return field, err
+		}
+	}
+	for s := sc; s != nil; s = s.parent {
+		field, err := s.column(name, clause)
+		if field != nil || err != nil {
+			return field, err
+		}
+		if s == sc && mode == aliasAfter && name.Table.L == "" {
+			if field, err := alias(name, sc, clause); field != nil || err != nil {
+				return field, err
+			}
+		}
+	}
+	return nil, ErrBadField.GenWithStackByArgs(nameString(name), clause)
+}
+
+// column returns the column of the tables of the scope which the name refers to,
+// it returns nil if the scope doesn't have the column.
+func (s *bindScope) column(name *ast.ColumnName, clause string) (*ast.ResultField, error) {
+	if name.Table.L != "" {
+		for _, src := range s.sources {
+			if src.name.L != name.Table.L || (name.Schema.L != "" && src.schema.L != "" && src.schema.L != name.Schema.L) {
+				continue
+			}
+			for _, col := range src.columns {
+				if col.field.ColumnAsName.L == name.Name.L {
+					return col.field, nil
+				}
+			}
+			return nil, ErrBadField.GenWithStackByArgs(nameString(name), clause)
+		}
+		return nil, nil
+	}
+	var found *bindColumn
+	for _, src := range s.sources {
+		for _, col := range src.columns {
+			if col.field.ColumnAsName.L != name.Name.L {
+				continue
+			}
+			if found != nil && found.root() != col.root() {
+				return nil, ErrNonUniq.GenWithStackByArgs(name.Name.O, clause)
+			}
+			found = col
+		}
+	}
+	if found == nil {
+		return nil, nil
+	}
+	return found.root().field, nil
+}
+
+// alias returns the select field which the name refers to, it returns nil if the
+// name isn't the name of a select field.
+func alias(name *ast.ColumnName, sc *bindScope, clause string) (*ast.ResultField, error) {
+	var found *ast.ResultField
+	for i, field := range sc.fields {
+		result := sc.results[i]
+		if field.AsName.L == "" && field.WildCard == nil {
+			if _, ok := field.Expr.(*ast.ColumnNameExpr); !ok {
+				continue
+			}
+		}
+		if result.ColumnAsName.L != name.Name.L {
+			continue
+		}
+		if found != nil && found != result && !sameColumn(found, result) {
+			return nil, ErrNonUniq.GenWithStackByArgs(name.Name.O, clause)
+		}
+		if found == nil {
+			found = result
+		}
+	}
+	return found, nil
+}
+
+// sameColumn reports whether the result fields are the same column of a table
+// selected twice, such as `SELECT a, a FROM t`.
+func sameColumn(a, b *ast.ResultField) bool {
+	if a.Column == nil || b.Column == nil || a.Table == nil || b.Table == nil {
+		return false
+	}
+	_, aIsName := a.Expr.(*ast.ColumnNameExpr)
+	_, bIsName := b.Expr.(*ast.ColumnNameExpr)
+	return (a.Expr == nil || aIsName) && (b.Expr == nil || bIsName) &&
+		a.Column == b.Column && a.TableName == b.TableName && a.TableAsName.L == b.TableAsName.L
+}
+
+// nameString returns the name as it's written without quotes, such as t.a.
+func nameString(name *ast.ColumnName) string {
+	var parts []string
+	for _, part := range []model.CIStr{name.Schema, name.Table, name.Name} {
+		if part.O != "" {
+			parts = append(parts, part.O)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+func (b *binder) insert(stmt *ast.InsertStmt, parent *bindScope) error {
+	sc := &bindScope{parent: parent}
+	if stmt.Table != nil {
+		if _, err := b.tableRefs(stmt.Table.TableRefs, sc); err != nil {
+			return err
+		}
+	}
+	for _, name := range stmt.Columns {
+		if _, err := b.column(name, sc, clauseField, aliasNone); err != nil {
+			return err
+		}
+	}
+	for _, row := range stmt.Lists {
+		for _, expr := range row {
+			if err := b.expr(expr, sc, clauseField, aliasNone); err != nil {
+				return err
+			}
+		}
+	}
+	if stmt.Select != nil {
+		if _, err := b.resultSet(stmt.Select, parent); err != nil {
+			return err
+		}
+	}
+	for _, list := range [][]*ast.Assignment{stmt.Setlist, stmt.OnDuplicate} {
+		if err := b.assignments(list, sc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *binder) assignments(list []*ast.Assignment, sc *bindScope) error {
+	for _, assign := range list {
+		if _, err := b.column(assign.Column, sc, clauseField, aliasNone); err != nil {
+			return err
+		}
+		if err := b.expr(assign.Expr, sc, clauseField, aliasNone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *binder) update(stmt *ast.UpdateStmt, parent *bindScope) error {
+	sc := &bindScope{parent: parent}
+	if err := b.with(stmt.With, sc); err != nil {
+		return err
+	}
+	if stmt.TableRefs != nil {
+		if _, err := b.tableRefs(stmt.TableRefs.TableRefs, sc); err != nil {
+			return err
+		}
+	}
+	if err := b.assignments(stmt.List, sc); err != nil {
+		return err
+	}
+	return b.filters(stmt.Where, stmt.Order, sc)
+}
+
+func (b *binder) delete(stmt *ast.DeleteStmt, parent *bindScope) error {
+	sc := &bindScope{parent: parent}
+	if err := b.with(stmt.With, sc); err != nil {
+		return err
+	}
+	if stmt.TableRefs != nil {
+		if _, err := b.tableRefs(stmt.TableRefs.TableRefs, sc); err != nil {
+			return err
+		}
+	}
+	if stmt.Tables != nil {
+		for _, tn := range stmt.Tables.Tables {
+			found := false
+			for _, src := range sc.sources {
+				found = found || src.name.L == tn.Name.L
+			}
+			if !found {
+				return ErrUnknownTable.GenWithStackByArgs(tn.Name.O, "MULTI DELETE")
+			}
+		}
+	}
+	return b.filters(stmt.Where, stmt.Order, sc)
+}
+
+// filters binds WHERE and ORDER BY of UPDATE and DELETE.
+func (b *binder) filters(where ast.ExprNode, order *ast.OrderByClause, sc *bindScope) error {
+	if where != nil {
+		if err := b.expr(where, sc, clauseWhere, aliasNone); err != nil {
+			return err
+		}
+	}
+	if order != nil {
+		return b.byItems(order.Items, sc, clauseOrder, aliasNone)
+	}
+	return nil
+}
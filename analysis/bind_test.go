@@ -0,0 +1,129 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis_test
+
+import (
+	"regexp"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	. "github.com/pingcap/parser/analysis"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/terror"
+)
+
+var _ = Suite(&testBindSuite{})
+
+type testBindSuite struct {
+}
+
+// referString formats the result field as `<table>.<column>`, or `<column>` if it isn't a column of a table.
+func referString(field *ast.ResultField) string {
+	if field.TableAsName.O == "" {
+		return field.ColumnAsName.O
+	}
+	return field.TableAsName.O + "." + field.ColumnAsName.O
+}
+
+// bindStrings binds the statement, and formats the column names it resolves in order.
+func bindStrings(c *C, sql string, catalog Catalog) ([]string, []string, error) {
+	stmt, err := parser.New().ParseOneStmt(sql, "", "")
+	c.Assert(err, IsNil, Commentf("sql: %s", sql))
+	fields, err := Bind(stmt, catalog)
+	if err != nil {
+		return nil, nil, err
+	}
+	var names, results []string
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.ColumnNameExpr:
+			names = append(names, referString(x.Refer))
+		case *ast.PositionExpr:
+			names = append(names, referString(x.Refer))
+		}
+		return true
+	})
+	for _, field := range fields {
+		results = append(results, referString(field))
+	}
+	return names, results, nil
+}
+
+func (s *testBindSuite) TestBind(c *C) {
+	catalog := mockCatalog{"t1": "id,a,b", "t2": "id,c", "db.t3": "id,x"}
+	cases := []struct {
+		sql     string
+		names   []string
+		results []string
+	}{
+		{"select a, t1.b, c from t1, t2", []string{"t1.a", "t1.b", "t2.c"}, []string{"t1.a", "t1.b", "t2.c"}},
+		{"select * from t1 x join db.t3 on x.id = t3.id", []string{"x.id", "t3.id"}, []string{"x.id", "x.a", "x.b", "t3.id", "t3.x"}},
+		{"select x.*, 1 as one, a + 1 from t1 x", []string{"x.a"}, []string{"x.id", "x.a", "x.b", "one", "a + 1"}},
+		{"select * from t1 join t2 using (id)", nil, []string{"t1.id", "t1.a", "t1.b", "t2.c"}},
+		{"select id from t1 natural join t2 where id > 0", []string{"t1.id", "t1.id"}, []string{"t1.id"}},
+		{"select id, t2.id from t1 right join t2 using (id)", []string{"t2.id", "t2.id"}, []string{"t2.id", "t2.id"}},
+		{"select a from t1 where exists (select 1 from t2 where c = a and id = t1.id)", []string{"t1.a", "t2.c", "t1.a", "t2.id", "t1.id"}, []string{"t1.a"}},
+		{"select d.x, y from (select a as x, b + 1 as y from t1) d", []string{"d.x", "d.y", "t1.a", "t1.b"}, []string{"d.x", "d.y"}},
+		{"select a as x, count(*) as n from t1 group by x having n > 1 order by x, 2", []string{"t1.a", "t1.x", "n", "t1.x", "n"}, []string{"t1.x", "n"}},
+		{"select a as id from t1 order by id", []string{"t1.a", "t1.id"}, []string{"t1.id"}},
+		{"select a as id from t1 group by id", []string{"t1.a", "t1.id"}, []string{"t1.id"}},
+		{"select a, a from t1 order by a", []string{"t1.a", "t1.a", "t1.a"}, []string{"t1.a", "t1.a"}},
+		{"with c (k) as (select a from t1) select k from c where k > 0", []string{"t1.a", "c.k", "c.k"}, []string{"c.k"}},
+		{"with recursive r as (select 1 as n union all select n + 1 from r where n < 3) select n from r", []string{"r.n", "r.n", "r.n"}, []string{"r.n"}},
+		{"select a from t1 union select c from t2 order by a", []string{"t1.a", "t2.c", "t1.a"}, []string{"t1.a"}},
+		{"insert into t1 (id, a) values (1, 2) on duplicate key update a = values(a) + b", []string{"t1.a", "t1.b"}, nil},
+		{"update t1 join t2 on t1.id = t2.id set a = c where b > 0", []string{"t1.id", "t2.id", "t2.c", "t1.b"}, nil},
+		{"delete t1 from t1 join t2 using (id) where c > 0", []string{"t2.c"}, nil},
+	}
+	for _, ca := range cases {
+		names, results, err := bindStrings(c, ca.sql, catalog)
+		c.Assert(err, IsNil, Commentf("sql: %s", ca.sql))
+		c.Assert(names, DeepEquals, ca.names, Commentf("sql: %s", ca.sql))
+		c.Assert(results, DeepEquals, ca.results, Commentf("sql: %s", ca.sql))
+	}
+}
+
+func (s *testBindSuite) TestBindErrors(c *C) {
+	catalog := mockCatalog{"t1": "id,a,b", "t2": "id,c"}
+	cases := []struct {
+		sql string
+		err *terror.Error
+		msg string
+	}{
+		{"select d from t1", ErrBadField, "Unknown column 'd' in 'field list'"},
+		{"select t2.a from t1", ErrBadField, "Unknown column 't2.a' in 'field list'"},
+		{"select t1.c from t1, t2", ErrBadField, "Unknown column 't1.c' in 'field list'"},
+		{"select id from t1, t2", ErrNonUniq, "Column 'id' in field list is ambiguous"},
+		{"select a as x from t1 where x > 0", ErrBadField, "Unknown column 'x' in 'where clause'"},
+		{"select a as x, b as x from t1 order by x", ErrNonUniq, "Column 'x' in order clause is ambiguous"},
+		{"select a from t1 order by 2", ErrBadField, "Unknown column '2' in 'order clause'"},
+		{"select * from t1 join t2 on t1.id = t3.id", ErrBadField, "Unknown column 't3.id' in 'on clause'"},
+		{"select * from t1 join t2 using (a)", ErrBadField, "Unknown column 'a' in 'from clause'"},
+		{"select * from t1 x, (select a from t2) d", ErrBadField, "Unknown column 'a' in 'field list'"},
+		{"select * from t1 x, (select x.a from t2) d", ErrBadField, "Unknown column 'x.a' in 'field list'"},
+		{"select * from (select a, a from t1) d", ErrDupFieldName, "Duplicate column name 'a'"},
+		{"select * from t1, t1", ErrNonuniqTable, "Not unique table/alias: 't1'"},
+		{"select * from t9", ErrNoSuchTable, "Table '.t9' doesn't exist"},
+		{"select t2.* from t1", ErrBadTable, "Unknown table 't2'"},
+		{"select *", ErrNoTablesUsed, "No tables used"},
+		{"with c (x, y) as (select a from t1) select * from c", ErrViewWrongList, "View's SELECT and view's field list have different column counts"},
+		{"delete t2 from t1", ErrUnknownTable, "Unknown table 't2' in MULTI DELETE"},
+		{"update t1 set d = 1", ErrBadField, "Unknown column 'd' in 'field list'"},
+	}
+	for _, ca := range cases {
+		_, _, err := bindStrings(c, ca.sql, catalog)
+		c.Assert(ca.err.Equal(err), IsTrue, Commentf("sql: %s, err: %v", ca.sql, err))
+		c.Assert(err.Error(), Matches, `\[\w+:\d+\]`+regexp.QuoteMeta(ca.msg), Commentf("sql: %s", ca.sql))
+	}
+}
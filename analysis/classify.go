@@ -0,0 +1,341 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"strings"
+
+	"github.com/pingcap/parser/ast"
+)
+
+// Category is the category of a statement.
+type Category int
+
+// Statement categories.
+const (
+	// CategoryDQL is the queries, such as SELECT and SHOW.
+	CategoryDQL Category = iota + 1
+	// CategoryDML is the statements modifying data, such as INSERT and UPDATE.
+	CategoryDML
+	// CategoryDDL is the statements modifying schemas, such as CREATE TABLE.
+	CategoryDDL
+	// CategoryDCL is the statements modifying users and privileges, such as GRANT.
+	CategoryDCL
+	// CategoryTCL is the statements controlling transactions and table locks, such as COMMIT.
+	CategoryTCL
+	// CategoryAdmin is the statements administrating the server, such as FLUSH and KILL.
+	CategoryAdmin
+	// CategorySession is the statements modifying the state of the session, such as SET and USE.
+	CategorySession
+)
+
+// String implements fmt.Stringer interface.
+func (c Category) String() string {
+	switch c {
+	case CategoryDQL:
+		return "DQL"
+	case CategoryDML:
+		return "DML"
+	case CategoryDDL:
+		return "DDL"
+	case CategoryDCL:
+		return "DCL"
+	case CategoryTCL:
+		return "TCL"
+	case CategoryAdmin:
+		return "admin"
+	case CategorySession:
+		return "session"
+	}
+	return "unknown"
+}
+
+// Classification is the classification of a statement.
+type Classification struct {
+	Category Category
+	// ImplicitCommit is true if the statement may commit the current transaction
+	// implicitly, see https://dev.mysql.com/doc/refman/8.0/en/implicit-commit.html.
+	ImplicitCommit bool
+	// ReadReplicaSafe is true if the statement can be executed by a read replica
+	// instead of the primary. It doesn't write data or files, take locks, or
+	// depend on or change the state of the session or the server.
+	ReadReplicaSafe bool
+	// HoldsLocks is true if the statement takes locks which are held after it's
+	// executed, until the transaction ends or they are released explicitly.
+	HoldsLocks bool
+	// Idempotent is true if the statement has the same effect when it's retried
+	// after it's executed, such as after the connection is lost.
+	Idempotent bool
+}
+
+// Classify classifies stmt. Unlike ast.IsReadOnly, the subqueries, CTEs and
+// the values of SET are inspected for the locking reads, the assignments to
+// variables and the functions with side effects.
+func Classify(stmt ast.StmtNode) Classification {
+	switch x := stmt.(type) {
+	case *ast.ExplainStmt:
+		if x.Analyze {
+			return Classify(x.Stmt)
+		}
+		// the statement isn't executed.
+		return Classification{Category: CategoryDQL, ReadReplicaSafe: true, Idempotent: true}
+	case *ast.TraceStmt:
+		return Classify(x.Stmt)
+	}
+
+	cls := Classification{Category: categoryOf(stmt)}
+	switch cls.Category {
+	case CategoryDQL:
+		cls.ReadReplicaSafe, cls.Idempotent = true, true
+	case CategoryDML:
+		cls.HoldsLocks = true
+	case CategoryDDL, CategoryDCL:
+		cls.ImplicitCommit = true
+	}
+	switch x := stmt.(type) {
+	case *ast.ShowStmt:
+		switch x.Tp {
+		case ast.ShowWarnings, ast.ShowErrors, ast.ShowProcessList, ast.ShowMasterStatus:
+			cls.ReadReplicaSafe = false
+		}
+	case *ast.UpdateStmt:
+		// the assigned values must not depend on the assigned columns, like `a = a + 1`.
+		assigned := make(map[string]bool, len(x.List))
+		for _, assignment := range x.List {
+			assigned[assignment.Column.Name.L] = true
+		}
+		cls.Idempotent = x.Limit == nil
+		for _, assignment := range x.List {
+			cls.Idempotent = cls.Idempotent && !refersTo(assignment.Expr, assigned, func(n ast.Node) string {
+				if col, ok := n.(*ast.ColumnNameExpr); ok {
+					return col.Name.Name.L
+				}
+				return ""
+			})
+		}
+	case *ast.DeleteStmt:
+		cls.Idempotent = x.Limit == nil
+	case *ast.CallStmt:
+		// the procedure may do anything.
+		cls.ImplicitCommit = true
+	case *ast.CreateTableStmt:
+		cls.ImplicitCommit = x.TemporaryKeyword != ast.TemporaryLocal
+		cls.Idempotent = x.IfNotExists
+	case *ast.DropTableStmt:
+		cls.ImplicitCommit = x.TemporaryKeyword != ast.TemporaryLocal
+		cls.Idempotent = x.IfExists
+	case *ast.CreateDatabaseStmt:
+		cls.Idempotent = x.IfNotExists
+	case *ast.DropDatabaseStmt:
+		cls.Idempotent = x.IfExists
+	case *ast.CreateViewStmt:
+		cls.Idempotent = x.OrReplace
+	case *ast.CreateIndexStmt:
+		cls.Idempotent = x.IfNotExists
+	case *ast.DropIndexStmt:
+		cls.Idempotent = x.IfExists
+	case *ast.CreateSequenceStmt:
+		cls.Idempotent = x.IfNotExists
+	case *ast.DropSequenceStmt:
+		cls.Idempotent = x.IfExists
+	case *ast.CreatePlacementPolicyStmt:
+		cls.Idempotent = x.IfNotExists
+	case *ast.DropPlacementPolicyStmt:
+		cls.Idempotent = x.IfExists
+	case *ast.CreateStatisticsStmt:
+		cls.Idempotent = x.IfNotExists
+	case *ast.TruncateTableStmt:
+		cls.Idempotent = true
+	case *ast.CreateUserStmt:
+		cls.Idempotent = x.IfNotExists
+	case *ast.DropUserStmt:
+		cls.Idempotent = x.IfExists
+	case *ast.AlterUserStmt, *ast.GrantStmt, *ast.GrantRoleStmt, *ast.GrantProxyStmt, *ast.SetPwdStmt:
+		cls.Idempotent = true
+	case *ast.SetDefaultRoleStmt:
+		// SET DEFAULT ROLE doesn't commit the transaction.
+		cls.ImplicitCommit, cls.Idempotent = false, true
+	case *ast.BeginStmt:
+		// a read-only transaction can be routed to a read replica as a whole.
+		cls.ImplicitCommit, cls.ReadReplicaSafe, cls.Idempotent = true, x.ReadOnly, true
+	case *ast.CommitStmt, *ast.RollbackStmt:
+		cls.Idempotent = true
+	case *ast.LockTablesStmt:
+		cls.ImplicitCommit, cls.HoldsLocks, cls.Idempotent = true, true, true
+	case *ast.UnlockTablesStmt:
+		// UNLOCK TABLES commits the transaction if any table is locked by LOCK TABLES.
+		cls.ImplicitCommit, cls.Idempotent = true, true
+	case *ast.FlushStmt:
+		cls.ImplicitCommit, cls.HoldsLocks, cls.Idempotent = true, x.ReadLock, true
+	case *ast.AnalyzeTableStmt, *ast.RepairTableStmt:
+		cls.ImplicitCommit, cls.Idempotent = true, true
+	case *ast.AdminStmt:
+		switch x.Tp {
+		case ast.AdminCheckTable, ast.AdminCheckIndex, ast.AdminCheckIndexRange, ast.AdminChecksumTable:
+			cls.ImplicitCommit, cls.Idempotent = true, true
+		case ast.AdminShowDDL, ast.AdminShowDDLJobs, ast.AdminShowDDLJobQueries, ast.AdminShowSlow,
+			ast.AdminShowNextRowID, ast.AdminShowTelemetry:
+			cls.Idempotent = true
+		}
+	case *ast.HelpStmt, *ast.ShowImportStmt:
+		cls.ReadReplicaSafe, cls.Idempotent = true, true
+	case *ast.SetStmt:
+		// the values must not depend on the assigned variables, like `@a = @a + 1`.
+		assigned := make(map[string]bool, len(x.Variables))
+		for _, assignment := range x.Variables {
+			if assignment.IsSystem && strings.EqualFold(assignment.Name, "autocommit") {
+				// enabling autocommit commits the transaction.
+				cls.ImplicitCommit = true
+			}
+			assigned[strings.ToLower(assignment.Name)] = true
+		}
+		cls.Idempotent = true
+		for _, assignment := range x.Variables {
+			cls.Idempotent = cls.Idempotent && !refersTo(assignment.Value, assigned, func(n ast.Node) string {
+				if v, ok := n.(*ast.VariableExpr); ok {
+					return strings.ToLower(v.Name)
+				}
+				return ""
+			})
+		}
+	case *ast.UseStmt, *ast.SetRoleStmt, *ast.PrepareStmt:
+		cls.Idempotent = true
+	case *ast.ExecuteStmt:
+		// the prepared statement is unknown.
+		cls.HoldsLocks = true
+	}
+
+	switch stmt.(type) {
+	case *ast.CreateViewStmt, *ast.CreateBindingStmt, *ast.DropBindingStmt, *ast.PrepareStmt:
+		// the queries in the statements aren't executed.
+		return cls
+	}
+	effects := effectsOf(stmt)
+	if effects.locks {
+		cls.HoldsLocks, cls.ReadReplicaSafe = true, false
+	}
+	if effects.writes {
+		cls.ReadReplicaSafe, cls.Idempotent = false, false
+	}
+	if effects.session {
+		cls.ReadReplicaSafe = false
+	}
+	if effects.nondeterministic && cls.Category != CategoryDQL {
+		// the statement may change different values when it's retried.
+		cls.Idempotent = false
+	}
+	return cls
+}
+
+func categoryOf(stmt ast.StmtNode) Category {
+	switch stmt.(type) {
+	case *ast.SelectStmt, *ast.SetOprStmt, *ast.ShowStmt, *ast.DoStmt:
+		return CategoryDQL
+	case *ast.InsertStmt, *ast.UpdateStmt, *ast.DeleteStmt, *ast.LoadDataStmt, *ast.CallStmt:
+		return CategoryDML
+	case *ast.CreateDatabaseStmt, *ast.AlterDatabaseStmt, *ast.DropDatabaseStmt,
+		*ast.CreateTableStmt, *ast.AlterTableStmt, *ast.DropTableStmt, *ast.RenameTableStmt, *ast.TruncateTableStmt,
+		*ast.CreateViewStmt, *ast.CreateIndexStmt, *ast.DropIndexStmt,
+		*ast.CreateSequenceStmt, *ast.AlterSequenceStmt, *ast.DropSequenceStmt,
+		*ast.CreatePlacementPolicyStmt, *ast.AlterPlacementPolicyStmt, *ast.DropPlacementPolicyStmt,
+		*ast.CreateStatisticsStmt, *ast.DropStatisticsStmt, *ast.RecoverTableStmt, *ast.FlashBackTableStmt:
+		return CategoryDDL
+	case *ast.GrantStmt, *ast.GrantRoleStmt, *ast.GrantProxyStmt, *ast.RevokeStmt, *ast.RevokeRoleStmt,
+		*ast.CreateUserStmt, *ast.AlterUserStmt, *ast.DropUserStmt, *ast.RenameUserStmt,
+		*ast.SetPwdStmt, *ast.SetDefaultRoleStmt:
+		return CategoryDCL
+	case *ast.BeginStmt, *ast.CommitStmt, *ast.RollbackStmt, *ast.LockTablesStmt, *ast.UnlockTablesStmt:
+		return CategoryTCL
+	case *ast.SetStmt, *ast.SetRoleStmt, *ast.UseStmt,
+		*ast.PrepareStmt, *ast.ExecuteStmt, *ast.DeallocateStmt:
+		return CategorySession
+	}
+	return CategoryAdmin
+}
+
+// refersTo returns whether the name of any node in node is in names, the names
+// are returned by nameOf, which returns an empty string if a node has no name.
+func refersTo(node ast.Node, names map[string]bool, nameOf func(ast.Node) string) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n != nil && names[nameOf(n)] {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// effects are the effects of the expressions and the queries in a statement.
+type effects struct {
+	// locks is true if there are locking reads or functions taking locks.
+	locks bool
+	// writes is true if files, variables, sequences or locks are modified.
+	writes bool
+	// session is true if the statement depends on the state of the session or the server.
+	session bool
+	// nondeterministic is true if there are functions returning different results for the same arguments.
+	nondeterministic bool
+}
+
+var (
+	lockingFuncs = map[string]bool{ast.GetLock: true}
+	writingFuncs = map[string]bool{
+		ast.GetLock: true, ast.ReleaseLock: true, ast.ReleaseAllLocks: true,
+		ast.NextVal: true, ast.SetVal: true,
+	}
+	// sessionFuncs are the functions depending on the session or the server. SLEEP
+	// and BENCHMARK are usually used to probe or occupy the server, instead of reading data.
+	sessionFuncs = map[string]bool{
+		ast.IsFreeLock: true, ast.IsUsedLock: true, ast.LastInsertId: true, ast.FoundRows: true,
+		ast.RowCount: true, ast.ConnectionID: true, ast.LastVal: true, ast.Sleep: true, ast.Benchmark: true,
+	}
+	nondeterministicFuncs = map[string]bool{
+		ast.Rand: true, ast.RandomBytes: true, ast.UUID: true, ast.UUIDShort: true,
+		ast.Now: true, ast.Sysdate: true, ast.CurrentTimestamp: true, ast.CurrentDate: true, ast.CurrentTime: true,
+		ast.Curdate: true, ast.Curtime: true, ast.LocalTime: true, ast.LocalTimestamp: true,
+		ast.UTCTimestamp: true, ast.UTCDate: true, ast.UTCTime: true, ast.UnixTimestamp: true,
+	}
+)
+
+func effectsOf(stmt ast.StmtNode) effects {
+	var eff effects
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.SelectStmt:
+			if x.LockInfo != nil && x.LockInfo.LockType != ast.SelectLockNone {
+				eff.locks = true
+			}
+			if x.SelectIntoOpt != nil {
+				// SELECT ... INTO writes a file or variables.
+				eff.writes = true
+			}
+		case *ast.VariableExpr:
+			// the user variables are in the session.
+			if !x.IsSystem {
+				eff.writes = eff.writes || x.Value != nil
+				eff.session = true
+			}
+		case *ast.FuncCallExpr:
+			name := x.FnName.L
+			eff.locks = eff.locks || lockingFuncs[name]
+			// LAST_INSERT_ID(expr) sets the value returned by LAST_INSERT_ID().
+			eff.writes = eff.writes || writingFuncs[name] || (name == ast.LastInsertId && len(x.Args) > 0)
+			eff.session = eff.session || sessionFuncs[name]
+			eff.nondeterministic = eff.nondeterministic || nondeterministicFuncs[name]
+		}
+		return true
+	})
+	return eff
+}
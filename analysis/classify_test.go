@@ -0,0 +1,128 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis_test
+
+import (
+	"strings"
+
+	. "github.com/pingcap/check"
+	. "github.com/pingcap/parser/analysis"
+)
+
+var _ = Suite(&testClassifySuite{})
+
+type testClassifySuite struct {
+}
+
+// classificationString formats the classification as the category followed by the true flags.
+func classificationString(cls Classification) string {
+	strs := []string{cls.Category.String()}
+	for _, flag := range []struct {
+		name string
+		set  bool
+	}{
+		{"commit", cls.ImplicitCommit},
+		{"replica", cls.ReadReplicaSafe},
+		{"locks", cls.HoldsLocks},
+		{"idempotent", cls.Idempotent},
+	} {
+		if flag.set {
+			strs = append(strs, flag.name)
+		}
+	}
+	return strings.Join(strs, " ")
+}
+
+func (s *testClassifySuite) TestClassify(c *C) {
+	cases := []struct {
+		sql      string
+		expected string
+	}{
+		{"select a from t where b = 1", "DQL replica idempotent"},
+		{"select a from t union select now()", "DQL replica idempotent"},
+		{"select a from t for update", "DQL locks idempotent"},
+		{"select a from t lock in share mode", "DQL locks idempotent"},
+		{"with c as (select a from t for update) select * from c", "DQL locks idempotent"},
+		{"select a from t where b in (select b from s for update)", "DQL locks idempotent"},
+		{"select a from t into outfile '/tmp/a'", "DQL"},
+		{"select @a := a from t", "DQL"},
+		{"select @a", "DQL idempotent"},
+		{"select @@version", "DQL replica idempotent"},
+		{"select get_lock('a', 10)", "DQL locks"},
+		{"select release_lock('a')", "DQL"},
+		{"select sleep(1)", "DQL idempotent"},
+		{"select last_insert_id()", "DQL idempotent"},
+		{"select nextval(seq)", "DQL"},
+		{"do get_lock('a', 10)", "DQL locks"},
+		{"show tables", "DQL replica idempotent"},
+		{"show warnings", "DQL idempotent"},
+		{"explain delete from t", "DQL replica idempotent"},
+		{"explain analyze select a from t", "DQL replica idempotent"},
+		{"explain analyze delete from t", "DML locks idempotent"},
+		{"trace select a from t for update", "DQL locks idempotent"},
+
+		{"insert into t values (1)", "DML locks"},
+		{"replace into t select * from s", "DML locks"},
+		{"update t set a = 1 where b = 2", "DML locks idempotent"},
+		{"update t set a = a + 1", "DML locks"},
+		{"update t set a = b, b = 1", "DML locks"},
+		{"update t set a = 1 limit 10", "DML locks"},
+		{"update t set a = now()", "DML locks"},
+		{"delete from t where a = 1", "DML locks idempotent"},
+		{"delete from t order by a limit 10", "DML locks"},
+		{"delete from t where a < unix_timestamp()", "DML locks"},
+		{"load data infile '/tmp/a' into table t", "DML locks"},
+		{"call p()", "DML commit locks"},
+
+		{"create table t (a int)", "DDL commit"},
+		{"create table if not exists t (a int)", "DDL commit idempotent"},
+		{"create temporary table t (a int)", "DDL"},
+		{"drop temporary table if exists t", "DDL idempotent"},
+		{"create table t select a from s for update", "DDL commit locks"},
+		{"create or replace view v as select get_lock('a', 1)", "DDL commit idempotent"},
+		{"alter table t add column b int", "DDL commit"},
+		{"truncate table t", "DDL commit idempotent"},
+		{"drop index if exists i on t", "DDL commit idempotent"},
+
+		{"grant select on db.* to u", "DCL commit idempotent"},
+		{"revoke select on db.* from u", "DCL commit"},
+		{"create user if not exists u", "DCL commit idempotent"},
+		{"set default role all to u", "DCL idempotent"},
+
+		{"begin", "TCL commit idempotent"},
+		{"start transaction read only", "TCL commit replica idempotent"},
+		{"commit", "TCL idempotent"},
+		{"lock tables t read", "TCL commit locks idempotent"},
+		{"unlock tables", "TCL commit idempotent"},
+
+		{"set @a = 1, @@session.sql_mode = ''", "session idempotent"},
+		{"set @a = @a + 1", "session"},
+		{"set @a = (select a from t for update)", "session locks idempotent"},
+		{"set @a = nextval(seq)", "session"},
+		{"set @a = rand()", "session"},
+		{"set autocommit = 1", "session commit idempotent"},
+		{"set names utf8mb4", "session idempotent"},
+		{"use db", "session idempotent"},
+		{"prepare s from 'select get_lock(?, 1)'", "session idempotent"},
+		{"execute s", "session locks"},
+
+		{"flush tables with read lock", "admin commit locks idempotent"},
+		{"analyze table t", "admin commit idempotent"},
+		{"kill 1", "admin"},
+		{"admin show ddl jobs", "admin idempotent"},
+	}
+	for _, ca := range cases {
+		c.Assert(classificationString(Classify(parse(c, ca.sql))), Equals, ca.expected, Commentf("sql: %s", ca.sql))
+	}
+}
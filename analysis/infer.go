@@ -0,0 +1,1086 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"strings"
+
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/charset"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/opcode"
+	"github.com/pingcap/parser/types"
+)
+
+// divPrecisionIncrement is the default value of the system variable div_precision_increment.
+const divPrecisionIncrement = 4
+
+// groupConcatMaxLen is the default value of the system variable group_concat_max_len.
+const groupConcatMaxLen = 1024
+
+// InferTypes binds the statement like Bind, and sets the types of the expressions
+// in it by SetType. The types are inferred by the rules of MySQL, from the types of
+// the columns in the catalog and the literals. It returns the result fields of the
+// statement if it's a query, whose Column.FieldType is the type of the column in
+// the result set.
+//
+// The columns of the inner tables of outer joins are nullable, and the columns of
+// set operations have the types merged from all the queries.
+func InferTypes(stmt ast.StmtNode, catalog Catalog) ([]*ast.ResultField, error) {
+	b := newBinder(catalog)
+	b.infer = true
+	return b.stmt(stmt, nil)
+}
+
+// typeInferrer sets the types of the expressions bottom up. The subqueries are
+// skipped, whose types are set when they are bound.
+type typeInferrer struct{}
+
+func (v *typeInferrer) Enter(n ast.Node) (ast.Node, bool) {
+	_, ok := n.(*ast.SubqueryExpr)
+	return n, ok
+}
+
+func (v *typeInferrer) Leave(n ast.Node) (ast.Node, bool) {
+	if expr, ok := n.(ast.ExprNode); ok {
+		if tp := inferType(expr); tp != nil {
+			expr.SetType(tp)
+		}
+	}
+	return n, true
+}
+
+// inferType returns the type of the expression whose children have the types, it
+// returns nil if the type of the expression needn't be changed.
+func inferType(expr ast.ExprNode) *types.FieldType {
+	switch x := expr.(type) {
+	case ast.ParamMarkerExpr:
+		return nil
+	case ast.ValueExpr:
+		tp := x.GetType().Clone()
+		if tp.Tp != mysql.TypeNull {
+			tp.Flag |= mysql.NotNullFlag
+		}
+		if isStringType(tp) && tp.Charset == "" {
+			tp.Charset, tp.Collate = mysql.DefaultCharset, mysql.DefaultCollationName
+		}
+		return tp
+	case *ast.ColumnNameExpr:
+		return x.Refer.Column.FieldType.Clone()
+	case *ast.ParenthesesExpr:
+		return x.Expr.GetType().Clone()
+	case *ast.BinaryOperationExpr:
+		return binaryOperationType(x)
+	case *ast.UnaryOperationExpr:
+		return unaryOperationType(x)
+	case *ast.IsNullExpr, *ast.IsTruthExpr, *ast.ExistsSubqueryExpr:
+		return nullable(boolType(), false)
+	case *ast.BetweenExpr:
+		return nullable(boolType(), !isNotNull(x.Expr, x.Left, x.Right))
+	case *ast.PatternInExpr:
+		return nullable(boolType(), x.Sel != nil || !isNotNull(append(x.List, x.Expr)...))
+	case *ast.PatternLikeExpr:
+		return nullable(boolType(), !isNotNull(x.Expr, x.Pattern))
+	case *ast.PatternRegexpExpr:
+		return nullable(boolType(), !isNotNull(x.Expr, x.Pattern))
+	case *ast.CompareSubqueryExpr:
+		return nullable(boolType(), true)
+	case *ast.MatchAgainst:
+		return nullable(doubleType(), false)
+	case *ast.CaseExpr:
+		args := make([]ast.ExprNode, 0, len(x.WhenClauses)+1)
+		for _, when := range x.WhenClauses {
+			args = append(args, when.Result)
+		}
+		if x.ElseClause == nil {
+			return nullable(mergeTypes(args...), true)
+		}
+		args = append(args, x.ElseClause)
+		return nullable(mergeTypes(args...), !isNotNull(args...))
+	case *ast.ValuesExpr:
+		return nullable(x.Column.GetType().Clone(), true)
+	case *ast.DefaultExpr:
+		return nil
+	case *ast.VariableExpr:
+		if x.Value != nil {
+			return nullable(x.Value.GetType().Clone(), true)
+		}
+		return stringType(types.UnspecifiedLength, "", "")
+	case *ast.SetCollationExpr:
+		tp := x.Expr.GetType().Clone()
+		if coll, err := charset.GetCollationByName(x.Collate); err == nil {
+			tp.Charset, tp.Collate = coll.CharsetName, coll.Name
+		}
+		return tp
+	case *ast.FuncCastExpr:
+		return castType(x)
+	case *ast.FuncCallExpr:
+		return funcCallType(x)
+	case *ast.AggregateFuncExpr:
+		return aggregateType(x.F, x.Args)
+	case *ast.WindowFuncExpr:
+		return windowType(x)
+	}
+	return nil
+}
+
+// binaryType returns the type of a numeric or temporal result, whose charset is binary.
+func binaryType(tp byte, flen, decimal int) *types.FieldType {
+	ft := types.NewFieldType(tp)
+	ft.Flen, ft.Decimal = flen, decimal
+	ft.Charset, ft.Collate = charset.CharsetBin, charset.CollationBin
+	ft.Flag |= mysql.BinaryFlag
+	return ft
+}
+
+func intType(flen int, unsigned bool) *types.FieldType {
+	ft := binaryType(mysql.TypeLonglong, flen, 0)
+	if unsigned {
+		ft.Flag |= mysql.UnsignedFlag
+	}
+	return ft
+}
+
+func boolType() *types.FieldType {
+	return intType(1, false)
+}
+
+func doubleType() *types.FieldType {
+	return binaryType(mysql.TypeDouble, mysql.MaxRealWidth, types.UnspecifiedLength)
+}
+
+func decimalType(flen, decimal int) *types.FieldType {
+	if decimal > mysql.MaxDecimalScale {
+		decimal = mysql.MaxDecimalScale
+	}
+	if flen < decimal+1 {
+		flen = decimal + 1
+	}
+	if flen > mysql.MaxDecimalWidth {
+		flen = mysql.MaxDecimalWidth
+	}
+	return binaryType(mysql.TypeNewDecimal, flen, decimal)
+}
+
+// temporalType returns the type of DATE, DATETIME, TIMESTAMP and TIME with the fractional seconds precision.
+func temporalType(tp byte, fsp int) *types.FieldType {
+	if fsp < 0 {
+		fsp = 0
+	}
+	flen := mysql.MaxDatetimeWidthNoFsp
+	switch tp {
+	case mysql.TypeDate:
+		return binaryType(tp, mysql.MaxDateWidth, 0)
+	case mysql.TypeDuration:
+		flen = mysql.MaxDurationWidthNoFsp
+	}
+	if fsp > 0 {
+		flen += 1 + fsp
+	}
+	return binaryType(tp, flen, fsp)
+}
+
+// stringType returns the type of a string result, which is a blob if it's too long
+// for VARCHAR. The charset is the default one if it's empty.
+func stringType(flen int, chs, coll string) *types.FieldType {
+	ft := types.NewFieldType(mysql.TypeVarString)
+	switch {
+	case flen > mysql.MaxBlobWidth-1:
+		ft.Tp = mysql.TypeLongBlob
+	case flen > mysql.MaxFieldVarCharLength:
+		ft.Tp = mysql.TypeMediumBlob
+	}
+	ft.Flen = flen
+	if chs == "" {
+		chs, coll = mysql.DefaultCharset, mysql.DefaultCollationName
+	}
+	ft.Charset, ft.Collate = chs, coll
+	if chs == charset.CharsetBin {
+		ft.Flag |= mysql.BinaryFlag
+	}
+	return ft
+}
+
+func jsonType() *types.FieldType {
+	ft := types.NewFieldType(mysql.TypeJSON)
+	ft.Flen, _ = mysql.GetDefaultFieldLengthAndDecimal(mysql.TypeJSON)
+	ft.Charset, ft.Collate = mysql.DefaultCharset, mysql.DefaultCollationName
+	ft.Flag |= mysql.BinaryFlag
+	return ft
+}
+
+// nullable sets the nullability of the type, and clears the flags of the key and
+// the default value, which only make sense for the columns of the tables.
+func nullable(ft *types.FieldType, null bool) *types.FieldType {
+	ft.Flag &^= mysql.NotNullFlag | mysql.PriKeyFlag | mysql.UniqueKeyFlag | mysql.MultipleKeyFlag |
+		mysql.AutoIncrementFlag | mysql.NoDefaultValueFlag | mysql.OnUpdateNowFlag | mysql.PartKeyFlag
+	if !null {
+		ft.Flag |= mysql.NotNullFlag
+	}
+	return ft
+}
+
+// isNotNull reports whether all the expressions are not null.
+func isNotNull(exprs ...ast.ExprNode) bool {
+	for _, expr := range exprs {
+		if !mysql.HasNotNullFlag(expr.GetType().Flag) {
+			return false
+		}
+	}
+	return true
+}
+
+// isStringType reports whether the type is a string, including ENUM, SET and JSON.
+func isStringType(ft *types.FieldType) bool {
+	if ft.Tp == mysql.TypeNull || ft.Tp == mysql.TypeUnspecified {
+		return false
+	}
+	return ft.EvalType() == types.ETString || ft.Tp == mysql.TypeJSON
+}
+
+// isTemporalType reports whether the type is DATE, DATETIME, TIMESTAMP or TIME.
+func isTemporalType(ft *types.FieldType) bool {
+	switch ft.Tp {
+	case mysql.TypeDate, mysql.TypeDatetime, mysql.TypeTimestamp, mysql.TypeDuration:
+		return true
+	}
+	return false
+}
+
+func isBlobType(tp byte) bool {
+	return types.IsTypeBlob(tp) || tp == mysql.TypeJSON
+}
+
+// lengthOf returns the display length of the type, which is the default one if it's unspecified.
+func lengthOf(ft *types.FieldType) int {
+	if ft.Flen != types.UnspecifiedLength {
+		return ft.Flen
+	}
+	l, _ := mysql.GetDefaultFieldLengthAndDecimal(ft.Tp)
+	if l < 0 {
+		return 0
+	}
+	return l
+}
+
+// precisionOf returns the number of the digits of a numeric type, which is the
+// length without the sign of a signed integer, like my_decimal_length_to_precision.
+func precisionOf(ft *types.FieldType) int {
+	l := lengthOf(ft)
+	if ft.EvalType() == types.ETInt && !mysql.HasUnsignedFlag(ft.Flag) && l > 1 {
+		l--
+	}
+	return l
+}
+
+// decimalOf returns the number of the decimal digits of a numeric or temporal type.
+func decimalOf(ft *types.FieldType) int {
+	if ft.Decimal < 0 || ft.Decimal == mysql.NotFixedDec {
+		return 0
+	}
+	return ft.Decimal
+}
+
+// stringLength returns the length of the type converted to a string.
+func stringLength(ft *types.FieldType) int {
+	switch ft.Tp {
+	case mysql.TypeNull:
+		return 0
+	case mysql.TypeEnum, mysql.TypeSet:
+		l := 0
+		for _, elem := range ft.Elems {
+			if ft.Tp == mysql.TypeSet {
+				l += len(elem) + 1
+			} else if len(elem) > l {
+				l = len(elem)
+			}
+		}
+		return l
+	case mysql.TypeFloat, mysql.TypeDouble:
+		if ft.Decimal == types.UnspecifiedLength || ft.Decimal == mysql.NotFixedDec {
+			l, _ := mysql.GetDefaultFieldLengthAndDecimal(ft.Tp)
+			return l
+		}
+	case mysql.TypeNewDecimal:
+		// the sign and the decimal point.
+		if decimalOf(ft) > 0 {
+			return lengthOf(ft) + 2
+		}
+		return lengthOf(ft) + 1
+	case mysql.TypeDate, mysql.TypeDatetime, mysql.TypeTimestamp, mysql.TypeDuration:
+		return lengthOf(temporalType(ft.Tp, decimalOf(ft)))
+	case mysql.TypeBit:
+		return (lengthOf(ft) + 7) / 8
+	}
+	return lengthOf(ft)
+}
+
+// numericType returns the type of the expression used as a number.
+func numericType(ft *types.FieldType) *types.FieldType {
+	switch ft.EvalType() {
+	case types.ETInt:
+		return ft
+	case types.ETDecimal:
+		return ft
+	case types.ETDatetime, types.ETTimestamp, types.ETDuration:
+		// such as 20210102030405.123 for DATETIME(3).
+		digits := 14
+		switch ft.Tp {
+		case mysql.TypeDate:
+			digits = 8
+		case mysql.TypeDuration:
+			digits = 7
+		}
+		if fsp := decimalOf(ft); fsp > 0 {
+			return decimalType(digits+fsp, fsp)
+		}
+		return intType(digits, false)
+	}
+	if ft.Tp == mysql.TypeNull {
+		return intType(0, false)
+	}
+	return doubleType()
+}
+
+// integerTypes are the integer types ordered by width.
+var integerTypes = []byte{mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong}
+
+// integerRank returns the index of the integer type in integerTypes, BIT and YEAR
+// are merged as BIGINT.
+func integerRank(tp byte) int {
+	for i, t := range integerTypes {
+		if t == tp {
+			return i
+		}
+	}
+	return len(integerTypes) - 1
+}
+
+func binaryOperationType(x *ast.BinaryOperationExpr) *types.FieldType {
+	l, r := x.L.GetType(), x.R.GetType()
+	notNull := isNotNull(x.L, x.R)
+	switch x.Op {
+	case opcode.LogicAnd, opcode.LogicOr, opcode.LogicXor, opcode.GE, opcode.LE, opcode.EQ, opcode.NE, opcode.LT, opcode.GT:
+		return nullable(boolType(), !notNull)
+	case opcode.NullEQ:
+		return nullable(boolType(), false)
+	case opcode.And, opcode.Or, opcode.Xor, opcode.LeftShift, opcode.RightShift:
+		return nullable(intType(mysql.MaxIntWidth, true), !notNull)
+	case opcode.Plus, opcode.Minus, opcode.Mul:
+		return nullable(arithmeticType(x.Op, numericType(l), numericType(r)), !notNull)
+	case opcode.Div, opcode.Mod:
+		// it's NULL if the divisor is 0.
+		return nullable(arithmeticType(x.Op, numericType(l), numericType(r)), true)
+	case opcode.IntDiv:
+		l, r = numericType(l), numericType(r)
+		unsigned := mysql.HasUnsignedFlag(l.Flag) || mysql.HasUnsignedFlag(r.Flag)
+		length := lengthOf(l) - decimalOf(l)
+		if length > mysql.MaxIntWidth || l.EvalType() == types.ETReal {
+			length = mysql.MaxIntWidth
+		}
+		return nullable(intType(length, unsigned), true)
+	}
+	return nil
+}
+
+// arithmeticType returns the type of +, -, *, / and % of the numeric types.
+func arithmeticType(op opcode.Op, l, r *types.FieldType) *types.FieldType {
+	if l.EvalType() == types.ETReal || r.EvalType() == types.ETReal {
+		return doubleType()
+	}
+	unsigned := mysql.HasUnsignedFlag(l.Flag) || mysql.HasUnsignedFlag(r.Flag)
+	if op == opcode.Mod {
+		unsigned = mysql.HasUnsignedFlag(l.Flag)
+	}
+	lDec, rDec := decimalOf(l), decimalOf(r)
+	lInt, rInt := precisionOf(l)-lDec, precisionOf(r)-rDec
+	if op == opcode.Div {
+		// the division of the integers is a decimal.
+		scale := lDec + divPrecisionIncrement
+		return decimalType(lInt+rDec+scale, scale)
+	}
+	if l.EvalType() == types.ETDecimal || r.EvalType() == types.ETDecimal {
+		var ft *types.FieldType
+		switch op {
+		case opcode.Mul:
+			ft = decimalType(precisionOf(l)+precisionOf(r), lDec+rDec)
+		case opcode.Mod:
+			scale := maxInt(lDec, rDec)
+			ft = decimalType(maxInt(lInt, rInt)+scale, scale)
+		default:
+			scale := maxInt(lDec, rDec)
+			ft = decimalType(maxInt(lInt, rInt)+scale+1, scale)
+		}
+		if unsigned {
+			ft.Flag |= mysql.UnsignedFlag
+		}
+		return ft
+	}
+	var length int
+	switch op {
+	case opcode.Mul:
+		length = lengthOf(l) + lengthOf(r)
+	case opcode.Mod:
+		length = maxInt(lengthOf(l), lengthOf(r))
+	default:
+		length = maxInt(lengthOf(l), lengthOf(r)) + 1
+	}
+	if length > mysql.MaxIntWidth {
+		length = mysql.MaxIntWidth
+	}
+	return intType(length, unsigned)
+}
+
+func unaryOperationType(x *ast.UnaryOperationExpr) *types.FieldType {
+	notNull := isNotNull(x.V)
+	switch x.Op {
+	case opcode.Not, opcode.Not2:
+		return nullable(boolType(), !notNull)
+	case opcode.BitNeg:
+		return nullable(intType(mysql.MaxIntWidth, true), !notNull)
+	case opcode.Minus:
+		ft := numericType(x.V.GetType()).Clone()
+		if ft.EvalType() == types.ETInt {
+			if mysql.HasUnsignedFlag(ft.Flag) && lengthOf(ft) >= mysql.MaxIntWidth {
+				ft = decimalType(mysql.MaxIntWidth, 0)
+			} else if ft.Flen = lengthOf(ft) + 1; ft.Flen > mysql.MaxIntWidth {
+				ft.Flen = mysql.MaxIntWidth
+			}
+			ft.Tp = mysql.TypeLonglong
+		}
+		ft.Flag &^= mysql.UnsignedFlag
+		return nullable(ft, !notNull)
+	case opcode.Plus:
+		return nullable(x.V.GetType().Clone(), !notNull)
+	}
+	return nil
+}
+
+// mergeTypes returns the type of the results of CASE, IF, COALESCE and set operations,
+// which is nullable. NULL is ignored unless all the results are NULL.
+func mergeTypes(exprs ...ast.ExprNode) *types.FieldType {
+	fts := make([]*types.FieldType, 0, len(exprs))
+	for _, expr := range exprs {
+		fts = append(fts, expr.GetType())
+	}
+	return mergeFieldTypes(fts...)
+}
+
+func mergeFieldTypes(fts ...*types.FieldType) *types.FieldType {
+	var merged *types.FieldType
+	for _, ft := range fts {
+		if ft.Tp == mysql.TypeNull || ft.Tp == mysql.TypeUnspecified {
+			continue
+		}
+		if merged == nil {
+			merged = ft.Clone()
+			if merged.Tp == mysql.TypeEnum || merged.Tp == mysql.TypeSet {
+				merged = stringType(stringLength(ft), ft.Charset, ft.Collate)
+			}
+		} else {
+			merged = mergeType(merged, ft)
+		}
+	}
+	if merged == nil {
+		merged = binaryType(mysql.TypeNull, 0, 0)
+	}
+	return nullable(merged, true)
+}
+
+// mergeType merges two types which aren't NULL.
+func mergeType(a, b *types.FieldType) *types.FieldType {
+	ea, eb := a.EvalType(), b.EvalType()
+	switch {
+	case ea == types.ETInt && eb == types.ETInt:
+		return mergeIntegerType(a, b)
+	case isTemporalType(a) && isTemporalType(b):
+		tp := a.Tp
+		if a.Tp != b.Tp {
+			tp = mysql.TypeDatetime
+		}
+		return temporalType(tp, maxInt(decimalOf(a), decimalOf(b)))
+	case isStringType(a) || isStringType(b) || isTemporalType(a) || isTemporalType(b):
+		if a.Tp == mysql.TypeJSON && b.Tp == mysql.TypeJSON {
+			return jsonType()
+		}
+		chs, coll := mergeCollation(a, b)
+		ft := stringType(maxInt(stringLength(a), stringLength(b)), chs, coll)
+		for _, x := range []*types.FieldType{a, b} {
+			tp := x.Tp
+			if tp == mysql.TypeJSON {
+				tp = mysql.TypeLongBlob
+			}
+			if isBlobType(tp) && (!isBlobType(ft.Tp) || tp > ft.Tp) {
+				ft.Tp = tp
+			}
+		}
+		if a.Tp == mysql.TypeString && b.Tp == mysql.TypeString {
+			ft.Tp = mysql.TypeString
+		}
+		return ft
+	case ea == types.ETReal || eb == types.ETReal:
+		if a.Tp == mysql.TypeFloat && b.Tp == mysql.TypeFloat {
+			return binaryType(mysql.TypeFloat, mysql.MaxRealWidth, types.UnspecifiedLength)
+		}
+		return doubleType()
+	}
+	scale := maxInt(decimalOf(a), decimalOf(b))
+	ft := decimalType(maxInt(precisionOf(a)-decimalOf(a), precisionOf(b)-decimalOf(b))+scale, scale)
+	if mysql.HasUnsignedFlag(a.Flag) && mysql.HasUnsignedFlag(b.Flag) {
+		ft.Flag |= mysql.UnsignedFlag
+	}
+	return ft
+}
+
+// mergeIntegerType merges two integer types. If only one of them is unsigned, the
+// result is the signed type wide enough for both.
+func mergeIntegerType(a, b *types.FieldType) *types.FieldType {
+	ra, rb := integerRank(a.Tp), integerRank(b.Tp)
+	ua, ub := mysql.HasUnsignedFlag(a.Flag), mysql.HasUnsignedFlag(b.Flag)
+	rank, length := maxInt(ra, rb), maxInt(lengthOf(a), lengthOf(b))
+	if ua != ub {
+		unsignedRank := ra
+		if ub {
+			unsignedRank = rb
+		}
+		if unsignedRank >= rank {
+			if rank == len(integerTypes)-1 {
+				return decimalType(mysql.MaxIntWidth, 0)
+			}
+			rank, length = rank+1, length+1
+		}
+	}
+	ft := binaryType(integerTypes[rank], length, 0)
+	if ua && ub {
+		ft.Flag |= mysql.UnsignedFlag
+	}
+	return ft
+}
+
+// mergeCollation returns the charset and the collation of the string result of the
+// types, which is binary if any of the strings is binary.
+func mergeCollation(fts ...*types.FieldType) (string, string) {
+	chs, coll := "", ""
+	for _, ft := range fts {
+		if !isStringType(ft) {
+			continue
+		}
+		if ft.Charset == charset.CharsetBin {
+			return charset.CharsetBin, charset.CollationBin
+		}
+		if chs == "" {
+			chs, coll = ft.Charset, ft.Collate
+		}
+	}
+	return chs, coll
+}
+
+func argTypes(args []ast.ExprNode) []*types.FieldType {
+	fts := make([]*types.FieldType, 0, len(args))
+	for _, arg := range args {
+		fts = append(fts, arg.GetType())
+	}
+	return fts
+}
+
+// constInt returns the value of the expression if it's an integer literal.
+func constInt(expr ast.ExprNode) (int, bool) {
+	v, ok := expr.(ast.ValueExpr)
+	if !ok {
+		return 0, false
+	}
+	switch x := v.GetValue().(type) {
+	case int64:
+		return int(x), true
+	case uint64:
+		return int(x), true
+	}
+	return 0, false
+}
+
+// fspArg returns the fractional seconds precision of the argument of the functions
+// like NOW(3), it's 0 if there is no argument.
+func fspArg(args []ast.ExprNode) int {
+	if len(args) == 0 {
+		return 0
+	}
+	fsp, _ := constInt(args[0])
+	return fsp
+}
+
+func castType(x *ast.FuncCastExpr) *types.FieldType {
+	ft := x.Tp.Clone()
+	arg := x.Expr.GetType()
+	switch ft.Tp {
+	case mysql.TypeVarString, mysql.TypeString:
+		if ft.Flen == types.UnspecifiedLength {
+			ft.Flen = stringLength(arg)
+		}
+		if ft.Charset == "" {
+			ft.Charset, ft.Collate = mysql.DefaultCharset, mysql.DefaultCollationName
+		}
+	case mysql.TypeNewDecimal:
+		defaultFlen, defaultDecimal := mysql.GetDefaultFieldLengthAndDecimalForCast(ft.Tp)
+		if ft.Flen == types.UnspecifiedLength {
+			ft.Flen = defaultFlen
+		}
+		if ft.Decimal == types.UnspecifiedLength {
+			ft.Decimal = defaultDecimal
+		}
+	case mysql.TypeLonglong:
+		ft.Flen = mysql.MaxIntWidth
+		ft.Decimal = 0
+	case mysql.TypeDate, mysql.TypeYear:
+		ft.Flen, ft.Decimal = mysql.GetDefaultFieldLengthAndDecimal(ft.Tp)
+	case mysql.TypeDatetime, mysql.TypeDuration:
+		ft = temporalType(ft.Tp, decimalOf(ft))
+	}
+	return nullable(ft, !isNotNull(x.Expr))
+}
+
+// intFunctions are the functions returning integers and their display lengths.
+var intFunctions = map[string]int{
+	ast.ASCII: 3, ast.Ord: 21, ast.BitCount: 2, ast.Sign: 2, ast.Strcmp: 2, ast.Interval: 2,
+	ast.Length: 10, ast.OctetLength: 10, ast.BitLength: 10, ast.CharLength: 10, ast.CharacterLength: 10,
+	ast.Instr: 11, ast.Locate: 11, ast.Position: 11, ast.FindInSet: 3, ast.Field: 3,
+	ast.Year: 4, ast.Month: 2, ast.Day: 2, ast.DayOfMonth: 2, ast.Hour: 3, ast.Minute: 2, ast.Second: 2,
+	ast.MicroSecond: 6, ast.Quarter: 1, ast.Week: 2, ast.Weekday: 1, ast.DayOfWeek: 1, ast.DayOfYear: 3,
+	ast.WeekOfYear: 2, ast.YearWeek: 6, ast.ToDays: 20, ast.ToSeconds: 20, ast.TimeToSec: 10,
+	ast.DateDiff: 20, ast.TimestampDiff: 20, ast.PeriodAdd: 6, ast.PeriodDiff: 6, ast.Extract: 20,
+	ast.JSONDepth: 11, ast.JSONLength: 11, ast.JSONStorageSize: 11, ast.JSONContains: 1,
+	ast.JSONContainsPath: 1, ast.JSONValid: 1, ast.IsIPv4: 1, ast.IsIPv4Compat: 1, ast.IsIPv4Mapped: 1,
+	ast.IsIPv6: 1, ast.IsFreeLock: 1, ast.IsUsedLock: 21, ast.GetLock: 1, ast.ReleaseLock: 1,
+	ast.ReleaseAllLocks: 21, ast.Sleep: 21, ast.Benchmark: 1, ast.Coercibility: 21,
+	ast.ValidatePasswordStrength: 21, ast.UncompressedLength: 10, ast.FoundRows: 21, ast.RowCount: 21,
+	ast.NextVal: 21, ast.LastVal: 21, ast.SetVal: 21,
+}
+
+// unsignedFunctions are the functions returning unsigned integers and their display lengths.
+var unsignedFunctions = map[string]int{
+	ast.CRC32: 10, ast.InetAton: 21, ast.ConnectionID: 10, ast.LastInsertId: 21, ast.UUIDShort: 21,
+}
+
+// doubleFunctions are the functions returning doubles.
+var doubleFunctions = map[string]bool{
+	ast.Acos: true, ast.Asin: true, ast.Atan: true, ast.Atan2: true, ast.Cos: true, ast.Cot: true,
+	ast.Degrees: true, ast.Exp: true, ast.Ln: true, ast.Log: true, ast.Log2: true, ast.Log10: true,
+	ast.Pow: true, ast.Power: true, ast.Radians: true, ast.Rand: true, ast.Sin: true, ast.Sqrt: true,
+	ast.Tan: true,
+}
+
+// utf8Functions are the functions returning the names in utf8 and their lengths.
+var utf8Functions = map[string]int{
+	ast.Database: 64, ast.Schema: 64, ast.User: 288, ast.CurrentUser: 288, ast.SessionUser: 288,
+	ast.SystemUser: 288, ast.CurrentRole: 288, ast.Version: 64, ast.TiDBVersion: 64, ast.Charset: 64,
+	ast.Collation: 64,
+}
+
+// stringFunctions are the functions returning strings of fixed lengths in the default charset.
+var stringFunctions = map[string]int{
+	ast.MD5: 32, ast.SHA1: 40, ast.SHA: 40, ast.SHA2: 128, ast.UUID: 36, ast.BinToUUID: 36,
+	ast.Bin: 64, ast.Oct: 64, ast.Conv: 64, ast.InetNtoa: 15, ast.Inet6Ntoa: 39, ast.DayName: 9,
+	ast.MonthName: 9, ast.GetFormat: 17, ast.JSONType: 51, ast.ExportSet: mysql.MaxFieldVarCharLength,
+	ast.MakeSet: mysql.MaxFieldVarCharLength, ast.DateFormat: mysql.MaxFieldCharLength,
+	ast.TimeFormat: mysql.MaxFieldCharLength, ast.Format: mysql.MaxFieldCharLength,
+	ast.PasswordFunc: 41, ast.Soundex: mysql.MaxFieldCharLength,
+}
+
+// binaryFunctions are the functions returning binary strings and their lengths.
+var binaryFunctions = map[string]int{
+	ast.Inet6Aton: 16, ast.UUIDToBin: 16, ast.AesEncrypt: mysql.MaxBlobWidth, ast.AesDecrypt: mysql.MaxBlobWidth,
+	ast.Compress: mysql.MaxBlobWidth, ast.Uncompress: mysql.MaxBlobWidth, ast.Encode: mysql.MaxBlobWidth,
+	ast.Decode: mysql.MaxBlobWidth, ast.DesEncrypt: mysql.MaxBlobWidth, ast.DesDecrypt: mysql.MaxBlobWidth,
+	ast.Encrypt: 13, ast.RandomBytes: 1024, ast.LoadFile: mysql.MaxBlobWidth,
+}
+
+// jsonFunctions are the functions returning JSON.
+var jsonFunctions = map[string]bool{
+	ast.JSONExtract: true, ast.JSONArray: true, ast.JSONObject: true, ast.JSONMerge: true,
+	ast.JSONMergePatch: true, ast.JSONMergePreserve: true, ast.JSONSet: true, ast.JSONInsert: true,
+	ast.JSONReplace: true, ast.JSONRemove: true, ast.JSONArrayAppend: true, ast.JSONArrayInsert: true,
+	ast.JSONKeys: true, ast.JSONSearch: true,
+}
+
+// notNullFunctions are the functions returning NULL only if some argument is NULL.
+var notNullFunctions = map[string]bool{
+	ast.ASCII: true, ast.Ord: true, ast.BitCount: true, ast.Sign: true, ast.Strcmp: true,
+	ast.Length: true, ast.OctetLength: true, ast.BitLength: true, ast.CharLength: true,
+	ast.CharacterLength: true, ast.Instr: true, ast.Locate: true, ast.Position: true, ast.FindInSet: true,
+	ast.CRC32: true, ast.MD5: true, ast.SHA1: true, ast.SHA: true, ast.Bin: true, ast.Oct: true,
+	ast.Atan: true, ast.Atan2: true, ast.Cos: true, ast.Degrees: true, ast.Exp: true,
+	ast.Pow: true, ast.Power: true, ast.Radians: true, ast.Sin: true, ast.Tan: true,
+	ast.Abs: true, ast.Ceil: true, ast.Ceiling: true, ast.Floor: true, ast.Round: true, ast.Truncate: true,
+	ast.Lower: true, ast.Lcase: true, ast.Upper: true, ast.Ucase: true, ast.Reverse: true, ast.Trim: true,
+	ast.LTrim: true, ast.RTrim: true, ast.Left: true, ast.Right: true, ast.Substring: true,
+	ast.Substr: true, ast.Mid: true, ast.SubstringIndex: true, ast.Replace: true, ast.InsertFunc: true,
+	ast.Concat: true, ast.Hex: true, ast.ToBase64: true, ast.Field: true, ast.Soundex: true, ast.Format: true,
+}
+
+// alwaysNotNullFunctions are the functions never returning NULL.
+var alwaysNotNullFunctions = map[string]bool{
+	ast.PI: true, ast.Rand: true, ast.Now: true, ast.CurrentTimestamp: true, ast.LocalTime: true,
+	ast.LocalTimestamp: true, ast.Sysdate: true, ast.UTCTimestamp: true, ast.Curdate: true,
+	ast.CurrentDate: true, ast.UTCDate: true, ast.Curtime: true, ast.CurrentTime: true, ast.UTCTime: true,
+	ast.DateLiteral: true, ast.TimeLiteral: true, ast.TimestampLiteral: true, ast.ConnectionID: true,
+	ast.LastInsertId: true, ast.FoundRows: true, ast.RowCount: true, ast.User: true, ast.CurrentUser: true,
+	ast.SessionUser: true, ast.SystemUser: true, ast.CurrentRole: true, ast.Version: true,
+	ast.TiDBVersion: true, ast.Charset: true, ast.Collation: true, ast.Coercibility: true, ast.UUID: true,
+	ast.UUIDShort: true, ast.Quote: true, ast.JSONArray: true, ast.JSONObject: true, ast.Interval: true,
+}
+
+func funcCallType(x *ast.FuncCallExpr) *types.FieldType {
+	name := x.FnName.L
+	ft := funcCallResultType(name, x.Args)
+	if ft == nil {
+		return nil
+	}
+	switch {
+	case alwaysNotNullFunctions[name]:
+		return nullable(ft, false)
+	case notNullFunctions[name]:
+		return nullable(ft, !isNotNull(x.Args...))
+	}
+	switch name {
+	case ast.If:
+		return nullable(ft, !isNotNull(x.Args[1:]...))
+	case ast.Ifnull:
+		return nullable(ft, !isNotNull(x.Args[0]) && !isNotNull(x.Args[1]))
+	case ast.Coalesce:
+		for _, arg := range x.Args {
+			if isNotNull(arg) {
+				return nullable(ft, false)
+			}
+		}
+	case ast.ConcatWS:
+		return nullable(ft, !isNotNull(x.Args[0]))
+	case ast.Greatest, ast.Least:
+		return nullable(ft, !isNotNull(x.Args...))
+	}
+	return nullable(ft, true)
+}
+
+// funcCallResultType returns the type of the function call without its nullability.
+func funcCallResultType(name string, args []ast.ExprNode) *types.FieldType {
+	if l, ok := intFunctions[name]; ok {
+		return intType(l, false)
+	}
+	if l, ok := unsignedFunctions[name]; ok {
+		return intType(l, true)
+	}
+	if doubleFunctions[name] {
+		return doubleType()
+	}
+	if jsonFunctions[name] {
+		return jsonType()
+	}
+	if l, ok := utf8Functions[name]; ok {
+		return stringType(l, charset.CharsetUTF8, charset.CollationUTF8)
+	}
+	if l, ok := stringFunctions[name]; ok {
+		return stringType(l, "", "")
+	}
+	if l, ok := binaryFunctions[name]; ok {
+		return stringType(l, charset.CharsetBin, charset.CollationBin)
+	}
+	fts := argTypes(args)
+	switch name {
+	case ast.If:
+		return mergeFieldTypes(fts[1:]...)
+	case ast.Ifnull, ast.Coalesce, ast.Greatest, ast.Least:
+		return mergeFieldTypes(fts...)
+	case ast.Nullif, ast.AnyValue:
+		return fts[0].Clone()
+	case ast.Elt:
+		return mergeFieldTypes(fts[1:]...)
+	case ast.Abs:
+		return numericType(fts[0]).Clone()
+	case ast.Ceil, ast.Ceiling, ast.Floor:
+		return roundType(fts[0], 0)
+	case ast.Round, ast.Truncate:
+		if len(args) < 2 {
+			return roundType(fts[0], 0)
+		}
+		if d, ok := constInt(args[1]); ok {
+			return roundType(fts[0], d)
+		}
+		return numericType(fts[0]).Clone()
+	case ast.PI:
+		return binaryType(mysql.TypeDouble, 8, 6)
+	case ast.Concat:
+		l := 0
+		for _, ft := range fts {
+			l += stringLength(ft)
+		}
+		chs, coll := mergeCollation(fts...)
+		return stringType(l, chs, coll)
+	case ast.ConcatWS:
+		l := 0
+		for _, ft := range fts[1:] {
+			l += stringLength(ft)
+		}
+		if len(fts) > 2 {
+			l += stringLength(fts[0]) * (len(fts) - 2)
+		}
+		chs, coll := mergeCollation(fts...)
+		return stringType(l, chs, coll)
+	case ast.Lower, ast.Lcase, ast.Upper, ast.Ucase, ast.Reverse, ast.Trim, ast.LTrim, ast.RTrim,
+		ast.Left, ast.Right, ast.Substring, ast.Substr, ast.Mid, ast.SubstringIndex, ast.Replace:
+		chs, coll := mergeCollation(fts[0])
+		return stringType(stringLength(fts[0]), chs, coll)
+	case ast.InsertFunc:
+		chs, coll := mergeCollation(fts[0], fts[3])
+		return stringType(stringLength(fts[0])+stringLength(fts[3]), chs, coll)
+	case ast.Repeat, ast.Lpad, ast.Rpad:
+		chs, coll := mergeCollation(fts[0])
+		l := mysql.MaxBlobWidth
+		if n, ok := constInt(args[1]); ok {
+			l = n
+			if name == ast.Repeat {
+				l = n * stringLength(fts[0])
+			}
+		}
+		return stringType(l, chs, coll)
+	case ast.Space:
+		l := mysql.MaxBlobWidth
+		if n, ok := constInt(args[0]); ok {
+			l = n
+		}
+		return stringType(l, "", "")
+	case ast.Hex:
+		return stringType(stringLength(fts[0])*2, "", "")
+	case ast.Unhex:
+		return stringType((stringLength(fts[0])+1)/2, charset.CharsetBin, charset.CollationBin)
+	case ast.ToBase64:
+		return stringType((stringLength(fts[0])+2)/3*4, "", "")
+	case ast.FromBase64:
+		return stringType(stringLength(fts[0])*3/4, charset.CharsetBin, charset.CollationBin)
+	case ast.Quote:
+		chs, coll := mergeCollation(fts[0])
+		return stringType(stringLength(fts[0])*2+2, chs, coll)
+	case ast.Convert:
+		if v, ok := args[1].(ast.ValueExpr); ok {
+			chs := strings.ToLower(v.GetString())
+			coll, _ := charset.GetDefaultCollation(chs)
+			return stringType(stringLength(fts[0]), chs, coll)
+		}
+	case ast.CharFunc:
+		chs, coll := charset.CharsetBin, charset.CollationBin
+		if v, ok := args[len(args)-1].(ast.ValueExpr); ok && v.GetValue() != nil {
+			chs = strings.ToLower(v.GetString())
+			coll, _ = charset.GetDefaultCollation(chs)
+		}
+		return stringType((len(args)-1)*4, chs, coll)
+	case ast.JSONUnquote, ast.JSONPretty:
+		return stringType(mysql.MaxBlobWidth, "", "")
+	case ast.JSONQuote:
+		return stringType(stringLength(fts[0])*2+2, "", "")
+	case ast.Now, ast.CurrentTimestamp, ast.LocalTime, ast.LocalTimestamp, ast.Sysdate, ast.UTCTimestamp:
+		return temporalType(mysql.TypeDatetime, fspArg(args))
+	case ast.Curdate, ast.CurrentDate, ast.UTCDate, ast.Date, ast.LastDay, ast.FromDays, ast.MakeDate:
+		return temporalType(mysql.TypeDate, 0)
+	case ast.Curtime, ast.CurrentTime, ast.UTCTime:
+		return temporalType(mysql.TypeDuration, fspArg(args))
+	case ast.DateLiteral:
+		return temporalType(mysql.TypeDate, 0)
+	case ast.TimeLiteral:
+		return temporalType(mysql.TypeDuration, literalFsp(args[0]))
+	case ast.TimestampLiteral:
+		return temporalType(mysql.TypeDatetime, literalFsp(args[0]))
+	case ast.Time, ast.TimeDiff, ast.SecToTime, ast.MakeTime:
+		return temporalType(mysql.TypeDuration, temporalFsp(fts...))
+	case ast.Timestamp, ast.ConvertTz, ast.TimestampAdd:
+		return temporalType(mysql.TypeDatetime, temporalFsp(fts...))
+	case ast.StrToDate:
+		return temporalType(mysql.TypeDatetime, 6)
+	case ast.FromUnixTime:
+		if len(args) > 1 {
+			return stringType(mysql.MaxFieldCharLength, "", "")
+		}
+		return temporalType(mysql.TypeDatetime, minInt(decimalOf(fts[0]), 6))
+	case ast.UnixTimestamp:
+		if len(args) == 0 {
+			return intType(11, false)
+		}
+		if fsp := minInt(temporalFsp(fts...), 6); fsp > 0 {
+			return decimalType(12+fsp, fsp)
+		}
+		return intType(11, false)
+	case ast.DateAdd, ast.DateSub, ast.AddDate, ast.SubDate:
+		return dateArithType(fts[0], args[2])
+	case ast.AddTime, ast.SubTime:
+		fsp := temporalFsp(fts...)
+		switch fts[0].Tp {
+		case mysql.TypeDatetime, mysql.TypeTimestamp:
+			return temporalType(mysql.TypeDatetime, fsp)
+		case mysql.TypeDuration:
+			return temporalType(mysql.TypeDuration, fsp)
+		}
+		return stringType(mysql.MaxDatetimeWidthWithFsp, "", "")
+	}
+	return stringType(types.UnspecifiedLength, "", "")
+}
+
+// roundType returns the type of ROUND(x, d), CEIL(x) and FLOOR(x) whose d is 0.
+func roundType(ft *types.FieldType, d int) *types.FieldType {
+	ft = numericType(ft)
+	switch ft.EvalType() {
+	case types.ETInt:
+		return ft.Clone()
+	case types.ETDecimal:
+		if d < 0 {
+			d = 0
+		}
+		if d > decimalOf(ft) {
+			d = decimalOf(ft)
+		}
+		// the carry of the rounding.
+		digits := lengthOf(ft) - decimalOf(ft) + 1
+		if d == 0 && digits < mysql.MaxIntWidth-1 {
+			return intType(digits, mysql.HasUnsignedFlag(ft.Flag))
+		}
+		return decimalType(digits+d, d)
+	}
+	return doubleType()
+}
+
+// literalFsp returns the fractional seconds precision of a literal like '12:00:00.123'.
+func literalFsp(expr ast.ExprNode) int {
+	v, ok := expr.(ast.ValueExpr)
+	if !ok {
+		return 0
+	}
+	s := v.GetString()
+	if i := strings.LastIndexByte(s, '.'); i >= 0 {
+		return minInt(len(s)-i-1, 6)
+	}
+	return 0
+}
+
+// temporalFsp returns the maximum fractional seconds precision of the temporal types.
+func temporalFsp(fts ...*types.FieldType) int {
+	fsp := 0
+	for _, ft := range fts {
+		if isTemporalType(ft) {
+			fsp = maxInt(fsp, decimalOf(ft))
+		} else if isStringType(ft) {
+			fsp = 6
+		}
+	}
+	return minInt(fsp, 6)
+}
+
+// dateArithType returns the type of DATE_ADD and DATE_SUB.
+func dateArithType(ft *types.FieldType, unit ast.ExprNode) *types.FieldType {
+	var u ast.TimeUnitType
+	if x, ok := unit.(*ast.TimeUnitExpr); ok {
+		u = x.Unit
+	}
+	dateUnit := false
+	switch u {
+	case ast.TimeUnitDay, ast.TimeUnitWeek, ast.TimeUnitMonth, ast.TimeUnitQuarter, ast.TimeUnitYear, ast.TimeUnitYearMonth:
+		dateUnit = true
+	}
+	fsp := decimalOf(ft)
+	switch u {
+	case ast.TimeUnitMicrosecond, ast.TimeUnitSecondMicrosecond, ast.TimeUnitMinuteMicrosecond,
+		ast.TimeUnitHourMicrosecond, ast.TimeUnitDayMicrosecond:
+		fsp = 6
+	}
+	switch ft.Tp {
+	case mysql.TypeDate:
+		if dateUnit {
+			return temporalType(mysql.TypeDate, 0)
+		}
+		return temporalType(mysql.TypeDatetime, fsp)
+	case mysql.TypeDatetime, mysql.TypeTimestamp:
+		return temporalType(mysql.TypeDatetime, fsp)
+	case mysql.TypeDuration:
+		if !dateUnit {
+			return temporalType(mysql.TypeDuration, fsp)
+		}
+		return temporalType(mysql.TypeDatetime, fsp)
+	}
+	return stringType(mysql.MaxDatetimeWidthWithFsp, "", "")
+}
+
+// aggregateType returns the type of the aggregate function, which is also used by
+// the aggregate functions as the window functions.
+func aggregateType(name string, args []ast.ExprNode) *types.FieldType {
+	switch name {
+	case ast.AggFuncCount, ast.AggFuncApproxCountDistinct:
+		return nullable(intType(mysql.MaxIntWidth+1, false), false)
+	case ast.AggFuncBitAnd, ast.AggFuncBitOr, ast.AggFuncBitXor:
+		return nullable(intType(mysql.MaxIntWidth+1, true), false)
+	case ast.AggFuncSum, ast.AggFuncAvg:
+		ft := numericType(args[0].GetType())
+		switch ft.EvalType() {
+		case types.ETInt, types.ETDecimal:
+			if name == ast.AggFuncSum {
+				ft = decimalType(precisionOf(ft)+22, decimalOf(ft))
+			} else {
+				ft = decimalType(precisionOf(ft)+divPrecisionIncrement, decimalOf(ft)+divPrecisionIncrement)
+			}
+		default:
+			ft = doubleType()
+		}
+		return nullable(ft, true)
+	case ast.AggFuncMax, ast.AggFuncMin, ast.AggFuncFirstRow, ast.AggFuncApproxPercentile:
+		return nullable(args[0].GetType().Clone(), true)
+	case ast.AggFuncVarPop, ast.AggFuncVarSamp, ast.AggFuncStddevPop, ast.AggFuncStddevSamp:
+		return nullable(doubleType(), true)
+	case ast.AggFuncGroupConcat:
+		// the last argument is the separator.
+		fts := argTypes(args[:len(args)-1])
+		chs, coll := mergeCollation(fts...)
+		ft := stringType(groupConcatMaxLen, chs, coll)
+		ft.Tp = mysql.TypeBlob
+		return nullable(ft, true)
+	case ast.AggFuncJsonArrayagg, ast.AggFuncJsonObjectAgg:
+		return nullable(jsonType(), true)
+	}
+	return nil
+}
+
+func windowType(x *ast.WindowFuncExpr) *types.FieldType {
+	switch x.F {
+	case ast.WindowFuncRowNumber, ast.WindowFuncRank, ast.WindowFuncDenseRank:
+		return nullable(intType(mysql.MaxIntWidth+1, false), false)
+	case ast.WindowFuncNtile:
+		return nullable(intType(mysql.MaxIntWidth+1, false), true)
+	case ast.WindowFuncCumeDist, ast.WindowFuncPercentRank:
+		return nullable(doubleType(), false)
+	case ast.WindowFuncLead, ast.WindowFuncLag:
+		if len(x.Args) > 2 {
+			return mergeTypes(x.Args[0], x.Args[2])
+		}
+		return nullable(x.Args[0].GetType().Clone(), true)
+	case ast.WindowFuncFirstValue, ast.WindowFuncLastValue, ast.WindowFuncNthValue:
+		return nullable(x.Args[0].GetType().Clone(), true)
+	}
+	return aggregateType(x.F, x.Args)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,133 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis_test
+
+import (
+	"fmt"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	. "github.com/pingcap/parser/analysis"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/catalog"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/types"
+)
+
+var _ = Suite(&testInferSuite{})
+
+type testInferSuite struct {
+}
+
+// typeString formats the type as `<type> [NOT NULL]`, such as `decimal(12,2) NOT NULL`.
+func typeString(ft *types.FieldType) string {
+	str := ft.String()
+	if mysql.HasNotNullFlag(ft.Flag) {
+		str += " NOT NULL"
+	}
+	return str
+}
+
+// inferStrings infers the types of the query, and formats its result fields as `<name>: <type>`.
+func inferStrings(c *C, cat *catalog.Catalog, sql string) []string {
+	stmt, err := parser.New().ParseOneStmt(sql, "", "")
+	c.Assert(err, IsNil, Commentf("sql: %s", sql))
+	fields, err := InferTypes(stmt, cat)
+	c.Assert(err, IsNil, Commentf("sql: %s", sql))
+	var strs []string
+	for _, field := range fields {
+		strs = append(strs, fmt.Sprintf("%s: %s", field.ColumnAsName.O, typeString(&field.Column.FieldType)))
+	}
+	return strs
+}
+
+func (s *testInferSuite) TestInferTypes(c *C) {
+	cat := catalog.New()
+	stmts, _, err := parser.New().Parse("create database test; use test; "+
+		"create table t (id int not null primary key, u int unsigned, p decimal(10,2) not null, f double, "+
+		"s varchar(20) not null, l varchar(10) charset latin1, b varbinary(8), tx text, d date, dt datetime(3), tm time, e enum('x','yy'), j json);"+
+		"create table t2 (id bigint not null, c char(5) not null)", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(cat.Apply(stmts...), IsNil)
+
+	cases := []struct {
+		sql      string
+		expected []string
+	}{
+		{"select id, u, p, s, e from t",
+			[]string{"id: int(11) NOT NULL", "u: int(10) UNSIGNED", "p: decimal(10,2) NOT NULL",
+				"s: varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL", "e: enum('x','yy')"}},
+		{"select 1, 1.5, 'abc', null, 1e2",
+			[]string{"1: bigint(1) BINARY NOT NULL", "1.5: decimal(3,1) BINARY NOT NULL", "'abc': var_string(3) NOT NULL",
+				"null: null BINARY", "1e2: double BINARY NOT NULL"}},
+		{"select id + 1, id + u, p * 2, p + f, p / 3, id / 2, id div 2, p % 3, s + 1, dt + 0 from t",
+			[]string{"id + 1: bigint(12) BINARY NOT NULL", "id + u: bigint(12) UNSIGNED BINARY", "p * 2: decimal(11,2) BINARY NOT NULL",
+				"p + f: double BINARY", "p / 3: decimal(14,6) BINARY", "id / 2: decimal(14,4) BINARY", "id div 2: bigint(11) BINARY",
+				"p % 3: decimal(10,2) BINARY", "s + 1: double BINARY NOT NULL", "dt + 0: decimal(18,3) BINARY"}},
+		{"select id > 1, u > 1, u in (1, 2), u is null, ~id from t",
+			[]string{"id > 1: bigint(1) BINARY NOT NULL", "u > 1: bigint(1) BINARY", "u in (1, 2): bigint(1) BINARY",
+				"u is null: bigint(1) BINARY NOT NULL", "~id: bigint(20) UNSIGNED BINARY NOT NULL"}},
+		{"select case when id > 0 then id else p end as c1, case id when 1 then 'a' end as c2, if(u, s, b) as c3, " +
+			"coalesce(u, id) as c4, ifnull(u, 0) as c5, nullif(id, 1) as c6, coalesce(d, dt) as c7, coalesce(d, 1) as c8 from t",
+			[]string{"c1: decimal(12,2) BINARY NOT NULL", "c2: var_string(1)", "c3: var_string(20) BINARY", "c4: bigint(12) BINARY NOT NULL",
+				"c5: bigint(10) BINARY NOT NULL", "c6: int(11)", "c7: datetime(3) BINARY", "c8: var_string(10) NOT NULL"}},
+		{"select concat(s, l) as c1, concat(s, id) as c2, concat(s, b) as c3, concat_ws(',', s, l) as c4, upper(s) as c5, " +
+			"lpad(s, 30, ' ') as c6, length(s) as c7, md5(s) as c8 from t",
+			[]string{"c1: var_string(30)", "c2: var_string(31) NOT NULL", "c3: var_string(28) BINARY", "c4: var_string(31) NOT NULL",
+				"c5: var_string(20) NOT NULL", "c6: var_string(30)", "c7: bigint(10) BINARY NOT NULL", "c8: var_string(32) NOT NULL"}},
+		{"select count(*), sum(id), sum(p), sum(f), avg(p), avg(id), max(s), group_concat(s), bit_or(id) from t",
+			[]string{"count(*): bigint(21) BINARY NOT NULL", "sum(id): decimal(32,0) BINARY", "sum(p): decimal(32,2) BINARY",
+				"sum(f): double BINARY", "avg(p): decimal(14,6) BINARY", "avg(id): decimal(14,4) BINARY", "max(s): varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci",
+				"group_concat(s): text CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci", "bit_or(id): bigint(21) UNSIGNED BINARY NOT NULL"}},
+		{"select cast(id as char) as c1, cast(s as signed) as c2, cast(p as decimal) as c3, cast(s as datetime(2)) as c4 from t",
+			[]string{"c1: var_string(11) NOT NULL", "c2: bigint(20) BINARY NOT NULL", "c3: decimal(10,0) BINARY NOT NULL",
+				"c4: datetime(2) BINARY NOT NULL"}},
+		{"select now(3) as c1, date_add(d, interval 1 day) as c2, date_add(d, interval 1 hour) as c3, floor(p) as c4, round(p, 1) as c5 from t",
+			[]string{"c1: datetime(3) BINARY NOT NULL", "c2: date BINARY", "c3: datetime BINARY", "c4: bigint(9) BINARY NOT NULL",
+				"c5: decimal(10,1) BINARY NOT NULL"}},
+		{"select sum(id), avg(id) from t2",
+			[]string{"sum(id): decimal(41,0) BINARY", "avg(id): decimal(23,4) BINARY"}},
+		{"select t.id, t2.id, c from t left join t2 on t.id = t2.id",
+			[]string{"id: int(11) NOT NULL", "id: bigint(20)", "c: char(5) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci"}},
+		{"select id from t right join t2 using (id)", []string{"id: bigint(20) NOT NULL"}},
+		{"select id, p from t union select u, 'x' from t", []string{"id: bigint(12) BINARY", "p: var_string(12) NOT NULL"}},
+		{"select x from (select id + 1 as x from t) d order by x", []string{"x: bigint(12) BINARY NOT NULL"}},
+		{"select (select max(id) from t2) as m, exists (select 1 from t2) as e",
+			[]string{"m: bigint(20)", "e: bigint(1) BINARY NOT NULL"}},
+		{"select rank() over (order by id) as r, lag(s) over (order by id) as l from t",
+			[]string{"r: bigint(21) BINARY NOT NULL", "l: varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci"}},
+	}
+	for _, ca := range cases {
+		c.Assert(inferStrings(c, cat, ca.sql), DeepEquals, ca.expected, Commentf("sql: %s", ca.sql))
+	}
+}
+
+func (s *testInferSuite) TestInferExpressions(c *C) {
+	cat := catalog.New()
+	stmts, _, err := parser.New().Parse("create database test; use test; create table t (id int not null, s varchar(10))", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(cat.Apply(stmts...), IsNil)
+
+	sql := "update t set s = concat(s, 'x') where id + 1 > 2 and s is not null"
+	stmt, err := parser.New().ParseOneStmt(sql, "", "")
+	c.Assert(err, IsNil)
+	_, err = InferTypes(stmt, cat)
+	c.Assert(err, IsNil)
+	update := stmt.(*ast.UpdateStmt)
+	c.Assert(typeString(update.List[0].Expr.GetType()), Equals, "var_string(11)")
+	c.Assert(typeString(update.Where.GetType()), Equals, "bigint(1) BINARY NOT NULL")
+
+	_, err = InferTypes(stmt, catalog.New())
+	c.Assert(ErrNoSuchTable.Equal(err), IsTrue)
+}
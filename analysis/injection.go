@@ -0,0 +1,429 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/format"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/opcode"
+)
+
+// Severity is the severity of a finding.
+type Severity int
+
+// Severity values, from the least to the most severe.
+const (
+	SeverityLow Severity = iota + 1
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+var severityNames = map[Severity]string{
+	SeverityLow:      "low",
+	SeverityMedium:   "medium",
+	SeverityHigh:     "high",
+	SeverityCritical: "critical",
+}
+
+// String implements fmt.Stringer interface.
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// MarshalText implements encoding.TextMarshaler interface.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler interface.
+func (s *Severity) UnmarshalText(text []byte) error {
+	for severity, name := range severityNames {
+		if strings.EqualFold(name, string(text)) {
+			*s = severity
+			return nil
+		}
+	}
+	return errors.Errorf("unknown severity %s", text)
+}
+
+// InjectionKind is the kind of an InjectionFinding.
+type InjectionKind int
+
+// InjectionKind values.
+const (
+	// InjectionTautology is an always true predicate, such as OR 1=1.
+	InjectionTautology InjectionKind = iota + 1
+	// InjectionStackedStatements is a statement after the first one.
+	InjectionStackedStatements
+	// InjectionUnionProbe is a UNION of constants, which probes the number of the columns.
+	InjectionUnionProbe
+	// InjectionCommentTruncation is a comment hiding the rest of the statement.
+	InjectionCommentTruncation
+	// InjectionDangerousFunction is a call of LOAD_FILE, SLEEP or BENCHMARK.
+	InjectionDangerousFunction
+	// InjectionFileWrite is SELECT ... INTO OUTFILE or DUMPFILE.
+	InjectionFileWrite
+)
+
+var injectionKindNames = map[InjectionKind]string{
+	InjectionTautology:         "tautology",
+	InjectionStackedStatements: "stacked statements",
+	InjectionUnionProbe:        "union probe",
+	InjectionCommentTruncation: "comment truncation",
+	InjectionDangerousFunction: "dangerous function",
+	InjectionFileWrite:         "file write",
+}
+
+// String implements fmt.Stringer interface.
+func (k InjectionKind) String() string {
+	if name, ok := injectionKindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// InjectionFinding is a sign of SQL injection found by DetectInjection.
+type InjectionFinding struct {
+	Kind     InjectionKind
+	Severity Severity
+	// Span is the range of the SQL text causing the finding.
+	Span    Span
+	Message string
+}
+
+// dangerousFuncs are the functions which read files or delay the response for the blind injection.
+var dangerousFuncs = map[string]Severity{
+	ast.LoadFile:  SeverityHigh,
+	ast.Sleep:     SeverityMedium,
+	ast.Benchmark: SeverityMedium,
+}
+
+// DetectInjection returns the signs of SQL injection in sql, ordered by the spans.
+// stmts are the statements parsed from sql in the SQL mode mode, which is also
+// used to split sql into tokens for the spans and the comments.
+//
+// The findings are heuristic: an always true predicate is of high severity in an
+// OR, but of low severity otherwise, since WHERE 1=1 AND ... is common in the
+// generated SQL.
+func DetectInjection(sql string, stmts []ast.StmtNode, mode mysql.SQLMode) []InjectionFinding {
+	spans := NewSpans(sql, mode)
+	d := detector{Spans: spans, tokens: spans.Tokens()}
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, d.enter)
+	}
+	if len(stmts) > 1 {
+		d.stackedStatements()
+	}
+	d.comments()
+	d.fileWrites()
+	sort.SliceStable(d.findings, func(i, j int) bool {
+		return d.findings[i].Span.Start < d.findings[j].Span.Start
+	})
+	return d.findings
+}
+
+type detector struct {
+	*Spans
+	tokens []parser.Token
+	// predicates are the start offsets of WHERE, HAVING and ON.
+	predicates []int
+	findings   []InjectionFinding
+}
+
+func (d *detector) add(kind InjectionKind, severity Severity, span Span, msg string) {
+	d.findings = append(d.findings, InjectionFinding{Kind: kind, Severity: severity, Span: span, Message: msg})
+}
+
+func (d *detector) enter(n ast.Node) bool {
+	switch x := n.(type) {
+	case *ast.SelectStmt:
+		d.predicate(x.Where)
+		if x.Having != nil {
+			d.predicate(x.Having.Expr)
+		}
+		if x.AfterSetOperator != nil && (*x.AfterSetOperator == ast.Union || *x.AfterSetOperator == ast.UnionAll) {
+			d.unionProbe(x)
+		}
+	case *ast.UpdateStmt:
+		d.predicate(x.Where)
+	case *ast.DeleteStmt:
+		d.predicate(x.Where)
+	case *ast.Join:
+		if x.On != nil {
+			d.predicate(x.On.Expr)
+		}
+	case *ast.FuncCallExpr:
+		if severity, ok := dangerousFuncs[x.FnName.L]; ok {
+			d.add(InjectionDangerousFunction, severity, d.Of(x), "call of "+strings.ToUpper(x.FnName.L))
+		}
+	}
+	return true
+}
+
+// predicate finds the always true predicates in the operands of AND and OR of expr.
+func (d *detector) predicate(expr ast.ExprNode) {
+	if expr == nil {
+		return
+	}
+	d.predicates = append(d.predicates, expr.OriginTextPosition())
+	var visit func(expr ast.ExprNode, inOr bool)
+	visit = func(expr ast.ExprNode, inOr bool) {
+		if x, ok := expr.(*ast.BinaryOperationExpr); ok && (x.Op == opcode.LogicOr || x.Op == opcode.LogicAnd) {
+			visit(x.L, inOr || x.Op == opcode.LogicOr)
+			visit(x.R, inOr || x.Op == opcode.LogicOr)
+			return
+		}
+		if !alwaysTrue(expr) {
+			return
+		}
+		if inOr {
+			d.add(InjectionTautology, SeverityHigh, d.Of(expr), "always true predicate in OR")
+		} else {
+			d.add(InjectionTautology, SeverityLow, d.Of(expr), "always true predicate")
+		}
+	}
+	visit(expr, false)
+}
+
+// unionProbe finds the UNION SELECT of constants without FROM, such as UNION SELECT NULL, NULL.
+func (d *detector) unionProbe(sel *ast.SelectStmt) {
+	if sel.From != nil || sel.Fields == nil || len(sel.Fields.Fields) == 0 {
+		return
+	}
+	for _, field := range sel.Fields.Fields {
+		if field.Expr == nil || !isConstant(field.Expr) {
+			return
+		}
+	}
+	fields := sel.Fields.Fields
+	span := Span{Start: fields[0].Expr.OriginTextPosition(), End: d.Of(fields[len(fields)-1].Expr).End}
+	// the span starts from the UNION before the fields.
+	for i := d.tokenAt(span.Start) - 1; i >= 0; i-- {
+		if strings.EqualFold(d.tokens[i].Text, "union") {
+			span.Start = d.tokens[i].Pos.Offset
+			break
+		}
+	}
+	d.add(InjectionUnionProbe, SeverityHigh, span, "UNION SELECT of "+strconv.Itoa(len(fields))+" constants")
+}
+
+// stackedStatements finds the statements after the semicolons.
+func (d *detector) stackedStatements() {
+	for i, tok := range d.tokens {
+		if tok.Kind != parser.TokenOperator || tok.Text != ";" {
+			continue
+		}
+		end := -1
+		for _, next := range d.tokens[i+1:] {
+			if next.Kind == parser.TokenOperator && next.Text == ";" {
+				break
+			}
+			if next.Kind != parser.TokenComment {
+				end = next.Pos.Offset + len(next.Text)
+			}
+		}
+		if end >= 0 {
+			d.add(InjectionStackedStatements, SeverityHigh, Span{Start: tok.Pos.Offset, End: end}, "stacked statement")
+		}
+	}
+}
+
+// truncatedKeywords are the keywords in a comment which show that the comment hides a part of the statement.
+var truncatedKeywords = map[string]bool{
+	"and": true, "or": true, "where": true, "having": true, "limit": true, "order": true,
+	"group": true, "union": true, "select": true, "from": true,
+}
+
+// comments finds the comments containing quotes or the keywords of clauses, which
+// are likely to hide the rest of the statement. A line comment after the start
+// of a predicate is of high severity, since it probably truncates the predicate.
+func (d *detector) comments() {
+	for _, tok := range d.tokens {
+		if tok.Kind != parser.TokenComment {
+			continue
+		}
+		body, line := tok.Text, true
+		switch {
+		case strings.HasPrefix(body, "#"):
+			body = body[1:]
+		case strings.HasPrefix(body, "--"):
+			body = body[2:]
+		default:
+			body, line = strings.TrimSuffix(strings.TrimPrefix(body, "/*"), "*/"), false
+		}
+		if !looksLikeSQL(body) {
+			continue
+		}
+		span := Span{Start: tok.Pos.Offset, End: tok.Pos.Offset + len(tok.Text)}
+		if line && d.afterPredicate(tok.Pos.Offset) {
+			d.add(InjectionCommentTruncation, SeverityHigh, span, "comment truncating a predicate")
+		} else {
+			d.add(InjectionCommentTruncation, SeverityMedium, span, "comment containing SQL")
+		}
+	}
+}
+
+func looksLikeSQL(body string) bool {
+	if strings.ContainsAny(body, `'"`) {
+		return true
+	}
+	for _, tok := range parser.Tokenize(body, 0) {
+		if (tok.Kind == parser.TokenKeyword || tok.Kind == parser.TokenReservedKeyword) && truncatedKeywords[strings.ToLower(tok.Text)] {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *detector) afterPredicate(offset int) bool {
+	for _, start := range d.predicates {
+		if start < offset {
+			return true
+		}
+	}
+	return false
+}
+
+// fileWrites finds INTO OUTFILE and INTO DUMPFILE.
+func (d *detector) fileWrites() {
+	for i := 0; i+1 < len(d.tokens); i++ {
+		tok, next := d.tokens[i], d.tokens[i+1]
+		if !isKeyword(tok, "into") || !isKeyword(next, "outfile") && !isKeyword(next, "dumpfile") {
+			continue
+		}
+		span := Span{Start: tok.Pos.Offset, End: next.Pos.Offset + len(next.Text)}
+		if i+2 < len(d.tokens) && d.tokens[i+2].Kind == parser.TokenString {
+			span.End = d.tokens[i+2].Pos.Offset + len(d.tokens[i+2].Text)
+		}
+		d.add(InjectionFileWrite, SeverityCritical, span, "SELECT INTO "+strings.ToUpper(next.Text))
+	}
+}
+
+func isKeyword(tok parser.Token, keyword string) bool {
+	return (tok.Kind == parser.TokenKeyword || tok.Kind == parser.TokenReservedKeyword) && strings.EqualFold(tok.Text, keyword)
+}
+
+// isConstant returns whether expr is a literal, or a literal in parentheses or with a sign.
+func isConstant(expr ast.ExprNode) bool {
+	switch x := expr.(type) {
+	case ast.ParamMarkerExpr:
+		return false
+	case ast.ValueExpr:
+		return true
+	case *ast.ParenthesesExpr:
+		return isConstant(x.Expr)
+	case *ast.UnaryOperationExpr:
+		return (x.Op == opcode.Minus || x.Op == opcode.Plus) && isConstant(x.V)
+	}
+	return false
+}
+
+// alwaysTrue returns whether expr is always true, such as 1, 1=1, 'a'='a', a=a,
+// 2>1, 'a' LIKE '%' and 1 IN (1, 2). The predicates which are always true except
+// for NULL are included, since they are as suspicious.
+func alwaysTrue(expr ast.ExprNode) bool {
+	switch x := expr.(type) {
+	case *ast.ParenthesesExpr:
+		return alwaysTrue(x.Expr)
+	case *ast.BinaryOperationExpr:
+		switch x.Op {
+		case opcode.LogicOr:
+			return alwaysTrue(x.L) || alwaysTrue(x.R)
+		case opcode.LogicAnd:
+			return alwaysTrue(x.L) && alwaysTrue(x.R)
+		}
+		if l := restoreExpr(x.L); l != "" && (isConstant(x.L) && isConstant(x.R) || isColumn(x.L) && isColumn(x.R)) && l == restoreExpr(x.R) {
+			return x.Op == opcode.EQ || x.Op == opcode.NullEQ || x.Op == opcode.GE || x.Op == opcode.LE
+		}
+		l, lok := number(x.L)
+		r, rok := number(x.R)
+		if !lok || !rok {
+			return false
+		}
+		switch x.Op {
+		case opcode.EQ, opcode.NullEQ:
+			return l == r
+		case opcode.NE:
+			return l != r
+		case opcode.LT:
+			return l < r
+		case opcode.LE:
+			return l <= r
+		case opcode.GT:
+			return l > r
+		case opcode.GE:
+			return l >= r
+		}
+	case *ast.PatternLikeExpr:
+		if x.Not || !isConstant(x.Pattern) {
+			return false
+		}
+		pattern := restoreExpr(x.Pattern)
+		return pattern == "'%'" || isConstant(x.Expr) && restoreExpr(x.Expr) == pattern
+	case *ast.PatternInExpr:
+		if x.Not || x.Sel != nil || !isConstant(x.Expr) {
+			return false
+		}
+		for _, item := range x.List {
+			if isConstant(item) && restoreExpr(item) == restoreExpr(x.Expr) {
+				return true
+			}
+		}
+	case *ast.UnaryOperationExpr, ast.ValueExpr:
+		if n, ok := number(x); ok {
+			return n != 0
+		}
+	}
+	return false
+}
+
+func isColumn(expr ast.ExprNode) bool {
+	_, ok := expr.(*ast.ColumnNameExpr)
+	return ok
+}
+
+// number returns the value of a numeric constant.
+func number(expr ast.ExprNode) (float64, bool) {
+	if !isConstant(expr) {
+		return 0, false
+	}
+	str := strings.Trim(restoreExpr(expr), "()")
+	if str == "TRUE" {
+		return 1, true
+	}
+	if str == "FALSE" {
+		return 0, true
+	}
+	n, err := strconv.ParseFloat(str, 64)
+	return n, err == nil
+}
+
+func restoreExpr(expr ast.ExprNode) string {
+	var sb strings.Builder
+	if err := expr.Restore(format.NewRestoreCtx(format.RestoreStringSingleQuotes|format.RestoreKeyWordUppercase|format.RestoreStringWithoutCharset, &sb)); err != nil {
+		return ""
+	}
+	return sb.String()
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis_test
+
+import (
+	"fmt"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	. "github.com/pingcap/parser/analysis"
+)
+
+var _ = Suite(&testInjectionSuite{})
+
+type testInjectionSuite struct {
+}
+
+func (s *testInjectionSuite) TestDetectInjection(c *C) {
+	cases := []struct {
+		sql      string
+		expected []string
+	}{
+		{"select a from t where id = 1", nil},
+		{"select a from t where id = 1 or 1=1", []string{"tautology high: 1=1"}},
+		{"select a from t where name = '' or 'a'='a'", []string{"tautology high: 'a'='a'"}},
+		{"select a from t where id = 1 or (2 > 1)", []string{"tautology high: (2 > 1)"}},
+		{"select a from t where id = 1 or true", []string{"tautology high: true"}},
+		{"select a from t where id = 1 or name like '%'", []string{"tautology high: name like '%'"}},
+		{"select a from t where 1 = 1 and id = 2", []string{"tautology low: 1 = 1"}},
+		{"delete from t where id = 1 or id = id", []string{"tautology high: id = id"}},
+		{"select a from t join s on t.id = s.id or 1 in (1, 2)", []string{"tautology high: 1 in (1, 2)"}},
+		{"select a from t where id = 1; drop table t", []string{"stacked statements high: ; drop table t"}},
+		{
+			"select a from t where id = 1 union all select null, 2, 'x'",
+			[]string{"union probe high: union all select null, 2, 'x'"},
+		},
+		{"select a from t union select b from s", nil},
+		{
+			"select a from t where name = 'admin' -- ' and password = 'x'",
+			[]string{"comment truncation high: -- ' and password = 'x'"},
+		},
+		{"select a /* or b */ from t", []string{"comment truncation medium: /* or b */"}},
+		{"select a from t # latest rows", nil},
+		{
+			"select load_file('/etc/passwd'), benchmark(1000000, md5(1)) from t where sleep(5)",
+			[]string{"dangerous function high: load_file('/etc/passwd')", "dangerous function medium: benchmark(1000000, md5(1))", "dangerous function medium: sleep(5)"},
+		},
+		{"select a from t into outfile '/tmp/a'", []string{"file write critical: into outfile '/tmp/a'"}},
+	}
+	for _, ca := range cases {
+		stmts, _, err := parser.New().Parse(ca.sql, "", "")
+		c.Assert(err, IsNil, Commentf("sql: %s", ca.sql))
+		var findings []string
+		for _, finding := range DetectInjection(ca.sql, stmts, 0) {
+			findings = append(findings, fmt.Sprintf("%s %s: %s", finding.Kind, finding.Severity, ca.sql[finding.Span.Start:finding.Span.End]))
+		}
+		c.Assert(findings, DeepEquals, ca.expected, Commentf("sql: %s", ca.sql))
+	}
+}
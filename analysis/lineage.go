@@ -0,0 +1,229 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/model"
+)
+
+// Catalog provides the schemas of tables.
+type Catalog interface {
+	// TableByName returns the table in schema, schema is empty for the default schema.
+	// It returns nil if the table doesn't exist.
+	TableByName(schema, table model.CIStr) *model.TableInfo
+}
+
+// tableColumns returns the visible columns of table provided by the catalog,
+// it returns nil if they are unknown.
+func (e *extractor) tableColumns(table *Table) []column {
+	if e.catalog == nil {
+		return nil
+	}
+	info := e.catalog.TableByName(table.Schema, table.Name)
+	if info == nil {
+		return nil
+	}
+	cols := make([]column, 0, len(info.Columns))
+	for _, col := range info.Columns {
+		if !col.Hidden {
+			cols = append(cols, baseColumn(table, col.Name))
+		}
+	}
+	return cols
+}
+
+// Transformation is the kind of the transformation from the base columns to a result column.
+type Transformation int
+
+// Transformation kinds. If a column is derived by several kinds of transformations,
+// its kind is the last one of them in the following order.
+const (
+	// TransformDirect is a column selected as it is, or a constant.
+	TransformDirect Transformation = iota
+	// TransformFunction is a column computed by functions or operators.
+	TransformFunction
+	// TransformCase is a column computed by CASE expressions.
+	TransformCase
+	// TransformAggregate is a column computed by aggregate functions.
+	TransformAggregate
+	// TransformWindow is a column computed by window functions.
+	TransformWindow
+)
+
+// String implements fmt.Stringer interface.
+func (t Transformation) String() string {
+	switch t {
+	case TransformDirect:
+		return "direct"
+	case TransformFunction:
+		return "function"
+	case TransformCase:
+		return "case"
+	case TransformAggregate:
+		return "aggregate"
+	case TransformWindow:
+		return "window"
+	}
+	return "unknown"
+}
+
+// BaseColumn is a column of a base table.
+type BaseColumn struct {
+	// Table is nil if the table of the column can't be resolved, see ColumnRef.
+	Table  *Table
+	Column model.CIStr
+}
+
+// Equal returns whether c and other are the same column.
+func (c BaseColumn) Equal(other BaseColumn) bool {
+	if c.Table == nil || other.Table == nil {
+		return c.Table == other.Table && c.Column.L == other.Column.L
+	}
+	return c.Table.Equal(*other.Table) && c.Column.L == other.Column.L
+}
+
+// String implements fmt.Stringer interface.
+func (c BaseColumn) String() string {
+	if c.Table == nil {
+		return c.Column.O
+	}
+	return c.Table.String() + "." + c.Column.O
+}
+
+// ColumnLineage is the lineage of a result column.
+//
+// If the columns of a table selected by a wildcard are unknown, because the
+// catalog is nil or doesn't have the table, they are represented by a column
+// named `*`, whose source is the `*` column of the table.
+type ColumnLineage struct {
+	// Name is the name of the result column, or the name of the written column
+	// for INSERT ... SELECT.
+	Name model.CIStr
+	// Sources are the distinct base columns which the column is derived from.
+	Sources        []BaseColumn
+	Transformation Transformation
+}
+
+// Lineage is the column lineage of a query.
+type Lineage struct {
+	// Target is the table written by INSERT ... SELECT and CREATE TABLE ... AS SELECT,
+	// it's nil for SELECT statements.
+	Target  *Table
+	Columns []ColumnLineage
+	// Filters are the base columns used by the WHERE, HAVING and join conditions,
+	// including the ones of subqueries, but not used by the result columns.
+	Filters []BaseColumn
+}
+
+// ExtractLineage returns the column lineage of a SELECT, a set operation, an
+// INSERT ... SELECT or a CREATE TABLE ... AS SELECT statement. catalog is used to
+// expand the wildcards and resolve the columns of joined tables, it can be nil.
+func ExtractLineage(stmt ast.StmtNode, catalog Catalog) (*Lineage, error) {
+	e := extractor{catalog: catalog}
+	var (
+		query  ast.ResultSetNode
+		target *ast.TableName
+		names  []model.CIStr
+	)
+	switch x := stmt.(type) {
+	case *ast.SelectStmt:
+		query = x
+	case *ast.SetOprStmt:
+		query = x
+	case *ast.InsertStmt:
+		if x.Select == nil {
+			return nil, errors.New("lineage of INSERT without SELECT is not supported")
+		}
+		query = x.Select
+		if x.Table != nil {
+			if ts, ok := singleTable(x.Table.TableRefs); ok {
+				target = ts.Source.(*ast.TableName)
+			}
+		}
+		for _, col := range x.Columns {
+			names = append(names, col.Name)
+		}
+	case *ast.CreateTableStmt:
+		if x.Select == nil {
+			return nil, errors.New("lineage of CREATE TABLE without SELECT is not supported")
+		}
+		query, target = x.Select, x.Table
+	default:
+		return nil, errors.Errorf("lineage of %T is not supported", stmt)
+	}
+
+	lineage := &Lineage{}
+	if target != nil {
+		lineage.Target = &Table{Schema: target.Schema, Name: target.Name}
+		if _, ok := stmt.(*ast.InsertStmt); ok && names == nil {
+			// the columns are written in the order of the columns of the table.
+			for _, col := range e.tableColumns(lineage.Target) {
+				names = append(names, col.name)
+			}
+		}
+	}
+	cols := e.resultSet(query, nil)
+	if len(names) != len(cols) {
+		names = nil
+	}
+	for _, col := range cols {
+		if col.wildcard {
+			names = nil
+		}
+		lineage.Columns = append(lineage.Columns, ColumnLineage{Name: col.name, Sources: col.sources, Transformation: col.kind})
+	}
+	for i, name := range names {
+		lineage.Columns[i].Name = name
+	}
+outer:
+	for _, src := range e.filters.sources {
+		for _, col := range lineage.Columns {
+			for _, used := range col.Sources {
+				if used.Equal(src) {
+					continue outer
+				}
+			}
+		}
+		lineage.Filters = append(lineage.Filters, src)
+	}
+	return lineage, nil
+}
+
+// usage is the base columns used by an expression, and the kind of the transformation by it.
+type usage struct {
+	sources []BaseColumn
+	kind    Transformation
+}
+
+// add adds the sources of col, which is used by the expression.
+func (u *usage) add(col column) {
+	for _, src := range col.sources {
+		found := false
+		for _, used := range u.sources {
+			found = found || used.Equal(src)
+		}
+		if !found {
+			u.sources = append(u.sources, src)
+		}
+	}
+	u.transform(col.kind)
+}
+
+func (u *usage) transform(kind Transformation) {
+	if kind > u.kind {
+		u.kind = kind
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis_test
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/pingcap/check"
+	. "github.com/pingcap/parser/analysis"
+	"github.com/pingcap/parser/model"
+)
+
+var _ = Suite(&testLineageSuite{})
+
+type testLineageSuite struct {
+}
+
+// mockCatalog has the tables with the columns separated by commas, such as `"db.t": "a,b"`.
+type mockCatalog map[string]string
+
+func (m mockCatalog) TableByName(schema, table model.CIStr) *model.TableInfo {
+	name := table.L
+	if schema.L != "" {
+		name = schema.L + "." + name
+	}
+	cols, ok := m[name]
+	if !ok {
+		return nil
+	}
+	info := &model.TableInfo{Name: table}
+	for _, col := range strings.Split(cols, ",") {
+		info.Columns = append(info.Columns, &model.ColumnInfo{Name: model.NewCIStr(col)})
+	}
+	return info
+}
+
+// lineageStrings formats the column lineages as `<name>=<kind>(<sources>)`, and the filters as `filters(<sources>)`.
+func lineageStrings(lineage *Lineage) []string {
+	var strs []string
+	for _, col := range lineage.Columns {
+		strs = append(strs, fmt.Sprintf("%s=%s%v", col.Name.O, col.Transformation, col.Sources))
+	}
+	return append(strs, fmt.Sprintf("filters%v", lineage.Filters))
+}
+
+func (s *testLineageSuite) TestLineage(c *C) {
+	catalog := mockCatalog{"t1": "id,a,b", "t2": "id,c", "db.t3": "x,y"}
+	cases := []struct {
+		sql      string
+		catalog  Catalog
+		expected []string
+	}{
+		{
+			"select a, t.b as b2, a + b, abs(a), 1 from t where c > 1",
+			nil,
+			[]string{"a=direct[t.a]", "b2=direct[t.b]", "a + b=function[t.a t.b]", "abs(a)=function[t.a]", "1=direct[]", "filters[t.c]"},
+		},
+		{
+			"select case when a > 0 then b else 0 end as x, sum(a), count(*), rank() over (partition by b order by a) r from t group by b having max(c) > 1",
+			nil,
+			[]string{"x=case[t.a t.b]", "sum(a)=aggregate[t.a]", "count(*)=aggregate[]", "r=window[t.b t.a]", "filters[t.c]"},
+		},
+		{
+			"select x.a, y.c from t1 x join t2 y on x.id = y.id where y.c > 0",
+			nil,
+			[]string{"a=direct[t1.a]", "c=direct[t2.c]", "filters[t1.id t2.id]"},
+		},
+		{
+			"select a, c from t1 join t2 using (id)",
+			catalog,
+			[]string{"a=direct[t1.a]", "c=direct[t2.c]", "filters[t1.id t2.id]"},
+		},
+		{
+			"select a, c from t1 join t2 using (id)",
+			nil,
+			[]string{"a=direct[a]", "c=direct[c]", "filters[t1.id t2.id]"},
+		},
+		{
+			"select * from t1, db.t3 where t1.id = db.t3.x",
+			catalog,
+			[]string{"id=direct[t1.id]", "a=direct[t1.a]", "b=direct[t1.b]", "x=direct[db.t3.x]", "y=direct[db.t3.y]", "filters[]"},
+		},
+		{
+			"select t.*, u.a from t, u",
+			nil,
+			[]string{"*=direct[t.*]", "a=direct[u.a]", "filters[]"},
+		},
+		{
+			"select d.total * 2 as t2, d.k from (select k, sum(v) as total from t where f = 1 group by k) as d where d.k > 0",
+			nil,
+			[]string{"t2=aggregate[t.v]", "k=direct[t.k]", "filters[t.f]"},
+		},
+		{
+			"select y from (select * from t) as d",
+			nil,
+			[]string{"y=direct[t.y]", "filters[]"},
+		},
+		{
+			"with c as (select a, b from t1 where id < 10) select a + 1 as a1 from c where b = 1",
+			nil,
+			[]string{"a1=function[t1.a]", "filters[t1.id t1.b]"},
+		},
+		{
+			"select a, (select max(c) from t2 where t2.id = t1.id) as m from t1 where b in (select c from t2)",
+			catalog,
+			[]string{"a=direct[t1.a]", "m=aggregate[t2.c]", "filters[t2.id t1.id t1.b]"},
+		},
+		{
+			"select a from t1 union all select sum(c) from t2",
+			nil,
+			[]string{"a=aggregate[t1.a t2.c]", "filters[]"},
+		},
+		{
+			"select a + b as s from t1 where a > 0 order by s",
+			nil,
+			[]string{"s=function[t1.a t1.b]", "filters[]"},
+		},
+		{
+			"insert into db.t3 select a, b from t1 where id = 1",
+			catalog,
+			[]string{"x=direct[t1.a]", "y=direct[t1.b]", "filters[t1.id]"},
+		},
+		{
+			"insert into t4 (p, q) select a, b from t1",
+			nil,
+			[]string{"p=direct[t1.a]", "q=direct[t1.b]", "filters[]"},
+		},
+		{
+			"create table t4 as select a, count(*) as n from t1 group by a",
+			nil,
+			[]string{"a=direct[t1.a]", "n=aggregate[]", "filters[]"},
+		},
+	}
+	for _, ca := range cases {
+		lineage, err := ExtractLineage(parse(c, ca.sql), ca.catalog)
+		c.Assert(err, IsNil, Commentf("sql: %s", ca.sql))
+		c.Assert(lineageStrings(lineage), DeepEquals, ca.expected, Commentf("sql: %s", ca.sql))
+	}
+}
+
+func (s *testLineageSuite) TestTarget(c *C) {
+	lineage, err := ExtractLineage(parse(c, "insert into db.t select * from s"), nil)
+	c.Assert(err, IsNil)
+	c.Assert(lineage.Target.String(), Equals, "db.t")
+	lineage, err = ExtractLineage(parse(c, "create table t select 1"), nil)
+	c.Assert(err, IsNil)
+	c.Assert(lineage.Target.String(), Equals, "t")
+	lineage, err = ExtractLineage(parse(c, "select 1"), nil)
+	c.Assert(err, IsNil)
+	c.Assert(lineage.Target, IsNil)
+
+	_, err = ExtractLineage(parse(c, "insert into t values (1)"), nil)
+	c.Assert(err, ErrorMatches, "lineage of INSERT without SELECT is not supported")
+	_, err = ExtractLineage(parse(c, "update t set a = 1"), nil)
+	c.Assert(err, ErrorMatches, `lineage of \*ast.UpdateStmt is not supported`)
+}
@@ -0,0 +1,795 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package analysis provides static analyses of statements, such as the tables
+// and columns referenced by them and the lineage of the result columns. The
+// analyses don't need the schemas of the tables, but some of them are more
+// precise with a Catalog.
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/mysql"
+)
+
+// Span is the range [Start, End) of the bytes of a reference in the parsed SQL.
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Table is a base table, Schema is empty if the table is in the default schema.
+type Table struct {
+	Schema model.CIStr
+	Name   model.CIStr
+}
+
+// Equal returns whether t and other are the same table.
+func (t Table) Equal(other Table) bool {
+	return t.Schema.L == other.Schema.L && t.Name.L == other.Name.L
+}
+
+// String implements fmt.Stringer interface.
+func (t Table) String() string {
+	if t.Schema.O == "" {
+		return t.Name.O
+	}
+	return t.Schema.O + "." + t.Name.O
+}
+
+// TableRef is a reference to a base table.
+type TableRef struct {
+	Table
+	// Alias is the alias of the table, it's empty if there isn't an alias.
+	Alias model.CIStr
+	Span  Span
+}
+
+// ColumnRef is a reference to a column.
+type ColumnRef struct {
+	// Name is the column name as written, such as `t1`.`a`.
+	Name *ast.ColumnName
+	// Table and Column are the base column resolved from the name.
+	// Table is nil if the name can't be resolved without the schemas, such as
+	// an unqualified name in a join, or refers to an expression of a derived table.
+	Table  *Table
+	Column model.CIStr
+	Span   Span
+}
+
+// References are the tables and columns referenced by a statement,
+// in the order they are found.
+type References struct {
+	// Reads are the tables read by the statement.
+	Reads []TableRef
+	// Writes are the tables written by the statement, including the tables
+	// created, altered or dropped by DDL statements. The tables which may have
+	// an unqualified column assigned by a multiple-table UPDATE are all written
+	// if the column can't be resolved without the schemas.
+	Writes  []TableRef
+	Columns []ColumnRef
+}
+
+// ReadTables returns the distinct tables read by the statement.
+func (r *References) ReadTables() []Table {
+	return distinctTables(r.Reads)
+}
+
+// WrittenTables returns the distinct tables written by the statement.
+func (r *References) WrittenTables() []Table {
+	return distinctTables(r.Writes)
+}
+
+// ColumnsOf returns the references to the columns of table.
+func (r *References) ColumnsOf(table Table) []ColumnRef {
+	var cols []ColumnRef
+	for _, col := range r.Columns {
+		if col.Table != nil && col.Table.Equal(table) {
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
+
+func distinctTables(refs []TableRef) []Table {
+	var tables []Table
+outer:
+	for _, ref := range refs {
+		for _, table := range tables {
+			if table.Equal(ref.Table) {
+				continue outer
+			}
+		}
+		tables = append(tables, ref.Table)
+	}
+	return tables
+}
+
+// ExtractReferences returns the tables and columns referenced by stmt.
+//
+// Aliases, derived tables and common table expressions are resolved, so the
+// references to them are reported as the references to the base tables if possible.
+// The spans of the references are the offsets in the text of stmt, which are the
+// offsets in the parsed SQL if it only has stmt, so they are only available for the
+// statements created by the parser.
+func ExtractReferences(stmt ast.StmtNode) *References {
+	text := stmt.Text()
+	e := extractor{spans: NewSpans(text, mysql.ModeNone)}
+	e.spans.Locate(stmt, Span{End: len(text)})
+	e.stmt(stmt, nil)
+	return &e.refs
+}
+
+// column is a column of a source, which may be a base column.
+type column struct {
+	name   model.CIStr
+	table  *Table
+	column model.CIStr
+	// sources are the base columns which the column is derived from, kind is the kind of the derivation.
+	sources []BaseColumn
+	kind    Transformation
+	// wildcard is true if the column stands for the unknown columns of table, which are selected by a wildcard.
+	wildcard bool
+}
+
+func baseColumn(table *Table, name model.CIStr) column {
+	return column{name: name, table: table, column: name, sources: []BaseColumn{{Table: table, Column: name}}}
+}
+
+func unresolvedColumn(name model.CIStr) column {
+	return column{name: name, column: name, sources: []BaseColumn{{Column: name}}}
+}
+
+// source is a table in the FROM clause.
+type source struct {
+	// name is the name to qualify the columns, it's the alias or the name of the table.
+	name   model.CIStr
+	schema model.CIStr
+	// table is the base table, ref is the reference to it, they are nil for derived tables and CTEs.
+	table *Table
+	ref   *TableRef
+	// columns are the columns of derived tables and CTEs, and the columns of
+	// base tables if they are provided by the catalog.
+	columns []column
+}
+
+func (s *source) column(name model.CIStr) column {
+	if s.table != nil {
+		return baseColumn(s.table, name)
+	}
+	var wildcard *column
+	for i, col := range s.columns {
+		if col.wildcard {
+			if wildcard != nil {
+				return unresolvedColumn(name)
+			}
+			wildcard = &s.columns[i]
+		} else if col.name.L == name.L {
+			return col
+		}
+	}
+	if wildcard != nil {
+		// the column may be one of the columns selected by the wildcard.
+		return baseColumn(wildcard.table, name)
+	}
+	return unresolvedColumn(name)
+}
+
+// known returns whether all the columns of the source are known.
+func (s *source) known() bool {
+	if s.table != nil && s.columns == nil {
+		return false
+	}
+	for _, col := range s.columns {
+		if col.wildcard {
+			return false
+		}
+	}
+	return true
+}
+
+// cte is a common table expression.
+type cte struct {
+	columns []column
+}
+
+// scope is the scope of a query block.
+type scope struct {
+	parent  *scope
+	ctes    map[string]*cte
+	sources []*source
+	// aliases are the aliases of the select fields, which may be referenced by GROUP BY, HAVING and ORDER BY.
+	aliases map[string]*column
+}
+
+func (s *scope) lookupCTE(name string) *cte {
+	for ; s != nil; s = s.parent {
+		if c, ok := s.ctes[name]; ok {
+			return c
+		}
+	}
+	return nil
+}
+
+type extractor struct {
+	refs    References
+	catalog Catalog
+	// used records the base columns used by the expression being extracted, it's nil if they aren't needed.
+	used *usage
+	// filters are the base columns used by the filters and join conditions.
+	filters usage
+	// spans are the spans of the names, they are unknown if it's nil.
+	spans *Spans
+}
+
+func (e *extractor) spanOf(n ast.Node) Span {
+	if e.spans == nil {
+		return Span{}
+	}
+	return e.spans.Of(n)
+}
+
+func (e *extractor) stmt(node ast.StmtNode, parent *scope) {
+	switch x := node.(type) {
+	case *ast.SelectStmt, *ast.SetOprStmt:
+		e.resultSet(x.(ast.ResultSetNode), parent)
+	case *ast.InsertStmt:
+		e.insert(x, parent)
+	case *ast.UpdateStmt:
+		e.update(x, parent)
+	case *ast.DeleteStmt:
+		e.delete(x, parent)
+	case *ast.ExplainStmt:
+		writes := len(e.refs.Writes)
+		e.stmt(x.Stmt, parent)
+		if !x.Analyze {
+			// the statement isn't executed.
+			e.refs.Reads = append(e.refs.Reads, e.refs.Writes[writes:]...)
+			e.refs.Writes = e.refs.Writes[:writes]
+		}
+	default:
+		e.generic(node, &scope{parent: parent})
+	}
+}
+
+// resultSet extracts the references of a query, and returns its result columns.
+func (e *extractor) resultSet(node ast.Node, parent *scope) []column {
+	switch x := node.(type) {
+	case *ast.SelectStmt:
+		return e.selectStmt(x, parent)
+	case *ast.SetOprStmt:
+		sc := &scope{parent: parent}
+		e.with(x.With, sc)
+		cols := e.resultSet(x.SelectList, sc)
+		// ORDER BY of a set operation refers to the result columns.
+		sc.sources = []*source{{columns: cols}}
+		if x.OrderBy != nil {
+			e.expr(x.OrderBy, sc, false)
+		}
+		return cols
+	case *ast.SetOprSelectList:
+		sc := &scope{parent: parent}
+		e.with(x.With, sc)
+		var cols []column
+		for i, sel := range x.Selects {
+			selCols := e.resultSet(sel, sc)
+			if i == 0 {
+				cols = selCols
+			} else {
+				mergeColumns(cols, selCols)
+			}
+		}
+		return cols
+	case *ast.SubqueryExpr:
+		return e.resultSet(x.Query, parent)
+	}
+	return nil
+}
+
+// mergeColumns keeps the base columns of cols only if they are the same as others,
+// which are the columns of another query of a set operation, and adds the sources of others to cols.
+func mergeColumns(cols, others []column) {
+	for i := range cols {
+		if i >= len(others) || others[i].table == nil || cols[i].table == nil ||
+			!cols[i].table.Equal(*others[i].table) || cols[i].column.L != others[i].column.L {
+			cols[i].table = nil
+		}
+		if i < len(others) {
+			u := usage{sources: append([]BaseColumn(nil), cols[i].sources...), kind: cols[i].kind}
+			u.add(others[i])
+			cols[i].sources, cols[i].kind = u.sources, u.kind
+		}
+	}
+}
+
+func (e *extractor) selectStmt(sel *ast.SelectStmt, parent *scope) []column {
+	used := e.used
+	e.used = nil
+	defer func() { e.used = used }()
+	sc := &scope{parent: parent, aliases: make(map[string]*column)}
+	e.with(sel.With, sc)
+	if sel.From != nil {
+		e.tableRefs(sel.From.TableRefs, sc)
+	}
+	for _, row := range sel.Lists {
+		e.expr(row, sc, false)
+	}
+	var cols []column
+	if sel.Fields != nil {
+		for _, field := range sel.Fields.Fields {
+			if field.AsName.L != "" {
+				sc.aliases[field.AsName.L] = &column{}
+			}
+		}
+		for _, field := range sel.Fields.Fields {
+			if field.WildCard != nil {
+				cols = append(cols, e.wildcard(field.WildCard, sc)...)
+				continue
+			}
+			var u usage
+			col := column{name: field.AsName}
+			if nameExpr, ok := field.Expr.(*ast.ColumnNameExpr); ok {
+				if col.name.L == "" {
+					col.name = nameExpr.Name.Name
+				}
+				resolved := e.resolve(nameExpr.Name, sc)
+				col.table, col.column = resolved.table, resolved.column
+			} else if col.name.L == "" {
+				col.name = model.NewCIStr(field.Text())
+			}
+			e.exprUsing(&u, field.Expr, sc, false)
+			col.sources, col.kind = u.sources, u.kind
+			if alias, ok := sc.aliases[field.AsName.L]; ok {
+				*alias = col
+			}
+			cols = append(cols, col)
+		}
+	}
+	if sel.Where != nil {
+		e.exprUsing(&e.filters, sel.Where, sc, false)
+	}
+	if sel.GroupBy != nil {
+		e.expr(sel.GroupBy, sc, true)
+	}
+	if sel.Having != nil {
+		e.exprUsing(&e.filters, sel.Having, sc, true)
+	}
+	for i := range sel.WindowSpecs {
+		e.expr(&sel.WindowSpecs[i], sc, true)
+	}
+	if sel.OrderBy != nil {
+		e.expr(sel.OrderBy, sc, true)
+	}
+	return cols
+}
+
+// wildcard returns the columns of the sources matched by the wildcard. The columns
+// of a base table are unknown if they aren't provided by the catalog, so they
+// are returned as a column which stands for all of them.
+func (e *extractor) wildcard(wildcard *ast.WildCardField, sc *scope) []column {
+	var cols []column
+	for _, src := range sc.sources {
+		if wildcard.Table.L != "" && (src.name.L != wildcard.Table.L || (wildcard.Schema.L != "" && src.schema.L != wildcard.Schema.L)) {
+			continue
+		}
+		if src.table != nil && src.columns == nil {
+			col := baseColumn(src.table, model.NewCIStr("*"))
+			col.wildcard = true
+			cols = append(cols, col)
+			continue
+		}
+		cols = append(cols, src.columns...)
+	}
+	return cols
+}
+
+func (e *extractor) with(with *ast.WithClause, sc *scope) {
+	if with == nil {
+		return
+	}
+	if sc.ctes == nil {
+		sc.ctes = make(map[string]*cte, len(with.CTEs))
+	}
+	for _, expr := range with.CTEs {
+		c := &cte{}
+		if with.IsRecursive {
+			// a recursive CTE may refer to itself.
+			sc.ctes[expr.Name.L] = c
+		}
+		c.columns = e.resultSet(expr.Query, sc)
+		for i, name := range expr.ColNameList {
+			if i < len(c.columns) {
+				c.columns[i].name = name
+				if c.columns[i].table == nil {
+					// the column isn't a base column, it's referred by the name of the CTE column.
+					c.columns[i].column = name
+				}
+			}
+		}
+		sc.ctes[expr.Name.L] = c
+	}
+}
+
+func (e *extractor) tableRefs(node ast.ResultSetNode, sc *scope) {
+	switch x := node.(type) {
+	case *ast.Join:
+		left := len(sc.sources)
+		e.tableRefs(x.Left, sc)
+		right := len(sc.sources)
+		if x.Right != nil {
+			e.tableRefs(x.Right, sc)
+		}
+		if x.On != nil {
+			e.exprUsing(&e.filters, x.On, sc, false)
+		}
+		for _, name := range x.Using {
+			// the column is in the tables of both sides.
+			for _, side := range [][]*source{sc.sources[left:right], sc.sources[right:]} {
+				col := unresolvedColumn(name.Name)
+				if len(side) == 1 {
+					col = side[0].column(name.Name)
+				}
+				e.refs.Columns = append(e.refs.Columns, ColumnRef{Name: name, Table: col.table, Column: col.column, Span: e.spanOf(name)})
+				e.filters.add(col)
+			}
+		}
+	case *ast.TableSource:
+		switch src := x.Source.(type) {
+		case *ast.TableName:
+			e.tableName(src, x.AsName, sc)
+		case *ast.SelectStmt, *ast.SetOprStmt:
+			cols := e.resultSet(src, sc)
+			sc.sources = append(sc.sources, &source{name: x.AsName, columns: cols})
+		default:
+			e.tableRefs(src, sc)
+		}
+	case *ast.TableName:
+		e.tableName(x, model.CIStr{}, sc)
+	}
+}
+
+// tableName adds the table to the scope, and reports the reading of it if it's a base table.
+func (e *extractor) tableName(tn *ast.TableName, alias model.CIStr, sc *scope) *source {
+	src := &source{name: alias}
+	if alias.L == "" {
+		src.name, src.schema = tn.Name, tn.Schema
+	}
+	if c := sc.lookupCTE(tn.Name.L); c != nil && tn.Schema.L == "" {
+		src.columns = c.columns
+	} else {
+		src.table = &Table{Schema: tn.Schema, Name: tn.Name}
+		src.ref = &TableRef{Table: *src.table, Alias: alias, Span: e.spanOf(tn)}
+		src.columns = e.tableColumns(src.table)
+		e.refs.Reads = append(e.refs.Reads, *src.ref)
+	}
+	if tn.AsOf != nil {
+		e.expr(tn.AsOf, sc, false)
+	}
+	sc.sources = append(sc.sources, src)
+	return src
+}
+
+// expr extracts the references in node, which is usually an expression.
+// The names in node may refer to the aliases of the select fields if allowAlias is true.
+func (e *extractor) expr(node ast.Node, sc *scope, allowAlias bool) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.ColumnNameExpr:
+			e.column(x.Name, sc, allowAlias)
+			return false
+		case *ast.DefaultExpr:
+			if x.Name != nil {
+				e.column(x.Name, sc, false)
+			}
+		case *ast.SubqueryExpr:
+			for _, col := range e.resultSet(x.Query, sc) {
+				e.use(col)
+			}
+			return false
+		case *ast.AggregateFuncExpr:
+			e.transform(TransformAggregate)
+		case *ast.WindowFuncExpr:
+			e.transform(TransformWindow)
+		case *ast.CaseExpr:
+			e.transform(TransformCase)
+		case *ast.ParenthesesExpr, ast.ValueExpr:
+		case ast.ExprNode:
+			e.transform(TransformFunction)
+		}
+		return true
+	})
+}
+
+// exprUsing extracts the references in node like expr, and records the base columns used by node to u.
+func (e *extractor) exprUsing(u *usage, node ast.Node, sc *scope, allowAlias bool) {
+	used := e.used
+	e.used = u
+	e.expr(node, sc, allowAlias)
+	e.used = used
+}
+
+func (e *extractor) use(col column) {
+	if e.used != nil {
+		e.used.add(col)
+	}
+}
+
+func (e *extractor) transform(kind Transformation) {
+	if e.used != nil {
+		e.used.transform(kind)
+	}
+}
+
+func (e *extractor) column(name *ast.ColumnName, sc *scope, allowAlias bool) *ColumnRef {
+	if allowAlias && name.Table.L == "" {
+		if alias, ok := sc.aliases[name.Name.L]; ok {
+			e.use(*alias)
+			return nil
+		}
+	}
+	col := e.resolve(name, sc)
+	e.use(col)
+	e.refs.Columns = append(e.refs.Columns, ColumnRef{Name: name, Table: col.table, Column: col.column, Span: e.spanOf(name)})
+	return &e.refs.Columns[len(e.refs.Columns)-1]
+}
+
+// resolve returns the column referred by name.
+func (e *extractor) resolve(name *ast.ColumnName, sc *scope) column {
+	for s := sc; s != nil; s = s.parent {
+		if name.Table.L != "" {
+			for _, src := range s.sources {
+				if src.name.L == name.Table.L && (name.Schema.L == "" || src.schema.L == name.Schema.L) {
+					return src.column(name.Name)
+				}
+			}
+			continue
+		}
+		if len(s.sources) == 0 {
+			continue
+		}
+		if len(s.sources) == 1 && !s.sources[0].known() {
+			return s.sources[0].column(name.Name)
+		}
+		// the column can be resolved only if the columns of all the sources are known.
+		var found *source
+		for _, src := range s.sources {
+			if !src.known() {
+				return unresolvedColumn(name.Name)
+			}
+			if hasColumn(src, name.Name) {
+				if found != nil {
+					return unresolvedColumn(name.Name)
+				}
+				found = src
+			}
+		}
+		if found != nil {
+			return found.column(name.Name)
+		}
+		// the column isn't in the sources, it may refer to an outer query.
+	}
+	return unresolvedColumn(name.Name)
+}
+
+func hasColumn(src *source, name model.CIStr) bool {
+	for _, col := range src.columns {
+		if col.name.L == name.L {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *extractor) write(src *source, tn *ast.TableName) {
+	if src == nil || src.ref == nil {
+		return
+	}
+	ref := *src.ref
+	if tn != nil {
+		ref.Span = e.spanOf(tn)
+	}
+	e.refs.Writes = append(e.refs.Writes, ref)
+}
+
+func (e *extractor) insert(stmt *ast.InsertStmt, parent *scope) {
+	// the target is only written, unless it's read by the query.
+	target := &scope{parent: parent}
+	if stmt.Table != nil {
+		if ts, ok := singleTable(stmt.Table.TableRefs); ok {
+			tn := ts.Source.(*ast.TableName)
+			table := &Table{Schema: tn.Schema, Name: tn.Name}
+			src := &source{name: tn.Name, schema: tn.Schema, table: table, ref: &TableRef{Table: *table, Span: e.spanOf(tn)}}
+			if ts.AsName.L != "" {
+				src.name, src.schema, src.ref.Alias = ts.AsName, model.CIStr{}, ts.AsName
+			}
+			target.sources = append(target.sources, src)
+			e.write(src, nil)
+		}
+	}
+	for _, col := range stmt.Columns {
+		e.column(col, target, false)
+	}
+	for _, row := range stmt.Lists {
+		for _, expr := range row {
+			e.expr(expr, target, false)
+		}
+	}
+	for _, assignment := range stmt.Setlist {
+		e.column(assignment.Column, target, false)
+		e.expr(assignment.Expr, target, false)
+	}
+	if stmt.Select != nil {
+		e.resultSet(stmt.Select, parent)
+	}
+	for _, assignment := range stmt.OnDuplicate {
+		e.column(assignment.Column, target, false)
+		e.expr(assignment.Expr, target, false)
+	}
+}
+
+// singleTable returns the table source of a table reference of a single table.
+func singleTable(node ast.ResultSetNode) (*ast.TableSource, bool) {
+	if join, ok := node.(*ast.Join); ok {
+		if join.Right != nil {
+			return nil, false
+		}
+		node = join.Left
+	}
+	ts, ok := node.(*ast.TableSource)
+	if !ok {
+		return nil, false
+	}
+	_, ok = ts.Source.(*ast.TableName)
+	return ts, ok
+}
+
+func (e *extractor) update(stmt *ast.UpdateStmt, parent *scope) {
+	sc := &scope{parent: parent}
+	e.with(stmt.With, sc)
+	if stmt.TableRefs != nil {
+		e.tableRefs(stmt.TableRefs.TableRefs, sc)
+	}
+	var written []*source
+	for _, assignment := range stmt.List {
+		e.column(assignment.Column, sc, false)
+		for _, src := range e.targetsOf(assignment.Column, sc) {
+			found := false
+			for _, w := range written {
+				found = found || w == src
+			}
+			if !found {
+				written = append(written, src)
+				e.write(src, nil)
+			}
+		}
+		e.expr(assignment.Expr, sc, false)
+	}
+	if stmt.Where != nil {
+		e.expr(stmt.Where, sc, false)
+	}
+	if stmt.Order != nil {
+		e.expr(stmt.Order, sc, false)
+	}
+}
+
+// sourceOf returns the source in sc which the column name refers to.
+func (e *extractor) sourceOf(name *ast.ColumnName, sc *scope) *source {
+	if name.Table.L == "" {
+		if len(sc.sources) == 1 {
+			return sc.sources[0]
+		}
+		return nil
+	}
+	for _, src := range sc.sources {
+		if src.name.L == name.Table.L && (name.Schema.L == "" || src.schema.L == name.Schema.L) {
+			return src
+		}
+	}
+	return nil
+}
+
+// targetsOf returns the sources in sc which the column assigned by UPDATE may belong to.
+// An unqualified name is resolved by the columns of the sources if they are known,
+// otherwise all the sources which may have the column are returned.
+func (e *extractor) targetsOf(name *ast.ColumnName, sc *scope) []*source {
+	if name.Table.L != "" || len(sc.sources) <= 1 {
+		if src := e.sourceOf(name, sc); src != nil {
+			return []*source{src}
+		}
+		return nil
+	}
+	var targets []*source
+	for _, src := range sc.sources {
+		if !src.known() || hasColumn(src, name.Name) {
+			targets = append(targets, src)
+		}
+	}
+	return targets
+}
+
+func (e *extractor) delete(stmt *ast.DeleteStmt, parent *scope) {
+	sc := &scope{parent: parent}
+	e.with(stmt.With, sc)
+	if stmt.TableRefs != nil {
+		e.tableRefs(stmt.TableRefs.TableRefs, sc)
+	}
+	if stmt.IsMultiTable && stmt.Tables != nil {
+		for _, tn := range stmt.Tables.Tables {
+			e.write(e.sourceOf(&ast.ColumnName{Schema: tn.Schema, Table: tn.Name}, sc), tn)
+		}
+	} else if len(sc.sources) == 1 {
+		e.write(sc.sources[0], nil)
+	}
+	if stmt.Where != nil {
+		e.expr(stmt.Where, sc, false)
+	}
+	if stmt.Order != nil {
+		e.expr(stmt.Order, sc, false)
+	}
+}
+
+// writtenFields are the fields of the tables written by the statements other than DML statements.
+var writtenFields = map[string]bool{
+	"*ast.CreateTableStmt.Table":      true,
+	"*ast.CreateViewStmt.ViewName":    true,
+	"*ast.CreateIndexStmt.Table":      true,
+	"*ast.CreateSequenceStmt.Name":    true,
+	"*ast.AlterTableStmt.Table":       true,
+	"*ast.AlterTableSpec.NewTable":    true,
+	"*ast.AlterSequenceStmt.Name":     true,
+	"*ast.DropTableStmt.Tables":       true,
+	"*ast.DropIndexStmt.Table":        true,
+	"*ast.DropSequenceStmt.Sequences": true,
+	"*ast.TruncateTableStmt.Table":    true,
+	"*ast.TableToTable.OldTable":      true,
+	"*ast.TableToTable.NewTable":      true,
+	"*ast.LoadDataStmt.Table":         true,
+	"*ast.RecoverTableStmt.Table":     true,
+	"*ast.FlashBackTableStmt.Table":   true,
+	"*ast.RepairTableStmt.Table":      true,
+}
+
+// generic extracts the references of the statements other than queries and DML statements.
+// The tables are read unless they are in writtenFields.
+func (e *extractor) generic(node ast.StmtNode, sc *scope) {
+	ast.Walk(node, func(c *ast.Cursor) bool {
+		switch x := c.Node().(type) {
+		case *ast.SelectStmt, *ast.SetOprStmt:
+			e.resultSet(x, sc)
+			return false
+		case *ast.InsertStmt, *ast.UpdateStmt, *ast.DeleteStmt:
+			e.stmt(x.(ast.StmtNode), sc)
+			return false
+		case *ast.SubqueryExpr:
+			e.resultSet(x.Query, sc)
+			return false
+		case *ast.ColumnNameExpr:
+			e.column(x.Name, sc, false)
+			return false
+		case *ast.TableName:
+			if c.Parent() == nil || !writtenFields[fmt.Sprintf("%T.%s", c.Parent().Node(), c.Name())] {
+				e.tableName(x, model.CIStr{}, sc)
+				return false
+			}
+			table := &Table{Schema: x.Schema, Name: x.Name}
+			e.refs.Writes = append(e.refs.Writes, TableRef{Table: *table, Span: e.spanOf(x)})
+			// the columns in the definitions refer to the table.
+			sc.sources = append(sc.sources, &source{name: x.Name, schema: x.Schema, table: table})
+			return false
+		}
+		return true
+	}, nil)
+}
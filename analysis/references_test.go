@@ -0,0 +1,246 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis_test
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	. "github.com/pingcap/parser/analysis"
+	"github.com/pingcap/parser/ast"
+	_ "github.com/pingcap/parser/test_driver"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testReferencesSuite{})
+
+type testReferencesSuite struct {
+}
+
+func parse(c *C, sql string) ast.StmtNode {
+	stmt, err := parser.New().ParseOneStmt(sql, "", "")
+	c.Assert(err, IsNil, Commentf("sql: %s", sql))
+	return stmt
+}
+
+func tableStrings(refs []TableRef) []string {
+	strs := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		str := ref.String()
+		if ref.Alias.O != "" {
+			str += " as " + ref.Alias.O
+		}
+		strs = append(strs, str)
+	}
+	return strs
+}
+
+// columnStrings formats the column references as `<name>=<base column>`, the base column is `?` if it's unresolved.
+func columnStrings(refs []ColumnRef) []string {
+	strs := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		name := ref.Name.Name.O
+		if ref.Name.Table.O != "" {
+			name = ref.Name.Table.O + "." + name
+		}
+		base := "?"
+		if ref.Table != nil {
+			base = ref.Table.String() + "." + ref.Column.O
+		}
+		strs = append(strs, name+"="+base)
+	}
+	return strs
+}
+
+func (s *testReferencesSuite) TestReferences(c *C) {
+	cases := []struct {
+		sql     string
+		reads   []string
+		writes  []string
+		columns []string
+	}{
+		{
+			"select a, t.b from db.t where c = 1 order by a",
+			[]string{"db.t"}, []string{},
+			[]string{"a=db.t.a", "t.b=db.t.b", "c=db.t.c", "a=db.t.a"},
+		},
+		{
+			"select x.a, y.b, c from t1 as x join t2 y on x.id = y.id where x.c > 1 group by c",
+			[]string{"t1 as x", "t2 as y"}, []string{},
+			[]string{"x.id=t1.id", "y.id=t2.id", "x.a=t1.a", "y.b=t2.b", "c=?", "x.c=t1.c", "c=?"},
+		},
+		{
+			"select a + 1 as s from t order by s, b",
+			[]string{"t"}, []string{},
+			[]string{"a=t.a", "b=t.b"},
+		},
+		{
+			"select d.x, d.b from (select a as x, b from t where c = 1) as d",
+			[]string{"t"}, []string{},
+			[]string{"a=t.a", "b=t.b", "c=t.c", "d.x=t.a", "d.b=t.b"},
+		},
+		{
+			"select a from t1 join t2 using (id)",
+			[]string{"t1", "t2"}, []string{},
+			[]string{"id=t1.id", "id=t2.id", "a=?"},
+		},
+		{
+			"select a from t1 where b in (select b from t2 where t2.c = t1.c)",
+			[]string{"t1", "t2"}, []string{},
+			[]string{"a=t1.a", "b=t1.b", "b=t2.b", "t2.c=t2.c", "t1.c=t1.c"},
+		},
+		{
+			"with c1(x) as (select a from t1), c2 as (select x from c1) select c2.x, t2.b from c2, t2",
+			[]string{"t1", "t2"}, []string{},
+			[]string{"a=t1.a", "x=t1.a", "c2.x=t1.a", "t2.b=t2.b"},
+		},
+		{
+			"with recursive cte(n) as (select 1 union all select n + 1 from cte where n < 5) select n from cte",
+			[]string{}, []string{},
+			[]string{"n=?", "n=?", "n=?"},
+		},
+		{
+			"select a from t1 union select b from t2",
+			[]string{"t1", "t2"}, []string{},
+			[]string{"a=t1.a", "b=t2.b"},
+		},
+		{
+			"insert into t (a, b) values (1, 2) on duplicate key update b = values(b) + 1",
+			[]string{}, []string{"t"},
+			[]string{"a=t.a", "b=t.b", "b=t.b", "b=t.b"},
+		},
+		{
+			"insert into t1 select * from t2 where c = 1",
+			[]string{"t2"}, []string{"t1"},
+			[]string{"c=t2.c"},
+		},
+		{
+			"update t set a = b + 1 where c = 2",
+			[]string{"t"}, []string{"t"},
+			[]string{"a=t.a", "b=t.b", "c=t.c"},
+		},
+		{
+			"update t1 join t2 on t1.id = t2.id set a = 1",
+			[]string{"t1", "t2"}, []string{"t1", "t2"},
+			[]string{"t1.id=t1.id", "t2.id=t2.id", "a=?"},
+		},
+		{
+			"update t1 x join t2 y on x.id = y.id set x.a = y.b, y.c = 1",
+			[]string{"t1 as x", "t2 as y"}, []string{"t1 as x", "t2 as y"},
+			[]string{"x.id=t1.id", "y.id=t2.id", "x.a=t1.a", "y.b=t2.b", "y.c=t2.c"},
+		},
+		{
+			"delete from t where a = 1",
+			[]string{"t"}, []string{"t"},
+			[]string{"a=t.a"},
+		},
+		{
+			"delete x from db.t1 as x join t2 on x.id = t2.id",
+			[]string{"db.t1 as x", "t2"}, []string{"db.t1 as x"},
+			[]string{"x.id=db.t1.id", "t2.id=t2.id"},
+		},
+		{
+			"with d as (select id from t3) delete t1 from t1, d where t1.id = d.id",
+			[]string{"t3", "t1"}, []string{"t1"},
+			[]string{"id=t3.id", "t1.id=t1.id", "d.id=t3.id"},
+		},
+		{
+			"create table t1 like t2",
+			[]string{"t2"}, []string{"t1"},
+			[]string{},
+		},
+		{
+			"create table t1 (a int, b int as (a + 1))",
+			[]string{}, []string{"t1"},
+			[]string{"a=t1.a"},
+		},
+		{
+			"create table t1 as select a from t2",
+			[]string{"t2"}, []string{"t1"},
+			[]string{"a=t2.a"},
+		},
+		{
+			"rename table t1 to t2",
+			[]string{}, []string{"t1", "t2"},
+			[]string{},
+		},
+		{
+			"alter table t1 add foreign key (a) references t2 (b)",
+			[]string{"t2"}, []string{"t1"},
+			[]string{},
+		},
+		{
+			"explain delete from t where a = 1",
+			[]string{"t", "t"}, []string{},
+			[]string{"a=t.a"},
+		},
+		{
+			"show columns from t",
+			[]string{"t"}, []string{},
+			[]string{},
+		},
+	}
+	for _, ca := range cases {
+		refs := ExtractReferences(parse(c, ca.sql))
+		comment := Commentf("sql: %s", ca.sql)
+		c.Assert(tableStrings(refs.Reads), DeepEquals, ca.reads, comment)
+		c.Assert(tableStrings(refs.Writes), DeepEquals, ca.writes, comment)
+		c.Assert(columnStrings(refs.Columns), DeepEquals, ca.columns, comment)
+	}
+}
+
+func (s *testReferencesSuite) TestSpans(c *C) {
+	sql := "select `x`.a, db . t2.b from `db`.t1 as x, db.t2 where c = 1"
+	refs := ExtractReferences(parse(c, sql))
+	var spans []string
+	for _, ref := range refs.Reads {
+		spans = append(spans, sql[ref.Span.Start:ref.Span.End])
+	}
+	for _, ref := range refs.Columns {
+		spans = append(spans, sql[ref.Span.Start:ref.Span.End])
+	}
+	c.Assert(spans, DeepEquals, []string{"`db`.t1", "db.t2", "`x`.a", "db . t2.b", "c"})
+
+	sql = "delete t1 from t1, t2"
+	refs = ExtractReferences(parse(c, sql))
+	c.Assert(refs.Writes, HasLen, 1)
+	c.Assert(fmt.Sprint(refs.Writes[0].Span), Equals, "{7 9}")
+
+	sql = "select a as b, /* b */ b from t where t.b = 1"
+	refs = ExtractReferences(parse(c, sql))
+	spans = spans[:0]
+	for _, ref := range refs.Columns {
+		spans = append(spans, fmt.Sprint(ref.Span))
+	}
+	c.Assert(spans, DeepEquals, []string{"{7 8}", "{23 24}", "{38 41}"})
+}
+
+func (s *testReferencesSuite) TestTables(c *C) {
+	refs := ExtractReferences(parse(c, "update t1 join T1 as x on t1.a = x.a join t2 set t1.b = 1, x.b = 2, t2.c = 3"))
+	c.Assert(fmt.Sprint(refs.ReadTables()), Equals, "[t1 t2]")
+	c.Assert(fmt.Sprint(refs.WrittenTables()), Equals, "[t1 t2]")
+	c.Assert(columnStrings(refs.ColumnsOf(Table{Name: refs.Reads[1].Name})), DeepEquals, []string{"t1.a=t1.a", "x.a=T1.a", "t1.b=t1.b", "x.b=T1.b"})
+
+	// the column of a recursive CTE is referred by its name in the column list.
+	refs = ExtractReferences(parse(c, "with recursive c(n) as (select 1 union all select n + 1 from c where n < 3) select n from c"))
+	last := refs.Columns[len(refs.Columns)-1]
+	c.Assert(last.Table, IsNil)
+	c.Assert(last.Column.O, Equals, "n")
+}
@@ -0,0 +1,272 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/mysql"
+)
+
+// Spans finds the spans of the nodes parsed from a SQL text by the tokens of the
+// text, since only the start offsets of the expressions are recorded by the parser.
+type Spans struct {
+	tokens []parser.Token
+	// names are the spans of the table names and the column names found by Locate.
+	names map[ast.Node]Span
+}
+
+// NewSpans returns the Spans of sql, which is split into tokens in the SQL mode.
+func NewSpans(sql string, mode mysql.SQLMode) *Spans {
+	return &Spans{tokens: parser.Tokenize(sql, mode)}
+}
+
+// Tokens returns the tokens of the SQL text, including the comments.
+func (s *Spans) Tokens() []parser.Token {
+	return s.tokens
+}
+
+// Of returns the span of n, from the first to the last token of n and its descendants,
+// including the closing parentheses. It returns an empty span if the position of n
+// is unknown, use Statements for the statements and Locate for the names.
+func (s *Spans) Of(n ast.Node) Span {
+	if field, ok := n.(*ast.SelectField); ok && field.WildCard != nil {
+		// the position of a wildcard is only recorded by Offset.
+		i := s.tokenAt(field.Offset)
+		for i >= 0 && i < len(s.tokens)-1 && s.tokens[i].Text != "*" {
+			i++
+		}
+		if i < 0 {
+			return Span{}
+		}
+		return Span{Start: field.Offset, End: s.end(i)}
+	}
+	start, end := -1, -1
+	ast.Inspect(n, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		if span, ok := s.names[n]; ok {
+			if start < 0 || span.Start < start {
+				start = span.Start
+			}
+			if span.End > end {
+				end = span.End
+			}
+			return true
+		}
+		pos := n.OriginTextPosition()
+		if pos <= 0 {
+			// the position is unknown, the text of a node without position, such as a
+			// subquery, is not used either.
+			return true
+		}
+		if start < 0 || pos < start {
+			start = pos
+		}
+		e := pos + len(n.Text())
+		if i := s.tokenAt(pos); n.Text() == "" && i >= 0 {
+			e = s.end(i)
+		}
+		if e > end {
+			end = e
+		}
+		return true
+	})
+	if start < 0 {
+		return Span{}
+	}
+	// a function call without arguments ends with the parentheses after the name.
+	_, call := n.(*ast.FuncCallExpr)
+	depth := 0
+	for i := s.tokenAt(start); i >= 0 && i < len(s.tokens); i++ {
+		tok := s.tokens[i]
+		if tok.Pos.Offset >= end && (depth <= 0 || tok.Text != ")") && (!call || tok.Text != "(") {
+			break
+		}
+		switch tok.Text {
+		case "(":
+			call = false
+			depth++
+		case ")":
+			depth--
+		}
+		if e := s.end(i); e > end {
+			end = e
+		}
+	}
+	return Span{Start: start, End: end}
+}
+
+// Statements returns the spans of the statements separated by semicolons, excluding
+// the semicolons and the comments around the statements.
+func (s *Spans) Statements() []Span {
+	var spans []Span
+	span := Span{Start: -1}
+	for i, tok := range s.tokens {
+		switch {
+		case tok.Kind == parser.TokenOperator && tok.Text == ";":
+			if span.Start >= 0 {
+				spans = append(spans, span)
+			}
+			span = Span{Start: -1}
+		case tok.Kind != parser.TokenComment:
+			if span.Start < 0 {
+				span.Start = tok.Pos.Offset
+			}
+			span.End = s.end(i)
+		}
+	}
+	if span.Start >= 0 {
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+// Locate finds the spans of the table names and the column names of n, which is
+// parsed from the text in span. The names are matched with the tokens in the order
+// of the text, a column name is matched at the position of its expression if possible.
+func (s *Spans) Locate(n ast.Node, span Span) {
+	if s.names == nil {
+		s.names = make(map[ast.Node]Span)
+	}
+	l := &locator{spans: s}
+	for i := s.tokenAt(span.Start-1) + 1; i < len(s.tokens) && s.end(i) <= span.End; i++ {
+		switch s.tokens[i].Kind {
+		case parser.TokenComment, parser.TokenHint, parser.TokenVersionComment:
+		default:
+			l.tokens = append(l.tokens, i)
+		}
+	}
+	l.claimed = make([]bool, len(l.tokens))
+	l.locate(n)
+}
+
+// locator matches the names with the tokens of a statement, excluding the comments.
+type locator struct {
+	spans   *Spans
+	tokens  []int
+	claimed []bool
+	// cursor is the index of the token after the last matched name.
+	cursor int
+}
+
+func (l *locator) locate(n ast.Node) {
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.DeleteStmt:
+			// the target tables of a multiple-table DELETE are before its table references.
+			if x.IsMultiTable && x.Tables != nil {
+				l.locate(x.Tables)
+			}
+		case *ast.ColumnNameExpr:
+			if x.Name != nil {
+				l.name(x.Name, x.OriginTextPosition(), x.Name.Schema.O, x.Name.Table.O, x.Name.Name.O)
+			}
+		case *ast.ColumnName:
+			l.name(x, 0, x.Schema.O, x.Table.O, x.Name.O)
+		case *ast.TableName:
+			l.name(x, 0, x.Schema.O, x.Name.O)
+		}
+		return true
+	})
+}
+
+// name finds the tokens of the name n, which are the non-empty parts separated by
+// dots. The tokens start at pos if it is known, or are the first unmatched tokens
+// after the cursor, or before the cursor.
+func (l *locator) name(n ast.Node, pos int, parts ...string) {
+	if _, ok := l.spans.names[n]; ok {
+		return
+	}
+	var names []string
+	for _, part := range parts {
+		if part != "" {
+			names = append(names, part)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	k := -1
+	if pos > 0 {
+		i := sort.Search(len(l.tokens), func(i int) bool { return l.spans.tokens[l.tokens[i]].Pos.Offset >= pos })
+		if i < len(l.tokens) && l.spans.tokens[l.tokens[i]].Pos.Offset == pos && l.matches(i, names) {
+			k = i
+		}
+	}
+	if k < 0 {
+		k = l.find(l.cursor, names)
+	}
+	if k < 0 {
+		k = l.find(0, names)
+	}
+	if k < 0 {
+		return
+	}
+	last := k + 2*len(names) - 2
+	for i := k; i <= last; i++ {
+		l.claimed[i] = true
+	}
+	l.cursor = last + 1
+	l.spans.names[n] = Span{Start: l.spans.tokens[l.tokens[k]].Pos.Offset, End: l.spans.end(l.tokens[last])}
+}
+
+// find returns the index of the first unmatched tokens of names from the index from.
+func (l *locator) find(from int, names []string) int {
+	for i := from; i < len(l.tokens); i++ {
+		if !l.claimed[i] && l.matches(i, names) {
+			return i
+		}
+	}
+	return -1
+}
+
+// matches reports whether the tokens from the index i are the whole qualified name of names.
+func (l *locator) matches(i int, names []string) bool {
+	last := i + 2*len(names) - 2
+	if last >= len(l.tokens) || i > 0 && l.isDot(i-1) || last+1 < len(l.tokens) && l.isDot(last+1) {
+		return false
+	}
+	for j, name := range names {
+		tok := l.spans.tokens[l.tokens[i+2*j]]
+		switch tok.Kind {
+		case parser.TokenIdentifier, parser.TokenQuotedIdentifier, parser.TokenKeyword, parser.TokenReservedKeyword:
+		default:
+			return false
+		}
+		if !strings.EqualFold(tok.Value, name) || j > 0 && !l.isDot(i+2*j-1) {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *locator) isDot(i int) bool {
+	tok := l.spans.tokens[l.tokens[i]]
+	return tok.Kind == parser.TokenOperator && tok.Text == "."
+}
+
+// tokenAt returns the index of the token starting at offset, or the last token before offset.
+func (s *Spans) tokenAt(offset int) int {
+	return sort.Search(len(s.tokens), func(i int) bool { return s.tokens[i].Pos.Offset > offset }) - 1
+}
+
+// end returns the end offset of the i-th token.
+func (s *Spans) end(i int) int {
+	return s.tokens[i].Pos.Offset + len(s.tokens[i].Text)
+}
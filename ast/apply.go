@@ -0,0 +1,142 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ApplyFunc is called by Apply for the visited nodes.
+type ApplyFunc func(c *Cursor) bool
+
+// Apply traverses root in depth-first order by Accept, calls pre and post for
+// each node, and returns the possibly modified root. The nodes can be modified
+// by the Replace, Delete, InsertBefore and InsertAfter methods of the cursor.
+//
+// If pre returns false, the children of the node are skipped and post is not
+// called for the node. If post returns false, the traversal is stopped.
+// pre and post may be nil.
+//
+// If pre replaces the node, the children of the replacement are traversed and
+// post is called with it. Deletions and insertions are done after all the
+// children of the parent are traversed, and the inserted nodes are not
+// traversed.
+func Apply(root Node, pre, post ApplyFunc) Node {
+	if root == nil {
+		return nil
+	}
+	if pre == nil {
+		pre = func(*Cursor) bool { return true }
+	}
+	if post == nil {
+		post = func(*Cursor) bool { return true }
+	}
+	result, _ := root.Accept(&walker{pre: pre, post: post, editable: true})
+	return result
+}
+
+// Replace replaces the current node with n, which must be assignable to the field holding the current node.
+// It's only supported by Apply.
+func (c *Cursor) Replace(n Node) {
+	c.checkEditable("Replace")
+	if n == nil {
+		panic("ast: Cursor.Replace with nil node, use Cursor.Delete instead")
+	}
+	c.checkAssignable(n, c.slot)
+	c.replacement = n
+}
+
+// Delete deletes the current node from the slice containing it, or sets the
+// field holding it to nil. It's only supported by Apply.
+func (c *Cursor) Delete() {
+	c.checkEditable("Delete")
+	if c.parent == nil {
+		panic("ast: Cursor.Delete of the root")
+	}
+	if !c.container.IsValid() {
+		if kind := c.slot.Kind(); !c.slot.IsValid() || (kind != reflect.Ptr && kind != reflect.Interface) {
+			panic(fmt.Sprintf("ast: Cursor.Delete of %T in %s, which is not a slice or a pointer", c.node, c.local))
+		}
+	}
+	c.deleted = true
+}
+
+// InsertBefore inserts n before the current node in the slice containing it.
+// It's only supported by Apply.
+func (c *Cursor) InsertBefore(n Node) {
+	c.checkInsert("InsertBefore", n)
+	c.before = append(c.before, n)
+}
+
+// InsertAfter inserts n after the current node in the slice containing it.
+// Nodes inserted by multiple calls are in the order of the calls.
+// It's only supported by Apply.
+func (c *Cursor) InsertAfter(n Node) {
+	c.checkInsert("InsertAfter", n)
+	c.after = append(c.after, n)
+}
+
+func (c *Cursor) checkEditable(method string) {
+	if !c.editable {
+		panic(fmt.Sprintf("ast: Cursor.%s is only supported by Apply", method))
+	}
+}
+
+func (c *Cursor) checkInsert(method string, n Node) {
+	c.checkEditable(method)
+	if !c.container.IsValid() {
+		panic(fmt.Sprintf("ast: Cursor.%s of %T in %s, which is not in a slice", method, c.node, c.local))
+	}
+	if n == nil {
+		panic(fmt.Sprintf("ast: Cursor.%s with nil node", method))
+	}
+	c.checkAssignable(n, c.slot)
+}
+
+func (c *Cursor) checkAssignable(n Node, slot reflect.Value) {
+	if slot.IsValid() && !assignable(reflect.TypeOf(n), slot.Type()) {
+		panic(fmt.Sprintf("ast: %T can't be put in %s of %T, which is %s", n, c.local, c.parent.node, slot.Type()))
+	}
+}
+
+// applyEdits deletes and inserts the children around the edited ones.
+// The children are edited in the reverse order, so the indexes of the
+// children edited later are not changed.
+func (c *Cursor) applyEdits() {
+	for i := len(c.edited) - 1; i >= 0; i-- {
+		child := c.edited[i]
+		if !child.container.IsValid() {
+			// a deleted child not in a slice.
+			slot := exposed(child.slot)
+			slot.Set(reflect.Zero(slot.Type()))
+			continue
+		}
+		container := exposed(child.container)
+		edited := reflect.MakeSlice(container.Type(), 0, container.Len()+len(child.before)+len(child.after))
+		edited = reflect.AppendSlice(edited, container.Slice(0, child.index))
+		for _, n := range child.before {
+			edited = reflect.Append(edited, reflect.ValueOf(n))
+		}
+		if !child.deleted {
+			edited = reflect.Append(edited, container.Index(child.index))
+		}
+		for _, n := range child.after {
+			edited = reflect.Append(edited, reflect.ValueOf(n))
+		}
+		edited = reflect.AppendSlice(edited, container.Slice(child.index+1, container.Len()))
+		container.Set(edited)
+	}
+	c.edited = nil
+}
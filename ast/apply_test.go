@@ -0,0 +1,182 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast_test
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	. "github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/opcode"
+)
+
+var _ = Suite(&testApplySuite{})
+
+type testApplySuite struct {
+}
+
+func parseForApply(c *C, sql string) StmtNode {
+	stmt, err := parser.New().ParseOneStmt(sql, "", "")
+	c.Assert(err, IsNil)
+	return stmt
+}
+
+func column(name string) *ColumnNameExpr {
+	return &ColumnNameExpr{Name: &ColumnName{Name: model.NewCIStr(name)}}
+}
+
+func (s *testApplySuite) TestReplace(c *C) {
+	// add a conjunct to the where clause.
+	stmt := parseForApply(c, "select a from t where b = 1 or c = 2")
+	result := Apply(stmt, nil, func(cur *Cursor) bool {
+		if cur.Name() == "Where" {
+			cur.Replace(&BinaryOperationExpr{
+				Op: opcode.LogicAnd,
+				L:  &ParenthesesExpr{Expr: cur.Node().(ExprNode)},
+				R:  &IsNullExpr{Expr: column("d"), Not: true},
+			})
+		}
+		return true
+	})
+	c.Assert(result, Equals, stmt)
+	c.Assert(restore(c, result), Equals, "SELECT `a` FROM `t` WHERE (`b`=1 OR `c`=2) AND `d` IS NOT NULL")
+
+	// the children of a replacement by pre are traversed.
+	stmt = parseForApply(c, "select a + 1 from t")
+	var visited []string
+	Apply(stmt, func(cur *Cursor) bool {
+		if _, ok := cur.Node().(*BinaryOperationExpr); ok {
+			cur.Replace(&FuncCallExpr{FnName: model.NewCIStr("abs"), Args: []ExprNode{column("x")}})
+		}
+		if col, ok := cur.Node().(*ColumnName); ok {
+			visited = append(visited, col.Name.O)
+		}
+		return true
+	}, func(cur *Cursor) bool {
+		if _, ok := cur.Node().(*FuncCallExpr); ok {
+			visited = append(visited, "post")
+		}
+		return true
+	})
+	c.Assert(visited, DeepEquals, []string{"x", "post"})
+	c.Assert(restore(c, stmt), Equals, "SELECT ABS(`x`) FROM `t`")
+
+	// the root can be replaced.
+	result = Apply(stmt, func(cur *Cursor) bool {
+		cur.Replace(&ShowStmt{Tp: ShowTables})
+		return false
+	}, nil)
+	c.Assert(restore(c, result), Equals, "SHOW TABLES")
+
+	// a node of a wrong type can't be put in a field.
+	stmt = parseForApply(c, "select a from t where b = 1")
+	c.Assert(func() {
+		Apply(stmt, func(cur *Cursor) bool {
+			if cur.Name() == "TableRefs" {
+				cur.Replace(column("x"))
+			}
+			return true
+		}, nil)
+	}, PanicMatches, `ast: \*ast.ColumnNameExpr can't be put in TableRefs of \*ast.TableRefsClause, which is \*ast.Join`)
+}
+
+func (s *testApplySuite) TestDelete(c *C) {
+	stmt := parseForApply(c, "select a, b, c, d from t where e = 1 order by f")
+	Apply(stmt, func(cur *Cursor) bool {
+		switch cur.Name() {
+		case "Fields":
+			if cur.Index() == 1 || cur.Index() == 3 {
+				cur.Delete()
+			}
+		case "Where", "OrderBy":
+			cur.Delete()
+		}
+		return true
+	}, nil)
+	c.Assert(restore(c, stmt), Equals, "SELECT `a`,`c` FROM `t`")
+
+	c.Assert(func() {
+		Apply(stmt, func(cur *Cursor) bool {
+			cur.Delete()
+			return true
+		}, nil)
+	}, PanicMatches, "ast: Cursor.Delete of the root")
+	c.Assert(func() {
+		Apply(parseForApply(c, "select a from t"), func(cur *Cursor) bool {
+			if _, ok := cur.Node().(*ColumnName); ok {
+				cur.InsertAfter(&ColumnName{})
+			}
+			return true
+		}, nil)
+	}, PanicMatches, `ast: Cursor.InsertAfter of \*ast.ColumnName in Name, which is not in a slice`)
+}
+
+func (s *testApplySuite) TestInsert(c *C) {
+	stmt := parseForApply(c, "update t set a = 1, b = 2")
+	Apply(stmt, func(cur *Cursor) bool {
+		if assign, ok := cur.Node().(*Assignment); ok {
+			if assign.Column.Name.L == "a" {
+				cur.InsertBefore(&Assignment{Column: &ColumnName{Name: model.NewCIStr("x")}, Expr: column("y")})
+			} else {
+				cur.InsertAfter(&Assignment{Column: &ColumnName{Name: model.NewCIStr("c")}, Expr: column("b")})
+				cur.InsertAfter(&Assignment{Column: &ColumnName{Name: model.NewCIStr("d")}, Expr: column("c")})
+			}
+			// the inserted nodes aren't traversed.
+			c.Assert(assign.Column.Name.L == "a" || assign.Column.Name.L == "b", IsTrue)
+		}
+		return true
+	}, nil)
+	c.Assert(restore(c, stmt), Equals, "UPDATE `t` SET `x`=`y`, `a`=1, `b`=2, `c`=`b`, `d`=`c`")
+
+	stmt = parseForApply(c, "alter table t add column a int, drop column b")
+	spec := parseForApply(c, "alter table t add index idx (a)").(*AlterTableStmt).Specs[0]
+	Apply(stmt, func(cur *Cursor) bool {
+		if cur.Name() == "Specs" && cur.Index() == 1 {
+			cur.Replace(spec)
+			cur.InsertBefore(&AlterTableSpec{Tp: AlterTableDropIndex, Name: "idx2"})
+		}
+		return true
+	}, nil)
+	c.Assert(restore(c, stmt), Equals, "ALTER TABLE `t` ADD COLUMN `a` INT, DROP INDEX `idx2`, ADD INDEX `idx`(`a`)")
+
+	c.Assert(func() {
+		Apply(stmt, func(cur *Cursor) bool {
+			if cur.Name() == "Specs" {
+				cur.InsertBefore(column("x"))
+			}
+			return true
+		}, nil)
+	}, PanicMatches, `ast: \*ast.ColumnNameExpr can't be put in Specs\[0\] of \*ast.AlterTableStmt, which is \*ast.AlterTableSpec`)
+}
+
+func (s *testApplySuite) TestStop(c *C) {
+	stmt := parseForApply(c, "select a, b, c from t")
+	var visited []string
+	Apply(stmt, nil, func(cur *Cursor) bool {
+		if col, ok := cur.Node().(*ColumnName); ok {
+			visited = append(visited, col.Name.O)
+			return col.Name.O != "b"
+		}
+		return true
+	})
+	c.Assert(visited, DeepEquals, []string{"a", "b"})
+
+	// Walk doesn't modify nodes.
+	c.Assert(func() {
+		Walk(stmt, func(cur *Cursor) bool {
+			cur.Delete()
+			return true
+		}, nil)
+	}, PanicMatches, `ast: Cursor.Delete is only supported by Apply`)
+}
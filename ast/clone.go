@@ -0,0 +1,142 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:generate go run generate_nodes.go
+
+package ast
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// Clone returns a deep copy of node, the copy doesn't share any memory with node,
+// so it can be rewritten without affecting node.
+// All the nodes of this package are supported, as well as the ValueExpr and
+// ParamMarkerExpr created by the registered parser driver. The text and the
+// positions of the nodes are kept. A node referenced several times is copied
+// once, so the copy has the same shape as node.
+func Clone(node Node) Node {
+	if node == nil {
+		return nil
+	}
+	c := cloner{copied: make(map[clonedPtr]reflect.Value)}
+	return c.clone(reflect.ValueOf(node)).Interface().(Node)
+}
+
+// CloneStmt returns a deep copy of stmt, see Clone.
+func CloneStmt(stmt StmtNode) StmtNode {
+	if stmt == nil {
+		return nil
+	}
+	return Clone(stmt).(StmtNode)
+}
+
+// CloneExpr returns a deep copy of expr, see Clone.
+func CloneExpr(expr ExprNode) ExprNode {
+	if expr == nil {
+		return nil
+	}
+	return Clone(expr).(ExprNode)
+}
+
+type clonedPtr struct {
+	ptr uintptr
+	tp  reflect.Type
+}
+
+type cloner struct {
+	// copied maps the pointers copied to their copies.
+	copied map[clonedPtr]reflect.Value
+}
+
+// clone returns a deep copy of v.
+func (c *cloner) clone(v reflect.Value) reflect.Value {
+	tp := v.Type()
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		key := clonedPtr{ptr: v.Pointer(), tp: tp}
+		if copied, ok := c.copied[key]; ok {
+			return copied
+		}
+		res := reflect.New(tp.Elem())
+		c.copied[key] = res
+		c.copyTo(res.Elem(), v.Elem())
+		return res
+	case reflect.Interface:
+		res := reflect.New(tp).Elem()
+		if !v.IsNil() {
+			res.Set(c.clone(v.Elem()))
+		}
+		return res
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		res := reflect.MakeSlice(tp, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.copyTo(res.Index(i), v.Index(i))
+		}
+		return res
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		res := reflect.MakeMapWithSize(tp, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			res.SetMapIndex(c.clone(iter.Key()), c.clone(iter.Value()))
+		}
+		return res
+	case reflect.Struct, reflect.Array:
+		res := reflect.New(tp).Elem()
+		if !v.CanAddr() {
+			// the fields of v are read by their addresses.
+			addressable := reflect.New(tp).Elem()
+			addressable.Set(v)
+			v = addressable
+		}
+		c.copyTo(res, v)
+		return res
+	}
+	// basic values, functions and channels are shared.
+	return v
+}
+
+// copyTo copies src to dst deeply, dst is addressable.
+func (c *cloner) copyTo(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Struct:
+		for i := 0; i < src.NumField(); i++ {
+			c.copyTo(exposed(dst.Field(i)), exposed(src.Field(i)))
+		}
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			c.copyTo(dst.Index(i), src.Index(i))
+		}
+	default:
+		dst.Set(c.clone(src))
+	}
+}
+
+// exposed returns the addressable field v which can be read and set even if it's unexported,
+// the unexported fields of nodes and driver values have to be copied too.
+func exposed(v reflect.Value) reflect.Value {
+	if v.CanSet() {
+		return v
+	}
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
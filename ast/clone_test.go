@@ -0,0 +1,276 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast_test
+
+import (
+	"fmt"
+	goast "go/ast"
+	goparser "go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	. "github.com/pingcap/parser/ast"
+	. "github.com/pingcap/parser/format"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/test_driver"
+)
+
+var _ = Suite(&testCloneSuite{})
+
+type testCloneSuite struct {
+}
+
+// TestAllNodesListed checks nodes_test.go is up to date, run `go generate` in this directory if it fails.
+func (s *testCloneSuite) TestAllNodesListed(c *C) {
+	fset := token.NewFileSet()
+	pkgs, err := goparser.ParseDir(fset, ".", func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	c.Assert(err, IsNil)
+	var expected []string
+	for _, f := range pkgs["ast"].Files {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*goast.FuncDecl)
+			if !ok || fn.Recv == nil || fn.Name.Name != "Accept" {
+				continue
+			}
+			if star, ok := fn.Recv.List[0].Type.(*goast.StarExpr); ok && star.X.(*goast.Ident).IsExported() {
+				expected = append(expected, star.X.(*goast.Ident).Name)
+			}
+		}
+	}
+	sort.Strings(expected)
+	listed := make([]string, 0, len(allNodes))
+	for _, node := range allNodes {
+		listed = append(listed, reflect.TypeOf(node).Elem().Name())
+	}
+	c.Assert(listed, DeepEquals, expected)
+}
+
+// nodeFiller fills all the exported fields of a node with non-zero values.
+type nodeFiller struct {
+	// candidates are the values to fill interface fields.
+	candidates []interface{}
+}
+
+const maxFillDepth = 3
+
+var nodeInterface = reflect.TypeOf((*Node)(nil)).Elem()
+
+func newNodeFiller() *nodeFiller {
+	var candidates []interface{}
+	for _, node := range allNodes {
+		candidates = append(candidates, node)
+	}
+	candidates = append(candidates, NewValueExpr([]byte("v"), "", ""), NewParamMarkerExpr(1), &PartitionDefinitionClauseLessThan{})
+	return &nodeFiller{candidates: candidates}
+}
+
+func (f *nodeFiller) fill(v reflect.Value, depth int) {
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(7)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(7)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1.5)
+	case reflect.String:
+		v.SetString("s")
+	case reflect.Slice:
+		// the elements must not be nil, Accept doesn't expect nil in slices.
+		if depth >= maxFillDepth {
+			return
+		}
+		v.Set(reflect.MakeSlice(v.Type(), 2, 2))
+		for i := 0; i < v.Len(); i++ {
+			f.fill(v.Index(i), depth+1)
+		}
+	case reflect.Map:
+		key := reflect.New(v.Type().Key()).Elem()
+		val := reflect.New(v.Type().Elem()).Elem()
+		f.fill(key, depth+1)
+		f.fill(val, depth+1)
+		v.Set(reflect.MakeMap(v.Type()))
+		v.SetMapIndex(key, val)
+	case reflect.Ptr:
+		// nodes are allocated a bit deeper, Accept doesn't expect nil nodes such as CommonTableExpression.Query.
+		if depth > maxFillDepth && (depth > maxFillDepth+2 || !v.Type().Implements(nodeInterface)) {
+			return
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		f.fill(v.Elem(), depth+1)
+	case reflect.Interface:
+		if depth > maxFillDepth {
+			// a leaf ends the tree, Accept doesn't expect nil expressions, result sets or partition clauses.
+			for _, leaf := range []interface{}{NewValueExpr(1, "", ""), &TableName{}, &PartitionDefinitionClauseHistory{}} {
+				if reflect.TypeOf(leaf).Implements(v.Type()) {
+					v.Set(reflect.ValueOf(leaf))
+					break
+				}
+			}
+			return
+		}
+		if v.NumMethod() == 0 {
+			v.Set(reflect.ValueOf("i"))
+			return
+		}
+		for _, candidate := range f.candidates {
+			tp := reflect.TypeOf(candidate)
+			if tp.Implements(v.Type()) {
+				n := reflect.New(tp.Elem())
+				if _, ok := candidate.(ValueExpr); ok {
+					// driver values are created by the driver.
+					n.Elem().Set(reflect.ValueOf(candidate).Elem())
+				} else {
+					f.fill(n.Elem(), depth+1)
+				}
+				v.Set(n)
+				return
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				f.fill(v.Field(i), depth)
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			f.fill(v.Index(i), depth)
+		}
+	}
+}
+
+// checkNotShared checks a and b don't share any memory.
+func checkNotShared(c *C, a, b reflect.Value, path string, visited map[uintptr]bool) {
+	switch a.Kind() {
+	case reflect.Ptr:
+		if a.IsNil() {
+			return
+		}
+		c.Assert(a.Pointer(), Not(Equals), b.Pointer(), Commentf("%s is shared", path))
+		if visited[a.Pointer()] {
+			return
+		}
+		visited[a.Pointer()] = true
+		checkNotShared(c, a.Elem(), b.Elem(), path, visited)
+	case reflect.Interface:
+		if !a.IsNil() {
+			checkNotShared(c, a.Elem(), b.Elem(), path, visited)
+		}
+	case reflect.Slice:
+		if a.Len() > 0 {
+			c.Assert(a.Pointer(), Not(Equals), b.Pointer(), Commentf("%s is shared", path))
+		}
+		for i := 0; i < a.Len(); i++ {
+			checkNotShared(c, a.Index(i), b.Index(i), fmt.Sprintf("%s[%d]", path, i), visited)
+		}
+	case reflect.Map:
+		if a.Len() > 0 {
+			c.Assert(a.Pointer(), Not(Equals), b.Pointer(), Commentf("%s is shared", path))
+		}
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			checkNotShared(c, a.Field(i), b.Field(i), path+"."+a.Type().Field(i).Name, visited)
+		}
+	case reflect.Array:
+		for i := 0; i < a.Len(); i++ {
+			checkNotShared(c, a.Index(i), b.Index(i), fmt.Sprintf("%s[%d]", path, i), visited)
+		}
+	}
+}
+
+func (s *testCloneSuite) TestCloneAllNodes(c *C) {
+	filler := newNodeFiller()
+	for _, node := range allNodes {
+		tp := reflect.TypeOf(node).Elem()
+		v := reflect.New(tp)
+		filler.fill(v.Elem(), 0)
+		n := v.Interface().(Node)
+		n.SetText("text")
+		n.SetOriginTextPosition(3)
+
+		cloned := Clone(n)
+		c.Assert(cloned, DeepEquals, n, Commentf("%s", tp.Name()))
+		c.Assert(cloned.Text(), Equals, "text")
+		c.Assert(cloned.OriginTextPosition(), Equals, 3)
+		checkNotShared(c, reflect.ValueOf(n), reflect.ValueOf(cloned), tp.Name(), make(map[uintptr]bool))
+	}
+	c.Assert(Clone(nil), IsNil)
+	c.Assert(CloneStmt(nil), IsNil)
+	c.Assert(CloneExpr(nil), IsNil)
+}
+
+func (s *testCloneSuite) TestCloneDriverValues(c *C) {
+	b := []byte("abc")
+	val := NewValueExpr(b, "", "")
+	cloned := CloneExpr(val).(*test_driver.ValueExpr)
+	b[0] = 'x'
+	c.Assert(cloned.GetBytes(), DeepEquals, []byte("abc"))
+	c.Assert(cloned.Type, DeepEquals, val.(*test_driver.ValueExpr).Type)
+
+	d := &test_driver.MyDecimal{}
+	c.Assert(d.FromString([]byte("10.5")), IsNil)
+	dec := NewValueExpr(d, "", "").(*test_driver.ValueExpr)
+	clonedDec := CloneExpr(dec).(*test_driver.ValueExpr)
+	c.Assert(clonedDec.GetMysqlDecimal(), Not(Equals), dec.GetMysqlDecimal())
+	c.Assert(clonedDec.GetMysqlDecimal().String(), Equals, "10.5")
+
+	param := NewParamMarkerExpr(2)
+	param.SetOrder(1)
+	clonedParam := CloneExpr(param).(*test_driver.ParamMarkerExpr)
+	c.Assert(clonedParam.Offset, Equals, 2)
+	c.Assert(clonedParam.Order, Equals, 1)
+}
+
+func (s *testCloneSuite) TestCloneParsed(c *C) {
+	sql := "WITH cte AS (SELECT a, b FROM t1) SELECT /*+ USE_INDEX(t2, idx) */ cte.a, SUM(t2.c) OVER (PARTITION BY t2.d) " +
+		"FROM cte JOIN t2 ON cte.b = t2.b WHERE t2.e IN (SELECT e FROM t3 WHERE f > ?) AND t2.g = 'x' GROUP BY 1"
+	stmt, err := parser.New().ParseOneStmt(sql, "", "")
+	c.Assert(err, IsNil)
+	cloned := CloneStmt(stmt)
+	c.Assert(cloned.Text(), Equals, sql)
+	c.Assert(cloned, DeepEquals, stmt)
+	checkNotShared(c, reflect.ValueOf(stmt), reflect.ValueOf(cloned), "stmt", make(map[uintptr]bool))
+
+	sel := cloned.(*SelectStmt)
+	c.Assert(sel.Fields.Fields[0].Text(), Equals, "cte.a")
+	c.Assert(sel.Where.OriginTextPosition(), Equals, stmt.(*SelectStmt).Where.OriginTextPosition())
+	c.Assert(sel.TableHints[0].HintName.L, Equals, "use_index")
+
+	sel.From.TableRefs.Right.(*TableSource).Source.(*TableName).Name.O = "t4"
+	c.Assert(restore(c, stmt), Equals, restore(c, CloneStmt(stmt)))
+	c.Assert(restore(c, cloned), Not(Equals), restore(c, stmt))
+}
+
+func (s *testCloneSuite) TestCloneKeepsShape(c *C) {
+	col := &ColumnNameExpr{Name: &ColumnName{Name: model.NewCIStr("a")}}
+	expr := &BinaryOperationExpr{L: col, R: col}
+	cloned := CloneExpr(expr).(*BinaryOperationExpr)
+	c.Assert(cloned.L, Equals, cloned.R)
+	c.Assert(cloned.L, Not(Equals), col)
+}
+
+func restore(c *C, node Node) string {
+	var sb strings.Builder
+	c.Assert(node.Restore(NewRestoreCtx(DefaultRestoreFlags, &sb)), IsNil)
+	return sb.String()
+}
@@ -0,0 +1,241 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pingcap/parser/model"
+)
+
+// CompareFlags controls how nodes are compared by Equal and Diff.
+type CompareFlags uint64
+
+const (
+	// CompareIgnoreIdentCase compares identifiers by model.CIStr.L, so `T1` equals `t1`.
+	CompareIgnoreIdentCase CompareFlags = 1 << iota
+	// CompareIgnoreLiteralValue treats all literal values of the same kind as equal, so `a = 1` equals `a = 2`.
+	CompareIgnoreLiteralValue
+)
+
+// Has returns whether f has the flag.
+func (f CompareFlags) Has(flag CompareFlags) bool {
+	return f&flag != 0
+}
+
+// NodeDiff is a difference between two ASTs found by Diff.
+type NodeDiff struct {
+	// Path is the path of the differing nodes from the root, such as `Where.L` or `Fields.Fields[1].Expr`,
+	// it is "" for the roots.
+	Path string
+	// A and B are the innermost nodes containing the difference.
+	A Node
+	B Node
+}
+
+// Equal reports whether a and b have the same structure and values.
+// The text and the positions of the nodes are ignored, so two statements
+// only differing in formatting are equal.
+func Equal(a, b Node, flags CompareFlags) bool {
+	c := nodeComparer{flags: flags, firstOnly: true}
+	c.compareNode(reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem(), "")
+	return len(c.diffs) == 0
+}
+
+// Diff returns the differences between a and b, which are compared like Equal.
+// A difference is reported on the innermost nodes containing it, and the
+// descendants of a node with a different type are not compared.
+func Diff(a, b Node, flags CompareFlags) []NodeDiff {
+	c := nodeComparer{flags: flags}
+	c.compareNode(reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem(), "")
+	return c.diffs
+}
+
+// ignoredFields are the fields which don't make difference between nodes.
+var ignoredFields = map[reflect.Type]map[string]bool{
+	reflect.TypeOf(node{}):        {"text": true, "offset": true},
+	reflect.TypeOf(exprNode{}):    {"flag": true},
+	reflect.TypeOf(SelectField{}): {"Offset": true},
+}
+
+var (
+	nodeType  = reflect.TypeOf((*Node)(nil)).Elem()
+	ciStrType = reflect.TypeOf(model.CIStr{})
+)
+
+type nodeComparer struct {
+	flags     CompareFlags
+	firstOnly bool
+	diffs     []NodeDiff
+
+	// path, a and b are the innermost nodes being compared.
+	path string
+	a    Node
+	b    Node
+}
+
+func (c *nodeComparer) done() bool {
+	return c.firstOnly && len(c.diffs) > 0
+}
+
+func (c *nodeComparer) report() {
+	if n := len(c.diffs); n > 0 && c.diffs[n-1].A == c.a && c.diffs[n-1].B == c.b && c.diffs[n-1].Path == c.path {
+		return
+	}
+	c.diffs = append(c.diffs, NodeDiff{Path: c.path, A: c.a, B: c.b})
+}
+
+// compareNode compares a and b, which are nodes if they are not nil.
+func (c *nodeComparer) compareNode(a, b reflect.Value, path string) {
+	na, okA := asNode(a)
+	nb, okB := asNode(b)
+	if !okA || !okB {
+		c.compare(a, b, path)
+		return
+	}
+	savedPath, savedA, savedB := c.path, c.a, c.b
+	c.path, c.a, c.b = path, na, nb
+	defer func() {
+		c.path, c.a, c.b = savedPath, savedA, savedB
+	}()
+	if reflect.TypeOf(na) != reflect.TypeOf(nb) {
+		c.report()
+		return
+	}
+	if va, ok := na.(ValueExpr); ok {
+		c.compareValue(va, nb.(ValueExpr), path)
+		return
+	}
+	c.compare(a, b, path)
+}
+
+// asNode returns the node in v, ok is false if v isn't a non-nil node.
+func asNode(v reflect.Value) (n Node, ok bool) {
+	if (v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface) || v.IsNil() || !v.CanInterface() {
+		return nil, false
+	}
+	if v.Kind() == reflect.Ptr && !v.Type().Implements(nodeType) {
+		return nil, false
+	}
+	n, ok = v.Interface().(Node)
+	return n, ok
+}
+
+// compareValue compares the literals created by the parser driver.
+func (c *nodeComparer) compareValue(a, b ValueExpr, path string) {
+	if _, ok := a.(ParamMarkerExpr); ok {
+		// parameter markers only differ in their positions.
+		return
+	}
+	if c.flags.Has(CompareIgnoreLiteralValue) {
+		// NULL is a kind of its own, though its eval type is string.
+		if (a.GetValue() == nil) != (b.GetValue() == nil) || a.GetType().EvalType() != b.GetType().EvalType() {
+			c.report()
+		}
+		return
+	}
+	if !reflect.DeepEqual(a.GetValue(), b.GetValue()) {
+		c.report()
+		return
+	}
+	c.compare(reflect.ValueOf(a.GetType()).Elem(), reflect.ValueOf(b.GetType()).Elem(), path)
+}
+
+func (c *nodeComparer) compare(a, b reflect.Value, path string) {
+	if c.done() {
+		return
+	}
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				c.report()
+			}
+			return
+		}
+		if a.Kind() == reflect.Interface && a.Elem().Type() != b.Elem().Type() {
+			c.report()
+			return
+		}
+		c.compareNode(a.Elem(), b.Elem(), path)
+	case reflect.Struct:
+		if a.Type() == ciStrType && c.flags.Has(CompareIgnoreIdentCase) {
+			if a.FieldByName("L").String() != b.FieldByName("L").String() {
+				c.report()
+			}
+			return
+		}
+		ignored := ignoredFields[a.Type()]
+		for i := 0; i < a.NumField(); i++ {
+			field := a.Type().Field(i)
+			if ignored[field.Name] {
+				continue
+			}
+			fieldPath := path
+			if !field.Anonymous {
+				fieldPath = joinPath(path, field.Name)
+			}
+			c.compareNode(a.Field(i), b.Field(i), fieldPath)
+		}
+	case reflect.Slice, reflect.Array:
+		if a.Len() != b.Len() {
+			c.report()
+			return
+		}
+		for i := 0; i < a.Len(); i++ {
+			c.compareNode(a.Index(i), b.Index(i), fmt.Sprintf("%s[%d]", path, i))
+		}
+	case reflect.Map:
+		if a.Len() != b.Len() {
+			c.report()
+			return
+		}
+		for _, key := range a.MapKeys() {
+			vb := b.MapIndex(key)
+			if !vb.IsValid() {
+				c.report()
+				return
+			}
+			c.compareNode(a.MapIndex(key), vb, fmt.Sprintf("%s[%v]", path, key))
+		}
+	case reflect.Bool:
+		if a.Bool() != b.Bool() {
+			c.report()
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if a.Int() != b.Int() {
+			c.report()
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if a.Uint() != b.Uint() {
+			c.report()
+		}
+	case reflect.Float32, reflect.Float64:
+		if a.Float() != b.Float() {
+			c.report()
+		}
+	case reflect.String:
+		if a.String() != b.String() {
+			c.report()
+		}
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
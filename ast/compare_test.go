@@ -0,0 +1,103 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast_test
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	. "github.com/pingcap/parser/ast"
+)
+
+var _ = Suite(&testCompareSuite{})
+
+type testCompareSuite struct {
+}
+
+func parseForCompare(c *C, sql string) StmtNode {
+	stmt, err := parser.New().ParseOneStmt(sql, "", "")
+	c.Assert(err, IsNil)
+	return stmt
+}
+
+func (s *testCompareSuite) TestEqual(c *C) {
+	cases := []struct {
+		a     string
+		b     string
+		flags CompareFlags
+		equal bool
+	}{
+		{"select a, b from t where c = 1", "SELECT  a,b\nFROM t  WHERE c=1", 0, true},
+		{"select a from t where c = 1", "select a from t where c = 2", 0, false},
+		{"select a from t where c = 1", "select a from t where c = 2", CompareIgnoreLiteralValue, true},
+		{"select a from t where c = 1", "select a from t where c = '1'", 0, false},
+		{"select a from t where c = 1", "select a from t where c = 'x'", CompareIgnoreLiteralValue, false},
+		{"select a from t where c = 1", "select a from t where c = null", CompareIgnoreLiteralValue, false},
+		{"select a from t where c = 1", "select a from t where c = 1.5", CompareIgnoreLiteralValue, false},
+		{"select a from t where c = 'x' or d is null", "select a from t where c = 'yz' or d is null", CompareIgnoreLiteralValue, true},
+		{"select a from t where c = 1", "select a from t where c = ?", CompareIgnoreLiteralValue, false},
+		{"select a from t where c = ? and d = ?", "select a from t where c = ?  and  d = ?", 0, true},
+		{"select A from T", "select a from t", 0, false},
+		{"select A from T", "select a from t", CompareIgnoreIdentCase, true},
+		{"select A from T where c = 1", "select a from t where c = 2", CompareIgnoreIdentCase | CompareIgnoreLiteralValue, true},
+		{"select a from t", "select a from t limit 1", 0, false},
+		{"select a from t where c in (1, 2)", "select a from t where c in (1, 2, 3)", CompareIgnoreLiteralValue, false},
+		{"insert into t values (1)", "select 1", 0, false},
+		{"create table t (a int, b varchar(10))", "CREATE TABLE t(a INT,b VARCHAR(10))", 0, true},
+		{"create table t (a int, b varchar(10))", "create table t (a int, b varchar(20))", 0, false},
+	}
+	for _, ca := range cases {
+		a := parseForCompare(c, ca.a)
+		b := parseForCompare(c, ca.b)
+		comment := Commentf("%s vs %s", ca.a, ca.b)
+		c.Assert(Equal(a, b, ca.flags), Equals, ca.equal, comment)
+		c.Assert(Equal(b, a, ca.flags), Equals, ca.equal, comment)
+		c.Assert(len(Diff(a, b, ca.flags)) == 0, Equals, ca.equal, comment)
+	}
+	c.Assert(Equal(nil, nil, 0), IsTrue)
+	c.Assert(Equal(nil, &SelectStmt{}, 0), IsFalse)
+}
+
+func (s *testCompareSuite) TestDiff(c *C) {
+	a := parseForCompare(c, "select a, b + 1 from t where c = 1 and d = 'x'")
+	b := parseForCompare(c, "select a, B + 2 from t where c = 1 and e = 'x'")
+	diffs := Diff(a, b, 0)
+	// the differences are in the order of the fields of nodes.
+	c.Assert(diffs, HasLen, 3)
+	c.Assert(diffs[0].Path, Equals, "Where.R.L.Name")
+	c.Assert(diffs[0].A.(*ColumnName).Name.O, Equals, "d")
+	c.Assert(diffs[0].B.(*ColumnName).Name.O, Equals, "e")
+	c.Assert(diffs[1].Path, Equals, "Fields.Fields[1].Expr.L.Name")
+	c.Assert(diffs[1].A, FitsTypeOf, &ColumnName{})
+	c.Assert(diffs[2].Path, Equals, "Fields.Fields[1].Expr.R")
+
+	diffs = Diff(a, b, CompareIgnoreIdentCase|CompareIgnoreLiteralValue)
+	c.Assert(diffs, HasLen, 1)
+	c.Assert(diffs[0].Path, Equals, "Where.R.L.Name")
+
+	// the children of nodes with different types are not compared.
+	a = parseForCompare(c, "select a from t where a = 1")
+	b = parseForCompare(c, "select a from t where a in (1)")
+	diffs = Diff(a, b, 0)
+	c.Assert(diffs, HasLen, 1)
+	c.Assert(diffs[0].Path, Equals, "Where")
+	c.Assert(diffs[0].A, FitsTypeOf, &BinaryOperationExpr{})
+	c.Assert(diffs[0].B, FitsTypeOf, &PatternInExpr{})
+
+	diffs = Diff(a, parseForCompare(c, "delete from t"), 0)
+	c.Assert(diffs, HasLen, 1)
+	c.Assert(diffs[0].Path, Equals, "")
+
+	// a clone has no difference.
+	c.Assert(Diff(a, Clone(a), 0), HasLen, 0)
+}
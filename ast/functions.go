@@ -447,7 +447,9 @@ func (n *FuncCallExpr) Restore(ctx *format.RestoreCtx) error {
 			ctx.WritePlain(" ")
 			fallthrough
 		case 2:
-			if expr, isValue := n.Args[1].(ValueExpr); !isValue || expr.GetValue() != nil {
+			// the remove string is omitted if it's nil, but a param marker is always written.
+			_, isMarker := n.Args[1].(ParamMarkerExpr)
+			if expr, isValue := n.Args[1].(ValueExpr); !isValue || isMarker || expr.GetValue() != nil {
 				if err := n.Args[1].Restore(ctx); err != nil {
 					return errors.Annotatef(err, "An error occurred while restore FuncCallExpr.Args[1]")
 				}
@@ -0,0 +1,127 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build ignore
+// +build ignore
+
+// generate_nodes.go generates node_types.go, which maps the names of all the
+// node types of package ast to their types, and nodes_test.go, which lists
+// them for tests to check every node type is handled.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	goformat "go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+const license = `// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by generate_nodes.go. DO NOT EDIT.
+`
+
+const nodeTypesHeader = `
+package ast
+
+import (
+	"reflect"
+)
+
+// nodeTypes maps the names of all the node types to their types.
+var nodeTypes = map[string]reflect.Type{
+`
+
+const nodesTestHeader = `
+package ast_test
+
+import (
+	"github.com/pingcap/parser/ast"
+)
+
+// allNodes are all the node types of package ast.
+var allNodes = []ast.Node{
+`
+
+func main() {
+	names, err := nodeTypeNames(".")
+	if err != nil {
+		log.Fatal(err)
+	}
+	writeFile("node_types.go", nodeTypesHeader, names, "\t%q: reflect.TypeOf(%[1]s{}),\n")
+	writeFile("nodes_test.go", nodesTestHeader, names, "\t&ast.%s{},\n")
+}
+
+func writeFile(name, header string, names []string, format string) {
+	var buf bytes.Buffer
+	buf.WriteString(license)
+	buf.WriteString(header)
+	for _, name := range names {
+		fmt.Fprintf(&buf, format, name)
+	}
+	buf.WriteString("}\n")
+	src, err := goformat.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = ioutil.WriteFile(name, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// nodeTypeNames returns the sorted names of the exported types which have an Accept method.
+func nodeTypeNames(dir string) ([]string, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, f := range pkgs["ast"].Files {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || fn.Name.Name != "Accept" {
+				continue
+			}
+			star, ok := fn.Recv.List[0].Type.(*ast.StarExpr)
+			if !ok {
+				continue
+			}
+			if ident, ok := star.X.(*ast.Ident); ok && ident.IsExported() {
+				names = append(names, ident.Name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,172 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+import (
+	"encoding/hex"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser/charset"
+	"github.com/pingcap/parser/format"
+	"github.com/pingcap/parser/mysql"
+)
+
+// BindParams returns a copy of node with the param markers replaced by the
+// literals of args, node itself is not changed. The param markers are bound
+// in the order of their positions in the SQL.
+//
+// The args can be the integers, floats, bools, strings, []byte, time.Time, nil
+// and the decimals created by NewDecimal. The []byte is bound as a hexadecimal
+// literal, and the time.Time is bound as a string in the format of DATETIME.
+// It returns an error if the number of args isn't the number of param markers,
+// or an arg has an unsupported type, or the arg of LIMIT isn't a non-negative integer.
+func BindParams(node Node, args []interface{}) (Node, error) {
+	p := parameterizer{bindArgs: true, args: args, pending: make(map[ParamMarkerExpr]Param)}
+	node = Apply(Clone(node), p.enter, nil)
+	if p.bound != len(args) {
+		return nil, errors.Errorf("the statement has %d param markers, but %d args are given", p.bound, len(args))
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return node, nil
+}
+
+// bind replaces the param marker at the cursor with the literal of the next arg.
+// The args are still counted after an error, so the number of param markers is reported first.
+func (p *parameterizer) bind(c *Cursor) {
+	i := p.bound
+	p.bound++
+	if p.err != nil || i >= len(p.args) {
+		return
+	}
+	value, err := bindParam(p.args[i], p.inLimit)
+	if err != nil {
+		p.err = errors.Annotatef(err, "arg %d", i)
+		return
+	}
+	c.Replace(value)
+}
+
+// Interpolate binds args to the param markers of node by BindParams, and restores
+// the result to a statement without param markers, which can be sent to a server
+// with sqlMode and the client charset cs. It returns an error if cs is unknown.
+//
+// The backslashes in the strings are escaped unless sqlMode has NO_BACKSLASH_ESCAPES.
+// The bound strings are written without the charset introducers, so they are in the
+// client charset once the statement is encoded in it. They must be valid UTF-8 unless
+// cs is a single-byte charset, otherwise the bytes of an invalid sequence may swallow
+// the escape characters in a multi-byte charset such as gbk.
+func Interpolate(node Node, args []interface{}, sqlMode mysql.SQLMode, cs string) (string, error) {
+	singleByte := false
+	switch strings.ToLower(cs) {
+	case charset.CharsetUCS2, charset.CharsetUTF16, charset.CharsetUTF16LE, charset.CharsetUTF32:
+		return "", errors.Errorf("%s can't be used as a client charset", cs)
+	default:
+		info, err := charset.GetCharsetInfo(cs)
+		if err == nil {
+			singleByte = info.Maxlen == 1
+		} else if !hasCollations(cs) {
+			// the charsets unsupported by the parser, such as gbk, are still known by their collations.
+			return "", errors.Trace(err)
+		}
+	}
+	if !singleByte {
+		for i, arg := range args {
+			if str, ok := arg.(string); ok && !utf8.ValidString(str) {
+				return "", errors.Errorf("arg %d: invalid UTF-8 string %q", i, str)
+			}
+		}
+	}
+
+	result, err := BindParams(node, args)
+	if err != nil {
+		return "", err
+	}
+	flags := format.DefaultRestoreFlags
+	if !sqlMode.HasNoBackslashEscapesMode() {
+		flags |= format.RestoreStringEscapeBackslash
+	}
+	var sb strings.Builder
+	if err = result.Restore(format.NewRestoreCtx(flags, &sb)); err != nil {
+		return "", errors.Trace(err)
+	}
+	return sb.String(), nil
+}
+
+// hasCollations reports whether cs is the charset of a known collation.
+func hasCollations(cs string) bool {
+	for _, co := range charset.GetCollations() {
+		if strings.EqualFold(co.CharsetName, cs) {
+			return true
+		}
+	}
+	return false
+}
+
+// bindParam converts arg to the literal of a param marker, which is an arg of LIMIT if limit is true.
+func bindParam(arg interface{}, limit bool) (ValueExpr, error) {
+	var value interface{}
+	switch x := arg.(type) {
+	case nil, bool, int64, uint64, float64, string:
+		value = x
+	case int:
+		value = int64(x)
+	case int8:
+		value = int64(x)
+	case int16:
+		value = int64(x)
+	case int32:
+		value = int64(x)
+	case uint:
+		value = uint64(x)
+	case uint8:
+		value = uint64(x)
+	case uint16:
+		value = uint64(x)
+	case uint32:
+		value = uint64(x)
+	case float32:
+		value = float64(x)
+	case []byte:
+		hexLit, err := NewHexLiteral("x'" + hex.EncodeToString(x) + "'")
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		value = hexLit
+	case time.Time:
+		value = x.Format("2006-01-02 15:04:05.999999")
+	default:
+		if dec, err := NewDecimal("0"); err != nil || reflect.TypeOf(dec) != reflect.TypeOf(arg) {
+			return nil, errors.Errorf("unsupported type %T", arg)
+		}
+		value = x
+	}
+	if f, ok := value.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+		return nil, errors.Errorf("unsupported float %v", f)
+	}
+	if limit {
+		if i, ok := value.(int64); ok && i >= 0 {
+			value = uint64(i)
+		} else if _, ok := value.(uint64); !ok {
+			return nil, errors.Errorf("the arg of LIMIT must be a non-negative integer, but got %v", arg)
+		}
+	}
+	return NewValueExpr(value, "", ""), nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast_test
+
+import (
+	"math"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	. "github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/mysql"
+)
+
+var _ = Suite(&testInterpolateSuite{})
+
+type testInterpolateSuite struct {
+}
+
+func (s *testInterpolateSuite) TestInterpolate(c *C) {
+	dec, err := NewDecimal("12.50")
+	c.Assert(err, IsNil)
+	cases := []struct {
+		sql      string
+		args     []interface{}
+		sqlMode  mysql.SQLMode
+		cs       string
+		expected string
+	}{
+		{
+			"select a from t where b = ? and c in (?, ?) and d = ? limit ?, ?",
+			[]interface{}{1, uint8(2), -3.5, nil, 10, uint64(20)},
+			0, "utf8mb4",
+			"SELECT `a` FROM `t` WHERE `b`=1 AND `c` IN (2,-3.5e+00) AND `d`=NULL LIMIT 10,20",
+		},
+		{
+			"insert into t values (?, ?, ?, ?)",
+			[]interface{}{"it's", `a\'b`, []byte("\x00'\\"), dec},
+			0, "utf8mb4",
+			`INSERT INTO ` + "`t`" + ` VALUES ('it''s','a\\''b',x'00275c',12.50)`,
+		},
+		{
+			"insert into t values (?, ?)",
+			[]interface{}{`a\'b`, true},
+			mysql.ModeNoBackslashEscapes, "utf8mb4",
+			`INSERT INTO ` + "`t`" + ` VALUES ('a\''b',TRUE)`,
+		},
+		{
+			"update t set a = ?, b = ? where c = 'x'",
+			[]interface{}{time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC), time.Date(2021, 1, 2, 3, 4, 5, 600000000, time.UTC)},
+			0, "gbk",
+			"UPDATE `t` SET `a`='2021-01-02 03:04:05', `b`='2021-01-02 03:04:05.6' WHERE `c`='x'",
+		},
+		{
+			"select ?",
+			[]interface{}{"\xbf'"},
+			0, "latin1",
+			"SELECT '\xbf'''",
+		},
+	}
+	for _, ca := range cases {
+		stmt, err := parser.New().ParseOneStmt(ca.sql, "", "")
+		c.Assert(err, IsNil)
+		sql, err := Interpolate(stmt, ca.args, ca.sqlMode, ca.cs)
+		comment := Commentf("sql: %s", ca.sql)
+		c.Assert(err, IsNil, comment)
+		c.Assert(sql, Equals, ca.expected, comment)
+		// the statement isn't changed, so it can be interpolated again.
+		c.Assert(restore(c, stmt), Matches, ".*\\?.*", comment)
+	}
+}
+
+func (s *testInterpolateSuite) TestInterpolateParameterized(c *C) {
+	stmt, err := parser.New().ParseOneStmt("select trim(leading 'x' from 'y') from t where a = 1 limit 2, 3", "", "")
+	c.Assert(err, IsNil)
+	result, params := Parameterize(stmt, 0)
+	args := make([]interface{}, 0, len(params))
+	for _, param := range params {
+		args = append(args, param.Value.GetValue())
+	}
+	args[0] = "z"
+	sql, err := Interpolate(result, args, 0, "utf8mb4")
+	c.Assert(err, IsNil)
+	c.Assert(sql, Equals, "SELECT TRIM(LEADING 'z' FROM 'y') FROM `t` WHERE `a`=1 LIMIT 2,3")
+}
+
+func (s *testInterpolateSuite) TestInterpolateErrors(c *C) {
+	cases := []struct {
+		sql  string
+		args []interface{}
+		cs   string
+		err  string
+	}{
+		{"select ?, ?", []interface{}{1}, "utf8mb4", "the statement has 2 param markers, but 1 args are given"},
+		{"select ?", []interface{}{1, 2}, "utf8mb4", "the statement has 1 param markers, but 2 args are given"},
+		{"select ?", []interface{}{struct{}{}}, "utf8mb4", "arg 0: unsupported type struct {}"},
+		{"select ?", []interface{}{math.NaN()}, "utf8mb4", "arg 0: unsupported float NaN"},
+		{"select a from t limit ?", []interface{}{"1"}, "utf8mb4", "arg 0: the arg of LIMIT must be a non-negative integer, but got 1"},
+		{"select a from t limit ?", []interface{}{-1}, "utf8mb4", "arg 0: the arg of LIMIT must be a non-negative integer, but got -1"},
+		{"select ?", []interface{}{"\xbf'"}, "gbk", `arg 0: invalid UTF-8 string "\\xbf'"`},
+		{"select ?", []interface{}{"a"}, "utf16", "utf16 can't be used as a client charset"},
+		{"select ?", []interface{}{"a"}, "nosuch", "Unknown charset nosuch"},
+	}
+	for _, ca := range cases {
+		stmt, err := parser.New().ParseOneStmt(ca.sql, "", "")
+		c.Assert(err, IsNil)
+		_, err = Interpolate(stmt, ca.args, 0, ca.cs)
+		c.Assert(err, ErrorMatches, ca.err, Commentf("sql: %s", ca.sql))
+	}
+}
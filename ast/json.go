@@ -0,0 +1,545 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser/auth"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/types"
+)
+
+// JSONVersion is the version of the JSON encoding of nodes, it's increased
+// when the encoding is changed incompatibly.
+const JSONVersion = 1
+
+// The JSON encoding of nodes is:
+//
+//	{"version": 1, "node": <node>}
+//
+// A node is encoded as an object, whose "@type" is the name of the node type,
+// "@text" and "@offset" are the text and the position of the node in the
+// original SQL, other keys are the names of the non-zero exported fields.
+// Fields of embedded unexported structs are encoded in the node object.
+//
+//   - model.CIStr is encoded as the original string.
+//   - []byte is encoded as a base64 string.
+//   - A non-node value in an interface field, such as PartitionDefinition.Clause,
+//     is encoded as {"@type": <type>, "value": <value>}.
+//   - A literal created by the parser driver is encoded as
+//     {"@type": "ValueExpr", "kind": <kind>, "value": <value>, "Type": <field type>}, kind is one of
+//     "null", "int", "uint", "float32", "float64", "string", "bytes", "decimal" and "binary".
+//     Integers and decimals are encoded as strings to keep the precision, and binary
+//     literals as hexadecimal strings.
+//   - A parameter marker is encoded as {"@type": "ParamMarkerExpr", "order": <order>, "Type": <field type>},
+//     order is the order set by SetOrder.
+//
+// The results of name binding and type inference, such as TableName.TableInfo and
+// the types of the expressions other than literals, and the states of execution,
+// such as ExecuteStmt.BinaryArgs, are not encoded.
+const (
+	jsonKeyType   = "@type"
+	jsonKeyText   = "@text"
+	jsonKeyOffset = "@offset"
+
+	jsonValueExpr       = "ValueExpr"
+	jsonParamMarkerExpr = "ParamMarkerExpr"
+)
+
+type jsonDocument struct {
+	Version int         `json:"version"`
+	Node    interface{} `json:"node"`
+}
+
+// jsonSkippedTypes are the types of fields which are not encoded.
+var jsonSkippedTypes = map[reflect.Type]bool{
+	reflect.TypeOf(&model.DBInfo{}):     true,
+	reflect.TypeOf(&model.TableInfo{}):  true,
+	reflect.TypeOf(&model.ColumnInfo{}): true,
+	reflect.TypeOf(&ResultField{}):      true,
+}
+
+// jsonSkippedFields are the fields which are not encoded.
+var jsonSkippedFields = map[reflect.Type]map[string]bool{
+	reflect.TypeOf(Prepared{}):    {"CachedPlan": true, "CachedNames": true},
+	reflect.TypeOf(ExecuteStmt{}): {"BinaryArgs": true},
+	reflect.TypeOf(exprNode{}):    {"Type": true},
+}
+
+// jsonDynamicTypes are the types of non-node values held by interface fields.
+var jsonDynamicTypes = map[string]reflect.Type{
+	"bool":          reflect.TypeOf(false),
+	"int64":         reflect.TypeOf(int64(0)),
+	"uint64":        reflect.TypeOf(uint64(0)),
+	"string":        reflect.TypeOf(""),
+	"CIStr":         reflect.TypeOf(model.CIStr{}),
+	"HintSetVar":    reflect.TypeOf(HintSetVar{}),
+	"HintTimeRange": reflect.TypeOf(HintTimeRange{}),
+	"RoleIdentity":  reflect.TypeOf(&auth.RoleIdentity{}),
+
+	"PartitionDefinitionClauseNone":     reflect.TypeOf(&PartitionDefinitionClauseNone{}),
+	"PartitionDefinitionClauseLessThan": reflect.TypeOf(&PartitionDefinitionClauseLessThan{}),
+	"PartitionDefinitionClauseIn":       reflect.TypeOf(&PartitionDefinitionClauseIn{}),
+	"PartitionDefinitionClauseHistory":  reflect.TypeOf(&PartitionDefinitionClauseHistory{}),
+}
+
+var (
+	jsonDynamicTypeNames = make(map[reflect.Type]string, len(jsonDynamicTypes))
+	jsonNodeTypeNames    = make(map[reflect.Type]string, len(nodeTypes))
+	bytesType            = reflect.TypeOf([]byte(nil))
+)
+
+func init() {
+	for name, tp := range jsonDynamicTypes {
+		jsonDynamicTypeNames[tp] = name
+	}
+	for name, tp := range nodeTypes {
+		jsonNodeTypeNames[tp] = name
+	}
+}
+
+// EncodeJSON encodes node to JSON with type discriminators, so it can be decoded by DecodeJSON.
+func EncodeJSON(node Node) ([]byte, error) {
+	encoded, err := encodeJSONNode(node)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonDocument{Version: JSONVersion, Node: encoded})
+}
+
+// DecodeJSON decodes the node encoded by EncodeJSON, literals are created by the registered parser driver.
+func DecodeJSON(data []byte) (Node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc jsonDocument
+	if err := dec.Decode(&doc); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if doc.Version != JSONVersion {
+		return nil, errors.Errorf("unsupported AST JSON version %d, expected %d", doc.Version, JSONVersion)
+	}
+	node, err := decodeJSONNode(doc.Node, "node")
+	if err != nil {
+		return nil, err
+	}
+	if node != nil {
+		SetFlag(node)
+	}
+	return node, nil
+}
+
+func encodeJSONNode(node Node) (interface{}, error) {
+	v := reflect.ValueOf(node)
+	if node == nil || v.IsNil() {
+		return nil, nil
+	}
+	obj := make(map[string]interface{})
+	if text := node.Text(); text != "" {
+		obj[jsonKeyText] = text
+	}
+	if offset := node.OriginTextPosition(); offset != 0 {
+		obj[jsonKeyOffset] = offset
+	}
+	if val, ok := node.(ValueExpr); ok {
+		return obj, encodeJSONValueExpr(obj, val)
+	}
+	name, ok := jsonNodeTypeNames[v.Type().Elem()]
+	if !ok || v.Kind() != reflect.Ptr {
+		return nil, errors.Errorf("unsupported node type %T", node)
+	}
+	obj[jsonKeyType] = name
+	return obj, encodeJSONFields(obj, v.Elem())
+}
+
+func encodeJSONValueExpr(obj map[string]interface{}, val ValueExpr) error {
+	tp, err := encodeJSONValue(reflect.ValueOf(val.GetType()))
+	if err != nil {
+		return err
+	}
+	obj["Type"] = tp
+	if _, ok := val.(ParamMarkerExpr); ok {
+		obj[jsonKeyType] = jsonParamMarkerExpr
+		// the order is only held by the field of the parser driver.
+		if order := reflect.Indirect(reflect.ValueOf(val)).FieldByName("Order"); order.Kind() == reflect.Int && order.Int() != 0 {
+			obj["order"] = order.Int()
+		}
+		return nil
+	}
+	obj[jsonKeyType] = jsonValueExpr
+	var kind string
+	switch x := val.GetValue().(type) {
+	case nil:
+		kind = "null"
+	case int64:
+		kind, obj["value"] = "int", strconv.FormatInt(x, 10)
+	case uint64:
+		kind, obj["value"] = "uint", strconv.FormatUint(x, 10)
+	case float32:
+		kind, obj["value"] = "float32", x
+	case float64:
+		kind, obj["value"] = "float64", x
+	case string:
+		kind, obj["value"] = "string", x
+	case []byte:
+		kind, obj["value"] = "bytes", x
+	default:
+		v := reflect.ValueOf(x)
+		if stringer, ok := x.(fmt.Stringer); ok && val.GetType().Tp == mysql.TypeNewDecimal {
+			kind, obj["value"] = "decimal", stringer.String()
+		} else if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			kind, obj["value"] = "binary", hex.EncodeToString(v.Bytes())
+		} else {
+			return errors.Errorf("unsupported literal value %T", x)
+		}
+	}
+	obj["kind"] = kind
+	return nil
+}
+
+// encodeJSONFields encodes the fields of struct v to obj.
+func encodeJSONFields(obj map[string]interface{}, v reflect.Value) error {
+	tp := v.Type()
+	skipped := jsonSkippedFields[tp]
+	for i := 0; i < v.NumField(); i++ {
+		field := tp.Field(i)
+		fv := v.Field(i)
+		if field.PkgPath != "" {
+			if field.Anonymous && fv.Kind() == reflect.Struct {
+				if err := encodeJSONFields(obj, fv); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if skipped[field.Name] || jsonSkippedTypes[field.Type] || fv.IsZero() {
+			continue
+		}
+		encoded, err := encodeJSONValue(fv)
+		if err != nil {
+			return errors.Annotatef(err, "field %s.%s", tp.Name(), field.Name)
+		}
+		obj[field.Name] = encoded
+	}
+	return nil
+}
+
+func encodeJSONValue(v reflect.Value) (interface{}, error) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil, nil
+		}
+		if node, ok := v.Interface().(Node); ok {
+			return encodeJSONNode(node)
+		}
+		return encodeJSONValue(v.Elem())
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		if node, ok := v.Interface().(Node); ok {
+			return encodeJSONNode(node)
+		}
+		name, ok := jsonDynamicTypeNames[v.Elem().Type()]
+		if !ok {
+			return nil, errors.Errorf("unsupported value %T", v.Interface())
+		}
+		encoded, err := encodeJSONValue(v.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{jsonKeyType: name, "value": encoded}, nil
+	case reflect.Struct:
+		if v.Type() == ciStrType {
+			return v.Interface().(model.CIStr).O, nil
+		}
+		obj := make(map[string]interface{})
+		return obj, encodeJSONFields(obj, v)
+	case reflect.Slice, reflect.Array:
+		if v.Type() == bytesType {
+			return v.Bytes(), nil
+		}
+		arr := make([]interface{}, v.Len())
+		for i := range arr {
+			encoded, err := encodeJSONValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = encoded
+		}
+		return arr, nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		return v.String(), nil
+	}
+	return nil, errors.Errorf("unsupported value of type %s", v.Type())
+}
+
+func decodeJSONNode(raw interface{}, path string) (Node, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("%s: node must be an object", path)
+	}
+	name, _ := obj[jsonKeyType].(string)
+	var node Node
+	switch name {
+	case jsonValueExpr, jsonParamMarkerExpr:
+		val, err := decodeJSONValueExpr(obj, name, path)
+		if err != nil {
+			return nil, err
+		}
+		node = val
+	default:
+		tp, ok := nodeTypes[name]
+		if !ok {
+			return nil, errors.Errorf("%s: unknown node type %q", path, name)
+		}
+		v := reflect.New(tp)
+		if err := decodeJSONFields(obj, v.Elem(), path); err != nil {
+			return nil, err
+		}
+		node = v.Interface().(Node)
+	}
+	if text, ok := obj[jsonKeyText].(string); ok {
+		node.SetText(text)
+	}
+	if offset, ok := obj[jsonKeyOffset].(json.Number); ok {
+		pos, err := offset.Int64()
+		if err != nil {
+			return nil, errors.Annotatef(err, "%s: invalid offset", path)
+		}
+		node.SetOriginTextPosition(int(pos))
+	}
+	return node, nil
+}
+
+func decodeJSONValueExpr(obj map[string]interface{}, name, path string) (ValueExpr, error) {
+	var val ValueExpr
+	if name == jsonParamMarkerExpr {
+		offset, _ := obj[jsonKeyOffset].(json.Number)
+		pos, _ := offset.Int64()
+		marker := NewParamMarkerExpr(int(pos))
+		order, _ := obj["order"].(json.Number)
+		if n, _ := order.Int64(); n != 0 {
+			marker.SetOrder(int(n))
+		}
+		val = marker
+	} else {
+		value, err := decodeJSONLiteral(obj)
+		if err != nil {
+			return nil, errors.Annotatef(err, "%s", path)
+		}
+		val = NewValueExpr(value, "", "")
+	}
+	tp := val.GetType()
+	*tp = types.FieldType{}
+	if err := decodeJSONValue(obj["Type"], reflect.ValueOf(tp).Elem(), path+".Type"); err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
+func decodeJSONLiteral(obj map[string]interface{}) (interface{}, error) {
+	kind, _ := obj["kind"].(string)
+	if kind == "null" {
+		return nil, nil
+	}
+	raw := obj["value"]
+	str, isStr := raw.(string)
+	num, isNum := raw.(json.Number)
+	switch {
+	case kind == "int" && isStr:
+		return strconv.ParseInt(str, 10, 64)
+	case kind == "uint" && isStr:
+		return strconv.ParseUint(str, 10, 64)
+	case kind == "float32" && isNum:
+		f, err := strconv.ParseFloat(string(num), 32)
+		return float32(f), err
+	case kind == "float64" && isNum:
+		return num.Float64()
+	case kind == "string" && isStr:
+		return str, nil
+	case kind == "bytes" && isStr:
+		return base64.StdEncoding.DecodeString(str)
+	case kind == "decimal" && isStr:
+		return NewDecimal(str)
+	case kind == "binary" && isStr:
+		return NewHexLiteral("x'" + str + "'")
+	}
+	return nil, errors.Errorf("invalid literal of kind %q: %v", kind, raw)
+}
+
+// decodeJSONFields decodes obj to the fields of struct v.
+func decodeJSONFields(obj map[string]interface{}, v reflect.Value, path string) error {
+	tp := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		field := tp.Field(i)
+		if field.PkgPath != "" {
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				if err := decodeJSONFields(obj, v.Field(i), path); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		raw, ok := obj[field.Name]
+		if !ok {
+			continue
+		}
+		if err := decodeJSONValue(raw, v.Field(i), path+"."+field.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeJSONValue decodes raw to v, which is settable.
+func decodeJSONValue(raw interface{}, v reflect.Value, path string) error {
+	if raw == nil {
+		return nil
+	}
+	tp := v.Type()
+	if tp.Kind() == reflect.Interface || (tp.Kind() == reflect.Ptr && tp.Implements(nodeType)) {
+		if obj, ok := raw.(map[string]interface{}); ok && tp.Kind() == reflect.Interface {
+			if name, ok := obj[jsonKeyType].(string); ok {
+				if dynamic, ok := jsonDynamicTypes[name]; ok {
+					if !dynamic.Implements(tp) {
+						return errors.Errorf("%s: %s is not %s", path, dynamic, tp)
+					}
+					val := reflect.New(dynamic).Elem()
+					if err := decodeJSONValue(obj["value"], val, path); err != nil {
+						return err
+					}
+					v.Set(val)
+					return nil
+				}
+			}
+		}
+		node, err := decodeJSONNode(raw, path)
+		if err != nil {
+			return err
+		}
+		if !reflect.TypeOf(node).AssignableTo(tp) {
+			return errors.Errorf("%s: %T is not %s", path, node, tp)
+		}
+		v.Set(reflect.ValueOf(node))
+		return nil
+	}
+	mismatch := errors.Errorf("%s: can't decode %v to %s", path, raw, tp)
+	switch tp.Kind() {
+	case reflect.Ptr:
+		v.Set(reflect.New(tp.Elem()))
+		return decodeJSONValue(raw, v.Elem(), path)
+	case reflect.Struct:
+		if tp == ciStrType {
+			str, ok := raw.(string)
+			if !ok {
+				return mismatch
+			}
+			v.Set(reflect.ValueOf(model.NewCIStr(str)))
+			return nil
+		}
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return mismatch
+		}
+		return decodeJSONFields(obj, v, path)
+	case reflect.Slice, reflect.Array:
+		if tp == bytesType {
+			str, ok := raw.(string)
+			if !ok {
+				return mismatch
+			}
+			b, err := base64.StdEncoding.DecodeString(str)
+			if err != nil {
+				return errors.Annotatef(err, "%s", path)
+			}
+			v.SetBytes(b)
+			return nil
+		}
+		arr, ok := raw.([]interface{})
+		if !ok || (tp.Kind() == reflect.Array && len(arr) != v.Len()) {
+			return mismatch
+		}
+		if tp.Kind() == reflect.Slice {
+			v.Set(reflect.MakeSlice(tp, len(arr), len(arr)))
+		}
+		for i, elem := range arr {
+			if err := decodeJSONValue(elem, v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Bool:
+		b, ok := raw.(bool)
+		if !ok {
+			return mismatch
+		}
+		v.SetBool(b)
+		return nil
+	case reflect.String:
+		str, ok := raw.(string)
+		if !ok {
+			return mismatch
+		}
+		v.SetString(str)
+		return nil
+	}
+	num, ok := raw.(json.Number)
+	if !ok {
+		return mismatch
+	}
+	switch tp.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(string(num), 10, 64)
+		if err != nil || v.OverflowInt(i) {
+			return mismatch
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(string(num), 10, 64)
+		if err != nil || v.OverflowUint(u) {
+			return mismatch
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := num.Float64()
+		if err != nil {
+			return mismatch
+		}
+		v.SetFloat(f)
+	default:
+		return mismatch
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast_test
+
+import (
+	"encoding/json"
+	"reflect"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	. "github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/test_driver"
+)
+
+var _ = Suite(&testJSONSuite{})
+
+type testJSONSuite struct {
+}
+
+func (s *testJSONSuite) TestRoundTrip(c *C) {
+	sqls := []string{
+		"select a, b as c, 1.5, -2, 18446744073709551615, 1e3, 'str', x'0aff', b'101', null, true from t where d = ? and e in (1, 2)",
+		"select /*+ USE_INDEX(t, idx), MAX_EXECUTION_TIME(1000), SET_VAR(sql_mode='') */ * from db.T as t1 join t2 using (a) order by 1 desc limit 10",
+		"with recursive cte(n) as (select 1 union all select n + 1 from cte where n < 5) select * from cte",
+		"insert into t (a, b) values (1, _utf8mb4'x'), (2, default) on duplicate key update b = values(b)",
+		"update t set a = a + 1 where b like 'x%' escape '|'",
+		"delete from t where a between 1 and 10",
+		"create table t (a int unsigned not null auto_increment primary key, b varchar(10) charset utf8mb4 collate utf8mb4_bin default 'x' comment 'c', c enum('x', 'y'), index idx (b(3))) engine = innodb partition by hash(a) partitions 4",
+		"create table t (a int) partition by range (a) (partition p0 values less than (10), partition p1 values less than maxvalue)",
+		"alter table t add partition (partition p2 values in ((1, 2), (3, 4)))",
+		"alter table t add column d decimal(10, 2) default 1.25, drop index idx",
+		"grant select, insert on db.* to 'u'@'%' with grant option",
+		"grant r1 to u1",
+		"select sum(a) over w from t window w as (partition by b order by c rows between 1 preceding and current row)",
+		"select json_extract(a, '$.b'), a->>'$.c', cast(a as char(10)), d + interval 1 day from t",
+	}
+	p := parser.New()
+	for _, sql := range sqls {
+		stmt, err := p.ParseOneStmt(sql, "", "")
+		c.Assert(err, IsNil, Commentf("sql: %s", sql))
+		data, err := EncodeJSON(stmt)
+		c.Assert(err, IsNil, Commentf("sql: %s", sql))
+		decoded, err := DecodeJSON(data)
+		c.Assert(err, IsNil, Commentf("sql: %s", sql))
+		comment := Commentf("sql: %s\njson: %s", sql, data)
+		c.Assert(Diff(stmt, decoded, 0), HasLen, 0, comment)
+		c.Assert(decoded.Text(), Equals, sql, comment)
+		c.Assert(restore(c, decoded), Equals, restore(c, stmt), comment)
+		data2, err := EncodeJSON(decoded)
+		c.Assert(err, IsNil)
+		c.Assert(string(data2), Equals, string(data))
+	}
+}
+
+func (s *testJSONSuite) TestFormat(c *C) {
+	stmt, err := parser.New().ParseOneStmt("select a from t where b = 1", "", "")
+	c.Assert(err, IsNil)
+	data, err := EncodeJSON(stmt)
+	c.Assert(err, IsNil)
+	var doc map[string]interface{}
+	c.Assert(json.Unmarshal(data, &doc), IsNil)
+	c.Assert(doc["version"], Equals, float64(JSONVersion))
+	node := doc["node"].(map[string]interface{})
+	c.Assert(node["@type"], Equals, "SelectStmt")
+	c.Assert(node["@text"], Equals, "select a from t where b = 1")
+	where := node["Where"].(map[string]interface{})
+	c.Assert(where["@type"], Equals, "BinaryOperationExpr")
+	c.Assert(where["@offset"], Equals, float64(22))
+	_, ok := where["Type"]
+	c.Assert(ok, IsFalse)
+	value := where["R"].(map[string]interface{})
+	c.Assert(value["@type"], Equals, "ValueExpr")
+	c.Assert(value["kind"], Equals, "int")
+	c.Assert(value["value"], Equals, "1")
+	col := where["L"].(map[string]interface{})["Name"].(map[string]interface{})
+	c.Assert(col["Name"], Equals, "b")
+
+	_, err = DecodeJSON([]byte(`{"version": 100, "node": null}`))
+	c.Assert(err, ErrorMatches, "unsupported AST JSON version 100.*")
+	_, err = DecodeJSON([]byte(`{"version": 1, "node": {"@type": "NoSuchStmt"}}`))
+	c.Assert(err, ErrorMatches, `.*unknown node type "NoSuchStmt"`)
+	_, err = DecodeJSON([]byte(`{"version": 1, "node": {"@type": "SelectStmt", "Where": {"@type": "TableName"}}}`))
+	c.Assert(err, ErrorMatches, `node.Where: \*ast.TableName is not ast.ExprNode`)
+	_, err = DecodeJSON([]byte(`{"version": 1, "node": {"@type": "SelectStmt", "Distinct": 1}}`))
+	c.Assert(err, ErrorMatches, `node.Distinct: can't decode 1 to bool`)
+	node2, err := DecodeJSON([]byte(`{"version": 1, "node": null}`))
+	c.Assert(err, IsNil)
+	c.Assert(node2, IsNil)
+}
+
+func (s *testJSONSuite) TestDriverValues(c *C) {
+	d := &test_driver.MyDecimal{}
+	c.Assert(d.FromString([]byte("-12.345")), IsNil)
+	values := []interface{}{nil, int64(-1), uint64(1 << 63), float32(1.5), 2.25, "s", []byte{0, 1, 2}, d}
+	for _, value := range values {
+		val := NewValueExpr(value, "", "")
+		data, err := EncodeJSON(val)
+		c.Assert(err, IsNil)
+		decoded, err := DecodeJSON(data)
+		c.Assert(err, IsNil)
+		c.Assert(Equal(val, decoded, 0), IsTrue, Commentf("json: %s", data))
+		c.Assert(reflect.TypeOf(decoded.(ValueExpr).GetValue()), Equals, reflect.TypeOf(val.GetValue()))
+	}
+
+	param := NewParamMarkerExpr(10)
+	param.SetOriginTextPosition(10)
+	param.SetOrder(2)
+	data, err := EncodeJSON(param)
+	c.Assert(err, IsNil)
+	decoded, err := DecodeJSON(data)
+	c.Assert(err, IsNil)
+	c.Assert(decoded.(*test_driver.ParamMarkerExpr).Offset, Equals, 10)
+	c.Assert(decoded.(*test_driver.ParamMarkerExpr).Order, Equals, 2)
+}
+
+// TestAllNodes checks every node type can be encoded and decoded.
+func (s *testJSONSuite) TestAllNodes(c *C) {
+	filler := newNodeFiller()
+	for _, node := range allNodes {
+		tp := reflect.TypeOf(node).Elem()
+		v := reflect.New(tp)
+		filler.fill(v.Elem(), 0)
+		n := v.Interface().(Node)
+		data, err := EncodeJSON(n)
+		c.Assert(err, IsNil, Commentf("%s", tp.Name()))
+		decoded, err := DecodeJSON(data)
+		c.Assert(err, IsNil, Commentf("%s: %s", tp.Name(), data))
+		c.Assert(decoded, FitsTypeOf, n)
+		data2, err := EncodeJSON(decoded)
+		c.Assert(err, IsNil)
+		c.Assert(string(data2), Equals, string(data), Commentf("%s", tp.Name()))
+	}
+}
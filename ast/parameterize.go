@@ -0,0 +1,217 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+import "strings"
+
+// ParameterizeFlags are the flags of Parameterize.
+type ParameterizeFlags uint64
+
+const (
+	// ParameterizeCollapseLists collapses the lists of literals, so the statements
+	// with the lists of different lengths are parameterized to the same statement.
+	// The list of an IN expression is collapsed to a single param marker, and the
+	// rows of INSERT ... VALUES are collapsed to a single row of param markers.
+	// The lists are collapsed only if all the items are literals.
+	ParameterizeCollapseLists ParameterizeFlags = 1 << iota
+)
+
+// Param is a param of a statement parameterized by Parameterize.
+type Param struct {
+	// Value is the literal replaced by the param marker. It's nil if the param
+	// marker is in the original statement, or stands for a collapsed list.
+	Value ValueExpr
+	// List is the literals of a collapsed list. For a collapsed row of INSERT ... VALUES,
+	// it's the literals of the column in all the rows.
+	List []ValueExpr
+}
+
+// Parameterize replaces the literals in node with param markers, and returns the
+// params in the order of the param markers, which are numbered by SetOrder.
+// The param markers already in node are kept, and are in the params too.
+//
+// Only the queries and DML statements in node are parameterized. The literals
+// which can't be replaced by param markers in the syntax, such as the charset of
+// CONVERT(expr USING charset) and the literal of DATE 'str', are kept.
+func Parameterize(node Node, flags ParameterizeFlags) (Node, []Param) {
+	p := parameterizer{flags: flags, pending: make(map[ParamMarkerExpr]Param)}
+	result := Apply(node, p.enter, nil)
+	return result, p.params
+}
+
+type parameterizer struct {
+	flags  ParameterizeFlags
+	params []Param
+	// pending are the param markers of the collapsed lists, which are numbered when they are visited.
+	pending map[ParamMarkerExpr]Param
+	// bindArgs keeps the literals, and replaces the param markers already in the node
+	// with the literals of args in order, it's used by BindParams.
+	bindArgs bool
+	args     []interface{}
+	bound    int
+	err      error
+	// inLimit is set while the args of LIMIT are visited.
+	inLimit bool
+}
+
+func (p *parameterizer) enter(c *Cursor) bool {
+	switch x := c.Node().(type) {
+	case ParamMarkerExpr:
+		if p.bindArgs {
+			p.bind(c)
+			return false
+		}
+		param, ok := p.pending[x]
+		if ok {
+			delete(p.pending, x)
+		}
+		p.add(x, param)
+		return false
+	case ValueExpr:
+		if p.bindArgs || literalOnly(c) {
+			return false
+		}
+		marker := NewParamMarkerExpr(x.OriginTextPosition())
+		c.Replace(marker)
+		p.add(marker, Param{Value: x})
+		return false
+	case *Limit:
+		// the offset is before the count in the SQL.
+		p.inLimit = true
+		p.inOrder(&x.Offset, &x.Count)
+		p.inLimit = false
+		return false
+	case *FuncCallExpr:
+		if x.FnName.L == Trim && len(x.Args) > 1 {
+			// the remove string is before the string in TRIM(remstr FROM str), it's nil if it's omitted.
+			if value, ok := x.Args[1].(ValueExpr); !ok || value.GetValue() != nil || isMarker(value) {
+				p.inOrder(&x.Args[1])
+			}
+			p.inOrder(&x.Args[0])
+			return false
+		}
+	case *PatternInExpr:
+		if p.flags&ParameterizeCollapseLists != 0 && len(x.List) > 0 {
+			if values, ok := literals(x.List); ok {
+				marker := NewParamMarkerExpr(values[0].OriginTextPosition())
+				p.pending[marker] = Param{List: values}
+				x.List = []ExprNode{marker}
+			}
+		}
+	case *InsertStmt:
+		if p.flags&ParameterizeCollapseLists != 0 && len(x.Lists) > 0 {
+			p.collapseRows(x)
+		}
+	case *SelectStmt, *SetOprStmt, *UpdateStmt, *DeleteStmt, *DoStmt:
+	case StmtNode:
+		// param markers are only supported by the queries and DML statements.
+		return false
+	case *TableSample:
+		return false
+	}
+	return true
+}
+
+// inOrder parameterizes the expressions in order, for the nodes whose children
+// are visited in an order different from the SQL.
+func (p *parameterizer) inOrder(exprs ...*ExprNode) {
+	for _, expr := range exprs {
+		if *expr != nil {
+			*expr = Apply(*expr, p.enter, nil).(ExprNode)
+		}
+	}
+}
+
+func (p *parameterizer) add(marker ParamMarkerExpr, param Param) {
+	marker.SetOrder(len(p.params))
+	p.params = append(p.params, param)
+}
+
+// collapseRows collapses the rows of INSERT ... VALUES to a single row, if all
+// the rows are literals of the same length.
+func (p *parameterizer) collapseRows(stmt *InsertStmt) {
+	columns := make([][]ValueExpr, len(stmt.Lists[0]))
+	for _, row := range stmt.Lists {
+		values, ok := literals(row)
+		if !ok || len(values) != len(columns) {
+			return
+		}
+		for i, value := range values {
+			columns[i] = append(columns[i], value)
+		}
+	}
+	row := make([]ExprNode, 0, len(columns))
+	for _, values := range columns {
+		marker := NewParamMarkerExpr(values[0].OriginTextPosition())
+		p.pending[marker] = Param{List: values}
+		row = append(row, marker)
+	}
+	stmt.Lists = [][]ExprNode{row}
+}
+
+// literals returns the literals in exprs, it returns false if not all of them are literals.
+func literals(exprs []ExprNode) ([]ValueExpr, bool) {
+	values := make([]ValueExpr, 0, len(exprs))
+	for _, expr := range exprs {
+		value, ok := expr.(ValueExpr)
+		if !ok || isMarker(value) {
+			return nil, false
+		}
+		values = append(values, value)
+	}
+	return values, true
+}
+
+func isMarker(value ValueExpr) bool {
+	_, ok := value.(ParamMarkerExpr)
+	return ok
+}
+
+// fspFuncs are the functions whose argument is the fractional seconds precision, which must be a literal.
+var fspFuncs = map[string]bool{
+	CurrentTimestamp: true, Now: true, Curtime: true, CurrentTime: true, Sysdate: true,
+	UTCTime: true, UTCTimestamp: true, LocalTime: true, LocalTimestamp: true,
+}
+
+// literalOnly returns whether the literal at the cursor can't be replaced by a param marker.
+func literalOnly(c *Cursor) bool {
+	if c.Parent() == nil {
+		return false
+	}
+	last := c.container.IsValid() && c.Index() == c.container.Len()-1
+	switch x := c.Parent().Node().(type) {
+	case *FuncCallExpr:
+		switch x.FnName.L {
+		case DateLiteral, TimeLiteral, TimestampLiteral, "json_quote":
+			// DATE 'str' and JSON_QUOTE(str).
+			return true
+		case WeightString, SetVal:
+			// WEIGHT_STRING(expr AS CHAR(n)) and SETVAL(seq, num).
+			return c.Index() > 0
+		case "convert":
+			// CONVERT(expr USING charset).
+			return c.Index() == 1
+		case CharFunc:
+			// CHAR(exprs USING charset).
+			return last
+		}
+		return fspFuncs[x.FnName.L]
+	case *AggregateFuncExpr:
+		// COUNT(*) and GROUP_CONCAT(exprs SEPARATOR str).
+		return strings.EqualFold(x.F, AggFuncCount) || strings.EqualFold(x.F, AggFuncGroupConcat) && last
+	case *WindowFuncExpr:
+		return strings.EqualFold(x.F, AggFuncCount)
+	}
+	return false
+}
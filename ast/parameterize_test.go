@@ -0,0 +1,182 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast_test
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	. "github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/test_driver"
+)
+
+var _ = Suite(&testParameterizeSuite{})
+
+type testParameterizeSuite struct {
+}
+
+// paramStrings formats the params, a collapsed list is formatted as [values], and an original param marker as ?.
+func paramStrings(params []Param) []string {
+	strs := make([]string, 0, len(params))
+	for _, param := range params {
+		switch {
+		case param.Value != nil:
+			strs = append(strs, fmt.Sprint(param.Value.GetValue()))
+		case param.List != nil:
+			values := make([]string, 0, len(param.List))
+			for _, value := range param.List {
+				values = append(values, fmt.Sprint(value.GetValue()))
+			}
+			strs = append(strs, "["+strings.Join(values, " ")+"]")
+		default:
+			strs = append(strs, "?")
+		}
+	}
+	return strs
+}
+
+func (s *testParameterizeSuite) TestParameterize(c *C) {
+	cases := []struct {
+		sql      string
+		flags    ParameterizeFlags
+		expected string
+		params   []string
+	}{
+		{
+			"select a, 1 from t where b = 'x' and c > -2.5 and d in (1, 2) order by a limit 10, 20",
+			0,
+			"SELECT `a`,? FROM `t` WHERE `b`=? AND `c`>-? AND `d` IN (?,?) ORDER BY `a` LIMIT ?,?",
+			[]string{"1", "x", "2.5", "1", "2", "10", "20"},
+		},
+		{
+			"select a from t where b in (1, 2, 3) and c in (4, d)",
+			ParameterizeCollapseLists,
+			"SELECT `a` FROM `t` WHERE `b` IN (?) AND `c` IN (?,`d`)",
+			[]string{"[1 2 3]", "4"},
+		},
+		{
+			"insert into t values (1, 'a'), (2, null) on duplicate key update b = 'c'",
+			ParameterizeCollapseLists,
+			"INSERT INTO `t` VALUES (?,?) ON DUPLICATE KEY UPDATE `b`=?",
+			[]string{"[1 2]", "[a <nil>]", "c"},
+		},
+		{
+			"insert into t values (1, 'a'), (2, now())",
+			ParameterizeCollapseLists,
+			"INSERT INTO `t` VALUES (?,?),(?,NOW())",
+			[]string{"1", "a", "2"},
+		},
+		{
+			"update t set a = ? where b = 1 and c = ?",
+			0,
+			"UPDATE `t` SET `a`=? WHERE `b`=? AND `c`=?",
+			[]string{"?", "1", "?"},
+		},
+		{
+			"delete from t where a = (select max(b) from s where c = 1)",
+			0,
+			"DELETE FROM `t` WHERE `a`=(SELECT MAX(`b`) FROM `s` WHERE `c`=?)",
+			[]string{"1"},
+		},
+		{
+			"select count(*), group_concat(a separator ';'), convert(b using utf8), date '2021-01-01', now(3), trim('x' from c), date_add(d, interval 1 day) from t",
+			0,
+			"SELECT COUNT(1),GROUP_CONCAT(`a` SEPARATOR ';'),CONVERT(`b` USING 'utf8'),DATE '2021-01-01',NOW(3),TRIM(? FROM `c`),DATE_ADD(`d`, INTERVAL ? DAY) FROM `t`",
+			[]string{"x", "1"},
+		},
+		{
+			"select trim(leading 'x' from 'y'), trim(leading from 'z') from t limit 3",
+			0,
+			"SELECT TRIM(LEADING ? FROM ?),TRIM(LEADING FROM ?) FROM `t` LIMIT ?",
+			[]string{"x", "y", "z", "3"},
+		},
+		{
+			"create table t (a int default 1)",
+			0,
+			"CREATE TABLE `t` (`a` INT DEFAULT 1)",
+			[]string{},
+		},
+	}
+	for _, ca := range cases {
+		stmt, err := parser.New().ParseOneStmt(ca.sql, "", "")
+		c.Assert(err, IsNil)
+		result, params := Parameterize(stmt, ca.flags)
+		comment := Commentf("sql: %s", ca.sql)
+		c.Assert(restore(c, result), Equals, ca.expected, comment)
+		c.Assert(paramStrings(params), DeepEquals, ca.params, comment)
+
+		// the param markers are numbered by the params.
+		var orders []int
+		Inspect(result, func(n Node) bool {
+			if marker, ok := n.(*test_driver.ParamMarkerExpr); ok {
+				orders = append(orders, marker.Order)
+			}
+			return true
+		})
+		sort.Ints(orders)
+		for i, order := range orders {
+			c.Assert(order, Equals, i, comment)
+		}
+		c.Assert(orders, HasLen, len(params), comment)
+	}
+}
+
+func (s *testParameterizeSuite) TestParamTypes(c *C) {
+	stmt, err := parser.New().ParseOneStmt("select 1, 1.5, 'a', x'01', null", "", "")
+	c.Assert(err, IsNil)
+	_, params := Parameterize(stmt, 0)
+	var types []byte
+	for _, param := range params {
+		types = append(types, param.Value.GetType().Tp)
+	}
+	c.Assert(types, DeepEquals, []byte{mysql.TypeLonglong, mysql.TypeNewDecimal, mysql.TypeVarString, mysql.TypeVarString, mysql.TypeNull})
+}
+
+func (s *testParameterizeSuite) TestParameterizeLargeValues(c *C) {
+	sql := insertValues(4000)
+	stmt, err := parser.New().ParseOneStmt(sql, "", "")
+	c.Assert(err, IsNil)
+	result, params := Parameterize(stmt, 0)
+	c.Assert(params, HasLen, 12000)
+	c.Assert(params[11998].Value.GetValue(), Equals, "v3999")
+	c.Assert(result.(*InsertStmt).Lists[3999][1].(*test_driver.ParamMarkerExpr).Order, Equals, 11998)
+
+	stmt, err = parser.New().ParseOneStmt(sql, "", "")
+	c.Assert(err, IsNil)
+	result, params = Parameterize(stmt, ParameterizeCollapseLists)
+	c.Assert(params, HasLen, 3)
+	c.Assert(params[1].List, HasLen, 4000)
+	c.Assert(result.(*InsertStmt).Lists, HasLen, 1)
+}
+
+func BenchmarkParameterizeLargeValues(b *testing.B) {
+	sql := insertValues(4000)
+	p := parser.New()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		stmt, err := p.ParseOneStmt(sql, "", "")
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+		Parameterize(stmt, 0)
+	}
+	b.ReportAllocs()
+}
@@ -0,0 +1,341 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Inspect traverses node in depth-first order by Accept: It starts by calling
+// f(node); if f returns true, Inspect invokes f recursively for each of the
+// children of node, followed by a call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	if node == nil {
+		return
+	}
+	node.Accept(&inspector{f: f})
+}
+
+// inspector implements Visitor for Inspect.
+type inspector struct {
+	f func(Node) bool
+	// skipped is set if the children of the entered node are skipped, Accept
+	// calls Leave for the node right after Enter in that case.
+	skipped bool
+}
+
+func (v *inspector) Enter(n Node) (Node, bool) {
+	v.skipped = !v.f(n)
+	return n, v.skipped
+}
+
+func (v *inspector) Leave(n Node) (Node, bool) {
+	if v.skipped {
+		v.skipped = false
+	} else {
+		v.f(nil)
+	}
+	return n, true
+}
+
+// Walk traverses node in depth-first order by Accept, like Inspect, but pre and
+// post are called with a Cursor describing the visited node and its parents.
+// If pre returns false, the children of the node are skipped and post is not
+// called for the node. post may be nil.
+func Walk(node Node, pre func(*Cursor) bool, post func(*Cursor)) {
+	if node == nil {
+		return
+	}
+	w := &walker{pre: pre, post: func(c *Cursor) bool {
+		if post != nil {
+			post(c)
+		}
+		return true
+	}}
+	node.Accept(w)
+}
+
+// Cursor describes a node visited by Walk or Apply.
+// The cursors of the ancestors are valid during the traversal of the node,
+// a cursor must not be used after Walk or Apply returns.
+type Cursor struct {
+	node   Node
+	parent *Cursor
+	// local is the path of the node in the parent, such as `Fields[1]` or `Definitions[0].Clause.Exprs[2]`.
+	local string
+	name  string
+	index int
+	// slot is the field or the element holding the node, container is the slice containing slot.
+	slot      reflect.Value
+	container reflect.Value
+
+	// children are the fields of the node holding its children, in the order of
+	// the fields; next is the index of the one expected to be entered next.
+	children []childSlot
+	scanned  bool
+	next     int
+
+	// the fields below are used by Apply.
+	editable    bool
+	skipped     bool
+	left        bool
+	result      Node
+	replacement Node
+	deleted     bool
+	before      []Node
+	after       []Node
+	// edited are the children to be deleted or inserted around, in the order of traversal.
+	edited []*Cursor
+}
+
+// Node returns the current node.
+func (c *Cursor) Node() Node {
+	return c.node
+}
+
+// Parent returns the cursor of the parent node, it is nil for the root.
+func (c *Cursor) Parent() *Cursor {
+	return c.parent
+}
+
+// Parents returns the ancestors of the current node, from the parent to the root.
+func (c *Cursor) Parents() []Node {
+	var parents []Node
+	for p := c.parent; p != nil; p = p.parent {
+		parents = append(parents, p.node)
+	}
+	return parents
+}
+
+// Name returns the name of the field through which the current node is reached
+// from the parent, such as `Where` of SelectStmt, it is "" for the root.
+// The field may be in a non-node struct of the parent, such as `Exprs` of
+// PartitionDefinitionClauseLessThan in PartitionOptions.
+func (c *Cursor) Name() string {
+	return c.name
+}
+
+// Index returns the index of the current node in the slice field returned by
+// Name, or -1 if the field isn't a slice.
+func (c *Cursor) Index() int {
+	return c.index
+}
+
+// Path returns the path of the current node from the root, such as
+// `Fields.Fields[1].Expr`, it is "" for the root.
+func (c *Cursor) Path() string {
+	var locals []string
+	for p := c; p.parent != nil; p = p.parent {
+		locals = append(locals, p.local)
+	}
+	var sb strings.Builder
+	for i := len(locals) - 1; i >= 0; i-- {
+		if sb.Len() > 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(locals[i])
+	}
+	return sb.String()
+}
+
+// walker implements Visitor for Walk and Apply.
+type walker struct {
+	pre      func(*Cursor) bool
+	post     func(*Cursor) bool
+	editable bool
+	cursor   *Cursor
+	// replaced is the cursor whose node is replaced by pre, its children are traversed
+	// by the Accept of the replacement.
+	replaced *Cursor
+	aborted  bool
+}
+
+func (w *walker) Enter(n Node) (Node, bool) {
+	if c := w.replaced; c != nil {
+		w.replaced = nil
+		w.cursor = c
+		return n, false
+	}
+	c := &Cursor{node: n, parent: w.cursor, index: -1, editable: w.editable}
+	if c.parent != nil {
+		c.parent.locateChild(c)
+	}
+	w.cursor = c
+	if !w.pre(c) || c.deleted {
+		c.skipped = true
+		return n, true
+	}
+	if c.replacement != nil {
+		replacement := c.replacement
+		c.node, c.replacement = replacement, nil
+		w.replaced = c
+		c.result, _ = replacement.Accept(w)
+		// the replacement has been left, the Leave of n only returns the result.
+		c.left = true
+		w.cursor = c
+		return n, true
+	}
+	return n, false
+}
+
+func (w *walker) Leave(n Node) (Node, bool) {
+	c := w.cursor
+	w.cursor = c.parent
+	if c.left {
+		return c.result, !w.aborted
+	}
+	c.left = true
+	c.node = n
+	if !c.skipped {
+		c.applyEdits()
+		if !w.post(c) {
+			w.aborted = true
+		}
+	}
+	if c.replacement != nil {
+		c.node, c.replacement = c.replacement, nil
+	}
+	if c.parent != nil && (c.deleted || len(c.before) > 0 || len(c.after) > 0) {
+		c.parent.edited = append(c.parent.edited, c)
+	}
+	return c.node, !w.aborted
+}
+
+// childSlot is a field or an element of a node which holds a child node.
+type childSlot struct {
+	ptr uintptr
+	// local is the path of the slot in the node, name and index are the field and the index in it.
+	local string
+	name  string
+	index int
+	// slot is the field or the element holding the child, container is the slice containing slot.
+	slot      reflect.Value
+	container reflect.Value
+}
+
+// locateChild finds the field holding the child, which is entered by Accept of the current node.
+// The fields are scanned once for all the children, and Accept visits the children mostly in the
+// order of the fields, so the field of a child is usually the next one of its previous sibling.
+func (c *Cursor) locateChild(child *Cursor) {
+	if !c.scanned {
+		c.scanned = true
+		if v := reflect.ValueOf(c.node); v.Kind() == reflect.Ptr {
+			s := childScanner{}
+			s.scan(v.Elem(), "", "", -1, reflect.Value{})
+			c.children = s.children
+		}
+	}
+	v := reflect.ValueOf(child.node)
+	if v.Kind() != reflect.Ptr {
+		return
+	}
+	ptr := v.Pointer()
+	for i := range c.children {
+		j := (c.next + i) % len(c.children)
+		if slot := &c.children[j]; slot.ptr == ptr {
+			child.local, child.name, child.index = slot.local, slot.name, slot.index
+			child.slot, child.container = slot.slot, slot.container
+			c.next = j + 1
+			return
+		}
+	}
+}
+
+// childScanner collects the fields of a node which hold its children.
+// The children are not scanned into, since they hold their own children.
+type childScanner struct {
+	children []childSlot
+	visited  map[uintptr]bool
+}
+
+func (s *childScanner) scan(v reflect.Value, path, name string, index int, container reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		ptr := v
+		if v.Kind() == reflect.Interface {
+			if ptr = v.Elem(); ptr.Kind() != reflect.Ptr {
+				s.scan(ptr, path, name, index, reflect.Value{})
+				return
+			}
+		}
+		if isNodeType(ptr.Type()) {
+			s.children = append(s.children, childSlot{
+				ptr: ptr.Pointer(), local: path, name: name, index: index, slot: v, container: container,
+			})
+			return
+		}
+		if s.visited == nil {
+			s.visited = make(map[uintptr]bool)
+		}
+		if s.visited[ptr.Pointer()] {
+			return
+		}
+		s.visited[ptr.Pointer()] = true
+		s.scan(ptr.Elem(), path, name, index, reflect.Value{})
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.Anonymous {
+				s.scan(v.Field(i), path, name, index, reflect.Value{})
+				continue
+			}
+			s.scan(v.Field(i), joinPath(path, field.Name), field.Name, -1, reflect.Value{})
+		}
+	case reflect.Slice, reflect.Array:
+		switch v.Type().Elem().Kind() {
+		case reflect.Ptr, reflect.Interface:
+			if v.Kind() == reflect.Slice {
+				container = v
+			} else {
+				container = reflect.Value{}
+			}
+		case reflect.Struct, reflect.Slice, reflect.Array:
+			container = reflect.Value{}
+		default:
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			s.scan(v.Index(i), path+"["+strconv.Itoa(i)+"]", name, i, container)
+		}
+	}
+}
+
+// assignableCache caches whether the types are assignable to the types of the fields holding
+// nodes, which is slow to check by reflection for the interface types.
+var assignableCache sync.Map
+
+type assignablePair struct {
+	from reflect.Type
+	to   reflect.Type
+}
+
+func assignable(from, to reflect.Type) bool {
+	pair := assignablePair{from, to}
+	if is, ok := assignableCache.Load(pair); ok {
+		return is.(bool)
+	}
+	is := from.AssignableTo(to)
+	assignableCache.Store(pair, is)
+	return is
+}
+
+func isNodeType(tp reflect.Type) bool {
+	return assignable(tp, nodeType)
+}
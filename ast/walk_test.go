@@ -0,0 +1,180 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	. "github.com/pingcap/parser/ast"
+)
+
+var _ = Suite(&testWalkSuite{})
+
+type testWalkSuite struct {
+}
+
+func (s *testWalkSuite) TestInspect(c *C) {
+	stmt, err := parser.New().ParseOneStmt("select a, b + 1 from t where c = (select max(d) from s)", "", "")
+	c.Assert(err, IsNil)
+	var columns []string
+	depth, maxDepth := 0, 0
+	Inspect(stmt, func(n Node) bool {
+		if n == nil {
+			depth--
+			return true
+		}
+		depth++
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+		if col, ok := n.(*ColumnName); ok {
+			columns = append(columns, col.Name.O)
+		}
+		// don't look into subqueries, f(nil) isn't called for the skipped nodes.
+		if _, ok := n.(*SubqueryExpr); ok {
+			depth--
+			return false
+		}
+		return true
+	})
+	c.Assert(depth, Equals, 0)
+	c.Assert(maxDepth > 3, IsTrue)
+	c.Assert(columns, DeepEquals, []string{"a", "b", "c"})
+
+	Inspect(nil, func(n Node) bool {
+		c.Fatal("unexpected call")
+		return true
+	})
+}
+
+func (s *testWalkSuite) TestWalk(c *C) {
+	stmt, err := parser.New().ParseOneStmt("select a from t join u on t.x = u.y where b = 1 group by c order by d", "", "")
+	c.Assert(err, IsNil)
+	var got []string
+	Walk(stmt, func(cur *Cursor) bool {
+		if _, ok := cur.Node().(*ColumnNameExpr); ok {
+			got = append(got, fmt.Sprintf("%s %s %d", cur.Path(), cur.Name(), cur.Index()))
+			c.Assert(cur.Parents()[len(cur.Parents())-1], Equals, stmt)
+		}
+		return true
+	}, nil)
+	c.Assert(got, DeepEquals, []string{
+		"Fields.Fields[0].Expr Expr -1",
+		"From.TableRefs.On.Expr.L L -1",
+		"From.TableRefs.On.Expr.R R -1",
+		"Where.L L -1",
+		"GroupBy.Items[0].Expr Expr -1",
+		"OrderBy.Items[0].Expr Expr -1",
+	})
+
+	// the clause of a column is found by the field names of its ancestors.
+	var clauses []string
+	Walk(stmt, func(cur *Cursor) bool {
+		if _, ok := cur.Node().(*ColumnNameExpr); !ok {
+			return true
+		}
+		for p := cur; p.Parent() != nil; p = p.Parent() {
+			if _, ok := p.Parent().Node().(*SelectStmt); ok {
+				clauses = append(clauses, p.Name())
+			}
+		}
+		return true
+	}, nil)
+	c.Assert(clauses, DeepEquals, []string{"Fields", "From", "From", "Where", "GroupBy", "OrderBy"})
+
+	// post is called after the children, but not for the skipped nodes.
+	var order []string
+	Walk(stmt, func(cur *Cursor) bool {
+		_, isWhere := cur.Node().(*BinaryOperationExpr)
+		return !isWhere || cur.Name() != "Where"
+	}, func(cur *Cursor) {
+		if cur.Parent() == nil {
+			order = append(order, "root")
+		} else if _, ok := cur.Parent().Node().(*SelectStmt); ok {
+			order = append(order, cur.Name())
+		}
+	})
+	c.Assert(order, DeepEquals, []string{"Fields", "From", "GroupBy", "OrderBy", "root"})
+}
+
+func (s *testWalkSuite) TestWalkNonNodeFields(c *C) {
+	stmt, err := parser.New().ParseOneStmt("create table t (a int, b int) partition by range columns (a, b) (partition p0 values less than (10, 20))", "", "")
+	c.Assert(err, IsNil)
+	var got []string
+	Walk(stmt, func(cur *Cursor) bool {
+		if _, ok := cur.Node().(ValueExpr); ok {
+			got = append(got, fmt.Sprintf("%s %s %d", cur.Path(), cur.Name(), cur.Index()))
+		}
+		return true
+	}, nil)
+	c.Assert(got, DeepEquals, []string{
+		"Partition.Definitions[0].Clause.Exprs[0] Exprs 0",
+		"Partition.Definitions[0].Clause.Exprs[1] Exprs 1",
+	})
+}
+
+// insertValues returns an INSERT statement with rows of VALUES.
+func insertValues(rows int) string {
+	var sb strings.Builder
+	sb.WriteString("insert into t (a, b, c) values ")
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, "(%d, 'v%d', %d.5)", i, i, i)
+	}
+	return sb.String()
+}
+
+func (s *testWalkSuite) TestWalkLargeValues(c *C) {
+	stmt, err := parser.New().ParseOneStmt(insertValues(4000), "", "")
+	c.Assert(err, IsNil)
+	var paths []string
+	Walk(stmt, func(cur *Cursor) bool {
+		if _, ok := cur.Node().(ValueExpr); ok && cur.Index() == 2 {
+			paths = append(paths, cur.Path())
+		}
+		return true
+	}, nil)
+	c.Assert(paths, HasLen, 4000)
+	c.Assert(paths[3999], Equals, "Lists[3999][2]")
+}
+
+func BenchmarkInspectLargeValues(b *testing.B) {
+	stmt, err := parser.New().ParseOneStmt(insertValues(4000), "", "")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Inspect(stmt, func(Node) bool { return true })
+	}
+	b.ReportAllocs()
+}
+
+func BenchmarkWalkLargeValues(b *testing.B) {
+	stmt, err := parser.New().ParseOneStmt(insertValues(4000), "", "")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Walk(stmt, func(*Cursor) bool { return true }, nil)
+	}
+	b.ReportAllocs()
+}
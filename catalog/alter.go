@@ -0,0 +1,542 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/charset"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/terror"
+	"github.com/pingcap/parser/types"
+)
+
+var (
+	ErrCantDropFieldOrKey            = terror.ClassDDL.NewStd(mysql.ErrCantDropFieldOrKey)
+	ErrCantRemoveAllFields           = terror.ClassDDL.NewStd(mysql.ErrCantRemoveAllFields)
+	ErrCoalesceOnlyOnHashPartition   = terror.ClassDDL.NewStd(mysql.ErrCoalesceOnlyOnHashPartition)
+	ErrCoalescePartitionNoPartition  = terror.ClassDDL.NewStd(mysql.ErrCoalescePartitionNoPartition)
+	ErrDependentByGeneratedColumn    = terror.ClassDDL.NewStd(mysql.ErrDependentByGeneratedColumn)
+	ErrDropLastPartition             = terror.ClassDDL.NewStd(mysql.ErrDropLastPartition)
+	ErrDropPartitionNonExistent      = terror.ClassDDL.NewStd(mysql.ErrDropPartitionNonExistent)
+	ErrFkColumnCannotDrop            = terror.ClassDDL.NewStd(mysql.ErrFkColumnCannotDrop)
+	ErrKeyDoesNotExist               = terror.ClassDDL.NewStd(mysql.ErrKeyDoesNotExist)
+	ErrOnlyOnRangeListPartition      = terror.ClassDDL.NewStd(mysql.ErrOnlyOnRangeListPartition)
+	ErrPartitionMgmtOnNonpartitioned = terror.ClassDDL.NewStd(mysql.ErrPartitionMgmtOnNonpartitioned)
+	ErrUnknownPartition              = terror.ClassDDL.NewStd(mysql.ErrUnknownPartition)
+	// The errors of the check constraints of MySQL 8.0, which aren't in the mysql package.
+	ErrCheckConstraintNotFound    = terror.ClassDDL.NewStdErr(3821, mysql.Message("Check constraint '%-.192s' is not found in the table.", nil))
+	ErrDependentByCheckConstraint = terror.ClassDDL.NewStdErr(3959, mysql.Message("Check constraint '%-.192s' uses column '%-.192s', hence column cannot be dropped or renamed.", nil))
+)
+
+// alterTable returns a copy of the table altered by the specs, whose database is db.
+// The table isn't renamed, and the specs which don't change the schema are ignored.
+func alterTable(tbl *model.TableInfo, specs []*ast.AlterTableSpec, db *model.DBInfo) (*model.TableInfo, error) {
+	b := &tableBuilder{table: cloneTable(tbl)}
+	for _, spec := range specs {
+		if err := b.alter(spec, db); err != nil {
+			return nil, err
+		}
+	}
+	if err := b.finish(); err != nil {
+		return nil, err
+	}
+	return b.table, nil
+}
+
+func (b *tableBuilder) alter(spec *ast.AlterTableSpec, db *model.DBInfo) error {
+	switch spec.Tp {
+	case ast.AlterTableOption:
+		return b.alterOptions(spec.Options, db)
+	case ast.AlterTableAddColumns:
+		return b.addColumns(spec)
+	case ast.AlterTableDropColumn:
+		return b.dropColumn(spec.OldColumnName.Name, spec.IfExists)
+	case ast.AlterTableModifyColumn, ast.AlterTableChangeColumn:
+		return b.changeColumn(spec)
+	case ast.AlterTableRenameColumn:
+		return b.renameColumn(spec.OldColumnName.Name, spec.NewColumnName.Name)
+	case ast.AlterTableAlterColumn:
+		return b.alterDefault(spec.NewColumns[0])
+	case ast.AlterTableAddConstraint:
+		return b.buildConstraints([]*ast.Constraint{spec.Constraint})
+	case ast.AlterTableDropPrimaryKey:
+		return b.dropPrimaryKey()
+	case ast.AlterTableDropIndex:
+		return b.dropIndex(spec.Name, spec.IfExists)
+	case ast.AlterTableDropForeignKey:
+		return b.dropForeignKey(spec.Name, spec.IfExists)
+	case ast.AlterTableRenameIndex:
+		return b.renameIndex(spec.FromKey, spec.ToKey)
+	case ast.AlterTableIndexInvisible:
+		idx := b.index(spec.IndexName.L)
+		if idx == nil {
+			return ErrKeyDoesNotExist.GenWithStackByArgs(spec.IndexName.O, b.table.Name.O)
+		}
+		idx.Invisible = spec.Visibility == ast.IndexVisibilityInvisible
+	case ast.AlterTableDropCheck, ast.AlterTableAlterCheck:
+		return b.alterCheck(spec)
+	case ast.AlterTablePartition:
+		return b.buildPartition(spec.Partition)
+	case ast.AlterTableRemovePartitioning, ast.AlterTableAddPartitions, ast.AlterTableDropPartition,
+		ast.AlterTableCoalescePartitions, ast.AlterTableTruncatePartition:
+		return b.alterPartitions(spec)
+	}
+	return nil
+}
+
+// alterOptions applies the table options, and CONVERT TO changes the charset of the
+// string columns.
+func (b *tableBuilder) alterOptions(options []*ast.TableOption, db *model.DBInfo) error {
+	tbl := b.table
+	cs, co := b.applyOptions(options)
+	convert := false
+	for _, opt := range options {
+		if opt.Tp != ast.TableOptionCharset {
+			continue
+		}
+		convert = opt.UintValue == ast.TableOptionCharsetWithConvertTo
+		if opt.Default {
+			cs, co = db.Charset, db.Collate
+		}
+	}
+	if cs == "" && co == "" {
+		return nil
+	}
+	var err error
+	if cs, co, err = resolveCharset(cs, co, "", ""); err != nil {
+		return err
+	}
+	tbl.Charset, tbl.Collate = cs, co
+	if !convert {
+		return nil
+	}
+	for _, col := range tbl.Columns {
+		if hasCharset(col.Tp) && col.Charset != charset.CharsetBin {
+			col.Charset, col.Collate = cs, co
+		}
+	}
+	return nil
+}
+
+// removeColumn removes the column from the columns of the table, and returns its
+// position.
+func (b *tableBuilder) removeColumn(col *model.ColumnInfo) int {
+	cols := b.table.Columns
+	for i, c := range cols {
+		if c == col {
+			b.table.Columns = append(cols[:i:i], cols[i+1:]...)
+			return i
+		}
+	}
+	return -1
+}
+
+// insertColumn inserts the column at the position, which is the end if pos is nil
+// or none, and updates the offsets.
+func (b *tableBuilder) insertColumn(col *model.ColumnInfo, i int, pos *ast.ColumnPosition) error {
+	tbl := b.table
+	if pos != nil {
+		switch pos.Tp {
+		case ast.ColumnPositionFirst:
+			i = 0
+		case ast.ColumnPositionAfter:
+			after := b.column(pos.RelativeColumn.Name.L)
+			if after == nil {
+				return ErrBadField.GenWithStackByArgs(pos.RelativeColumn.Name.O, tbl.Name.O)
+			}
+			for j, c := range tbl.Columns {
+				if c == after {
+					i = j + 1
+				}
+			}
+		}
+	}
+	cols := make([]*model.ColumnInfo, 0, len(tbl.Columns)+1)
+	cols = append(cols, tbl.Columns[:i]...)
+	cols = append(cols, col)
+	tbl.Columns = append(cols, tbl.Columns[i:]...)
+	b.updateOffsets()
+	return nil
+}
+
+// checkDependences checks the generated columns of the table depend on the columns.
+func (b *tableBuilder) checkDependences() error {
+	for _, col := range b.table.Columns {
+		for name := range col.Dependences {
+			if b.column(name) == nil {
+				return ErrBadField.GenWithStackByArgs(name, "generated column function")
+			}
+		}
+	}
+	return nil
+}
+
+func (b *tableBuilder) addColumns(spec *ast.AlterTableSpec) error {
+	var constraints []*ast.Constraint
+	for _, def := range spec.NewColumns {
+		if spec.IfNotExists && b.column(def.Name.Name.L) != nil {
+			continue
+		}
+		cons, err := b.buildColumn(def)
+		if err != nil {
+			return err
+		}
+		col := b.table.Columns[len(b.table.Columns)-1]
+		if err := b.insertColumn(col, b.removeColumn(col), spec.Position); err != nil {
+			return err
+		}
+		constraints = append(constraints, cons...)
+	}
+	if err := b.checkDependences(); err != nil {
+		return err
+	}
+	return b.buildConstraints(append(constraints, spec.NewConstraints...))
+}
+
+// checkDropOrRename checks the column isn't referred by the generated columns and the
+// check constraints, except the check constraints of the column only if it's dropped.
+func (b *tableBuilder) checkDropOrRename(col *model.ColumnInfo, drop bool) error {
+	tbl := b.table
+	for _, c := range tbl.Columns {
+		if _, ok := c.Dependences[col.Name.L]; ok && c != col {
+			return ErrDependentByGeneratedColumn.GenWithStackByArgs(col.Name.O)
+		}
+	}
+	for _, cons := range tbl.Constraints {
+		for _, name := range cons.ConstraintCols {
+			if name.L == col.Name.L && (!drop || len(cons.ConstraintCols) > 1) {
+				return ErrDependentByCheckConstraint.GenWithStackByArgs(cons.Name.O, col.Name.O)
+			}
+		}
+	}
+	return nil
+}
+
+// dropColumn drops the column, and removes it from the indexes, which are dropped if
+// they have no columns.
+func (b *tableBuilder) dropColumn(name model.CIStr, ifExists bool) error {
+	tbl := b.table
+	col := b.column(name.L)
+	if col == nil {
+		if ifExists {
+			return nil
+		}
+		return ErrCantDropFieldOrKey.GenWithStackByArgs(name.O)
+	}
+	if len(tbl.Columns) == 1 {
+		return ErrCantRemoveAllFields.GenWithStackByArgs()
+	}
+	if err := b.checkDropOrRename(col, true); err != nil {
+		return err
+	}
+	for _, fk := range tbl.ForeignKeys {
+		for _, c := range fk.Cols {
+			if c.L == col.Name.L {
+				return ErrFkColumnCannotDrop.GenWithStackByArgs(col.Name.O, fk.Name.O)
+			}
+		}
+	}
+	constraints := tbl.Constraints[:0]
+	for _, cons := range tbl.Constraints {
+		if len(cons.ConstraintCols) != 1 || cons.ConstraintCols[0].L != col.Name.L {
+			constraints = append(constraints, cons)
+		}
+	}
+	tbl.Constraints = constraints
+	indices := tbl.Indices[:0]
+	for _, idx := range tbl.Indices {
+		ics := idx.Columns[:0]
+		for _, ic := range idx.Columns {
+			if ic.Name.L != col.Name.L {
+				ics = append(ics, ic)
+			}
+		}
+		if idx.Columns = ics; len(ics) > 0 {
+			indices = append(indices, idx)
+		}
+	}
+	tbl.Indices = indices
+	if tbl.PKIsHandle && mysql.HasPriKeyFlag(col.Flag) {
+		tbl.PKIsHandle = false
+	}
+	b.removeColumn(col)
+	b.updateOffsets()
+	return nil
+}
+
+// renameReferences renames the column of the indexes and the foreign keys.
+func (b *tableBuilder) renameReferences(from, to model.CIStr) {
+	for _, idx := range b.table.Indices {
+		for _, ic := range idx.Columns {
+			if ic.Name.L == from.L {
+				ic.Name = to
+			}
+		}
+	}
+	for _, fk := range b.table.ForeignKeys {
+		for i, c := range fk.Cols {
+			if c.L == from.L {
+				fk.Cols[i] = to
+			}
+		}
+	}
+}
+
+// changeColumn redefines the column of MODIFY or CHANGE, which keeps its ID and its
+// position unless the position is specified.
+func (b *tableBuilder) changeColumn(spec *ast.AlterTableSpec) error {
+	tbl := b.table
+	def := spec.NewColumns[0]
+	name := def.Name.Name
+	if spec.Tp == ast.AlterTableChangeColumn {
+		name = spec.OldColumnName.Name
+	}
+	old := b.column(name.L)
+	if old == nil {
+		return ErrBadField.GenWithStackByArgs(name.O, tbl.Name.O)
+	}
+	renamed := old.Name.L != def.Name.Name.L
+	if renamed {
+		if err := b.checkDropOrRename(old, false); err != nil {
+			return err
+		}
+	}
+	i := b.removeColumn(old)
+	maxID := tbl.MaxColumnID
+	constraints, err := b.buildColumn(def)
+	if err != nil {
+		return err
+	}
+	col := tbl.Columns[len(tbl.Columns)-1]
+	col.ID, tbl.MaxColumnID = old.ID, maxID
+	b.removeColumn(col)
+	b.renameReferences(old.Name, col.Name)
+	if err := b.insertColumn(col, i, spec.Position); err != nil {
+		return err
+	}
+	if err := b.checkDependences(); err != nil {
+		return err
+	}
+	if tbl.PKIsHandle && mysql.HasPriKeyFlag(old.Flag) {
+		col.Flag |= mysql.PriKeyFlag | mysql.NotNullFlag
+		if !mysql.IsIntegerType(col.Tp) {
+			// the primary key of the column isn't the handle any more.
+			tbl.PKIsHandle = false
+			key := []*ast.IndexPartSpecification{{Column: &ast.ColumnName{Name: col.Name}, Length: types.UnspecifiedLength}}
+			if _, err := b.buildIndex(mysql.PrimaryKeyName, &ast.Constraint{Tp: ast.ConstraintPrimaryKey, Keys: key}); err != nil {
+				return err
+			}
+		}
+	}
+	return b.buildConstraints(constraints)
+}
+
+func (b *tableBuilder) renameColumn(from, to model.CIStr) error {
+	col := b.column(from.L)
+	if col == nil {
+		return ErrBadField.GenWithStackByArgs(from.O, b.table.Name.O)
+	}
+	if from.L == to.L {
+		col.Name = to
+		b.renameReferences(from, to)
+		return nil
+	}
+	if b.column(to.L) != nil {
+		return ErrDupFieldName.GenWithStackByArgs(to.O)
+	}
+	if err := b.checkDropOrRename(col, false); err != nil {
+		return err
+	}
+	b.renameReferences(col.Name, to)
+	col.Name = to
+	return nil
+}
+
+// alterDefault sets the default value of the column, or drops it if no value is
+// specified.
+func (b *tableBuilder) alterDefault(def *ast.ColumnDef) error {
+	col := b.column(def.Name.Name.L)
+	if col == nil {
+		return ErrBadField.GenWithStackByArgs(def.Name.Name.O, b.table.Name.O)
+	}
+	if len(def.Options) == 0 {
+		col.DefaultValue, col.DefaultIsExpr, col.DefaultValueBit = nil, false, nil
+		col.Flag |= mysql.NoDefaultValueFlag
+		return nil
+	}
+	col.Flag &^= mysql.NoDefaultValueFlag
+	return setDefaultValue(col, def.Options[0].Expr)
+}
+
+func (b *tableBuilder) dropPrimaryKey() error {
+	tbl := b.table
+	if tbl.PKIsHandle {
+		tbl.PKIsHandle = false
+		for _, col := range tbl.Columns {
+			col.Flag &^= mysql.PriKeyFlag
+		}
+		return nil
+	}
+	for i, idx := range tbl.Indices {
+		if idx.Primary {
+			tbl.Indices = append(tbl.Indices[:i:i], tbl.Indices[i+1:]...)
+			tbl.IsCommonHandle = false
+			return nil
+		}
+	}
+	return ErrCantDropFieldOrKey.GenWithStackByArgs(mysql.PrimaryKeyName)
+}
+
+func (b *tableBuilder) dropIndex(name string, ifExists bool) error {
+	tbl := b.table
+	if strings.EqualFold(name, mysql.PrimaryKeyName) {
+		return b.dropPrimaryKey()
+	}
+	for i, idx := range tbl.Indices {
+		if idx.Name.L == strings.ToLower(name) {
+			tbl.Indices = append(tbl.Indices[:i:i], tbl.Indices[i+1:]...)
+			return nil
+		}
+	}
+	if ifExists {
+		return nil
+	}
+	return ErrCantDropFieldOrKey.GenWithStackByArgs(name)
+}
+
+// dropForeignKey drops the foreign key, whose index isn't dropped like MySQL.
+func (b *tableBuilder) dropForeignKey(name string, ifExists bool) error {
+	tbl := b.table
+	for i, fk := range tbl.ForeignKeys {
+		if fk.Name.L == strings.ToLower(name) {
+			tbl.ForeignKeys = append(tbl.ForeignKeys[:i:i], tbl.ForeignKeys[i+1:]...)
+			return nil
+		}
+	}
+	if ifExists {
+		return nil
+	}
+	return ErrCantDropFieldOrKey.GenWithStackByArgs(name)
+}
+
+func (b *tableBuilder) renameIndex(from, to model.CIStr) error {
+	idx := b.index(from.L)
+	if idx == nil {
+		return ErrKeyDoesNotExist.GenWithStackByArgs(from.O, b.table.Name.O)
+	}
+	if idx.Primary || to.L == strings.ToLower(mysql.PrimaryKeyName) {
+		return ErrWrongNameForIndex.GenWithStackByArgs(to.O)
+	}
+	if from.L != to.L && b.index(to.L) != nil {
+		return ErrDupKeyName.GenWithStackByArgs(to.O)
+	}
+	idx.Name = to
+	return nil
+}
+
+// alterCheck drops or alters the enforcement of a check constraint.
+func (b *tableBuilder) alterCheck(spec *ast.AlterTableSpec) error {
+	tbl := b.table
+	for i, cons := range tbl.Constraints {
+		if cons.Name.L != strings.ToLower(spec.Constraint.Name) {
+			continue
+		}
+		if spec.Tp == ast.AlterTableDropCheck {
+			tbl.Constraints = append(tbl.Constraints[:i:i], tbl.Constraints[i+1:]...)
+		} else {
+			cons.Enforced = spec.Constraint.Enforced
+		}
+		return nil
+	}
+	return ErrCheckConstraintNotFound.GenWithStackByArgs(spec.Constraint.Name)
+}
+
+// alterPartitions changes the partitions of the table.
+func (b *tableBuilder) alterPartitions(spec *ast.AlterTableSpec) error {
+	tbl := b.table
+	pi := tbl.Partition
+	if pi == nil {
+		return ErrPartitionMgmtOnNonpartitioned.GenWithStackByArgs()
+	}
+	hash := pi.Type == model.PartitionTypeHash || pi.Type == model.PartitionTypeKey
+	switch spec.Tp {
+	case ast.AlterTableRemovePartitioning:
+		tbl.Partition = nil
+		return nil
+	case ast.AlterTableAddPartitions:
+		var nextID int64
+		for _, pd := range pi.Definitions {
+			if pd.ID > nextID {
+				nextID = pd.ID
+			}
+		}
+		defs := spec.PartDefinitions
+		for i := uint64(0); len(defs) == 0 && i < spec.Num; i++ {
+			nextID++
+			name := model.NewCIStr(fmt.Sprintf("p%d", len(pi.Definitions)))
+			pi.Definitions = append(pi.Definitions, model.PartitionDefinition{ID: nextID, Name: name})
+		}
+		for _, def := range defs {
+			if tbl.FindPartitionDefinitionByName(def.Name.L) != nil {
+				return ErrSameNamePartition.GenWithStackByArgs(def.Name.O)
+			}
+			nextID++
+			pi.Definitions = append(pi.Definitions, buildPartitionDefinition(def, nextID))
+		}
+	case ast.AlterTableDropPartition:
+		if hash {
+			return ErrOnlyOnRangeListPartition.GenWithStackByArgs("DROP")
+		}
+		dropped := make(map[string]bool)
+		for _, name := range spec.PartitionNames {
+			if tbl.FindPartitionDefinitionByName(name.L) == nil {
+				return ErrDropPartitionNonExistent.GenWithStackByArgs("DROP")
+			}
+			dropped[name.L] = true
+		}
+		if len(dropped) == len(pi.Definitions) {
+			return ErrDropLastPartition.GenWithStackByArgs()
+		}
+		defs := pi.Definitions[:0]
+		for _, pd := range pi.Definitions {
+			if !dropped[pd.Name.L] {
+				defs = append(defs, pd)
+			}
+		}
+		pi.Definitions = defs
+	case ast.AlterTableCoalescePartitions:
+		if !hash {
+			return ErrCoalesceOnlyOnHashPartition.GenWithStackByArgs()
+		}
+		if spec.Num == 0 {
+			return ErrCoalescePartitionNoPartition.GenWithStackByArgs()
+		}
+		if spec.Num >= uint64(len(pi.Definitions)) {
+			return ErrDropLastPartition.GenWithStackByArgs()
+		}
+		pi.Definitions = pi.Definitions[:len(pi.Definitions)-int(spec.Num)]
+	case ast.AlterTableTruncatePartition:
+		for _, name := range spec.PartitionNames {
+			if tbl.FindPartitionDefinitionByName(name.L) == nil {
+				return ErrUnknownPartition.GenWithStackByArgs(name.O, tbl.Name.O)
+			}
+		}
+	}
+	pi.Num = uint64(len(pi.Definitions))
+	return nil
+}
@@ -0,0 +1,228 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog_test
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/mysql"
+)
+
+var _ = Suite(&testAlterSuite{})
+
+type testAlterSuite struct {
+}
+
+const alterSchema = `create table t (
+	id int primary key,
+	a int not null,
+	b varchar(10),
+	c int as (a + 1),
+	key ab (a, b),
+	unique key (b),
+	constraint ck check (a > 0)
+)`
+
+func (s *testAlterSuite) TestAlterColumns(c *C) {
+	cases := []struct {
+		sql      string
+		expected string
+	}{
+		{"alter table t add column d int", "id a b c d; PRIMARY(id) ab(a,b) b(b) CHECK ck"},
+		{"alter table t add column d int first, add e int after a", "d id a e b c; PRIMARY(id) ab(a,b) b(b) CHECK ck"},
+		{"alter table t add column (d int, e int unique)", "id a b c d e; PRIMARY(id) ab(a,b) b(b) e(e) CHECK ck"},
+		{"alter table t add column if not exists a int", "id a b c; PRIMARY(id) ab(a,b) b(b) CHECK ck"},
+		{"alter table t drop column b", "id a c; PRIMARY(id) ab(a) CHECK ck"},
+		{"alter table t drop column id", "a b c; ab(a,b) b(b) CHECK ck"},
+		{"alter table t drop column if exists x", "id a b c; PRIMARY(id) ab(a,b) b(b) CHECK ck"},
+		{"alter table t modify b varchar(20) first", "b id a c; PRIMARY(id) ab(a,b) b(b) CHECK ck"},
+		{"alter table t change b d varchar(20)", "id a d c; PRIMARY(id) ab(a,d) b(d) CHECK ck"},
+		{"alter table t rename column b to B", "id a B c; PRIMARY(id) ab(a,B) b(B) CHECK ck"},
+		{"alter table t modify id bigint", "id a b c; PRIMARY(id) ab(a,b) b(b) CHECK ck"},
+		{"alter table t modify id varchar(10)", "id a b c; ab(a,b) b(b) PRIMARY(id) CHECK ck"},
+	}
+	for _, ca := range cases {
+		cat := newCatalog(c, alterSchema)
+		old := cat.Table("test", "t")
+		c.Assert(exec(c, cat, ca.sql), IsNil, Commentf("sql: %s", ca.sql))
+		c.Assert(describe(cat.Table("test", "t")), Equals, ca.expected, Commentf("sql: %s", ca.sql))
+		c.Assert(describe(old), Equals, "id a b c; PRIMARY(id) ab(a,b) b(b) CHECK ck", Commentf("sql: %s", ca.sql))
+	}
+
+	cat := newCatalog(c, alterSchema+"; alter table t modify b varchar(20) not null, add column d int, change id id2 int")
+	tbl := cat.Table("test", "t")
+	c.Assert(tbl.Columns[1].ID, Equals, int64(2))
+	c.Assert(tbl.Columns[4].ID, Equals, int64(5))
+	c.Assert(tbl.MaxColumnID, Equals, int64(5))
+	c.Assert(tbl.Columns[2].Flen, Equals, 20)
+	c.Assert(mysql.HasNoDefaultValueFlag(tbl.Columns[2].Flag), IsTrue)
+	c.Assert(mysql.HasUniKeyFlag(tbl.Columns[2].Flag), IsTrue)
+	c.Assert(mysql.HasPriKeyFlag(tbl.Columns[0].Flag), IsTrue)
+
+	c.Assert(exec(c, cat, "alter table t alter column d set default 3"), IsNil)
+	c.Assert(cat.Table("test", "t").Columns[4].DefaultValue, Equals, "3")
+	c.Assert(exec(c, cat, "alter table t alter column d drop default"), IsNil)
+	d := cat.Table("test", "t").Columns[4]
+	c.Assert(d.DefaultValue, IsNil)
+	c.Assert(mysql.HasNoDefaultValueFlag(d.Flag), IsTrue)
+
+	c.Assert(exec(c, cat, "alter table t add column e bit(4) default b'11'"), IsNil)
+	c.Assert(cat.Table("test", "t").Columns[5].GetDefaultValue(), Equals, "\x03")
+	c.Assert(exec(c, cat, "alter table t alter column e drop default"), IsNil)
+	c.Assert(cat.Table("test", "t").Columns[5].GetDefaultValue(), IsNil)
+}
+
+func (s *testAlterSuite) TestAlterKeys(c *C) {
+	cases := []struct {
+		sql      string
+		expected string
+	}{
+		{"alter table t add index (a)", "PRIMARY(id) ab(a,b) b(b) a(a) CHECK ck"},
+		{"alter table t add unique key u (a, c), add fulltext (b)", "PRIMARY(id) ab(a,b) b(b) u(a,c) b_2(b) CHECK ck"},
+		{"create index i on t (c)", "PRIMARY(id) ab(a,b) b(b) i(c) CHECK ck"},
+		{"create index if not exists ab on t (c)", "PRIMARY(id) ab(a,b) b(b) CHECK ck"},
+		{"drop index ab on t", "PRIMARY(id) b(b) CHECK ck"},
+		{"alter table t drop primary key", "ab(a,b) b(b) CHECK ck"},
+		{"alter table t drop primary key, add primary key (id, a)", "ab(a,b) b(b) PRIMARY(id,a) CHECK ck"},
+		{"alter table t drop index `primary`", "ab(a,b) b(b) CHECK ck"},
+		{"alter table t rename index ab to ba", "PRIMARY(id) ba(a,b) b(b) CHECK ck"},
+		{"alter table t add constraint fk foreign key (c) references p (id)", "PRIMARY(id) ab(a,b) b(b) fk(c) FK fk CHECK ck"},
+		{"alter table t add foreign key (a) references p (id)", "PRIMARY(id) ab(a,b) b(b) FK t_ibfk_1 CHECK ck"},
+		{"alter table t add check (b <> '')", "PRIMARY(id) ab(a,b) b(b) CHECK ck CHECK t_chk_2"},
+		{"alter table t drop check ck", "PRIMARY(id) ab(a,b) b(b)"},
+	}
+	for _, ca := range cases {
+		cat := newCatalog(c, "create table t (id int primary key, a int, b varchar(10), c int, key ab (a, b), unique key (b), constraint ck check (a > 0))")
+		c.Assert(exec(c, cat, ca.sql), IsNil, Commentf("sql: %s", ca.sql))
+		c.Assert(describe(cat.Table("test", "t")), Equals, "id a b c; "+ca.expected, Commentf("sql: %s", ca.sql))
+	}
+
+	cat := newCatalog(c, "create table t (id int primary key, a int, b varchar(10), key ab (a, b), unique key (b), constraint ck check (a > 0)); alter table t drop column a")
+	c.Assert(describe(cat.Table("test", "t")), Equals, "id b; PRIMARY(id) ab(b) b(b)")
+	c.Assert(exec(c, cat, "alter table t drop column id, drop column b"), ErrorMatches, ".*You can't delete all columns with ALTER TABLE; use DROP TABLE instead")
+
+	cat = newCatalog(c, alterSchema+"; alter table t alter index ab invisible, alter check ck not enforced")
+	tbl := cat.Table("test", "t")
+	c.Assert(tbl.Indices[0].Invisible, IsTrue)
+	c.Assert(tbl.Constraints[0].Enforced, IsFalse)
+	c.Assert(mysql.HasMultipleKeyFlag(tbl.Columns[1].Flag), IsTrue)
+	c.Assert(exec(c, cat, "alter table t drop index ab"), IsNil)
+	c.Assert(mysql.HasMultipleKeyFlag(cat.Table("test", "t").Columns[1].Flag), IsFalse)
+}
+
+func (s *testAlterSuite) TestAlterTable(c *C) {
+	cat := newCatalog(c, `
+		create database d;
+		create table t (id int, a varchar(10) charset latin1, b blob) partition by range (id) (partition p0 values less than (10), partition p1 values less than (20));
+		alter table t comment 'c', engine = MyISAM, auto_increment = 5, default charset utf8`)
+	tbl := cat.Table("test", "t")
+	c.Assert(tbl.Comment+" "+tbl.Engine, Equals, "c MyISAM")
+	c.Assert(tbl.AutoIncID, Equals, int64(5))
+	c.Assert(tbl.Charset+" "+tbl.Collate, Equals, "utf8 utf8_general_ci")
+	c.Assert(tbl.Columns[1].Charset, Equals, "latin1")
+	c.Assert(exec(c, cat, "alter table t convert to character set utf8mb4 collate utf8mb4_general_ci"), IsNil)
+	tbl = cat.Table("test", "t")
+	c.Assert(tbl.Columns[1].Charset+" "+tbl.Columns[1].Collate, Equals, "utf8mb4 utf8mb4_general_ci")
+	c.Assert(tbl.Columns[2].Charset, Equals, "binary")
+
+	partitions := func() (names []string) {
+		for _, pd := range cat.Table("test", "t").Partition.Definitions {
+			names = append(names, pd.Name.O)
+		}
+		return names
+	}
+	c.Assert(exec(c, cat, "alter table t add partition (partition p2 values less than (30))"), IsNil)
+	c.Assert(partitions(), DeepEquals, []string{"p0", "p1", "p2"})
+	c.Assert(cat.Table("test", "t").Partition.Definitions[2].ID, Equals, int64(3))
+	c.Assert(exec(c, cat, "alter table t drop partition p0, p2"), IsNil)
+	c.Assert(partitions(), DeepEquals, []string{"p1"})
+	c.Assert(cat.Table("test", "t").Partition.Num, Equals, uint64(1))
+	c.Assert(exec(c, cat, "alter table t partition by hash (id) partitions 4; alter table t coalesce partition 1"), IsNil)
+	c.Assert(partitions(), DeepEquals, []string{"p0", "p1", "p2"})
+	c.Assert(exec(c, cat, "alter table t add partition partitions 2"), IsNil)
+	c.Assert(partitions(), DeepEquals, []string{"p0", "p1", "p2", "p3", "p4"})
+	c.Assert(exec(c, cat, "alter table t remove partitioning"), IsNil)
+	c.Assert(cat.Table("test", "t").Partition, IsNil)
+
+	c.Assert(exec(c, cat, "alter table t rename to d.t2, add column c int"), IsNil)
+	c.Assert(cat.Table("test", "t"), IsNil)
+	c.Assert(describe(cat.Table("d", "t2")), Equals, "id a b c;")
+	c.Assert(exec(c, cat, "create table test.t (a int); alter table d.t2 rename to test.t"), ErrorMatches, ".*Table 't' already exists")
+}
+
+func (s *testAlterSuite) TestAlterErrors(c *C) {
+	cases := []struct {
+		sql string
+		err string
+	}{
+		{"alter table nope add column x int", ".*Table 'test.nope' doesn't exist"},
+		{"alter table t add column a int", ".*Duplicate column name 'a'"},
+		{"alter table t add column x int after nope", ".*Unknown column 'nope' in 't'"},
+		{"alter table t add column x int as (nope + 1)", ".*Unknown column 'nope' in 'generated column function'"},
+		{"alter table t drop column x", ".*Can't DROP 'x'; check that column/key exists"},
+		{"alter table t drop column a", ".*Column 'a' has a generated column dependency."},
+		{"alter table t drop column e", ".*Cannot drop column 'e': needed in a foreign key constraint 'fk'"},
+		{"alter table t drop column b", ".*Check constraint 'ck' uses column 'b', hence column cannot be dropped or renamed."},
+		{"alter table t modify x int", ".*Unknown column 'x' in 't'"},
+		{"alter table t change e a int", ".*Duplicate column name 'a'"},
+		{"alter table t rename column d to b", ".*Duplicate column name 'b'"},
+		{"alter table t rename column b to x", ".*Check constraint 'ck' uses column 'b', hence column cannot be dropped or renamed."},
+		{"alter table t modify id int primary key", ".*Multiple primary key defined"},
+		{"alter table t add column x int auto_increment unique", ".*Incorrect table definition; there can be only one auto column and it must be defined as a key"},
+		{"alter table t alter column x set default 1", ".*Unknown column 'x' in 't'"},
+		{"alter table t add index i (x)", ".*Key column 'x' doesn't exist in table"},
+		{"alter table t add index i (a), add index i (b)", ".*Duplicate key name 'i'"},
+		{"alter table t add primary key (a)", ".*Multiple primary key defined"},
+		{"alter table t drop primary key", ".*Incorrect table definition; there can be only one auto column and it must be defined as a key"},
+		{"alter table t drop index x", ".*Can't DROP 'x'; check that column/key exists"},
+		{"alter table t drop foreign key x", ".*Can't DROP 'x'; check that column/key exists"},
+		{"alter table t rename index x to y", ".*Key 'x' doesn't exist in table 't'"},
+		{"alter table t rename index ab to e", ".*Duplicate key name 'e'"},
+		{"alter table t alter index x invisible", ".*Key 'x' doesn't exist in table 't'"},
+		{"alter table t drop check x", ".*Check constraint 'x' is not found in the table."},
+		{"alter table t add constraint ck check (a > 1)", ".*Duplicate check constraint name 'ck'."},
+		{"alter table t drop partition p0", ".*Partition management on a not partitioned table is not possible"},
+		{"drop index x on t", ".*Can't DROP 'x'; check that column/key exists"},
+	}
+	for _, ca := range cases {
+		cat := newCatalog(c, `create table t (
+			id int auto_increment primary key,
+			a int, b int, c int as (a + 1), d int, e int,
+			key ab (a, b), key (e),
+			constraint fk foreign key (e) references p (id),
+			constraint ck check (b > d))`)
+		old := describe(cat.Table("test", "t"))
+		c.Assert(exec(c, cat, ca.sql), ErrorMatches, ca.err, Commentf("sql: %s", ca.sql))
+		c.Assert(describe(cat.Table("test", "t")), Equals, old, Commentf("sql: %s", ca.sql))
+	}
+
+	cat := newCatalog(c, "create table t (a int) partition by range (a) (partition p0 values less than (10), partition p1 values less than (20))")
+	cases = []struct {
+		sql string
+		err string
+	}{
+		{"alter table t add partition (partition p0 values less than (30))", ".*Duplicate partition name p0"},
+		{"alter table t drop partition p2", ".*Error in list of partitions to DROP"},
+		{"alter table t drop partition p0, p1", ".*Cannot remove all partitions, use DROP TABLE instead"},
+		{"alter table t coalesce partition 1", ".*COALESCE PARTITION can only be used on HASH/KEY partitions"},
+		{"alter table t truncate partition p2", ".*Unknown partition 'p2' in table 't'"},
+	}
+	for _, ca := range cases {
+		c.Assert(exec(c, cat, ca.sql), ErrorMatches, ca.err, Commentf("sql: %s", ca.sql))
+	}
+	c.Assert(exec(c, cat, "alter table t partition by hash (a) partitions 2"), IsNil)
+	c.Assert(exec(c, cat, "alter table t drop partition p0"), ErrorMatches, ".*DROP PARTITION can only be used on RANGE/LIST partitions")
+	c.Assert(exec(c, cat, "alter table t coalesce partition 2"), ErrorMatches, ".*Cannot remove all partitions, use DROP TABLE instead")
+	c.Assert(cat.Table("test", "t").Partition.Type, Equals, model.PartitionTypeHash)
+}
@@ -0,0 +1,874 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package catalog builds the schema described by the model package from the
+// DDL statements, without a server.
+package catalog
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/charset"
+	"github.com/pingcap/parser/format"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/opcode"
+	"github.com/pingcap/parser/terror"
+	"github.com/pingcap/parser/types"
+)
+
+// The errors returned by the catalog, which are compatible with MySQL.
+var (
+	ErrBadField                 = terror.ClassDDL.NewStd(mysql.ErrBadField)
+	ErrBlobCantHaveDefault      = terror.ClassDDL.NewStd(mysql.ErrBlobCantHaveDefault)
+	ErrBlobKeyWithoutLength     = terror.ClassDDL.NewStd(mysql.ErrBlobKeyWithoutLength)
+	ErrCollationCharsetMismatch = terror.ClassDDL.NewStd(mysql.ErrCollationCharsetMismatch)
+	ErrDupFieldName             = terror.ClassDDL.NewStd(mysql.ErrDupFieldName)
+	ErrDupKeyName               = terror.ClassDDL.NewStd(mysql.ErrDupKeyName)
+	ErrFieldNotFoundPart        = terror.ClassDDL.NewStd(mysql.ErrFieldNotFoundPart)
+	ErrFkDupName                = terror.ClassDDL.NewStd(mysql.ErrFkDupName)
+	ErrInvalidDefault           = terror.ClassDDL.NewStd(mysql.ErrInvalidDefault)
+	ErrKeyColumnDoesNotExits    = terror.ClassDDL.NewStd(mysql.ErrKeyColumnDoesNotExits)
+	ErrMultiplePriKey           = terror.ClassDDL.NewStd(mysql.ErrMultiplePriKey)
+	ErrNotSupportedYet          = terror.ClassDDL.NewStd(mysql.ErrNotSupportedYet)
+	ErrSameNamePartition        = terror.ClassDDL.NewStd(mysql.ErrSameNamePartition)
+	ErrTableMustHaveColumns     = terror.ClassDDL.NewStd(mysql.ErrTableMustHaveColumns)
+	ErrUnknownCharacterSet      = terror.ClassDDL.NewStd(mysql.ErrUnknownCharacterSet)
+	ErrUnknownCollation         = terror.ClassDDL.NewStd(mysql.ErrUnknownCollation)
+	ErrWrongAutoKey             = terror.ClassDDL.NewStd(mysql.ErrWrongAutoKey)
+	ErrWrongNameForIndex        = terror.ClassDDL.NewStd(mysql.ErrWrongNameForIndex)
+	// ErrCheckConstraintDupName is ER_CHECK_CONSTRAINT_DUP_NAME of MySQL 8.0.
+	ErrCheckConstraintDupName = terror.ClassDDL.NewStdErr(3822, mysql.Message("Duplicate check constraint name '%-.192s'.", nil))
+)
+
+// BuildTableInfo builds the table created by stmt in db, which provides the default
+// charset and collation of the table, or the server defaults are used if db is nil.
+// The tables created by LIKE are copies of the tables referred, which aren't built
+// by it, and only the columns defined are built for CREATE TABLE ... SELECT.
+//
+// The IDs of the columns, indexes, constraints and partitions are allocated from 1.
+// Like TiDB, the primary key of a single integer column is the handle of the table,
+// which isn't in the indexes, and the REFERENCES of a column definition is ignored
+// like MySQL.
+func BuildTableInfo(stmt *ast.CreateTableStmt, db *model.DBInfo) (*model.TableInfo, error) {
+	if stmt.ReferTable != nil {
+		return nil, errors.Errorf("can't build the table created by LIKE %s", stmt.ReferTable.Name.O)
+	}
+	if len(stmt.Cols) == 0 && stmt.Select == nil {
+		return nil, ErrTableMustHaveColumns.GenWithStackByArgs()
+	}
+	b := &tableBuilder{table: &model.TableInfo{
+		Name:    stmt.Table.Name,
+		State:   model.StatePublic,
+		Version: model.CurrLatestTableInfoVersion,
+	}}
+	defCs, defCo := mysql.DefaultCharset, defaultCollation(mysql.DefaultCharset)
+	if db != nil && db.Charset != "" {
+		defCs, defCo = db.Charset, db.Collate
+	}
+	cs, co := b.applyOptions(stmt.Options)
+	var err error
+	if b.table.Charset, b.table.Collate, err = resolveCharset(cs, co, defCs, defCo); err != nil {
+		return nil, err
+	}
+	constraints := append([]*ast.Constraint(nil), stmt.Constraints...)
+	for _, def := range stmt.Cols {
+		cons, err := b.buildColumn(def)
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, cons...)
+	}
+	for _, col := range b.table.Columns {
+		for name := range col.Dependences {
+			if b.column(name) == nil {
+				return nil, ErrBadField.GenWithStackByArgs(name, "generated column function")
+			}
+		}
+	}
+	if err := b.buildConstraints(constraints); err != nil {
+		return nil, err
+	}
+	if err := b.buildPartition(stmt.Partition); err != nil {
+		return nil, err
+	}
+	if err := b.finish(); err != nil {
+		return nil, err
+	}
+	return b.table, nil
+}
+
+// tableBuilder builds a new table, or alters a table.
+type tableBuilder struct {
+	table *model.TableInfo
+	// reserved are the names of the constraints, which aren't generated.
+	reserved map[string]bool
+	// fkIndexNames are the names of the indexes of the foreign keys built, which
+	// are empty if the names aren't specified.
+	fkIndexNames map[string]string
+}
+
+// applyOptions applies the table options except the charset and the collation,
+// which are returned.
+func (b *tableBuilder) applyOptions(options []*ast.TableOption) (cs, co string) {
+	tbl := b.table
+	for _, opt := range options {
+		switch opt.Tp {
+		case ast.TableOptionCharset:
+			cs = opt.StrValue
+		case ast.TableOptionCollate:
+			co = opt.StrValue
+		case ast.TableOptionEngine:
+			tbl.Engine = opt.StrValue
+		case ast.TableOptionRowFormat:
+			tbl.RowFormat = rowFormat(opt)
+		case ast.TableOptionKeyBlockSize:
+			tbl.KeyBlockSize = opt.UintValue
+		case ast.TableOptionComment:
+			tbl.Comment = opt.StrValue
+		case ast.TableOptionAutoIncrement:
+			tbl.AutoIncID = int64(opt.UintValue)
+		case ast.TableOptionAutoIdCache:
+			tbl.AutoIdCache = int64(opt.UintValue)
+		case ast.TableOptionAutoRandomBase:
+			tbl.AutoRandID = int64(opt.UintValue)
+		case ast.TableOptionShardRowID:
+			tbl.ShardRowIDBits = opt.UintValue
+			tbl.MaxShardRowIDBits = opt.UintValue
+		case ast.TableOptionPreSplitRegion:
+			tbl.PreSplitRegions = opt.UintValue
+		case ast.TableOptionCompression:
+			tbl.Compression = opt.StrValue
+		}
+	}
+	return cs, co
+}
+
+// rowFormat returns the name of the row format of opt, such as DYNAMIC.
+func rowFormat(opt *ast.TableOption) string {
+	var sb strings.Builder
+	if err := opt.Restore(format.NewRestoreCtx(format.RestoreKeyWordUppercase, &sb)); err != nil {
+		return ""
+	}
+	s := sb.String()
+	return strings.TrimSpace(s[strings.Index(s, "=")+1:])
+}
+
+// defaultCollation returns the collation of cs used by MySQL 8.0 if it's omitted.
+func defaultCollation(cs string) string {
+	if co, ok := primaryCollations[cs]; ok {
+		return co
+	}
+	co, _ := charset.GetDefaultCollation(cs)
+	return co
+}
+
+// resolveCharset returns the charset and the collation specified by cs and co, which
+// are defCs and defCo if both are empty. The collation of cs is its primary collation
+// of MySQL 8.0 if it's omitted.
+func resolveCharset(cs, co, defCs, defCo string) (string, string, error) {
+	cs, co = strings.ToLower(cs), strings.ToLower(co)
+	if cs == "" && co == "" {
+		cs, co = defCs, defCo
+	}
+	if cs != "" {
+		if _, err := charset.GetCharsetInfo(cs); err != nil {
+			return "", "", ErrUnknownCharacterSet.GenWithStackByArgs(cs)
+		}
+	}
+	if co == "" {
+		return cs, defaultCollation(cs), nil
+	}
+	collation, err := charset.GetCollationByName(co)
+	if err != nil {
+		return "", "", ErrUnknownCollation.GenWithStackByArgs(co)
+	}
+	if cs == "" {
+		cs = collation.CharsetName
+	} else if collation.CharsetName != cs {
+		return "", "", ErrCollationCharsetMismatch.GenWithStackByArgs(co, cs)
+	}
+	return cs, co, nil
+}
+
+// column returns the column by name, or nil if it's not found.
+func (b *tableBuilder) column(name string) *model.ColumnInfo {
+	for _, col := range b.table.Columns {
+		if col.Name.L == strings.ToLower(name) {
+			return col
+		}
+	}
+	return nil
+}
+
+// index returns the index by name, or nil if it's not found.
+func (b *tableBuilder) index(name string) *model.IndexInfo {
+	for _, idx := range b.table.Indices {
+		if idx.Name.L == strings.ToLower(name) {
+			return idx
+		}
+	}
+	return nil
+}
+
+// buildColumn builds the column of def, and returns the constraints defined by the
+// column options.
+func (b *tableBuilder) buildColumn(def *ast.ColumnDef) ([]*ast.Constraint, error) {
+	tbl := b.table
+	if b.column(def.Name.Name.L) != nil {
+		return nil, ErrDupFieldName.GenWithStackByArgs(def.Name.Name.O)
+	}
+	tbl.MaxColumnID++
+	col := &model.ColumnInfo{
+		ID:        tbl.MaxColumnID,
+		Name:      def.Name.Name,
+		Offset:    len(tbl.Columns),
+		FieldType: *def.Tp,
+		State:     model.StatePublic,
+		Version:   model.CurrLatestColumnInfoVersion,
+	}
+	var (
+		constraints []*ast.Constraint
+		defaultExpr ast.ExprNode
+		collate     string
+	)
+	key := []*ast.IndexPartSpecification{{Column: def.Name, Length: types.UnspecifiedLength}}
+	for _, opt := range def.Options {
+		switch opt.Tp {
+		case ast.ColumnOptionNotNull:
+			col.Flag |= mysql.NotNullFlag
+		case ast.ColumnOptionNull:
+			col.Flag &^= mysql.NotNullFlag
+		case ast.ColumnOptionPrimaryKey:
+			col.Flag |= mysql.PriKeyFlag | mysql.NotNullFlag
+			constraints = append(constraints, &ast.Constraint{
+				Tp:     ast.ConstraintPrimaryKey,
+				Keys:   key,
+				Option: &ast.IndexOption{PrimaryKeyTp: opt.PrimaryKeyTp},
+			})
+		case ast.ColumnOptionUniqKey:
+			constraints = append(constraints, &ast.Constraint{Tp: ast.ConstraintUniqKey, Keys: key})
+		case ast.ColumnOptionAutoIncrement:
+			col.Flag |= mysql.AutoIncrementFlag | mysql.NotNullFlag
+		case ast.ColumnOptionDefaultValue:
+			defaultExpr = opt.Expr
+		case ast.ColumnOptionOnUpdate:
+			col.Flag |= mysql.OnUpdateNowFlag
+		case ast.ColumnOptionComment:
+			if v, ok := opt.Expr.(ast.ValueExpr); ok {
+				col.Comment = v.GetString()
+			}
+		case ast.ColumnOptionGenerated:
+			col.GeneratedExprString = restore(opt.Expr)
+			col.GeneratedStored = opt.Stored
+			col.Dependences = make(map[string]struct{})
+			ast.Inspect(opt.Expr, func(n ast.Node) bool {
+				if x, ok := n.(*ast.ColumnName); ok {
+					col.Dependences[x.Name.L] = struct{}{}
+				}
+				return true
+			})
+		case ast.ColumnOptionCollate:
+			collate = opt.StrValue
+		case ast.ColumnOptionCheck:
+			constraints = append(constraints, &ast.Constraint{
+				Tp:           ast.ConstraintCheck,
+				Name:         opt.ConstraintName,
+				Expr:         opt.Expr,
+				Enforced:     opt.Enforced,
+				InColumn:     true,
+				InColumnName: def.Name.Name.O,
+			})
+		}
+	}
+	if err := b.buildFieldType(col, collate); err != nil {
+		return nil, err
+	}
+	if defaultExpr != nil {
+		if err := setDefaultValue(col, defaultExpr); err != nil {
+			return nil, err
+		}
+	}
+	tbl.Columns = append(tbl.Columns, col)
+	return constraints, nil
+}
+
+// buildFieldType fills the default length, decimal, charset and collation of the column.
+func (b *tableBuilder) buildFieldType(col *model.ColumnInfo, collate string) error {
+	tp := &col.FieldType
+	flen, decimal := mysql.GetDefaultFieldLengthAndDecimal(tp.Tp)
+	if tp.Flen == types.UnspecifiedLength {
+		tp.Flen = flen
+		if mysql.IsIntegerType(tp.Tp) && tp.Tp != mysql.TypeLonglong && mysql.HasUnsignedFlag(tp.Flag) {
+			// the sign isn't displayed.
+			tp.Flen--
+		}
+	}
+	if tp.Decimal == types.UnspecifiedLength {
+		tp.Decimal = decimal
+	}
+	switch tp.Tp {
+	case mysql.TypeEnum:
+		tp.Flen = 0
+		for _, elem := range tp.Elems {
+			if len(elem) > tp.Flen {
+				tp.Flen = len(elem)
+			}
+		}
+	case mysql.TypeSet:
+		tp.Flen = 0
+		for _, elem := range tp.Elems {
+			tp.Flen += len(elem) + 1
+		}
+		if tp.Flen > 0 {
+			tp.Flen--
+		}
+	}
+	if !hasCharset(tp.Tp) || tp.Charset == charset.CharsetBin {
+		tp.Charset, tp.Collate = charset.CharsetBin, charset.CollationBin
+		return nil
+	}
+	if collate != "" {
+		tp.Collate = collate
+	}
+	var err error
+	tp.Charset, tp.Collate, err = resolveCharset(tp.Charset, tp.Collate, b.table.Charset, b.table.Collate)
+	return err
+}
+
+// setDefaultValue sets the default value of the column, which is a string, nil for
+// NULL, or the text of an expression. The default value of a BIT column is the
+// string of its bytes like TiDB, which is also kept in DefaultValueBit.
+func setDefaultValue(col *model.ColumnInfo, expr ast.ExprNode) error {
+	value, isExpr := defaultValue(expr)
+	switch {
+	case value == nil && mysql.HasNotNullFlag(col.Flag):
+		return ErrInvalidDefault.GenWithStackByArgs(col.Name.O)
+	case value != nil && !isExpr && (types.IsTypeBlob(col.Tp) || col.Tp == mysql.TypeJSON || col.Tp == mysql.TypeGeometry):
+		return ErrBlobCantHaveDefault.GenWithStackByArgs(col.Name.O)
+	case value != nil && !isExpr && col.Tp == mysql.TypeBit:
+		bits, ok := bitValue(expr.(ast.ValueExpr), col.Flen)
+		if !ok {
+			return ErrInvalidDefault.GenWithStackByArgs(col.Name.O)
+		}
+		col.DefaultIsExpr = false
+		return col.SetDefaultValue(bits)
+	}
+	col.DefaultValue, col.DefaultIsExpr, col.DefaultValueBit = value, isExpr, nil
+	return nil
+}
+
+// bitValue returns the bytes of the value of a BIT(flen) column, ok is false if v
+// isn't a bit, hexadecimal, string or integer literal of at most flen bits.
+func bitValue(v ast.ValueExpr, flen int) (_ string, ok bool) {
+	var b []byte
+	switch x := v.GetValue().(type) {
+	case int64:
+		if x < 0 {
+			return "", false
+		}
+		b = big.NewInt(x).Bytes()
+	case uint64:
+		b = new(big.Int).SetUint64(x).Bytes()
+	case string:
+		b = []byte(x)
+	default:
+		// the bit and hexadecimal literals of the parser driver are byte slices.
+		rv := reflect.ValueOf(x)
+		if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() != reflect.Uint8 {
+			return "", false
+		}
+		b = rv.Bytes()
+	}
+	n := new(big.Int).SetBytes(b)
+	if n.BitLen() > flen {
+		return "", false
+	}
+	b = n.Bytes()
+	bits := make([]byte, (flen+7)/8-len(b), (flen+7)/8)
+	return string(append(bits, b...)), true
+}
+
+// defaultValue returns the value of a default expression, and whether it's the text
+// of an expression.
+func defaultValue(expr ast.ExprNode) (interface{}, bool) {
+	switch x := expr.(type) {
+	case ast.ValueExpr:
+		switch v := x.GetValue().(type) {
+		case nil:
+			return nil, false
+		case string:
+			return v, false
+		default:
+			return fmt.Sprint(v), false
+		}
+	case *ast.UnaryOperationExpr:
+		if v, ok := x.V.(ast.ValueExpr); ok && v.GetValue() != nil && x.Op == opcode.Minus {
+			value, _ := defaultValue(v)
+			return "-" + value.(string), false
+		}
+	case *ast.FuncCallExpr:
+		switch x.FnName.L {
+		case ast.CurrentTimestamp, ast.Now, ast.LocalTime, ast.LocalTimestamp:
+			if len(x.Args) == 0 {
+				return strings.ToUpper(ast.CurrentTimestamp), false
+			}
+			if v, ok := x.Args[0].(ast.ValueExpr); ok {
+				return fmt.Sprintf("%s(%v)", strings.ToUpper(ast.CurrentTimestamp), v.GetValue()), false
+			}
+		}
+	}
+	return restore(expr), true
+}
+
+// bitLiteral returns the bit literal of the bytes of the value of a BIT column, such as b'101'.
+func bitLiteral(bits string) string {
+	return "b'" + new(big.Int).SetBytes([]byte(bits)).Text(2) + "'"
+}
+
+// restore returns the text of node, which is restored like TiDB stores the
+// generated columns, the check constraints and the views.
+func restore(node ast.Node) string {
+	var sb strings.Builder
+	flags := format.RestoreStringSingleQuotes | format.RestoreKeyWordLowercase | format.RestoreNameBackQuotes | format.RestoreSpacesAroundBinaryOperation
+	if err := node.Restore(format.NewRestoreCtx(flags, &sb)); err != nil {
+		return ""
+	}
+	return sb.String()
+}
+
+// hasCharset reports whether the columns of tp have a charset, regardless of the
+// binary flag, which only means the binary collation of the charset of a column.
+func hasCharset(tp byte) bool {
+	return types.HasCharset(&types.FieldType{Tp: tp})
+}
+
+// buildConstraints builds the constraints, the names of the indexes are generated
+// after the names specified are reserved.
+func (b *tableBuilder) buildConstraints(constraints []*ast.Constraint) error {
+	b.reserved = make(map[string]bool)
+	for _, cons := range constraints {
+		b.reserved[strings.ToLower(cons.Name)] = true
+	}
+	for _, cons := range constraints {
+		if err := b.buildConstraint(cons); err != nil {
+			return err
+		}
+	}
+	b.reserved = nil
+	return nil
+}
+
+func (b *tableBuilder) buildConstraint(cons *ast.Constraint) error {
+	switch cons.Tp {
+	case ast.ConstraintPrimaryKey:
+		return b.buildPrimaryKey(cons)
+	case ast.ConstraintKey, ast.ConstraintIndex, ast.ConstraintUniq, ast.ConstraintUniqKey, ast.ConstraintUniqIndex,
+		ast.ConstraintFulltext, ast.ConstraintSPATIAL:
+		_, err := b.buildIndex(cons.Name, cons)
+		return err
+	case ast.ConstraintForeignKey:
+		return b.buildForeignKey(cons)
+	case ast.ConstraintCheck:
+		return b.buildCheck(cons)
+	}
+	return nil
+}
+
+func (b *tableBuilder) buildPrimaryKey(cons *ast.Constraint) error {
+	tbl := b.table
+	if tbl.PKIsHandle || b.index(mysql.PrimaryKeyName) != nil {
+		return ErrMultiplePriKey.GenWithStackByArgs()
+	}
+	pkTp := model.PrimaryKeyTypeDefault
+	if cons.Option != nil {
+		pkTp = cons.Option.PrimaryKeyTp
+	}
+	if len(cons.Keys) == 1 && cons.Keys[0].Expr == nil && pkTp != model.PrimaryKeyTypeNonClustered {
+		col := b.column(cons.Keys[0].Column.Name.L)
+		if col == nil {
+			return ErrKeyColumnDoesNotExits.GenWithStackByArgs(cons.Keys[0].Column.Name.O)
+		}
+		if mysql.IsIntegerType(col.Tp) {
+			tbl.PKIsHandle = true
+			col.Flag |= mysql.PriKeyFlag | mysql.NotNullFlag
+			return nil
+		}
+	}
+	if _, err := b.buildIndex(mysql.PrimaryKeyName, cons); err != nil {
+		return err
+	}
+	tbl.IsCommonHandle = pkTp == model.PrimaryKeyTypeClustered
+	return nil
+}
+
+// buildIndex builds the index named name, which is generated from the first column
+// if it's empty.
+func (b *tableBuilder) buildIndex(name string, cons *ast.Constraint) (*model.IndexInfo, error) {
+	tbl := b.table
+	primary := cons.Tp == ast.ConstraintPrimaryKey
+	if name == "" {
+		name = b.indexName(cons.Keys)
+	} else if !primary && strings.EqualFold(name, mysql.PrimaryKeyName) {
+		return nil, ErrWrongNameForIndex.GenWithStackByArgs(name)
+	}
+	if b.index(name) != nil {
+		return nil, ErrDupKeyName.GenWithStackByArgs(name)
+	}
+	idx := &model.IndexInfo{
+		Name:    model.NewCIStr(name),
+		Table:   tbl.Name,
+		State:   model.StatePublic,
+		Tp:      model.IndexTypeBtree,
+		Primary: primary,
+	}
+	switch cons.Tp {
+	case ast.ConstraintPrimaryKey, ast.ConstraintUniq, ast.ConstraintUniqKey, ast.ConstraintUniqIndex:
+		idx.Unique = true
+	case ast.ConstraintFulltext:
+		idx.Tp, idx.Fulltext = model.IndexTypeInvalid, true
+	case ast.ConstraintSPATIAL:
+		idx.Tp = model.IndexTypeRtree
+	}
+	if opt := cons.Option; opt != nil {
+		if opt.Tp != model.IndexTypeInvalid && idx.Tp == model.IndexTypeBtree {
+			idx.Tp = opt.Tp
+		}
+		idx.Comment = opt.Comment
+		idx.Invisible = opt.Visibility == ast.IndexVisibilityInvisible
+	}
+	for _, key := range cons.Keys {
+		if key.Expr != nil {
+			return nil, ErrNotSupportedYet.GenWithStackByArgs("building the expression indexes")
+		}
+		col := b.column(key.Column.Name.L)
+		if col == nil {
+			return nil, ErrKeyColumnDoesNotExits.GenWithStackByArgs(key.Column.Name.O)
+		}
+		length := key.Length
+		if length <= 0 {
+			length = types.UnspecifiedLength
+			if types.IsTypeBlob(col.Tp) && !idx.Fulltext {
+				return nil, ErrBlobKeyWithoutLength.GenWithStackByArgs(col.Name.O)
+			}
+		}
+		idx.Columns = append(idx.Columns, &model.IndexColumn{Name: col.Name, Offset: col.Offset, Length: length})
+	}
+	if primary {
+		for _, ic := range idx.Columns {
+			tbl.Columns[ic.Offset].Flag |= mysql.NotNullFlag
+		}
+	}
+	tbl.MaxIndexID++
+	idx.ID = tbl.MaxIndexID
+	tbl.Indices = append(tbl.Indices, idx)
+	return idx, nil
+}
+
+// indexName returns the name of an index of keys like MySQL, which is the name of
+// the first column, with a suffix such as _2 if the name is used or reserved.
+func (b *tableBuilder) indexName(keys []*ast.IndexPartSpecification) string {
+	name := "idx"
+	if len(keys) > 0 && keys[0].Column != nil {
+		name = keys[0].Column.Name.O
+	}
+	if b.index(name) == nil && !b.reserved[strings.ToLower(name)] && !strings.EqualFold(name, mysql.PrimaryKeyName) {
+		return name
+	}
+	for i := 2; ; i++ {
+		if n := fmt.Sprintf("%s_%d", name, i); b.index(n) == nil && !b.reserved[strings.ToLower(n)] {
+			return n
+		}
+	}
+}
+
+func (b *tableBuilder) buildForeignKey(cons *ast.Constraint) error {
+	tbl := b.table
+	name := cons.Name
+	if name == "" {
+		name = fmt.Sprintf("%s_ibfk_%d", tbl.Name.O, len(tbl.ForeignKeys)+1)
+	}
+	for _, fk := range tbl.ForeignKeys {
+		if fk.Name.L == strings.ToLower(name) {
+			return ErrFkDupName.GenWithStackByArgs(name)
+		}
+	}
+	fk := &model.FKInfo{
+		ID:       int64(len(tbl.ForeignKeys) + 1),
+		Name:     model.NewCIStr(name),
+		RefTable: cons.Refer.Table.Name,
+		State:    model.StatePublic,
+	}
+	for _, key := range cons.Keys {
+		col := b.column(key.Column.Name.L)
+		if col == nil {
+			return ErrKeyColumnDoesNotExits.GenWithStackByArgs(key.Column.Name.O)
+		}
+		fk.Cols = append(fk.Cols, col.Name)
+	}
+	for _, key := range cons.Refer.IndexPartSpecifications {
+		fk.RefCols = append(fk.RefCols, key.Column.Name)
+	}
+	if cons.Refer.OnDelete != nil {
+		fk.OnDelete = int(cons.Refer.OnDelete.ReferOpt)
+	}
+	if cons.Refer.OnUpdate != nil {
+		fk.OnUpdate = int(cons.Refer.OnUpdate.ReferOpt)
+	}
+	tbl.ForeignKeys = append(tbl.ForeignKeys, fk)
+	if b.fkIndexNames == nil {
+		b.fkIndexNames = make(map[string]string)
+	}
+	b.fkIndexNames[fk.Name.L] = cons.Name
+	return nil
+}
+
+// buildForeignKeyIndexes builds the indexes of the foreign keys built like MySQL,
+// if no index starts with the columns of a foreign key.
+func (b *tableBuilder) buildForeignKeyIndexes() error {
+	for _, fk := range b.table.ForeignKeys {
+		name, ok := b.fkIndexNames[fk.Name.L]
+		if !ok || b.indexed(fk.Cols) {
+			continue
+		}
+		cons := &ast.Constraint{Tp: ast.ConstraintKey}
+		for _, col := range fk.Cols {
+			cons.Keys = append(cons.Keys, &ast.IndexPartSpecification{Column: &ast.ColumnName{Name: col}})
+		}
+		if _, err := b.buildIndex(name, cons); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexed reports whether an index starts with the columns.
+func (b *tableBuilder) indexed(cols []model.CIStr) bool {
+	if b.table.PKIsHandle && len(cols) == 1 {
+		if col := b.column(cols[0].L); col != nil && mysql.HasPriKeyFlag(col.Flag) {
+			return true
+		}
+	}
+	for _, idx := range b.table.Indices {
+		if len(idx.Columns) < len(cols) {
+			continue
+		}
+		matched := true
+		for i, col := range cols {
+			matched = matched && idx.Columns[i].Name.L == col.L
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *tableBuilder) buildCheck(cons *ast.Constraint) error {
+	tbl := b.table
+	name := cons.Name
+	if name == "" {
+		name = fmt.Sprintf("%s_chk_%d", tbl.Name.O, len(tbl.Constraints)+1)
+	}
+	if tbl.FindConstraintInfoByName(name) != nil {
+		return ErrCheckConstraintDupName.GenWithStackByArgs(name)
+	}
+	tbl.MaxConstraintID++
+	info := &model.ConstraintInfo{
+		ID:         tbl.MaxConstraintID,
+		Name:       model.NewCIStr(name),
+		Table:      tbl.Name,
+		Enforced:   cons.Enforced,
+		InColumn:   cons.InColumn,
+		ExprString: restore(cons.Expr),
+		State:      model.StatePublic,
+	}
+	var err error
+	ast.Inspect(cons.Expr, func(n ast.Node) bool {
+		if x, ok := n.(*ast.ColumnName); ok && err == nil {
+			col := b.column(x.Name.L)
+			if col == nil {
+				err = ErrBadField.GenWithStackByArgs(x.Name.O, "check constraint "+name+" expression")
+				return false
+			}
+			for _, c := range info.ConstraintCols {
+				if c.L == col.Name.L {
+					return true
+				}
+			}
+			info.ConstraintCols = append(info.ConstraintCols, col.Name)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	tbl.Constraints = append(tbl.Constraints, info)
+	return nil
+}
+
+// finish updates the offsets and the flags of the columns after the table is built
+// or altered, and checks the table.
+func (b *tableBuilder) finish() error {
+	tbl := b.table
+	b.updateOffsets()
+	if err := b.buildForeignKeyIndexes(); err != nil {
+		return err
+	}
+	b.fkIndexNames = nil
+	if err := b.checkAutoIncrement(); err != nil {
+		return err
+	}
+	for _, col := range tbl.Columns {
+		// the handle keeps PriKeyFlag.
+		if !tbl.PKIsHandle || !mysql.HasPriKeyFlag(col.Flag) {
+			col.Flag &^= mysql.PriKeyFlag
+		}
+		col.Flag &^= mysql.UniqueKeyFlag | mysql.MultipleKeyFlag
+	}
+	for _, idx := range tbl.Indices {
+		for i, ic := range idx.Columns {
+			col := tbl.Columns[ic.Offset]
+			switch {
+			case idx.Primary:
+				col.Flag |= mysql.PriKeyFlag
+			case idx.Unique && len(idx.Columns) == 1:
+				col.Flag |= mysql.UniqueKeyFlag
+			case i == 0:
+				col.Flag |= mysql.MultipleKeyFlag
+			}
+		}
+	}
+	for _, col := range tbl.Columns {
+		if mysql.HasPriKeyFlag(col.Flag) || mysql.HasUniKeyFlag(col.Flag) {
+			col.Flag &^= mysql.MultipleKeyFlag
+		}
+		if mysql.HasNotNullFlag(col.Flag) && col.DefaultValue == nil && !mysql.HasAutoIncrementFlag(col.Flag) && !col.IsGenerated() {
+			col.Flag |= mysql.NoDefaultValueFlag
+		}
+	}
+	return nil
+}
+
+// updateOffsets updates the offsets of the columns and the index columns.
+func (b *tableBuilder) updateOffsets() {
+	for i, col := range b.table.Columns {
+		col.Offset = i
+	}
+	for _, idx := range b.table.Indices {
+		for _, ic := range idx.Columns {
+			ic.Offset = b.column(ic.Name.L).Offset
+		}
+	}
+}
+
+// checkAutoIncrement checks there is at most one auto-increment column, which is
+// the first column of an index.
+func (b *tableBuilder) checkAutoIncrement() error {
+	var auto *model.ColumnInfo
+	for _, col := range b.table.Columns {
+		if !mysql.HasAutoIncrementFlag(col.Flag) {
+			continue
+		}
+		if auto != nil {
+			return ErrWrongAutoKey.GenWithStackByArgs()
+		}
+		auto = col
+	}
+	if auto != nil && !b.indexed([]model.CIStr{auto.Name}) {
+		return ErrWrongAutoKey.GenWithStackByArgs()
+	}
+	return nil
+}
+
+func (b *tableBuilder) buildPartition(opts *ast.PartitionOptions) error {
+	if opts == nil {
+		return nil
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	pi := &model.PartitionInfo{Type: opts.Tp, Enable: true}
+	if opts.Expr != nil {
+		pi.Expr = restore(opts.Expr)
+	}
+	var err error
+	check := func(name *ast.ColumnName) bool {
+		if b.column(name.Name.L) == nil {
+			err = ErrFieldNotFoundPart.GenWithStackByArgs()
+			return false
+		}
+		return true
+	}
+	if opts.Expr != nil {
+		ast.Inspect(opts.Expr, func(n ast.Node) bool {
+			if x, ok := n.(*ast.ColumnName); ok {
+				return check(x)
+			}
+			return err == nil
+		})
+	}
+	for _, name := range opts.ColumnNames {
+		if !check(name) {
+			break
+		}
+		pi.Columns = append(pi.Columns, name.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(opts.Definitions) == 0 {
+		for i := uint64(0); i < opts.Num; i++ {
+			pi.Definitions = append(pi.Definitions, model.PartitionDefinition{
+				ID:   int64(i + 1),
+				Name: model.NewCIStr(fmt.Sprintf("p%d", i)),
+			})
+		}
+	}
+	for i, def := range opts.Definitions {
+		for _, pd := range pi.Definitions {
+			if pd.Name.L == def.Name.L {
+				return ErrSameNamePartition.GenWithStackByArgs(def.Name.O)
+			}
+		}
+		pi.Definitions = append(pi.Definitions, buildPartitionDefinition(def, int64(i+1)))
+	}
+	pi.Num = uint64(len(pi.Definitions))
+	b.table.Partition = pi
+	return nil
+}
+
+func buildPartitionDefinition(def *ast.PartitionDefinition, id int64) model.PartitionDefinition {
+	pd := model.PartitionDefinition{ID: id, Name: def.Name}
+	pd.Comment, _ = def.Comment()
+	switch clause := def.Clause.(type) {
+	case *ast.PartitionDefinitionClauseLessThan:
+		for _, expr := range clause.Exprs {
+			pd.LessThan = append(pd.LessThan, restore(expr))
+		}
+	case *ast.PartitionDefinitionClauseIn:
+		for _, values := range clause.Values {
+			var strs []string
+			for _, expr := range values {
+				strs = append(strs, restore(expr))
+			}
+			pd.InValues = append(pd.InValues, strs)
+		}
+	}
+	return pd
+}
@@ -0,0 +1,249 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	. "github.com/pingcap/parser/catalog"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/mysql"
+	_ "github.com/pingcap/parser/test_driver"
+	"github.com/pingcap/parser/types"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testBuildSuite{})
+
+type testBuildSuite struct {
+}
+
+func buildTable(c *C, sql string, db *model.DBInfo) (*model.TableInfo, error) {
+	stmt, err := parser.New().ParseOneStmt(sql, "", "")
+	c.Assert(err, IsNil, Commentf("sql: %s", sql))
+	return BuildTableInfo(stmt.(*ast.CreateTableStmt), db)
+}
+
+func (s *testBuildSuite) TestBuildColumns(c *C) {
+	db := &model.DBInfo{Name: model.NewCIStr("test"), Charset: "latin1", Collate: "latin1_bin"}
+	tbl, err := buildTable(c, `create table t (
+		id int unsigned not null auto_increment,
+		a bigint,
+		b varchar(10) not null default 'x' comment 'b',
+		c char charset utf8mb4,
+		d decimal(5),
+		e text collate utf8mb4_general_ci,
+		f blob,
+		g enum('x', 'yy', 'zzz') default null,
+		h set('a', 'bb'),
+		ts timestamp(3) default current_timestamp(3) on update current_timestamp(3),
+		n int default -1,
+		v int as (n + 1) virtual,
+		primary key (id)
+	) engine=InnoDB row_format=dynamic comment='t' auto_increment=100`, db)
+	c.Assert(err, IsNil)
+	c.Assert(tbl.Name.O, Equals, "t")
+	c.Assert(tbl.Charset, Equals, "latin1")
+	c.Assert(tbl.Collate, Equals, "latin1_bin")
+	c.Assert(tbl.Engine, Equals, "InnoDB")
+	c.Assert(tbl.RowFormat, Equals, "DYNAMIC")
+	c.Assert(tbl.Comment, Equals, "t")
+	c.Assert(tbl.AutoIncID, Equals, int64(100))
+	c.Assert(tbl.PKIsHandle, IsTrue)
+	c.Assert(tbl.Indices, HasLen, 0)
+	c.Assert(tbl.MaxColumnID, Equals, int64(12))
+
+	cols := make(map[string]*model.ColumnInfo)
+	for i, col := range tbl.Columns {
+		c.Assert(col.Offset, Equals, i)
+		c.Assert(col.ID, Equals, int64(i+1))
+		c.Assert(col.State, Equals, model.StatePublic)
+		cols[col.Name.L] = col
+	}
+	id := cols["id"]
+	c.Assert(id.Flen, Equals, 10)
+	c.Assert(mysql.HasPriKeyFlag(id.Flag) && mysql.HasAutoIncrementFlag(id.Flag) && mysql.HasUnsignedFlag(id.Flag), IsTrue)
+	c.Assert(mysql.HasNoDefaultValueFlag(id.Flag), IsFalse)
+	c.Assert(cols["a"].Flen, Equals, 20)
+	c.Assert(cols["a"].Charset, Equals, "binary")
+	c.Assert(cols["a"].DefaultValue, IsNil)
+	b := cols["b"]
+	c.Assert(b.Charset+" "+b.Collate, Equals, "latin1 latin1_bin")
+	c.Assert(b.DefaultValue, Equals, "x")
+	c.Assert(b.Comment, Equals, "b")
+	c.Assert(mysql.HasNoDefaultValueFlag(b.Flag), IsFalse)
+	c.Assert(cols["c"].Flen, Equals, 1)
+	c.Assert(cols["c"].Charset+" "+cols["c"].Collate, Equals, "utf8mb4 utf8mb4_0900_ai_ci")
+	c.Assert(cols["d"].Flen, Equals, 5)
+	c.Assert(cols["d"].Decimal, Equals, 0)
+	c.Assert(cols["e"].Charset+" "+cols["e"].Collate, Equals, "utf8mb4 utf8mb4_general_ci")
+	c.Assert(cols["f"].Charset, Equals, "binary")
+	c.Assert(cols["g"].Flen, Equals, 3)
+	c.Assert(cols["h"].Flen, Equals, 4)
+	ts := cols["ts"]
+	c.Assert(ts.Decimal, Equals, 3)
+	c.Assert(ts.DefaultValue, Equals, "CURRENT_TIMESTAMP(3)")
+	c.Assert(mysql.HasOnUpdateNowFlag(ts.Flag), IsTrue)
+	c.Assert(cols["n"].DefaultValue, Equals, "-1")
+	v := cols["v"]
+	c.Assert(v.GeneratedExprString, Equals, "`n` + 1")
+	c.Assert(v.GeneratedStored, IsFalse)
+	c.Assert(v.Dependences, DeepEquals, map[string]struct{}{"n": {}})
+}
+
+func (s *testBuildSuite) TestBuildIndexes(c *C) {
+	tbl, err := buildTable(c, `create table t (
+		a varchar(10) primary key,
+		b int unique,
+		c int,
+		d text,
+		e int,
+		f int,
+		index (c, b),
+		key c (c),
+		unique key uk (c, e) comment 'uk',
+		fulltext key ft (d),
+		key p (d(5)) using hash invisible,
+		constraint fk foreign key (e) references p (id) on delete cascade,
+		foreign key (f) references q (id),
+		foreign key (c) references q (id),
+		check (e > 0),
+		constraint chk_f check (f < 10) not enforced
+	)`, nil)
+	c.Assert(err, IsNil)
+	c.Assert(tbl.Charset+" "+tbl.Collate, Equals, "utf8mb4 utf8mb4_0900_ai_ci")
+	c.Assert(tbl.PKIsHandle, IsFalse)
+	var names []string
+	for i, idx := range tbl.Indices {
+		c.Assert(idx.ID, Equals, int64(i+1))
+		names = append(names, idx.Name.O)
+	}
+	c.Assert(names, DeepEquals, []string{"c_2", "c", "uk", "ft", "p", "PRIMARY", "b", "fk", "f"})
+	pk := tbl.Indices[5]
+	c.Assert(pk.Primary && pk.Unique, IsTrue)
+	c.Assert(pk.Columns[0].Name.O, Equals, "a")
+	c.Assert(tbl.Indices[0].Columns, HasLen, 2)
+	c.Assert(tbl.Indices[0].Tp, Equals, model.IndexTypeBtree)
+	c.Assert(tbl.Indices[2].Unique, IsTrue)
+	c.Assert(tbl.Indices[2].Comment, Equals, "uk")
+	c.Assert(tbl.Indices[3].Fulltext, IsTrue)
+	c.Assert(tbl.Indices[3].Tp, Equals, model.IndexTypeInvalid)
+	c.Assert(tbl.Indices[3].Columns[0].Length, Equals, types.UnspecifiedLength)
+	for _, idx := range tbl.Indices[:4] {
+		data, err := json.Marshal(idx)
+		c.Assert(err, IsNil)
+		var decoded model.IndexInfo
+		c.Assert(json.Unmarshal(data, &decoded), IsNil)
+		c.Assert(decoded.Fulltext, Equals, idx.Fulltext)
+		c.Assert(decoded.Tp, Equals, idx.Tp)
+		c.Assert(strings.Contains(string(data), "is_fulltext"), Equals, idx.Fulltext)
+	}
+	c.Assert(tbl.Indices[4].Tp, Equals, model.IndexTypeHash)
+	c.Assert(tbl.Indices[4].Invisible, IsTrue)
+	c.Assert(tbl.Indices[4].Columns[0].Length, Equals, 5)
+
+	cols := tbl.Columns
+	c.Assert(mysql.HasPriKeyFlag(cols[0].Flag) && mysql.HasNotNullFlag(cols[0].Flag), IsTrue)
+	c.Assert(mysql.HasNoDefaultValueFlag(cols[0].Flag), IsTrue)
+	c.Assert(mysql.HasUniKeyFlag(cols[1].Flag), IsTrue)
+	c.Assert(mysql.HasMultipleKeyFlag(cols[2].Flag), IsTrue)
+	c.Assert(mysql.HasMultipleKeyFlag(cols[4].Flag), IsTrue)
+
+	c.Assert(tbl.ForeignKeys, HasLen, 3)
+	fk := tbl.ForeignKeys[0]
+	c.Assert(fk.Name.O, Equals, "fk")
+	c.Assert(fk.RefTable.O, Equals, "p")
+	c.Assert(fk.Cols, DeepEquals, []model.CIStr{model.NewCIStr("e")})
+	c.Assert(fk.RefCols, DeepEquals, []model.CIStr{model.NewCIStr("id")})
+	c.Assert(fk.OnDelete, Equals, int(ast.ReferOptionCascade))
+	c.Assert(tbl.ForeignKeys[1].Name.O, Equals, "t_ibfk_2")
+
+	c.Assert(tbl.Constraints, HasLen, 2)
+	c.Assert(tbl.Constraints[0].Name.O, Equals, "t_chk_1")
+	c.Assert(tbl.Constraints[0].ExprString, Equals, "`e` > 0")
+	c.Assert(tbl.Constraints[0].Enforced, IsTrue)
+	c.Assert(tbl.Constraints[0].ConstraintCols, DeepEquals, []model.CIStr{model.NewCIStr("e")})
+	c.Assert(tbl.Constraints[1].Name.O, Equals, "chk_f")
+	c.Assert(tbl.Constraints[1].Enforced, IsFalse)
+}
+
+func (s *testBuildSuite) TestBuildPartition(c *C) {
+	tbl, err := buildTable(c, "create table t (a int, b date) partition by range (year(b)) (partition p0 values less than (2000) comment 'old', partition p1 values less than maxvalue)", nil)
+	c.Assert(err, IsNil)
+	pi := tbl.Partition
+	c.Assert(pi.Type, Equals, model.PartitionTypeRange)
+	c.Assert(pi.Expr, Equals, "year(`b`)")
+	c.Assert(pi.Num, Equals, uint64(2))
+	c.Assert(pi.Definitions[0].Name.O, Equals, "p0")
+	c.Assert(pi.Definitions[0].LessThan, DeepEquals, []string{"2000"})
+	c.Assert(pi.Definitions[0].Comment, Equals, "old")
+	c.Assert(pi.Definitions[1].LessThan, DeepEquals, []string{"maxvalue"})
+
+	tbl, err = buildTable(c, "create table t (a int, b varchar(10)) partition by list columns (a, b) (partition p0 values in ((1, 'x'), (2, 'y')))", nil)
+	c.Assert(err, IsNil)
+	c.Assert(tbl.Partition.Columns, DeepEquals, []model.CIStr{model.NewCIStr("a"), model.NewCIStr("b")})
+	c.Assert(tbl.Partition.Definitions[0].InValues, DeepEquals, [][]string{{"1", "'x'"}, {"2", "'y'"}})
+
+	tbl, err = buildTable(c, "create table t (a int) partition by hash (a) partitions 3", nil)
+	c.Assert(err, IsNil)
+	c.Assert(tbl.Partition.Num, Equals, uint64(3))
+	c.Assert(tbl.Partition.Definitions[2].Name.O, Equals, "p2")
+}
+
+func (s *testBuildSuite) TestBuildBitDefault(c *C) {
+	tbl, err := buildTable(c, "create table t (a bit(3) default b'101', b bit(10) not null default 5, c bit default x'01', d bit(8) default null)", nil)
+	c.Assert(err, IsNil)
+	c.Assert(tbl.Columns[0].GetDefaultValue(), Equals, "\x05")
+	c.Assert(tbl.Columns[1].GetDefaultValue(), Equals, "\x00\x05")
+	c.Assert(tbl.Columns[2].GetDefaultValue(), Equals, "\x01")
+	c.Assert(tbl.Columns[3].GetDefaultValue(), IsNil)
+}
+
+func (s *testBuildSuite) TestBuildErrors(c *C) {
+	cases := []struct {
+		sql string
+		err string
+	}{
+		{"create table t (a int, a int)", ".*Duplicate column name 'a'"},
+		{"create table t (a int, key k (a), key k (a))", ".*Duplicate key name 'k'"},
+		{"create table t (a int primary key, b int, primary key (b))", ".*Multiple primary key defined"},
+		{"create table t (a int, key (b))", ".*Key column 'b' doesn't exist in table"},
+		{"create table t (a int not null default null)", ".*Invalid default value for 'a'"},
+		{"create table t (a text default 'x')", ".*BLOB/TEXT/JSON column 'a' can't have a default value"},
+		{"create table t (a bit(2) default b'101')", ".*Invalid default value for 'a'"},
+		{"create table t (a bit(8) default 1.5)", ".*Invalid default value for 'a'"},
+		{"create table t (a text, key (a))", ".*BLOB/TEXT column 'a' used in key specification without a key length"},
+		{"create table t (a int auto_increment)", ".*Incorrect table definition; there can be only one auto column and it must be defined as a key"},
+		{"create table t (a varchar(10) charset latin1 collate utf8mb4_bin)", ".*COLLATION 'utf8mb4_bin' is not valid for CHARACTER SET 'latin1'"},
+		{"create table t (a int as (b + 1))", ".*Unknown column 'b' in 'generated column function'"},
+		{"create table t (a int, check (b > 0))", ".*Unknown column 'b' in 'check constraint t_chk_1 expression'"},
+		{"create table t (a int, constraint c check (a > 0), constraint c check (a < 9))", ".*Duplicate check constraint name 'c'."},
+		{"create table t (a int) partition by hash (b)", ".*Field in list of fields for partition function not found in table"},
+		{"create table t (a int) partition by range (a) (partition p values less than (1), partition p values less than (2))", ".*Duplicate partition name p"},
+		{"create table t like s", "can't build the table created by LIKE s"},
+	}
+	for _, ca := range cases {
+		_, err := buildTable(c, ca.sql, nil)
+		c.Assert(err, ErrorMatches, ca.err, Commentf("sql: %s", ca.sql))
+	}
+}
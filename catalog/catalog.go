@@ -0,0 +1,797 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog
+
+import (
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser/analysis"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/charset"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/terror"
+	"github.com/pingcap/parser/types"
+)
+
+var (
+	ErrBadDB          = terror.ClassDDL.NewStd(mysql.ErrBadDB)
+	ErrBadTable       = terror.ClassDDL.NewStd(mysql.ErrBadTable)
+	ErrDBCreateExists = terror.ClassDDL.NewStd(mysql.ErrDBCreateExists)
+	ErrDBDropExists   = terror.ClassDDL.NewStd(mysql.ErrDBDropExists)
+	ErrNoDB           = terror.ClassDDL.NewStd(mysql.ErrNoDB)
+	ErrNoSuchTable    = terror.ClassDDL.NewStd(mysql.ErrNoSuchTable)
+	ErrNoTablesUsed   = terror.ClassDDL.NewStd(mysql.ErrNoTablesUsed)
+	ErrNonUniq        = terror.ClassDDL.NewStd(mysql.ErrNonUniq)
+	ErrTableExists    = terror.ClassDDL.NewStd(mysql.ErrTableExists)
+	ErrViewWrongList  = terror.ClassDDL.NewStd(mysql.ErrViewWrongList)
+	ErrWrongObject    = terror.ClassDDL.NewStd(mysql.ErrWrongObject)
+)
+
+// Catalog is an in-memory schema of databases, which replays the DDL statements
+// like MySQL without a server, such as the migration scripts of a project.
+//
+// The statements other than DDL are ignored except USE, which selects the current
+// database, and a statement failed doesn't change the catalog. The tables returned
+// aren't changed by the statements applied later, which replace them.
+type Catalog struct {
+	dbs     []*model.DBInfo
+	current *model.DBInfo
+	// lastID is the last ID allocated to the databases and the tables.
+	lastID int64
+}
+
+// New returns an empty catalog.
+func New() *Catalog {
+	return &Catalog{}
+}
+
+// Databases returns the databases in the order created.
+func (c *Catalog) Databases() []*model.DBInfo {
+	return c.dbs
+}
+
+// Database returns the database by name, or nil if it's not found.
+func (c *Catalog) Database(name string) *model.DBInfo {
+	for _, db := range c.dbs {
+		if db.Name.L == strings.ToLower(name) {
+			return db
+		}
+	}
+	return nil
+}
+
+// Table returns the table or the view by name, or nil if it's not found. The
+// current database is used if db is empty.
+func (c *Catalog) Table(db, name string) *model.TableInfo {
+	schema := c.current
+	if db != "" {
+		schema = c.Database(db)
+	}
+	if schema == nil {
+		return nil
+	}
+	tbl := findTable(schema, name)
+	return tbl
+}
+
+// TableByName is like Table, which makes the catalog an analysis.Catalog.
+func (c *Catalog) TableByName(schema, table model.CIStr) *model.TableInfo {
+	return c.Table(schema.O, table.O)
+}
+
+// CurrentDatabase returns the name of the current database, or "" if no database
+// is selected.
+func (c *Catalog) CurrentDatabase() string {
+	if c.current == nil {
+		return ""
+	}
+	return c.current.Name.O
+}
+
+// Use selects the current database.
+func (c *Catalog) Use(name string) error {
+	db := c.Database(name)
+	if db == nil {
+		return ErrBadDB.GenWithStackByArgs(name)
+	}
+	c.current = db
+	return nil
+}
+
+// Apply applies the statements in order, and stops at the first error.
+func (c *Catalog) Apply(stmts ...ast.StmtNode) error {
+	for _, stmt := range stmts {
+		if err := c.apply(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Catalog) apply(stmt ast.StmtNode) error {
+	switch x := stmt.(type) {
+	case *ast.UseStmt:
+		return c.Use(x.DBName)
+	case *ast.CreateDatabaseStmt:
+		return c.createDatabase(x)
+	case *ast.AlterDatabaseStmt:
+		return c.alterDatabase(x)
+	case *ast.DropDatabaseStmt:
+		return c.dropDatabase(x)
+	case *ast.CreateTableStmt:
+		return c.createTable(x)
+	case *ast.AlterTableStmt:
+		return c.alterTable(x.Table, x.Specs)
+	case *ast.RenameTableStmt:
+		return c.renameTables(x)
+	case *ast.DropTableStmt:
+		return c.dropTables(x)
+	case *ast.TruncateTableStmt:
+		return c.truncateTable(x)
+	case *ast.CreateIndexStmt:
+		return c.createIndex(x)
+	case *ast.DropIndexStmt:
+		spec := &ast.AlterTableSpec{Tp: ast.AlterTableDropIndex, Name: x.IndexName, IfExists: x.IfExists}
+		return c.alterTable(x.Table, []*ast.AlterTableSpec{spec})
+	case *ast.CreateViewStmt:
+		return c.createView(x)
+	}
+	return nil
+}
+
+// databaseCharset returns the charset and the collation of the database options,
+// which are def if they aren't specified.
+func databaseCharset(options []*ast.DatabaseOption, def *model.DBInfo) (string, string, error) {
+	var cs, co string
+	for _, opt := range options {
+		switch opt.Tp {
+		case ast.DatabaseOptionCharset:
+			cs = opt.Value
+		case ast.DatabaseOptionCollate:
+			co = opt.Value
+		}
+	}
+	return resolveCharset(cs, co, def.Charset, def.Collate)
+}
+
+func (c *Catalog) createDatabase(stmt *ast.CreateDatabaseStmt) error {
+	if c.Database(stmt.Name) != nil {
+		if stmt.IfNotExists {
+			return nil
+		}
+		return ErrDBCreateExists.GenWithStackByArgs(stmt.Name)
+	}
+	def := &model.DBInfo{Charset: mysql.DefaultCharset, Collate: defaultCollation(mysql.DefaultCharset)}
+	cs, co, err := databaseCharset(stmt.Options, def)
+	if err != nil {
+		return err
+	}
+	c.lastID++
+	c.dbs = append(c.dbs, &model.DBInfo{
+		ID:      c.lastID,
+		Name:    model.NewCIStr(stmt.Name),
+		Charset: cs,
+		Collate: co,
+		State:   model.StatePublic,
+	})
+	return nil
+}
+
+func (c *Catalog) alterDatabase(stmt *ast.AlterDatabaseStmt) error {
+	db := c.current
+	if !stmt.AlterDefaultDatabase {
+		if db = c.Database(stmt.Name); db == nil {
+			return ErrBadDB.GenWithStackByArgs(stmt.Name)
+		}
+	} else if db == nil {
+		return ErrNoDB.GenWithStackByArgs()
+	}
+	cs, co, err := databaseCharset(stmt.Options, db)
+	if err != nil {
+		return err
+	}
+	db.Charset, db.Collate = cs, co
+	return nil
+}
+
+func (c *Catalog) dropDatabase(stmt *ast.DropDatabaseStmt) error {
+	for i, db := range c.dbs {
+		if db.Name.L != strings.ToLower(stmt.Name) {
+			continue
+		}
+		c.dbs = append(c.dbs[:i:i], c.dbs[i+1:]...)
+		if c.current == db {
+			c.current = nil
+		}
+		return nil
+	}
+	if stmt.IfExists {
+		return nil
+	}
+	return ErrDBDropExists.GenWithStackByArgs(stmt.Name)
+}
+
+// schema returns the database of the table name, which is the current database if
+// the schema isn't specified.
+func (c *Catalog) schema(name *ast.TableName) (*model.DBInfo, error) {
+	if name.Schema.L == "" {
+		if c.current == nil {
+			return nil, ErrNoDB.GenWithStackByArgs()
+		}
+		return c.current, nil
+	}
+	db := c.Database(name.Schema.L)
+	if db == nil {
+		return nil, ErrBadDB.GenWithStackByArgs(name.Schema.O)
+	}
+	return db, nil
+}
+
+// table returns the table or the view of the table name.
+func (c *Catalog) table(name *ast.TableName) (*model.DBInfo, *model.TableInfo, error) {
+	db, err := c.schema(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	tbl := findTable(db, name.Name.L)
+	if tbl == nil {
+		return nil, nil, ErrNoSuchTable.GenWithStackByArgs(db.Name.O, name.Name.O)
+	}
+	return db, tbl, nil
+}
+
+// findTable returns the table or the view of the database by name, or nil if it's
+// not found.
+func findTable(db *model.DBInfo, name string) *model.TableInfo {
+	for _, tbl := range db.Tables {
+		if tbl.Name.L == strings.ToLower(name) {
+			return tbl
+		}
+	}
+	return nil
+}
+
+// removeTable removes the table from the database.
+func removeTable(db *model.DBInfo, tbl *model.TableInfo) {
+	for i, t := range db.Tables {
+		if t == tbl {
+			db.Tables = append(db.Tables[:i:i], db.Tables[i+1:]...)
+			return
+		}
+	}
+}
+
+// replaceTable replaces the table of the database, which keeps the position.
+func replaceTable(db *model.DBInfo, old, tbl *model.TableInfo) {
+	tables := append([]*model.TableInfo(nil), db.Tables...)
+	for i, t := range tables {
+		if t == old {
+			tables[i] = tbl
+		}
+	}
+	db.Tables = tables
+}
+
+// addTable adds the table to the database, and allocates its ID.
+func (c *Catalog) addTable(db *model.DBInfo, tbl *model.TableInfo) {
+	c.lastID++
+	tbl.ID = c.lastID
+	db.Tables = append(db.Tables[:len(db.Tables):len(db.Tables)], tbl)
+}
+
+// cloneTable returns a deep copy of the table, which is changed by the statements
+// instead of the table.
+func cloneTable(tbl *model.TableInfo) *model.TableInfo {
+	nt := tbl.Clone()
+	nt.Constraints = make([]*model.ConstraintInfo, len(tbl.Constraints))
+	for i, cons := range tbl.Constraints {
+		nt.Constraints[i] = cons.Clone()
+	}
+	if tbl.Partition != nil {
+		pi := *tbl.Partition
+		pi.Columns = append([]model.CIStr(nil), pi.Columns...)
+		pi.Definitions = make([]model.PartitionDefinition, len(tbl.Partition.Definitions))
+		for i := range tbl.Partition.Definitions {
+			pi.Definitions[i] = tbl.Partition.Definitions[i].Clone()
+		}
+		nt.Partition = &pi
+	}
+	if tbl.View != nil {
+		view := *tbl.View
+		nt.View = &view
+	}
+	return nt
+}
+
+// renameTable returns a copy of the table renamed.
+func renameTable(tbl *model.TableInfo, name model.CIStr) *model.TableInfo {
+	nt := cloneTable(tbl)
+	nt.Name = name
+	for _, idx := range nt.Indices {
+		idx.Table = name
+	}
+	for _, cons := range nt.Constraints {
+		cons.Table = name
+	}
+	return nt
+}
+
+func (c *Catalog) createTable(stmt *ast.CreateTableStmt) error {
+	db, err := c.schema(stmt.Table)
+	if err != nil {
+		return err
+	}
+	if tbl := findTable(db, stmt.Table.Name.L); tbl != nil {
+		if stmt.IfNotExists {
+			return nil
+		}
+		return ErrTableExists.GenWithStackByArgs(stmt.Table.Name.O)
+	}
+	var tbl *model.TableInfo
+	switch {
+	case stmt.ReferTable != nil:
+		referDB, refer, err := c.table(stmt.ReferTable)
+		if err != nil {
+			return err
+		}
+		if refer.IsView() {
+			return ErrWrongObject.GenWithStackByArgs(referDB.Name.O, refer.Name.O, "BASE TABLE")
+		}
+		// the foreign keys and the auto-increment value aren't copied like MySQL.
+		tbl = renameTable(refer, stmt.Table.Name)
+		tbl.ForeignKeys = nil
+		tbl.AutoIncID = 0
+	case stmt.Select != nil:
+		if tbl, err = c.buildTableAsSelect(stmt, db); err != nil {
+			return err
+		}
+	default:
+		if tbl, err = BuildTableInfo(stmt, db); err != nil {
+			return err
+		}
+	}
+	c.addTable(db, tbl)
+	return nil
+}
+
+// buildTableAsSelect builds the table created by CREATE TABLE ... SELECT, whose
+// columns of the query are appended to the columns defined like MySQL, unless they
+// are defined.
+func (c *Catalog) buildTableAsSelect(stmt *ast.CreateTableStmt, db *model.DBInfo) (*model.TableInfo, error) {
+	selected, err := c.queryColumns(stmt.Select)
+	if err != nil {
+		return nil, err
+	}
+	create := *stmt
+	create.Cols = append([]*ast.ColumnDef(nil), stmt.Cols...)
+	var appended []*model.ColumnInfo
+	for _, col := range selected {
+		defined := false
+		for _, def := range stmt.Cols {
+			defined = defined || def.Name.Name.L == col.Name.L
+		}
+		if defined {
+			continue
+		}
+		def := &ast.ColumnDef{Name: &ast.ColumnName{Name: col.Name}, Tp: col.FieldType.Clone()}
+		if mysql.HasNotNullFlag(col.Flag) {
+			def.Options = append(def.Options, &ast.ColumnOption{Tp: ast.ColumnOptionNotNull})
+		}
+		create.Cols = append(create.Cols, def)
+		appended = append(appended, col)
+	}
+	tbl, err := BuildTableInfo(&create, db)
+	if err != nil {
+		return nil, err
+	}
+	// the defaults and the comments of the columns are retained.
+	offset := len(tbl.Columns) - len(appended)
+	for i, col := range appended {
+		built := tbl.Columns[offset+i]
+		built.DefaultValue, built.DefaultIsExpr, built.Comment = col.DefaultValue, col.DefaultIsExpr, col.Comment
+		built.DefaultValueBit = col.DefaultValueBit
+		if built.DefaultValue != nil {
+			built.Flag &^= mysql.NoDefaultValueFlag
+		}
+	}
+	return tbl, nil
+}
+
+// source is a table or a derived table of the FROM clause.
+type source struct {
+	name    model.CIStr
+	columns []*model.ColumnInfo
+}
+
+// queryColumns returns the columns of the result of the query, whose names and
+// types are derived like MySQL, and the tables are in the current database unless
+// the schema is specified. The types of the expressions other than columns,
+// literals and CAST are inferred by analysis.InferTypes.
+func (c *Catalog) queryColumns(node ast.Node) ([]*model.ColumnInfo, error) {
+	if x, ok := node.(*ast.SetOprStmt); ok && len(x.SelectList.Selects) > 0 {
+		// the columns of a UNION are named by its first query.
+		return c.queryColumns(x.SelectList.Selects[0])
+	}
+	sel, ok := node.(*ast.SelectStmt)
+	if !ok || sel.Fields == nil {
+		return nil, errors.Errorf("can't derive the columns of %T", node)
+	}
+	// the types of the expressions are inferred like MySQL.
+	if _, err := analysis.InferTypes(sel, c); err != nil {
+		return nil, err
+	}
+	var sources []source
+	if sel.From != nil {
+		var err error
+		if sources, err = c.fromSources(sel.From.TableRefs); err != nil {
+			return nil, err
+		}
+	}
+	var columns []*model.ColumnInfo
+	for _, field := range sel.Fields.Fields {
+		if wildcard := field.WildCard; wildcard != nil {
+			if len(sources) == 0 {
+				return nil, ErrNoTablesUsed.GenWithStackByArgs()
+			}
+			matched := false
+			for _, src := range sources {
+				if wildcard.Table.L == "" || wildcard.Table.L == src.name.L {
+					matched = true
+					for _, col := range src.columns {
+						columns = append(columns, col.Clone())
+					}
+				}
+			}
+			if !matched {
+				return nil, ErrBadTable.GenWithStackByArgs(wildcard.Table.O)
+			}
+			continue
+		}
+		col, err := exprColumn(sources, field.Expr)
+		if err != nil {
+			return nil, err
+		}
+		col.Name = model.NewCIStr(fieldName(field))
+		columns = append(columns, col)
+	}
+	return columns, nil
+}
+
+// fromSources returns the sources of a FROM clause.
+func (c *Catalog) fromSources(node ast.ResultSetNode) ([]source, error) {
+	switch x := node.(type) {
+	case *ast.Join:
+		sources, err := c.fromSources(x.Left)
+		if err != nil || x.Right == nil {
+			return sources, err
+		}
+		right, err := c.fromSources(x.Right)
+		return append(sources, right...), err
+	case *ast.TableSource:
+		src := source{name: x.AsName}
+		switch s := x.Source.(type) {
+		case *ast.TableName:
+			if s.Schema.L == "" && s.Name.L == "dual" {
+				return nil, nil
+			}
+			_, tbl, err := c.table(s)
+			if err != nil {
+				return nil, err
+			}
+			src.columns = tbl.Columns
+			if src.name.L == "" {
+				src.name = tbl.Name
+			}
+		default:
+			var err error
+			if src.columns, err = c.queryColumns(s); err != nil {
+				return nil, err
+			}
+		}
+		return []source{src}, nil
+	}
+	return nil, nil
+}
+
+// exprColumn returns the column of the value of expr, whose name isn't set.
+func exprColumn(sources []source, expr ast.ExprNode) (*model.ColumnInfo, error) {
+	switch x := expr.(type) {
+	case *ast.ParenthesesExpr:
+		return exprColumn(sources, x.Expr)
+	case *ast.ColumnNameExpr:
+		var found *model.ColumnInfo
+		for _, src := range sources {
+			if x.Name.Table.L != "" && x.Name.Table.L != src.name.L {
+				continue
+			}
+			for _, col := range src.columns {
+				if col.Name.L != x.Name.Name.L {
+					continue
+				}
+				if found != nil {
+					return nil, ErrNonUniq.GenWithStackByArgs(x.Name.String(), "field list")
+				}
+				found = col
+			}
+		}
+		if found == nil {
+			return nil, ErrBadField.GenWithStackByArgs(x.Name.String(), "field list")
+		}
+		col := found.Clone()
+		// the keys, the auto-increment and the generated expression aren't copied.
+		col.Flag &^= mysql.PriKeyFlag | mysql.UniqueKeyFlag | mysql.MultipleKeyFlag | mysql.AutoIncrementFlag | mysql.OnUpdateNowFlag
+		col.GeneratedExprString, col.GeneratedStored, col.Dependences = "", false, nil
+		return col, nil
+	case ast.ValueExpr:
+		col := &model.ColumnInfo{FieldType: *x.GetType().Clone()}
+		if col.Tp == mysql.TypeNull {
+			col.FieldType = *types.NewFieldType(mysql.TypeString)
+			col.Flen, col.Charset, col.Collate = 0, charset.CharsetBin, charset.CollationBin
+		} else {
+			col.Flag |= mysql.NotNullFlag
+		}
+		if col.Tp == mysql.TypeVarString {
+			col.Tp = mysql.TypeVarchar
+		}
+		return col, nil
+	case *ast.FuncCastExpr:
+		return &model.ColumnInfo{FieldType: *x.Tp.Clone()}, nil
+	}
+	// such as the stored functions, whose types are unknown.
+	tp := expr.GetType()
+	if tp.Tp == mysql.TypeUnspecified || tp.Tp == mysql.TypeVarString && tp.Flen == types.UnspecifiedLength {
+		return nil, errors.Errorf("can't infer the type of %s", restore(expr))
+	}
+	col := &model.ColumnInfo{FieldType: *tp.Clone()}
+	if col.Tp == mysql.TypeVarString {
+		col.Tp = mysql.TypeVarchar
+	}
+	return col, nil
+}
+
+// fieldName returns the name of the column of a field like MySQL.
+func fieldName(field *ast.SelectField) string {
+	if field.AsName.L != "" {
+		return field.AsName.O
+	}
+	switch x := field.Expr.(type) {
+	case *ast.ColumnNameExpr:
+		return x.Name.Name.O
+	case ast.ValueExpr:
+		if x.GetType().Tp == mysql.TypeVarString {
+			return x.GetString()
+		}
+	}
+	if text := field.Text(); text != "" {
+		return text
+	}
+	return restore(field.Expr)
+}
+
+func (c *Catalog) alterTable(name *ast.TableName, specs []*ast.AlterTableSpec) error {
+	db, tbl, err := c.table(name)
+	if err != nil {
+		return err
+	}
+	if tbl.IsView() {
+		return ErrWrongObject.GenWithStackByArgs(db.Name.O, tbl.Name.O, "BASE TABLE")
+	}
+	altered, err := alterTable(tbl, specs, db)
+	if err != nil {
+		return err
+	}
+	var newName *ast.TableName
+	for _, spec := range specs {
+		if spec.Tp == ast.AlterTableRenameTable {
+			newName = spec.NewTable
+		}
+	}
+	if newName == nil {
+		replaceTable(db, tbl, altered)
+		return nil
+	}
+	newDB, err := c.schema(newName)
+	if err != nil {
+		return err
+	}
+	if t := findTable(newDB, newName.Name.L); t != nil && t != tbl {
+		return ErrTableExists.GenWithStackByArgs(newName.Name.O)
+	}
+	altered = renameTable(altered, newName.Name)
+	if newDB == db {
+		replaceTable(db, tbl, altered)
+		return nil
+	}
+	removeTable(db, tbl)
+	newDB.Tables = append(newDB.Tables[:len(newDB.Tables):len(newDB.Tables)], altered)
+	return nil
+}
+
+// renameTables renames the tables in order, which are all renamed or none is.
+func (c *Catalog) renameTables(stmt *ast.RenameTableStmt) error {
+	saved := make([][]*model.TableInfo, len(c.dbs))
+	for i, db := range c.dbs {
+		saved[i] = db.Tables
+	}
+	for _, t2t := range stmt.TableToTables {
+		if err := c.renameTable(t2t.OldTable, t2t.NewTable); err != nil {
+			for i, db := range c.dbs {
+				db.Tables = saved[i]
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Catalog) renameTable(oldName, newName *ast.TableName) error {
+	db, tbl, err := c.table(oldName)
+	if err != nil {
+		return err
+	}
+	newDB, err := c.schema(newName)
+	if err != nil {
+		return err
+	}
+	if t := findTable(newDB, newName.Name.L); t != nil {
+		return ErrTableExists.GenWithStackByArgs(newName.Name.O)
+	}
+	removeTable(db, tbl)
+	newDB.Tables = append(newDB.Tables[:len(newDB.Tables):len(newDB.Tables)], renameTable(tbl, newName.Name))
+	return nil
+}
+
+// dropTables drops the tables or the views, which are all dropped or none is like
+// MySQL 8.0.
+func (c *Catalog) dropTables(stmt *ast.DropTableStmt) error {
+	type dropped struct {
+		db  *model.DBInfo
+		tbl *model.TableInfo
+	}
+	var (
+		tables  []dropped
+		unknown []string
+	)
+	for _, name := range stmt.Tables {
+		// the tables of an unknown database are unknown.
+		db, schema := c.current, name.Schema.O
+		if schema != "" {
+			db = c.Database(schema)
+		} else if db == nil {
+			return ErrNoDB.GenWithStackByArgs()
+		} else {
+			schema = db.Name.O
+		}
+		var tbl *model.TableInfo
+		if db != nil {
+			tbl = findTable(db, name.Name.L)
+		}
+		if tbl != nil && stmt.IsView && !tbl.IsView() {
+			return ErrWrongObject.GenWithStackByArgs(schema, tbl.Name.O, "VIEW")
+		}
+		if tbl == nil || tbl.IsView() != stmt.IsView {
+			if !stmt.IfExists {
+				unknown = append(unknown, schema+"."+name.Name.O)
+			}
+			continue
+		}
+		tables = append(tables, dropped{db, tbl})
+	}
+	if len(unknown) > 0 {
+		return ErrBadTable.GenWithStackByArgs(strings.Join(unknown, ","))
+	}
+	for _, t := range tables {
+		removeTable(t.db, t.tbl)
+	}
+	return nil
+}
+
+func (c *Catalog) truncateTable(stmt *ast.TruncateTableStmt) error {
+	db, tbl, err := c.table(stmt.Table)
+	if err != nil {
+		return err
+	}
+	if tbl.IsView() {
+		return ErrNoSuchTable.GenWithStackByArgs(db.Name.O, tbl.Name.O)
+	}
+	truncated := cloneTable(tbl)
+	truncated.AutoIncID = 0
+	replaceTable(db, tbl, truncated)
+	return nil
+}
+
+func (c *Catalog) createIndex(stmt *ast.CreateIndexStmt) error {
+	cons := &ast.Constraint{
+		Tp:     ast.ConstraintIndex,
+		Name:   stmt.IndexName,
+		Keys:   stmt.IndexPartSpecifications,
+		Option: stmt.IndexOption,
+	}
+	switch stmt.KeyType {
+	case ast.IndexKeyTypeUnique:
+		cons.Tp = ast.ConstraintUniq
+	case ast.IndexKeyTypeSpatial:
+		cons.Tp = ast.ConstraintSPATIAL
+	case ast.IndexKeyTypeFullText:
+		cons.Tp = ast.ConstraintFulltext
+	}
+	if stmt.IfNotExists {
+		if _, tbl, err := c.table(stmt.Table); err == nil && tbl.FindIndexByName(stmt.IndexName) != nil {
+			return nil
+		}
+	}
+	spec := &ast.AlterTableSpec{Tp: ast.AlterTableAddConstraint, Constraint: cons}
+	return c.alterTable(stmt.Table, []*ast.AlterTableSpec{spec})
+}
+
+func (c *Catalog) createView(stmt *ast.CreateViewStmt) error {
+	db, err := c.schema(stmt.ViewName)
+	if err != nil {
+		return err
+	}
+	name := stmt.ViewName.Name
+	old := findTable(db, name.L)
+	if old != nil {
+		if !old.IsView() {
+			return ErrWrongObject.GenWithStackByArgs(db.Name.O, name.O, "VIEW")
+		}
+		if !stmt.OrReplace {
+			return ErrTableExists.GenWithStackByArgs(name.O)
+		}
+	}
+	columns, err := c.queryColumns(stmt.Select)
+	if err != nil {
+		return err
+	}
+	if len(stmt.Cols) > 0 && len(stmt.Cols) != len(columns) {
+		return ErrViewWrongList.GenWithStackByArgs()
+	}
+	view := &model.TableInfo{
+		Name:    name,
+		Charset: db.Charset,
+		Collate: db.Collate,
+		State:   model.StatePublic,
+		Version: model.CurrLatestTableInfoVersion,
+		View: &model.ViewInfo{
+			Algorithm:   stmt.Algorithm,
+			Definer:     stmt.Definer,
+			Security:    stmt.Security,
+			SelectStmt:  restore(stmt.Select),
+			CheckOption: stmt.CheckOption,
+			Cols:        stmt.Cols,
+		},
+	}
+	for i, col := range columns {
+		if len(stmt.Cols) > 0 {
+			col.Name = stmt.Cols[i]
+		}
+		for _, prev := range view.Columns {
+			if prev.Name.L == col.Name.L {
+				return ErrDupFieldName.GenWithStackByArgs(col.Name.O)
+			}
+		}
+		col.ID, col.Offset, col.State = int64(i+1), i, model.StatePublic
+		col.Flag &^= mysql.NoDefaultValueFlag
+		view.Columns = append(view.Columns, col)
+	}
+	if old != nil {
+		view.ID = old.ID
+		replaceTable(db, old, view)
+		return nil
+	}
+	c.addTable(db, view)
+	return nil
+}
@@ -0,0 +1,192 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog_test
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	. "github.com/pingcap/parser/catalog"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/mysql"
+)
+
+var _ = Suite(&testCatalogSuite{})
+
+type testCatalogSuite struct {
+}
+
+func exec(c *C, cat *Catalog, sql string) error {
+	stmts, _, err := parser.New().Parse(sql, "", "")
+	c.Assert(err, IsNil, Commentf("sql: %s", sql))
+	return cat.Apply(stmts...)
+}
+
+func newCatalog(c *C, sql string) *Catalog {
+	cat := New()
+	c.Assert(exec(c, cat, "create database test; use test;"+sql), IsNil, Commentf("sql: %s", sql))
+	return cat
+}
+
+// describe returns the columns, the indexes and the constraints of the table, such
+// as "id a b; PRIMARY(id) a(a,b)".
+func describe(tbl *model.TableInfo) string {
+	var cols, keys []string
+	for i, col := range tbl.Columns {
+		if col.Offset != i {
+			return fmt.Sprintf("bad offset of %s", col.Name)
+		}
+		cols = append(cols, col.Name.O)
+	}
+	if tbl.PKIsHandle {
+		keys = append(keys, fmt.Sprintf("PRIMARY(%s)", tbl.GetPkName()))
+	}
+	for _, idx := range tbl.Indices {
+		var names []string
+		for _, ic := range idx.Columns {
+			if tbl.Columns[ic.Offset].Name.L != ic.Name.L {
+				return fmt.Sprintf("bad offset of %s", ic.Name)
+			}
+			names = append(names, ic.Name.O)
+		}
+		keys = append(keys, fmt.Sprintf("%s(%s)", idx.Name, strings.Join(names, ",")))
+	}
+	for _, fk := range tbl.ForeignKeys {
+		keys = append(keys, fmt.Sprintf("FK %s", fk.Name))
+	}
+	for _, cons := range tbl.Constraints {
+		keys = append(keys, fmt.Sprintf("CHECK %s", cons.Name))
+	}
+	return strings.TrimSpace(strings.Join(cols, " ") + "; " + strings.Join(keys, " "))
+}
+
+func (s *testCatalogSuite) TestDatabases(c *C) {
+	cat := New()
+	c.Assert(exec(c, cat, "create table t (a int)"), ErrorMatches, ".*No database selected")
+	c.Assert(exec(c, cat, "use test"), ErrorMatches, ".*Unknown database 'test'")
+	c.Assert(exec(c, cat, "create database test; create database if not exists test; create database d charset latin1"), IsNil)
+	c.Assert(exec(c, cat, "create database TEST"), ErrorMatches, ".*Can't create database 'TEST'; database exists")
+	c.Assert(cat.Databases(), HasLen, 2)
+	c.Assert(cat.Database("test").Charset+" "+cat.Database("test").Collate, Equals, "utf8mb4 utf8mb4_0900_ai_ci")
+	c.Assert(cat.Database("d").Collate, Equals, "latin1_swedish_ci")
+
+	c.Assert(exec(c, cat, "use d; alter database collate latin1_general_ci; create table t (a varchar(10))"), IsNil)
+	c.Assert(cat.CurrentDatabase(), Equals, "d")
+	c.Assert(cat.Table("", "t").Collate, Equals, "latin1_general_ci")
+	c.Assert(cat.Table("d", "T").Columns[0].Charset, Equals, "latin1")
+	c.Assert(exec(c, cat, "alter database test charset utf8"), IsNil)
+	c.Assert(cat.Database("test").Collate, Equals, "utf8_general_ci")
+
+	c.Assert(exec(c, cat, "drop database d; drop database if exists d"), IsNil)
+	c.Assert(cat.CurrentDatabase(), Equals, "")
+	c.Assert(exec(c, cat, "drop database d"), ErrorMatches, ".*Can't drop database 'd'; database doesn't exist")
+	c.Assert(exec(c, cat, "create table d.t (a int)"), ErrorMatches, ".*Unknown database 'd'")
+}
+
+func (s *testCatalogSuite) TestTables(c *C) {
+	cat := newCatalog(c, `
+		create table p (id int primary key, name varchar(20) not null default 'x' comment 'n', v int as (id + 1));
+		create table t (id bigint auto_increment primary key, a int, key (a), foreign key (a) references p (id)) auto_increment = 10;
+		create table if not exists t (b int);
+		create table l like t;
+		create database d;
+		create table d.s (x int);`)
+	c.Assert(describe(cat.Table("test", "t")), Equals, "id a; PRIMARY(id) a(a) FK t_ibfk_1")
+	c.Assert(exec(c, cat, "create table T (a int)"), ErrorMatches, ".*Table 'T' already exists")
+
+	l := cat.Table("test", "l")
+	c.Assert(describe(l), Equals, "id a; PRIMARY(id) a(a)")
+	c.Assert(l.AutoIncID, Equals, int64(0))
+	c.Assert(l.ID, Not(Equals), cat.Table("test", "t").ID)
+	c.Assert(exec(c, cat, "create table l2 like nope"), ErrorMatches, ".*Table 'test.nope' doesn't exist")
+
+	c.Assert(exec(c, cat, "create table s (id int, extra int) select p.*, d.s.x, 1 as one, 'abc', null as n, cast(id as char(5)) c5, upper(name), concat(name, 'x') cx, id + 1 i1 from p join d.s"), IsNil)
+	sel := cat.Table("test", "s")
+	c.Assert(describe(sel), Equals, "id extra name v x one abc n c5 upper(name) cx i1;")
+	cols := sel.Columns
+	c.Assert(cols[0].Tp, Equals, mysql.TypeLong)
+	c.Assert(mysql.HasPriKeyFlag(cols[0].Flag), IsFalse)
+	c.Assert(cols[2].Tp, Equals, mysql.TypeVarchar)
+	c.Assert(cols[2].Flen, Equals, 20)
+	c.Assert(mysql.HasNotNullFlag(cols[2].Flag), IsTrue)
+	c.Assert(cols[2].DefaultValue, Equals, "x")
+	c.Assert(cols[2].Comment, Equals, "n")
+	c.Assert(cols[3].IsGenerated(), IsFalse)
+	c.Assert(cols[5].Tp, Equals, mysql.TypeLonglong)
+	c.Assert(mysql.HasNotNullFlag(cols[5].Flag), IsTrue)
+	c.Assert(cols[6].Tp, Equals, mysql.TypeVarchar)
+	c.Assert(cols[6].Flen, Equals, 3)
+	c.Assert(cols[7].Tp, Equals, mysql.TypeString)
+	c.Assert(cols[7].Charset, Equals, "binary")
+	c.Assert(cols[8].Tp, Equals, mysql.TypeVarString)
+	c.Assert(cols[8].Flen, Equals, 5)
+	c.Assert(cols[9].Tp, Equals, mysql.TypeVarchar)
+	c.Assert(cols[9].Flen, Equals, 20)
+	c.Assert(cols[9].Charset, Equals, "utf8mb4")
+	c.Assert(cols[10].Tp, Equals, mysql.TypeVarchar)
+	c.Assert(cols[10].Flen, Equals, 21)
+	c.Assert(mysql.HasNotNullFlag(cols[10].Flag), IsTrue)
+	c.Assert(cols[11].Tp, Equals, mysql.TypeLonglong)
+	c.Assert(mysql.HasNotNullFlag(cols[11].Flag), IsTrue)
+	c.Assert(exec(c, cat, "create table s2 select x from p"), ErrorMatches, ".*Unknown column 'x' in 'field list'")
+	c.Assert(exec(c, cat, "create table s2 select id from p, t"), ErrorMatches, ".*Column 'id' in field list is ambiguous")
+	c.Assert(exec(c, cat, "create table s2 select id, id from p"), ErrorMatches, ".*Duplicate column name 'id'")
+	c.Assert(exec(c, cat, "create table s2 select f(id) from p"), ErrorMatches, "can't infer the type of f\\(`id`\\)")
+
+	c.Assert(exec(c, cat, "rename table t to t2, l to t, t2 to l, d.s to s3"), IsNil)
+	c.Assert(describe(cat.Table("test", "t")), Equals, "id a; PRIMARY(id) a(a)")
+	c.Assert(cat.Table("test", "l").ForeignKeys, HasLen, 1)
+	c.Assert(cat.Table("test", "s3"), NotNil)
+	c.Assert(cat.Table("d", "s"), IsNil)
+	c.Assert(exec(c, cat, "rename table s3 to d.s, t to l"), ErrorMatches, ".*Table 'l' already exists")
+	c.Assert(cat.Table("test", "s3"), NotNil)
+	c.Assert(exec(c, cat, "rename table nope to x"), ErrorMatches, ".*Table 'test.nope' doesn't exist")
+
+	c.Assert(exec(c, cat, "truncate table l"), IsNil)
+	c.Assert(cat.Table("test", "l").AutoIncID, Equals, int64(0))
+
+	c.Assert(exec(c, cat, "drop table t, nope, d.nope"), ErrorMatches, `.*Unknown table 'test.nope,d.nope'`)
+	c.Assert(cat.Table("test", "t"), NotNil)
+	c.Assert(exec(c, cat, "drop table if exists t, nope"), IsNil)
+	c.Assert(cat.Table("test", "t"), IsNil)
+}
+
+func (s *testCatalogSuite) TestViews(c *C) {
+	cat := newCatalog(c, `
+		create table t (id int primary key, a varchar(10));
+		create view v as select id, a as name from t where id > 1;
+		create view v2 (x, y) as select * from t`)
+	v := cat.Table("test", "v")
+	c.Assert(v.IsView(), IsTrue)
+	c.Assert(describe(v), Equals, "id name;")
+	c.Assert(v.View.SelectStmt, Equals, "select `id`,`a` as `name` from `t` where `id` > 1")
+	c.Assert(mysql.HasPriKeyFlag(v.Columns[0].Flag), IsFalse)
+	c.Assert(describe(cat.Table("test", "v2")), Equals, "x y;")
+
+	c.Assert(exec(c, cat, "create view v as select 1"), ErrorMatches, ".*Table 'v' already exists")
+	c.Assert(exec(c, cat, "create or replace view v as select 1 as one"), IsNil)
+	c.Assert(describe(cat.Table("test", "v")), Equals, "one;")
+	c.Assert(exec(c, cat, "create or replace view t as select 1"), ErrorMatches, ".*'test.t' is not VIEW")
+	c.Assert(exec(c, cat, "create view v3 (x) as select * from t"), ErrorMatches, ".*View's SELECT and view's field list have different column counts")
+	c.Assert(exec(c, cat, "create view v3 as select id, a as id from t"), ErrorMatches, ".*Duplicate column name 'id'")
+	c.Assert(exec(c, cat, "create view v3 as select *"), ErrorMatches, ".*No tables used")
+	c.Assert(exec(c, cat, "alter table v add column b int"), ErrorMatches, ".*'test.v' is not BASE TABLE")
+
+	c.Assert(exec(c, cat, "drop view t"), ErrorMatches, ".*'test.t' is not VIEW")
+	c.Assert(exec(c, cat, "drop table v"), ErrorMatches, ".*Unknown table 'test.v'")
+	c.Assert(exec(c, cat, "drop view v, v2"), IsNil)
+	c.Assert(cat.Table("test", "v"), IsNil)
+}
@@ -0,0 +1,875 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/charset"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/types"
+)
+
+// DiffOptions are the options of DiffTables.
+type DiffOptions struct {
+	// Renames are the columns renamed, from the old names to the new names.
+	Renames map[string]string
+	// NoDestructive forbids the changes which may lose data, which are dropping the
+	// columns and the partitions, and changing the columns to the types which can't
+	// hold all the values.
+	NoDestructive bool
+	// Split splits the changes into the statements of a change each.
+	Split bool
+}
+
+// DiffCreateTables returns the ALTER TABLE statements which transform the table
+// created by from into the table created by to, see DiffTables.
+func DiffCreateTables(from, to *ast.CreateTableStmt, opts DiffOptions) ([]*ast.AlterTableStmt, error) {
+	fromTable, err := BuildTableInfo(from, nil)
+	if err != nil {
+		return nil, err
+	}
+	toTable, err := BuildTableInfo(to, nil)
+	if err != nil {
+		return nil, err
+	}
+	return DiffTables(fromTable, toTable, opts)
+}
+
+// DiffTables returns the ALTER TABLE statements which transform the table from into
+// the table to, which are applied in order. The changes are in one statement unless
+// they are split, except the changes of the partitions, which are in their own
+// statements like MySQL requires.
+//
+// The columns are matched by name, by the renames of the options, or by ID if the
+// definitions are identical except the names and the tables have the same ID, such
+// as the columns of a table of a Catalog renamed by RENAME COLUMN. The expressions
+// are parsed by the parser, so a driver such as test_driver must be imported.
+func DiffTables(from, to *model.TableInfo, opts DiffOptions) ([]*ast.AlterTableStmt, error) {
+	d := &differ{from: from, to: to, opts: opts}
+	if err := d.diff(); err != nil {
+		return nil, err
+	}
+	return d.statements(), nil
+}
+
+type differ struct {
+	from, to *model.TableInfo
+	opts     DiffOptions
+	// matched are the columns of from matched by the columns of to, and renamed are
+	// the new names of the columns of from, which are empty if they are dropped.
+	matched map[*model.ColumnInfo]*model.ColumnInfo
+	renamed map[string]string
+	// drops, columns, indexes, constraints, options and partitions are the changes
+	// in order.
+	drops, columns, indexes, constraints, options, partitions []*ast.AlterTableSpec
+}
+
+func (d *differ) diff() error {
+	d.matchColumns()
+	for _, fn := range []func() error{d.diffForeignKeys, d.diffChecks, d.diffIndexes, d.diffColumns, d.diffOptions, d.diffPartitions} {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *differ) statements() []*ast.AlterTableStmt {
+	var stmts []*ast.AlterTableStmt
+	name := d.from.Name
+	add := func(specs ...*ast.AlterTableSpec) {
+		if len(specs) > 0 {
+			stmts = append(stmts, &ast.AlterTableStmt{Table: &ast.TableName{Name: name}, Specs: specs})
+		}
+	}
+	var specs []*ast.AlterTableSpec
+	for _, changes := range [][]*ast.AlterTableSpec{d.drops, d.columns, d.indexes, d.constraints, d.options} {
+		specs = append(specs, changes...)
+	}
+	rename := d.from.Name.L != d.to.Name.L
+	if rename && len(d.partitions) == 0 && !d.opts.Split {
+		specs = append(specs, &ast.AlterTableSpec{Tp: ast.AlterTableRenameTable, NewTable: &ast.TableName{Name: d.to.Name}})
+		rename = false
+	}
+	if d.opts.Split {
+		for _, spec := range specs {
+			add(spec)
+		}
+	} else {
+		add(specs...)
+	}
+	for _, spec := range d.partitions {
+		add(spec)
+	}
+	if rename {
+		add(&ast.AlterTableSpec{Tp: ast.AlterTableRenameTable, NewTable: &ast.TableName{Name: d.to.Name}})
+	}
+	return stmts
+}
+
+// destructive returns the error if the destructive changes are forbidden.
+func (d *differ) destructive(spec *ast.AlterTableSpec) error {
+	if !d.opts.NoDestructive {
+		return nil
+	}
+	stmt := &ast.AlterTableStmt{Table: &ast.TableName{Name: d.from.Name}, Specs: []*ast.AlterTableSpec{spec}}
+	return errors.Errorf("the destructive change is forbidden: %s", restore(stmt))
+}
+
+// matchColumns matches the columns of from by the columns of to.
+func (d *differ) matchColumns() {
+	d.matched = make(map[*model.ColumnInfo]*model.ColumnInfo)
+	renames := make(map[string]string)
+	for old, name := range d.opts.Renames {
+		renames[strings.ToLower(old)] = strings.ToLower(name)
+	}
+	used := make(map[*model.ColumnInfo]bool)
+	for _, col := range d.to.Columns {
+		for _, old := range d.from.Columns {
+			name, ok := renames[old.Name.L]
+			if !ok {
+				name = old.Name.L
+			}
+			if name == col.Name.L && !used[old] {
+				d.matched[col], used[old] = old, true
+				break
+			}
+		}
+	}
+	for _, col := range d.to.Columns {
+		if d.matched[col] != nil || d.from.ID != d.to.ID || d.from.ID == 0 {
+			continue
+		}
+		for _, old := range d.from.Columns {
+			if !used[old] && old.ID == col.ID && d.definition(old, nil) == d.definition(col, nil) {
+				d.matched[col], used[old] = old, true
+				break
+			}
+		}
+	}
+	d.renamed = make(map[string]string)
+	for col, old := range d.matched {
+		d.renamed[old.Name.L] = col.Name.O
+	}
+}
+
+// definition returns the text of the definition of the column except the name,
+// whose charset is explicit.
+func (d *differ) definition(col *model.ColumnInfo, err *error) string {
+	def, e := columnDef(col, nil)
+	if e != nil {
+		if err != nil && *err == nil {
+			*err = e
+		}
+		return ""
+	}
+	def.Name = &ast.ColumnName{}
+	return restore(def)
+}
+
+func (d *differ) diffColumns() error {
+	tbl := d.from
+	for _, old := range tbl.Columns {
+		if _, ok := d.renamed[old.Name.L]; ok {
+			continue
+		}
+		spec := &ast.AlterTableSpec{Tp: ast.AlterTableDropColumn, OldColumnName: &ast.ColumnName{Name: old.Name}}
+		if err := d.destructive(spec); err != nil {
+			return err
+		}
+		d.columns = append(d.columns, spec)
+	}
+	// the columns in the longest sequence of increasing positions aren't moved.
+	var positions []int
+	for _, col := range d.to.Columns {
+		if old := d.matched[col]; old != nil {
+			positions = append(positions, old.Offset)
+		}
+	}
+	fixed := make(map[int]bool)
+	for _, i := range longestIncreasing(positions) {
+		fixed[positions[i]] = true
+	}
+	// current are the columns of to in the order of the table changed.
+	var current []*model.ColumnInfo
+	for _, old := range tbl.Columns {
+		for col, o := range d.matched {
+			if o == old {
+				current = append(current, col)
+			}
+		}
+	}
+	for i, col := range d.to.Columns {
+		var prev *model.ColumnInfo
+		if i > 0 {
+			prev = d.to.Columns[i-1]
+		}
+		pos := &ast.ColumnPosition{Tp: ast.ColumnPositionFirst}
+		if prev != nil {
+			pos = &ast.ColumnPosition{Tp: ast.ColumnPositionAfter, RelativeColumn: &ast.ColumnName{Name: prev.Name}}
+		}
+		old := d.matched[col]
+		moved := old == nil || !fixed[old.Offset]
+		if moved {
+			current = moveColumn(current, col, prev)
+			if old == nil && current[len(current)-1] == col && prev != nil {
+				pos = &ast.ColumnPosition{Tp: ast.ColumnPositionNone}
+			}
+		} else {
+			pos = &ast.ColumnPosition{Tp: ast.ColumnPositionNone}
+		}
+		def, err := columnDef(col, d.charsetTable())
+		if err != nil {
+			return err
+		}
+		if old == nil {
+			d.columns = append(d.columns, &ast.AlterTableSpec{Tp: ast.AlterTableAddColumns, NewColumns: []*ast.ColumnDef{def}, Position: pos})
+			continue
+		}
+		var err2 error
+		changed := d.definition(old, &err2) != d.definition(col, &err2)
+		if err2 != nil {
+			return err2
+		}
+		renamed := old.Name.O != col.Name.O
+		var spec *ast.AlterTableSpec
+		switch {
+		case !changed && !moved && renamed:
+			spec = &ast.AlterTableSpec{Tp: ast.AlterTableRenameColumn, OldColumnName: &ast.ColumnName{Name: old.Name}, NewColumnName: &ast.ColumnName{Name: col.Name}}
+		case renamed:
+			spec = &ast.AlterTableSpec{Tp: ast.AlterTableChangeColumn, OldColumnName: &ast.ColumnName{Name: old.Name}, NewColumns: []*ast.ColumnDef{def}, Position: pos}
+		case changed || moved:
+			spec = &ast.AlterTableSpec{Tp: ast.AlterTableModifyColumn, NewColumns: []*ast.ColumnDef{def}, Position: pos}
+		default:
+			continue
+		}
+		if changed && lossy(&old.FieldType, &col.FieldType) {
+			if err := d.destructive(spec); err != nil {
+				return err
+			}
+		}
+		d.columns = append(d.columns, spec)
+	}
+	return nil
+}
+
+// charsetTable returns the table whose charset and collation are omitted in the
+// column definitions, which is nil if the charset of the table is changed.
+func (d *differ) charsetTable() *model.TableInfo {
+	if d.from.Charset != d.to.Charset || d.from.Collate != d.to.Collate {
+		return nil
+	}
+	return d.to
+}
+
+// moveColumn moves or inserts the column after prev, or to the first if prev is nil.
+func moveColumn(cols []*model.ColumnInfo, col, prev *model.ColumnInfo) []*model.ColumnInfo {
+	moved := make([]*model.ColumnInfo, 0, len(cols)+1)
+	if prev == nil {
+		moved = append(moved, col)
+	}
+	for _, c := range cols {
+		if c == col {
+			continue
+		}
+		moved = append(moved, c)
+		if c == prev {
+			moved = append(moved, col)
+		}
+	}
+	return moved
+}
+
+// longestIncreasing returns the indexes of a longest increasing subsequence.
+func longestIncreasing(a []int) []int {
+	lengths, prevs := make([]int, len(a)), make([]int, len(a))
+	best := -1
+	for i := range a {
+		lengths[i], prevs[i] = 1, -1
+		for j := 0; j < i; j++ {
+			if a[j] < a[i] && lengths[j]+1 > lengths[i] {
+				lengths[i], prevs[i] = lengths[j]+1, j
+			}
+		}
+		if best < 0 || lengths[i] > lengths[best] {
+			best = i
+		}
+	}
+	var indexes []int
+	for i := best; i >= 0; i = prevs[i] {
+		indexes = append([]int{i}, indexes...)
+	}
+	return indexes
+}
+
+// columnDef returns the definition of the column, whose charset and collation are
+// omitted if they are the defaults of tbl.
+func columnDef(col *model.ColumnInfo, tbl *model.TableInfo) (*ast.ColumnDef, error) {
+	tp := col.FieldType.Clone()
+	tp.Flag &= mysql.UnsignedFlag | mysql.ZerofillFlag | mysql.BinaryFlag
+	switch {
+	case mysql.IsIntegerType(tp.Tp) && !mysql.HasZerofillFlag(tp.Flag), tp.Tp == mysql.TypeYear, types.IsTypeBlob(tp.Tp):
+		// the display widths and the lengths of the blobs are omitted.
+		tp.Flen = types.UnspecifiedLength
+	case tp.Tp == mysql.TypeFloat || tp.Tp == mysql.TypeDouble:
+		if tp.Decimal == types.UnspecifiedLength {
+			tp.Flen = types.UnspecifiedLength
+		}
+	case isTimeType(tp.Tp):
+		if tp.Decimal == 0 {
+			tp.Decimal = types.UnspecifiedLength
+		}
+	}
+	if tbl != nil && tp.Charset == tbl.Charset && tp.Collate == tbl.Collate {
+		tp.Charset, tp.Collate = "", ""
+	}
+	def := &ast.ColumnDef{Name: &ast.ColumnName{Name: col.Name}, Tp: tp}
+	addOption := func(tp ast.ColumnOptionType, text string) error {
+		opt := &ast.ColumnOption{Tp: tp}
+		if text != "" {
+			expr, err := parseExpr(text)
+			if err != nil {
+				return err
+			}
+			opt.Expr = expr
+		}
+		def.Options = append(def.Options, opt)
+		return nil
+	}
+	if col.IsGenerated() {
+		if err := addOption(ast.ColumnOptionGenerated, col.GeneratedExprString); err != nil {
+			return nil, err
+		}
+		def.Options[0].Stored = col.GeneratedStored
+	}
+	if mysql.HasNotNullFlag(col.Flag) {
+		def.Options = append(def.Options, &ast.ColumnOption{Tp: ast.ColumnOptionNotNull})
+	}
+	if col.DefaultValue != nil {
+		if err := addOption(ast.ColumnOptionDefaultValue, defaultText(col)); err != nil {
+			return nil, err
+		}
+	}
+	if mysql.HasAutoIncrementFlag(col.Flag) {
+		def.Options = append(def.Options, &ast.ColumnOption{Tp: ast.ColumnOptionAutoIncrement})
+	}
+	if mysql.HasOnUpdateNowFlag(col.Flag) {
+		now := "CURRENT_TIMESTAMP"
+		if col.Decimal > 0 {
+			now = fmt.Sprintf("CURRENT_TIMESTAMP(%d)", col.Decimal)
+		}
+		if err := addOption(ast.ColumnOptionOnUpdate, now); err != nil {
+			return nil, err
+		}
+	}
+	if col.Comment != "" {
+		if err := addOption(ast.ColumnOptionComment, quote(col.Comment)); err != nil {
+			return nil, err
+		}
+	}
+	return def, nil
+}
+
+// isTimeType reports whether tp is a type with fractional seconds.
+func isTimeType(tp byte) bool {
+	switch tp {
+	case mysql.TypeDatetime, mysql.TypeTimestamp, mysql.TypeDuration:
+		return true
+	}
+	return false
+}
+
+// defaultText returns the text of the default value of the column.
+func defaultText(col *model.ColumnInfo) string {
+	s := fmt.Sprint(col.DefaultValue)
+	switch {
+	case col.DefaultIsExpr, strings.HasPrefix(strings.ToUpper(s), "CURRENT_TIMESTAMP") && isTimeType(col.Tp):
+		return s
+	case col.Tp == mysql.TypeBit:
+		return bitLiteral(fmt.Sprint(col.GetDefaultValue()))
+	case mysql.IsIntegerType(col.Tp) || col.Tp == mysql.TypeNewDecimal || col.Tp == mysql.TypeFloat || col.Tp == mysql.TypeDouble:
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			return s
+		}
+	}
+	return quote(s)
+}
+
+// quote returns the string literal of s.
+func quote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", "''").Replace(s) + "'"
+}
+
+// parseExpr parses the text of an expression.
+func parseExpr(text string) (ast.ExprNode, error) {
+	expr, _, err := parser.New().ParseExpr(text)
+	return expr, err
+}
+
+// parseSpec parses the text of an ALTER TABLE spec.
+func parseSpec(text string) (*ast.AlterTableSpec, error) {
+	stmt, err := parser.New().ParseOneStmt("ALTER TABLE t "+text, "", "")
+	if err != nil {
+		return nil, err
+	}
+	return stmt.(*ast.AlterTableStmt).Specs[0], nil
+}
+
+// lossy reports whether changing the type from a to b may lose data.
+func lossy(a, b *types.FieldType) bool {
+	isString := func(tp *types.FieldType) bool {
+		return types.IsTypeChar(tp.Tp) || types.IsTypeBlob(tp.Tp)
+	}
+	switch {
+	case mysql.IsIntegerType(a.Tp) && mysql.IsIntegerType(b.Tp):
+		return mysql.HasUnsignedFlag(a.Flag) != mysql.HasUnsignedFlag(b.Flag) || integerRank(b.Tp) < integerRank(a.Tp)
+	case isString(a) && isString(b):
+		if (a.Charset == charset.CharsetBin) != (b.Charset == charset.CharsetBin) {
+			return true
+		}
+		if a.Charset != b.Charset && b.Charset != charset.CharsetUTF8MB4 {
+			return true
+		}
+		return maxLength(b) < maxLength(a)
+	case a.Tp != b.Tp:
+		return !(a.Tp == mysql.TypeFloat && b.Tp == mysql.TypeDouble)
+	case a.Tp == mysql.TypeEnum || a.Tp == mysql.TypeSet:
+		elems := make(map[string]bool)
+		for _, elem := range b.Elems {
+			elems[elem] = true
+		}
+		for _, elem := range a.Elems {
+			if !elems[elem] {
+				return true
+			}
+		}
+		return false
+	case a.Tp == mysql.TypeNewDecimal:
+		return b.Flen-b.Decimal < a.Flen-a.Decimal || b.Decimal < a.Decimal
+	}
+	return b.Flen < a.Flen || b.Decimal < a.Decimal
+}
+
+func integerRank(tp byte) int {
+	return strings.Index(string([]byte{mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong}), string([]byte{tp}))
+}
+
+// maxLength returns the max length of the values of a string type.
+func maxLength(tp *types.FieldType) int {
+	switch tp.Tp {
+	case mysql.TypeTinyBlob:
+		return 1<<8 - 1
+	case mysql.TypeBlob:
+		return 1<<16 - 1
+	case mysql.TypeMediumBlob:
+		return 1<<24 - 1
+	case mysql.TypeLongBlob:
+		return 1<<32 - 1
+	}
+	return tp.Flen
+}
+
+// indexes returns the indexes of the table, including the primary key of the handle.
+func indexes(tbl *model.TableInfo) []*model.IndexInfo {
+	idxs := tbl.Indices
+	if tbl.PKIsHandle {
+		if col := tbl.GetPkColInfo(); col != nil {
+			pk := &model.IndexInfo{
+				Name:    model.NewCIStr(mysql.PrimaryKeyName),
+				Columns: []*model.IndexColumn{{Name: col.Name, Length: types.UnspecifiedLength}},
+				Primary: true,
+				Unique:  true,
+				Tp:      model.IndexTypeBtree,
+			}
+			idxs = append([]*model.IndexInfo{pk}, idxs...)
+		}
+	}
+	return idxs
+}
+
+// indexConstraint returns the constraint of the index, whose columns are renamed.
+func indexConstraint(idx *model.IndexInfo, renamed map[string]string) *ast.Constraint {
+	cons := &ast.Constraint{Tp: ast.ConstraintIndex, Name: idx.Name.O, Option: &ast.IndexOption{Comment: idx.Comment}}
+	switch {
+	case idx.Primary:
+		cons.Tp, cons.Name = ast.ConstraintPrimaryKey, ""
+	case idx.Unique:
+		cons.Tp = ast.ConstraintUniq
+	case idx.Fulltext:
+		cons.Tp = ast.ConstraintFulltext
+	case idx.Tp == model.IndexTypeRtree:
+		cons.Tp = ast.ConstraintSPATIAL
+	}
+	if idx.Tp == model.IndexTypeHash {
+		cons.Option.Tp = idx.Tp
+	}
+	if idx.Invisible {
+		cons.Option.Visibility = ast.IndexVisibilityInvisible
+	}
+	for _, ic := range idx.Columns {
+		name := ic.Name
+		if renamed != nil {
+			name = model.NewCIStr(renamed[ic.Name.L])
+		}
+		cons.Keys = append(cons.Keys, &ast.IndexPartSpecification{Column: &ast.ColumnName{Name: name}, Length: ic.Length})
+	}
+	if *cons.Option == (ast.IndexOption{}) {
+		cons.Option = nil
+	}
+	return cons
+}
+
+// indexText returns the text of the constraint except the name and the visibility.
+func indexText(cons *ast.Constraint) string {
+	c := *cons
+	c.Name = ""
+	if c.Option != nil {
+		option := *c.Option
+		option.Visibility, c.Option = ast.IndexVisibilityDefault, &option
+		if option == (ast.IndexOption{}) {
+			c.Option = nil
+		}
+	}
+	return restore(&c)
+}
+
+func (d *differ) diffIndexes() error {
+	olds, news := indexes(d.from), indexes(d.to)
+	oldCons := make(map[*model.IndexInfo]*ast.Constraint)
+	matched := make(map[*model.IndexInfo]*model.IndexInfo)
+	for _, old := range olds {
+		oldCons[old] = indexConstraint(old, d.renamed)
+		for _, idx := range news {
+			if idx.Name.L == old.Name.L {
+				matched[idx] = old
+			}
+		}
+	}
+	// the indexes not matched by name are renamed if they are identical.
+	used := make(map[*model.IndexInfo]bool)
+	for _, old := range matched {
+		used[old] = true
+	}
+	for _, idx := range news {
+		if matched[idx] != nil || idx.Primary {
+			continue
+		}
+		text := indexText(indexConstraint(idx, nil))
+		for _, old := range olds {
+			if !used[old] && !old.Primary && indexText(oldCons[old]) == text {
+				matched[idx], used[old] = old, true
+				break
+			}
+		}
+	}
+	for _, old := range olds {
+		if !used[old] {
+			d.drops = append(d.drops, dropIndex(old))
+		}
+	}
+	for _, idx := range news {
+		cons := indexConstraint(idx, nil)
+		old := matched[idx]
+		switch {
+		case old == nil:
+		case indexText(oldCons[old]) != indexText(cons):
+			d.drops = append(d.drops, dropIndex(old))
+		default:
+			if old.Name.L != idx.Name.L {
+				d.indexes = append(d.indexes, &ast.AlterTableSpec{Tp: ast.AlterTableRenameIndex, FromKey: old.Name, ToKey: idx.Name})
+			}
+			if old.Invisible != idx.Invisible {
+				d.indexes = append(d.indexes, &ast.AlterTableSpec{Tp: ast.AlterTableIndexInvisible, IndexName: idx.Name, Visibility: visibility(idx)})
+			}
+			continue
+		}
+		d.indexes = append(d.indexes, &ast.AlterTableSpec{Tp: ast.AlterTableAddConstraint, Constraint: cons})
+	}
+	return nil
+}
+
+func visibility(idx *model.IndexInfo) ast.IndexVisibility {
+	if idx.Invisible {
+		return ast.IndexVisibilityInvisible
+	}
+	return ast.IndexVisibilityVisible
+}
+
+func dropIndex(idx *model.IndexInfo) *ast.AlterTableSpec {
+	if idx.Primary {
+		return &ast.AlterTableSpec{Tp: ast.AlterTableDropPrimaryKey}
+	}
+	return &ast.AlterTableSpec{Tp: ast.AlterTableDropIndex, Name: idx.Name.O}
+}
+
+// foreignKeyConstraint returns the constraint of the foreign key, whose columns are
+// renamed.
+func foreignKeyConstraint(fk *model.FKInfo, renamed map[string]string) *ast.Constraint {
+	refer := &ast.ReferenceDef{
+		Table:    &ast.TableName{Name: fk.RefTable},
+		OnDelete: &ast.OnDeleteOpt{ReferOpt: ast.ReferOptionType(fk.OnDelete)},
+		OnUpdate: &ast.OnUpdateOpt{ReferOpt: ast.ReferOptionType(fk.OnUpdate)},
+	}
+	for _, col := range fk.RefCols {
+		refer.IndexPartSpecifications = append(refer.IndexPartSpecifications, &ast.IndexPartSpecification{Column: &ast.ColumnName{Name: col}, Length: types.UnspecifiedLength})
+	}
+	cons := &ast.Constraint{Tp: ast.ConstraintForeignKey, Name: fk.Name.O, Refer: refer}
+	for _, col := range fk.Cols {
+		if renamed != nil {
+			col = model.NewCIStr(renamed[col.L])
+		}
+		cons.Keys = append(cons.Keys, &ast.IndexPartSpecification{Column: &ast.ColumnName{Name: col}, Length: types.UnspecifiedLength})
+	}
+	return cons
+}
+
+func (d *differ) diffForeignKeys() error {
+	news := make(map[string]*model.FKInfo)
+	for _, fk := range d.to.ForeignKeys {
+		news[fk.Name.L] = fk
+	}
+	olds := make(map[string]*model.FKInfo)
+	for _, fk := range d.from.ForeignKeys {
+		olds[fk.Name.L] = fk
+		if idx := news[fk.Name.L]; idx == nil || restore(foreignKeyConstraint(fk, d.renamed)) != restore(foreignKeyConstraint(idx, nil)) {
+			d.drops = append(d.drops, &ast.AlterTableSpec{Tp: ast.AlterTableDropForeignKey, Name: fk.Name.O})
+			delete(olds, fk.Name.L)
+		}
+	}
+	for _, fk := range d.to.ForeignKeys {
+		if olds[fk.Name.L] == nil {
+			d.constraints = append(d.constraints, &ast.AlterTableSpec{Tp: ast.AlterTableAddConstraint, Constraint: foreignKeyConstraint(fk, nil)})
+		}
+	}
+	return nil
+}
+
+func (d *differ) diffChecks() error {
+	olds := make(map[string]*model.ConstraintInfo)
+	for _, cons := range d.from.Constraints {
+		olds[cons.Name.L] = cons
+	}
+	for _, cons := range d.from.Constraints {
+		c := d.to.FindConstraintInfoByName(cons.Name.L)
+		if c == nil || c.ExprString != cons.ExprString {
+			d.drops = append(d.drops, &ast.AlterTableSpec{Tp: ast.AlterTableDropCheck, Constraint: &ast.Constraint{Name: cons.Name.O}})
+			delete(olds, cons.Name.L)
+		}
+	}
+	for _, cons := range d.to.Constraints {
+		old := olds[cons.Name.L]
+		if old == nil {
+			expr, err := parseExpr(cons.ExprString)
+			if err != nil {
+				return err
+			}
+			c := &ast.Constraint{Tp: ast.ConstraintCheck, Name: cons.Name.O, Expr: expr, Enforced: cons.Enforced}
+			d.constraints = append(d.constraints, &ast.AlterTableSpec{Tp: ast.AlterTableAddConstraint, Constraint: c})
+		} else if old.Enforced != cons.Enforced {
+			c := &ast.Constraint{Name: cons.Name.O, Enforced: cons.Enforced}
+			d.constraints = append(d.constraints, &ast.AlterTableSpec{Tp: ast.AlterTableAlterCheck, Constraint: c})
+		}
+	}
+	return nil
+}
+
+// rowFormats are the row formats of MySQL.
+var rowFormats = map[string]uint64{
+	"DEFAULT":    ast.RowFormatDefault,
+	"DYNAMIC":    ast.RowFormatDynamic,
+	"FIXED":      ast.RowFormatFixed,
+	"COMPRESSED": ast.RowFormatCompressed,
+	"REDUNDANT":  ast.RowFormatRedundant,
+	"COMPACT":    ast.RowFormatCompact,
+}
+
+func (d *differ) diffOptions() error {
+	from, to := d.from, d.to
+	var options []*ast.TableOption
+	if from.Engine != to.Engine && to.Engine != "" {
+		options = append(options, &ast.TableOption{Tp: ast.TableOptionEngine, StrValue: to.Engine})
+	}
+	if from.Charset != to.Charset || from.Collate != to.Collate {
+		options = append(options,
+			&ast.TableOption{Tp: ast.TableOptionCharset, StrValue: to.Charset},
+			&ast.TableOption{Tp: ast.TableOptionCollate, StrValue: to.Collate})
+	}
+	if from.RowFormat != to.RowFormat {
+		if format, ok := rowFormats[strings.ToUpper(to.RowFormat)]; ok {
+			options = append(options, &ast.TableOption{Tp: ast.TableOptionRowFormat, UintValue: format})
+		}
+	}
+	if from.KeyBlockSize != to.KeyBlockSize {
+		options = append(options, &ast.TableOption{Tp: ast.TableOptionKeyBlockSize, UintValue: to.KeyBlockSize})
+	}
+	if from.AutoIncID != to.AutoIncID && to.AutoIncID != 0 {
+		options = append(options, &ast.TableOption{Tp: ast.TableOptionAutoIncrement, UintValue: uint64(to.AutoIncID)})
+	}
+	if from.Comment != to.Comment {
+		options = append(options, &ast.TableOption{Tp: ast.TableOptionComment, StrValue: to.Comment})
+	}
+	if len(options) > 0 {
+		d.options = append(d.options, &ast.AlterTableSpec{Tp: ast.AlterTableOption, Options: options})
+	}
+	return nil
+}
+
+// partitionScheme returns the text of the partitioning method of the partitions.
+func partitionScheme(pi *model.PartitionInfo) string {
+	var sb strings.Builder
+	sb.WriteString("PARTITION BY " + pi.Type.String())
+	if len(pi.Columns) > 0 {
+		if pi.Type != model.PartitionTypeKey {
+			sb.WriteString(" COLUMNS")
+		}
+		names := make([]string, 0, len(pi.Columns))
+		for _, col := range pi.Columns {
+			names = append(names, "`"+col.O+"`")
+		}
+		sb.WriteString("(" + strings.Join(names, ",") + ")")
+	} else {
+		sb.WriteString(" (" + pi.Expr + ")")
+	}
+	return sb.String()
+}
+
+// partitionDefinitions returns the text of the definitions of the partitions, which
+// is the number of the partitions for HASH and KEY if the names are the defaults.
+func partitionDefinitions(pi *model.PartitionInfo, defs []model.PartitionDefinition) string {
+	if pi.Type == model.PartitionTypeHash || pi.Type == model.PartitionTypeKey {
+		defaults := true
+		for i, pd := range defs {
+			defaults = defaults && pd.Name.L == fmt.Sprintf("p%d", i) && pd.Comment == ""
+		}
+		if defaults {
+			return fmt.Sprintf("PARTITIONS %d", len(defs))
+		}
+	}
+	strs := make([]string, 0, len(defs))
+	for _, pd := range defs {
+		s := "PARTITION `" + pd.Name.O + "`"
+		switch {
+		case len(pd.LessThan) == 1 && len(pi.Columns) == 0 && strings.EqualFold(pd.LessThan[0], "maxvalue"):
+			s += " VALUES LESS THAN MAXVALUE"
+		case len(pd.LessThan) > 0:
+			s += " VALUES LESS THAN (" + strings.Join(pd.LessThan, ",") + ")"
+		case len(pd.InValues) > 0:
+			values := make([]string, 0, len(pd.InValues))
+			for _, vs := range pd.InValues {
+				if len(vs) == 1 {
+					values = append(values, vs[0])
+				} else {
+					values = append(values, "("+strings.Join(vs, ",")+")")
+				}
+			}
+			s += " VALUES IN (" + strings.Join(values, ",") + ")"
+		}
+		if pd.Comment != "" {
+			s += " COMMENT = " + quote(pd.Comment)
+		}
+		strs = append(strs, s)
+	}
+	return "(" + strings.Join(strs, ",") + ")"
+}
+
+func (d *differ) diffPartitions() error {
+	from, to := d.from.Partition, d.to.Partition
+	var texts []string
+	switch {
+	case to == nil && from == nil:
+	case to == nil:
+		texts = append(texts, "REMOVE PARTITIONING")
+	case from == nil || partitionScheme(from) != partitionScheme(to):
+		texts = append(texts, partitionScheme(to)+" "+partitionDefinitions(to, to.Definitions))
+	default:
+		texts = d.diffPartitionDefinitions(from, to)
+	}
+	for _, text := range texts {
+		spec, err := parseSpec(text)
+		if err != nil {
+			return err
+		}
+		if spec.Tp == ast.AlterTableDropPartition {
+			if err := d.destructive(spec); err != nil {
+				return err
+			}
+		}
+		d.partitions = append(d.partitions, spec)
+	}
+	return nil
+}
+
+// diffPartitionDefinitions returns the texts of the changes of the partitions of the
+// same partitioning method, which are the partitions dropped and the partitions
+// added at the end, or the partitions redefined.
+func (d *differ) diffPartitionDefinitions(from, to *model.PartitionInfo) []string {
+	defText := func(pi *model.PartitionInfo, pd model.PartitionDefinition) string {
+		return partitionDefinitions(pi, []model.PartitionDefinition{pd})
+	}
+	if partitionDefinitions(from, from.Definitions) == partitionDefinitions(to, to.Definitions) {
+		return nil
+	}
+	if to.Type == model.PartitionTypeHash || to.Type == model.PartitionTypeKey {
+		n, m := len(from.Definitions), len(to.Definitions)
+		if strings.HasPrefix(partitionDefinitions(from, from.Definitions), "PARTITIONS") && strings.HasPrefix(partitionDefinitions(to, to.Definitions), "PARTITIONS") {
+			if m > n {
+				return []string{fmt.Sprintf("ADD PARTITION PARTITIONS %d", m-n)}
+			}
+			return []string{fmt.Sprintf("COALESCE PARTITION %d", n-m)}
+		}
+	} else {
+		var dropped []string
+		i := 0
+		for _, pd := range from.Definitions {
+			if i < len(to.Definitions) && defText(from, pd) == defText(to, to.Definitions[i]) {
+				i++
+				continue
+			}
+			if findPartition(to, pd.Name.L) != nil {
+				dropped = nil
+				i = -1
+				break
+			}
+			dropped = append(dropped, "`"+pd.Name.O+"`")
+		}
+		if i >= 0 {
+			var texts []string
+			if len(dropped) > 0 {
+				texts = append(texts, "DROP PARTITION "+strings.Join(dropped, ","))
+			}
+			if i < len(to.Definitions) {
+				texts = append(texts, "ADD PARTITION "+partitionDefinitions(to, to.Definitions[i:]))
+			}
+			return texts
+		}
+	}
+	return []string{partitionScheme(to) + " " + partitionDefinitions(to, to.Definitions)}
+}
+
+// findPartition returns the partition by name, or nil if it's not found.
+func findPartition(pi *model.PartitionInfo, name string) *model.PartitionDefinition {
+	for i := range pi.Definitions {
+		if pi.Definitions[i].Name.L == name {
+			return &pi.Definitions[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog_test
+
+import (
+	"strings"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	. "github.com/pingcap/parser/catalog"
+	"github.com/pingcap/parser/format"
+)
+
+var _ = Suite(&testDiffSuite{})
+
+type testDiffSuite struct {
+}
+
+func restoreStmts(c *C, stmts []*ast.AlterTableStmt) string {
+	var strs []string
+	for _, stmt := range stmts {
+		var sb strings.Builder
+		c.Assert(stmt.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)), IsNil)
+		strs = append(strs, sb.String())
+	}
+	return strings.Join(strs, "; ")
+}
+
+// diff returns the migration from the table t created by from to the table created
+// by to, and checks the migration by applying it.
+func diff(c *C, from, to string, opts DiffOptions) string {
+	cat := newCatalog(c, from)
+	c.Assert(exec(c, cat, "create database d; use d;"+strings.Replace(to, "t2", "t", 1)), IsNil, Commentf("to: %s", to))
+	target := cat.Table("d", "t")
+	stmts, err := DiffTables(cat.Table("test", "t"), target, opts)
+	c.Assert(err, IsNil, Commentf("to: %s", to))
+	sql := restoreStmts(c, stmts)
+	c.Assert(exec(c, cat, "use test;"+sql), IsNil, Commentf("sql: %s", sql))
+	// the indexes are compared by the diff, which ignores the order of them.
+	applied := cat.Table("test", "t")
+	c.Assert(strings.Split(describe(applied), ";")[0], Equals, strings.Split(describe(target), ";")[0], Commentf("sql: %s", sql))
+	stmts, err = DiffTables(applied, target, DiffOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(stmts, HasLen, 0, Commentf("sql: %s, then: %s", sql, restoreStmts(c, stmts)))
+	return sql
+}
+
+func (s *testDiffSuite) TestDiffColumns(c *C) {
+	from := "create table t (id int primary key, a int not null, b varchar(10), c int as (a + 1))"
+	cases := []struct {
+		to       string
+		expected string
+	}{
+		{"create table t2 (id int primary key, a int not null, b varchar(10), c int as (a + 1))", ""},
+		{"create table t2 (id int primary key, a int not null, b varchar(10), c int as (a + 1), d int default 1 comment 'it''s')",
+			"ALTER TABLE `t` ADD COLUMN `d` INT DEFAULT 1 COMMENT 'it''s'"},
+		{"create table t2 (x char(1), id int primary key, a int not null, y text, b varchar(10), c int as (a + 1))",
+			"ALTER TABLE `t` ADD COLUMN `x` CHAR(1) FIRST, ADD COLUMN `y` TEXT AFTER `a`"},
+		{"create table t2 (id int primary key, a int not null, c int as (a + 1))",
+			"ALTER TABLE `t` DROP COLUMN `b`"},
+		{"create table t2 (id int primary key, b varchar(10), a int not null, c int as (a + 1))",
+			"ALTER TABLE `t` MODIFY COLUMN `a` INT NOT NULL AFTER `b`"},
+		{"create table t2 (c int as (a + 1), b varchar(10), a int not null, id int primary key)",
+			"ALTER TABLE `t` MODIFY COLUMN `b` VARCHAR(10) AFTER `c`, MODIFY COLUMN `a` INT NOT NULL AFTER `b`, MODIFY COLUMN `id` INT NOT NULL AFTER `a`"},
+		{"create table t2 (id bigint unsigned primary key, a int not null default 0, b varchar(20) charset latin1, c int as (a + 1) stored)",
+			"ALTER TABLE `t` MODIFY COLUMN `id` BIGINT UNSIGNED NOT NULL, MODIFY COLUMN `a` INT NOT NULL DEFAULT 0, MODIFY COLUMN `b` VARCHAR(20) CHARACTER SET LATIN1 COLLATE latin1_swedish_ci, MODIFY COLUMN `c` INT GENERATED ALWAYS AS(`a`+1) STORED"},
+		{"create table t2 (id int primary key, a int not null, b datetime(3) default current_timestamp(3) on update current_timestamp(3), c int as (a + 1))",
+			"ALTER TABLE `t` MODIFY COLUMN `b` DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3)"},
+		{"create table t2 (id int primary key, a int not null, b enum('x', 'y') default 'y', c int as (a + 1))",
+			"ALTER TABLE `t` MODIFY COLUMN `b` ENUM('x','y') DEFAULT 'y'"},
+		{"create table t2 (id int primary key, a int not null, b bit(3) default b'101', c int as (a + 1))",
+			"ALTER TABLE `t` MODIFY COLUMN `b` BIT(3) DEFAULT b'101'"},
+		{"create table t2 (id int primary key, a int not null, b double, c int as (a + 1), d decimal(10,2), e float(7,3))",
+			"ALTER TABLE `t` MODIFY COLUMN `b` DOUBLE, ADD COLUMN `d` DECIMAL(10,2), ADD COLUMN `e` FLOAT(7,3)"},
+	}
+	for _, ca := range cases {
+		c.Assert(diff(c, from, ca.to, DiffOptions{}), Equals, ca.expected, Commentf("to: %s", ca.to))
+	}
+}
+
+func (s *testDiffSuite) TestDiffRenames(c *C) {
+	from := "create table t (id int primary key, a int not null, b varchar(10), key ab (a, b))"
+	to := "create table t2 (id int primary key, a2 int not null, b varchar(10), key ab (a2, b))"
+	c.Assert(diff(c, from, to, DiffOptions{}), Equals,
+		"ALTER TABLE `t` DROP INDEX `ab`, DROP COLUMN `a`, ADD COLUMN `a2` INT NOT NULL AFTER `id`, ADD INDEX `ab`(`a2`, `b`)")
+	c.Assert(diff(c, from, to, DiffOptions{Renames: map[string]string{"A": "a2"}}), Equals,
+		"ALTER TABLE `t` RENAME COLUMN `a` TO `a2`")
+	to = "create table t2 (id int primary key, b varchar(10), a2 bigint not null, key ab (a2, b))"
+	c.Assert(diff(c, from, to, DiffOptions{Renames: map[string]string{"a": "a2"}}), Equals,
+		"ALTER TABLE `t` CHANGE COLUMN `a` `a2` BIGINT NOT NULL AFTER `b`")
+
+	// the columns renamed by RENAME COLUMN are matched by ID.
+	cat := newCatalog(c, from)
+	old := cat.Table("test", "t")
+	c.Assert(exec(c, cat, "alter table t rename column a to a2"), IsNil)
+	stmts, err := DiffTables(old, cat.Table("test", "t"), DiffOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(restoreStmts(c, stmts), Equals, "ALTER TABLE `t` RENAME COLUMN `a` TO `a2`")
+}
+
+func (s *testDiffSuite) TestDiffKeys(c *C) {
+	from := `create table p (id int primary key);
+		create table t (id int primary key, a int, b varchar(10), key ab (a, b), unique key (b), constraint fk foreign key (a) references p (id), constraint ck check (a > 0))`
+	cases := []struct {
+		to       string
+		expected string
+	}{
+		{"create table t2 (id int, a int, b varchar(10), key ab (a, b), unique key (b), constraint fk foreign key (a) references p (id), constraint ck check (a > 0))",
+			"ALTER TABLE `t` DROP PRIMARY KEY, MODIFY COLUMN `id` INT"},
+		{"create table t2 (id int, a int, b varchar(10), primary key (id, a), key ab (a, b), unique key (b), constraint fk foreign key (a) references p (id), constraint ck check (a > 0))",
+			"ALTER TABLE `t` DROP PRIMARY KEY, MODIFY COLUMN `a` INT NOT NULL, ADD PRIMARY KEY(`id`, `a`)"},
+		{"create table t2 (id int primary key, a int, b varchar(10), key a (a), unique key b2 (b) invisible, constraint fk foreign key (a) references p (id), constraint ck check (a > 0))",
+			"ALTER TABLE `t` DROP INDEX `ab`, ADD INDEX `a`(`a`), RENAME INDEX `b` TO `b2`, ALTER INDEX `b2` INVISIBLE"},
+		{"create table t2 (id int primary key, a int, b varchar(10), key ab (a, b(5)), unique key (b), constraint fk foreign key (a) references p (id) on delete cascade, constraint ck check (a > 1) not enforced)",
+			"ALTER TABLE `t` DROP FOREIGN KEY `fk`, DROP CHECK `ck`, DROP INDEX `ab`, ADD INDEX `ab`(`a`, `b`(5)), ADD CONSTRAINT `fk` FOREIGN KEY (`a`) REFERENCES `p`(`id`) ON DELETE CASCADE, ADD CONSTRAINT `ck` CHECK(`a`>1) NOT ENFORCED"},
+		{"create table t2 (id int primary key, a int, b varchar(10), key ab (a, b), unique key (b), constraint fk foreign key (a) references p (id), constraint ck check (a > 0) not enforced)",
+			"ALTER TABLE `t` ALTER CHECK `ck` NOT ENFORCED"},
+	}
+	for _, ca := range cases {
+		c.Assert(diff(c, from, ca.to, DiffOptions{}), Equals, ca.expected, Commentf("to: %s", ca.to))
+	}
+}
+
+func (s *testDiffSuite) TestDiffTable(c *C) {
+	from := "create table t (id int primary key, a varchar(10)) comment 'x'"
+	cases := []struct {
+		to       string
+		expected string
+	}{
+		{"create table t2 (id int primary key, a varchar(10)) engine = MyISAM comment 'y' row_format = compressed auto_increment = 5",
+			"ALTER TABLE `t` ENGINE = MyISAM ROW_FORMAT = COMPRESSED AUTO_INCREMENT = 5 COMMENT = 'y'"},
+		{"create table t2 (id int primary key, a varchar(10)) charset latin1 comment 'x'",
+			"ALTER TABLE `t` MODIFY COLUMN `a` VARCHAR(10) CHARACTER SET LATIN1 COLLATE latin1_swedish_ci, CHARACTER SET LATIN1 COLLATE LATIN1_SWEDISH_CI"},
+		{"create table t2 (id int primary key, a varchar(10)) comment 'x' partition by hash (id) partitions 4",
+			"ALTER TABLE `t` PARTITION BY HASH (`id`) PARTITIONS 4"},
+	}
+	for _, ca := range cases {
+		c.Assert(diff(c, from, ca.to, DiffOptions{}), Equals, ca.expected, Commentf("to: %s", ca.to))
+	}
+
+	from = "create table t (id int primary key) partition by range (id) (partition p0 values less than (10), partition p1 values less than (20))"
+	cases = []struct {
+		to       string
+		expected string
+	}{
+		{"create table t2 (id int primary key)", "ALTER TABLE `t` REMOVE PARTITIONING"},
+		{"create table t2 (id int primary key) partition by range (id) (partition p1 values less than (20), partition p2 values less than maxvalue)",
+			"ALTER TABLE `t` DROP PARTITION `p0`; ALTER TABLE `t` ADD PARTITION (PARTITION `p2` VALUES LESS THAN (MAXVALUE))"},
+		{"create table t2 (id int primary key) partition by range (id) (partition p0 values less than (15), partition p1 values less than (20))",
+			"ALTER TABLE `t` PARTITION BY RANGE (`id`) (PARTITION `p0` VALUES LESS THAN (15),PARTITION `p1` VALUES LESS THAN (20))"},
+		{"create table t2 (id int primary key) partition by hash (id) partitions 2",
+			"ALTER TABLE `t` PARTITION BY HASH (`id`) PARTITIONS 2"},
+	}
+	for _, ca := range cases {
+		c.Assert(diff(c, from, ca.to, DiffOptions{}), Equals, ca.expected, Commentf("to: %s", ca.to))
+	}
+	from = "create table t (id int primary key) partition by hash (id) partitions 4"
+	c.Assert(diff(c, from, "create table t2 (id int primary key) partition by hash (id) partitions 6", DiffOptions{}), Equals,
+		"ALTER TABLE `t` ADD PARTITION PARTITIONS 2")
+	c.Assert(diff(c, from, "create table t2 (id int primary key) partition by hash (id) partitions 1", DiffOptions{}), Equals,
+		"ALTER TABLE `t` COALESCE PARTITION 3")
+}
+
+func (s *testDiffSuite) TestDiffOptions(c *C) {
+	from := "create table t (id int primary key, a varchar(10), b int)"
+	to := "create table t2 (id int primary key, a varchar(20), c int) comment 'c'"
+	c.Assert(diff(c, from, to, DiffOptions{Split: true}), Equals,
+		"ALTER TABLE `t` DROP COLUMN `b`; ALTER TABLE `t` MODIFY COLUMN `a` VARCHAR(20); ALTER TABLE `t` ADD COLUMN `c` INT; ALTER TABLE `t` COMMENT = 'c'")
+
+	parse := func(sql string) *ast.CreateTableStmt {
+		stmt, err := parser.New().ParseOneStmt(sql, "", "")
+		c.Assert(err, IsNil)
+		return stmt.(*ast.CreateTableStmt)
+	}
+	stmts, err := DiffCreateTables(parse(from), parse(to), DiffOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(restoreStmts(c, stmts), Equals,
+		"ALTER TABLE `t` DROP COLUMN `b`, MODIFY COLUMN `a` VARCHAR(20), ADD COLUMN `c` INT, COMMENT = 'c', RENAME AS `t2`")
+	stmts, err = DiffCreateTables(parse(from), parse(to), DiffOptions{Split: true})
+	c.Assert(err, IsNil)
+	c.Assert(stmts, HasLen, 5)
+
+	cases := []struct {
+		to      string
+		message string
+	}{
+		{to, "the destructive change is forbidden: alter table `t` drop column `b`"},
+		{"create table t2 (id int primary key, a varchar(5), b int)", ".* modify column `a` varchar\\(5\\)"},
+		{"create table t2 (id int primary key, a varchar(10) charset latin1, b int)", ".* modify column `a` varchar\\(10\\) .*"},
+		{"create table t2 (id int primary key, a varchar(10), b int unsigned)", ".* modify column `b` int unsigned"},
+		{"create table t2 (id int primary key, a varchar(10), b tinyint)", ".* modify column `b` tinyint"},
+	}
+	for _, ca := range cases {
+		stmts, err = DiffCreateTables(parse(from), parse(ca.to), DiffOptions{NoDestructive: true})
+		c.Assert(err, ErrorMatches, ca.message, Commentf("to: %s", ca.to))
+	}
+	stmts, err = DiffCreateTables(parse(from), parse("create table t (id bigint primary key, a text, b int, c int)"), DiffOptions{NoDestructive: true})
+	c.Assert(err, IsNil)
+	c.Assert(stmts, HasLen, 1)
+
+	from = "create table t (id int primary key) partition by list (id) (partition p0 values in (1, 2), partition p1 values in (3))"
+	to = "create table t (id int primary key) partition by list (id) (partition p0 values in (1, 2))"
+	_, err = DiffCreateTables(parse(from), parse(to), DiffOptions{NoDestructive: true})
+	c.Assert(err, ErrorMatches, ".* drop partition `p1`")
+}
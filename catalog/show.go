@@ -0,0 +1,484 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/charset"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/types"
+)
+
+// primaryCollations are the primary collations of MySQL 8.0 which aren't the
+// default collations of the charset package.
+var primaryCollations = map[string]string{
+	charset.CharsetUTF8MB4: "utf8mb4_0900_ai_ci",
+	charset.CharsetUTF8:    "utf8_general_ci",
+	charset.CharsetLatin1:  "latin1_swedish_ci",
+	charset.CharsetASCII:   "ascii_general_ci",
+}
+
+// isPrimaryCollation reports whether co is the primary collation of cs in MySQL.
+func isPrimaryCollation(cs, co string) bool {
+	if primary, ok := primaryCollations[cs]; ok {
+		return co == primary
+	}
+	collation, err := charset.GetCollationByName(co)
+	return err == nil && collation.IsDefault
+}
+
+// showCollation reports whether the collation of a table or a database is shown,
+// which is not the primary collation or is utf8mb4_0900_ai_ci.
+func showCollation(cs, co string) bool {
+	return co != "" && (!isPrimaryCollation(cs, co) || co == primaryCollations[charset.CharsetUTF8MB4])
+}
+
+// engineNames are the names of the storage engines of MySQL as it shows them.
+var engineNames = []string{
+	"InnoDB", "MyISAM", "MEMORY", "CSV", "ARCHIVE", "BLACKHOLE", "MRG_MYISAM",
+	"FEDERATED", "PERFORMANCE_SCHEMA", "ndbcluster", "TempTable",
+}
+
+// showEngine returns the name of the storage engine as MySQL shows it, which is
+// InnoDB if it's empty.
+func showEngine(engine string) string {
+	if engine == "" {
+		return "InnoDB"
+	}
+	for _, name := range engineNames {
+		if strings.EqualFold(engine, name) {
+			return name
+		}
+	}
+	return engine
+}
+
+// ShowCreateDatabase returns the text of SHOW CREATE DATABASE of MySQL 8.0.
+func ShowCreateDatabase(db *model.DBInfo) string {
+	var sb strings.Builder
+	sb.WriteString("CREATE DATABASE " + quoteName(db.Name.O))
+	if db.Charset != "" {
+		sb.WriteString(" /*!40100 DEFAULT CHARACTER SET " + db.Charset)
+		if showCollation(db.Charset, db.Collate) {
+			sb.WriteString(" COLLATE " + db.Collate)
+		}
+		sb.WriteString(" */")
+	}
+	sb.WriteString(" /*!80016 DEFAULT ENCRYPTION='N' */")
+	return sb.String()
+}
+
+// ShowCreateTable returns the text of SHOW CREATE TABLE of MySQL 8.0, which is the
+// text of SHOW CREATE VIEW if the table is a view. The expressions are parsed by the
+// parser, so a driver such as test_driver must be imported.
+func ShowCreateTable(tbl *model.TableInfo) string {
+	if tbl.IsView() {
+		return showCreateView(tbl)
+	}
+	var sb strings.Builder
+	sb.WriteString("CREATE TABLE " + quoteName(tbl.Name.O) + " (\n")
+	var lines []string
+	for _, col := range tbl.Columns {
+		lines = append(lines, showColumn(col, tbl))
+	}
+	for _, idx := range sortIndexes(tbl) {
+		lines = append(lines, showIndex(idx))
+	}
+	for _, fk := range tbl.ForeignKeys {
+		lines = append(lines, showForeignKey(fk))
+	}
+	for _, cons := range tbl.Constraints {
+		s := "CONSTRAINT " + quoteName(cons.Name.O) + " CHECK (" + wrapExpr(cons.ExprString) + ")"
+		if !cons.Enforced {
+			s += " /*!80016 NOT ENFORCED */"
+		}
+		lines = append(lines, s)
+	}
+	sb.WriteString("  " + strings.Join(lines, ",\n  ") + "\n)")
+	engine := showEngine(tbl.Engine)
+	sb.WriteString(" ENGINE=" + engine)
+	if tbl.AutoIncID > 1 {
+		sb.WriteString(fmt.Sprintf(" AUTO_INCREMENT=%d", tbl.AutoIncID))
+	}
+	if tbl.Charset != "" {
+		sb.WriteString(" DEFAULT CHARSET=" + tbl.Charset)
+		if showCollation(tbl.Charset, tbl.Collate) {
+			sb.WriteString(" COLLATE=" + tbl.Collate)
+		}
+	}
+	if tbl.RowFormat != "" && !strings.EqualFold(tbl.RowFormat, "DEFAULT") {
+		sb.WriteString(" ROW_FORMAT=" + strings.ToUpper(tbl.RowFormat))
+	}
+	if tbl.KeyBlockSize > 0 {
+		sb.WriteString(fmt.Sprintf(" KEY_BLOCK_SIZE=%d", tbl.KeyBlockSize))
+	}
+	if tbl.Compression != "" {
+		sb.WriteString(" COMPRESSION=" + stringLiteral(tbl.Compression))
+	}
+	if tbl.Comment != "" {
+		sb.WriteString(" COMMENT=" + stringLiteral(tbl.Comment))
+	}
+	if tbl.Partition != nil {
+		sb.WriteString("\n" + showPartition(tbl.Partition, engine))
+	}
+	return sb.String()
+}
+
+func showCreateView(tbl *model.TableInfo) string {
+	v := tbl.View
+	var sb strings.Builder
+	sb.WriteString("CREATE ALGORITHM=" + v.Algorithm.String())
+	// the current user isn't known.
+	if v.Definer != nil && !v.Definer.CurrentUser {
+		sb.WriteString(" DEFINER=" + quoteName(v.Definer.Username) + "@" + quoteName(v.Definer.Hostname))
+	}
+	sb.WriteString(" SQL SECURITY " + v.Security.String() + " VIEW " + quoteName(tbl.Name.O))
+	if len(v.Cols) > 0 {
+		names := make([]string, 0, len(v.Cols))
+		for _, col := range v.Cols {
+			names = append(names, quoteName(col.O))
+		}
+		sb.WriteString(" (" + strings.Join(names, ",") + ")")
+	}
+	sb.WriteString(" AS " + v.SelectStmt)
+	// the cascaded check option is the default like CreateViewStmt restores it.
+	if v.CheckOption != model.CheckOptionCascaded {
+		sb.WriteString(" WITH " + v.CheckOption.String() + " CHECK OPTION")
+	}
+	return sb.String()
+}
+
+// showType returns the text of the type of the column, which omits the display
+// width of the integers unless they are ZEROFILL or TINYINT(1).
+func showType(tp *types.FieldType) string {
+	name := types.TypeToStr(tp.Tp, tp.Charset)
+	switch tp.Tp {
+	case mysql.TypeVarString:
+		name = types.TypeToStr(mysql.TypeVarchar, tp.Charset)
+		fallthrough
+	case mysql.TypeVarchar, mysql.TypeString, mysql.TypeBit:
+		name += fmt.Sprintf("(%d)", tp.Flen)
+	case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong:
+		if (mysql.HasZerofillFlag(tp.Flag) || tp.Tp == mysql.TypeTiny && tp.Flen == 1) && tp.Flen > 0 {
+			name += fmt.Sprintf("(%d)", tp.Flen)
+		}
+	case mysql.TypeFloat, mysql.TypeDouble:
+		if tp.Decimal != types.UnspecifiedLength && tp.Flen > 0 {
+			name += fmt.Sprintf("(%d,%d)", tp.Flen, tp.Decimal)
+		}
+	case mysql.TypeNewDecimal:
+		name += fmt.Sprintf("(%d,%d)", tp.Flen, tp.Decimal)
+	case mysql.TypeDatetime, mysql.TypeTimestamp, mysql.TypeDuration:
+		if tp.Decimal > 0 {
+			name += fmt.Sprintf("(%d)", tp.Decimal)
+		}
+	case mysql.TypeEnum, mysql.TypeSet:
+		elems := make([]string, 0, len(tp.Elems))
+		for _, elem := range tp.Elems {
+			elems = append(elems, stringLiteral(elem))
+		}
+		name += "(" + strings.Join(elems, ",") + ")"
+	}
+	if mysql.HasUnsignedFlag(tp.Flag) {
+		name += " unsigned"
+	}
+	if mysql.HasZerofillFlag(tp.Flag) {
+		name += " zerofill"
+	}
+	return name
+}
+
+func showColumn(col *model.ColumnInfo, tbl *model.TableInfo) string {
+	var sb strings.Builder
+	sb.WriteString(quoteName(col.Name.O) + " " + showType(&col.FieldType))
+	if hasCharset(col.Tp) && col.Charset != charset.CharsetBin {
+		if col.Charset != tbl.Charset {
+			sb.WriteString(" CHARACTER SET " + col.Charset)
+		}
+		// utf8mb4_0900_ai_ci is shown unless it's the collation of the table, like showCollation.
+		utf8mb4Primary := col.Collate == primaryCollations[charset.CharsetUTF8MB4] && col.Collate != tbl.Collate
+		if !isPrimaryCollation(col.Charset, col.Collate) || col.Charset == tbl.Charset && col.Collate != tbl.Collate || utf8mb4Primary {
+			sb.WriteString(" COLLATE " + col.Collate)
+		}
+	}
+	if col.IsGenerated() {
+		sb.WriteString(" GENERATED ALWAYS AS (" + wrapExpr(col.GeneratedExprString) + ")")
+		if col.GeneratedStored {
+			sb.WriteString(" STORED")
+		} else {
+			sb.WriteString(" VIRTUAL")
+		}
+	}
+	if mysql.HasNotNullFlag(col.Flag) {
+		sb.WriteString(" NOT NULL")
+	} else if col.Tp == mysql.TypeTimestamp {
+		sb.WriteString(" NULL")
+	}
+	if col.Hidden {
+		sb.WriteString(" /*!80023 INVISIBLE */")
+	}
+	switch {
+	case col.IsGenerated(), mysql.HasAutoIncrementFlag(col.Flag):
+	case col.DefaultValue != nil:
+		sb.WriteString(" DEFAULT " + showDefault(col))
+	case !mysql.HasNotNullFlag(col.Flag) && !types.IsTypeBlob(col.Tp):
+		sb.WriteString(" DEFAULT NULL")
+	}
+	if mysql.HasOnUpdateNowFlag(col.Flag) {
+		sb.WriteString(" ON UPDATE CURRENT_TIMESTAMP")
+		if col.Decimal > 0 {
+			sb.WriteString(fmt.Sprintf("(%d)", col.Decimal))
+		}
+	}
+	if mysql.HasAutoIncrementFlag(col.Flag) {
+		sb.WriteString(" AUTO_INCREMENT")
+	}
+	if col.Comment != "" {
+		sb.WriteString(" COMMENT " + stringLiteral(col.Comment))
+	}
+	return sb.String()
+}
+
+// showDefault returns the text of the default value of the column, the literals
+// are quoted, the bits are bit literals and the decimals have the scale of the
+// column.
+func showDefault(col *model.ColumnInfo) string {
+	s := fmt.Sprint(col.DefaultValue)
+	switch {
+	case col.DefaultIsExpr:
+		return "(" + wrapExpr(s) + ")"
+	case isTimeType(col.Tp) && strings.HasPrefix(strings.ToUpper(s), "CURRENT_TIMESTAMP"):
+		return strings.ToUpper(s)
+	case col.Tp == mysql.TypeBit:
+		return bitLiteral(fmt.Sprint(col.GetDefaultValue()))
+	case col.Tp == mysql.TypeNewDecimal && col.Decimal >= 0:
+		s = scaleDecimal(s, col.Decimal)
+	}
+	return stringLiteral(s)
+}
+
+// scaleDecimal returns the text of the decimal with the scale, or s if it's not a
+// decimal.
+func scaleDecimal(s string, scale int) string {
+	parts := strings.SplitN(s, ".", 2)
+	if strings.Trim(parts[0], "+-0123456789") != "" {
+		return s
+	}
+	frac := ""
+	if len(parts) == 2 {
+		frac = parts[1]
+	}
+	if strings.Trim(frac, "0123456789") != "" {
+		return s
+	}
+	if len(frac) < scale {
+		frac += strings.Repeat("0", scale-len(frac))
+	}
+	if scale == 0 {
+		return parts[0]
+	}
+	return parts[0] + "." + frac[:scale]
+}
+
+// sortIndexes returns the indexes of the table in the order of MySQL, which are the
+// primary key, the unique keys without nullable columns, the other unique keys, the
+// ordinary keys and the fulltext keys.
+func sortIndexes(tbl *model.TableInfo) []*model.IndexInfo {
+	idxs := append([]*model.IndexInfo(nil), indexes(tbl)...)
+	rank := func(idx *model.IndexInfo) int {
+		switch {
+		case idx.Primary:
+			return 0
+		case idx.Unique:
+			for _, ic := range idx.Columns {
+				if col := model.FindColumnInfo(tbl.Columns, ic.Name.L); col != nil && !mysql.HasNotNullFlag(col.Flag) {
+					return 2
+				}
+			}
+			return 1
+		case idx.Fulltext:
+			return 4
+		}
+		return 3
+	}
+	sort.SliceStable(idxs, func(i, j int) bool {
+		return rank(idxs[i]) < rank(idxs[j])
+	})
+	return idxs
+}
+
+func showIndex(idx *model.IndexInfo) string {
+	var sb strings.Builder
+	switch {
+	case idx.Primary:
+		sb.WriteString("PRIMARY KEY")
+	case idx.Unique:
+		sb.WriteString("UNIQUE KEY " + quoteName(idx.Name.O))
+	case idx.Fulltext:
+		sb.WriteString("FULLTEXT KEY " + quoteName(idx.Name.O))
+	case idx.Tp == model.IndexTypeRtree:
+		sb.WriteString("SPATIAL KEY " + quoteName(idx.Name.O))
+	default:
+		sb.WriteString("KEY " + quoteName(idx.Name.O))
+	}
+	cols := make([]string, 0, len(idx.Columns))
+	for _, ic := range idx.Columns {
+		s := quoteName(ic.Name.O)
+		if ic.Length != types.UnspecifiedLength {
+			s += fmt.Sprintf("(%d)", ic.Length)
+		}
+		cols = append(cols, s)
+	}
+	sb.WriteString(" (" + strings.Join(cols, ",") + ")")
+	if idx.Tp == model.IndexTypeHash {
+		sb.WriteString(" USING HASH")
+	}
+	if idx.Comment != "" {
+		sb.WriteString(" COMMENT " + stringLiteral(idx.Comment))
+	}
+	if idx.Invisible {
+		sb.WriteString(" /*!80000 INVISIBLE */")
+	}
+	return sb.String()
+}
+
+func showForeignKey(fk *model.FKInfo) string {
+	names := func(cols []model.CIStr) string {
+		strs := make([]string, 0, len(cols))
+		for _, col := range cols {
+			strs = append(strs, quoteName(col.O))
+		}
+		return strings.Join(strs, ", ")
+	}
+	s := fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)", quoteName(fk.Name.O), names(fk.Cols), quoteName(fk.RefTable.O), names(fk.RefCols))
+	for _, rule := range []struct {
+		clause string
+		opt    ast.ReferOptionType
+	}{{"ON DELETE", ast.ReferOptionType(fk.OnDelete)}, {"ON UPDATE", ast.ReferOptionType(fk.OnUpdate)}} {
+		if rule.opt != ast.ReferOptionNoOption && rule.opt != ast.ReferOptionNoAction {
+			s += " " + rule.clause + " " + rule.opt.String()
+		}
+	}
+	return s
+}
+
+// showPartition returns the text of the partitioning in the versioned comment.
+func showPartition(pi *model.PartitionInfo, engine string) string {
+	var sb strings.Builder
+	version := "50100"
+	if len(pi.Columns) > 0 && pi.Type != model.PartitionTypeKey {
+		version = "50500"
+	}
+	sb.WriteString("/*!" + version + " PARTITION BY " + pi.Type.String())
+	if len(pi.Columns) > 0 {
+		names := make([]string, 0, len(pi.Columns))
+		for _, col := range pi.Columns {
+			names = append(names, partitionName(col.O))
+		}
+		if pi.Type == model.PartitionTypeKey {
+			sb.WriteString(" (" + strings.Join(names, ",") + ")")
+		} else {
+			sb.WriteString("  COLUMNS(" + strings.Join(names, ",") + ")")
+		}
+	} else {
+		sb.WriteString(" (" + pi.Expr + ")")
+	}
+	if text := partitionDefinitions(pi, pi.Definitions); strings.HasPrefix(text, "PARTITIONS") {
+		return sb.String() + "\n" + text + " */"
+	}
+	defs := make([]string, 0, len(pi.Definitions))
+	for _, pd := range pi.Definitions {
+		s := "PARTITION " + partitionName(pd.Name.O)
+		switch {
+		case len(pd.LessThan) > 0:
+			values := make([]string, 0, len(pd.LessThan))
+			for _, v := range pd.LessThan {
+				if strings.EqualFold(v, "maxvalue") {
+					v = "MAXVALUE"
+				}
+				values = append(values, v)
+			}
+			if len(pi.Columns) == 0 && values[0] == "MAXVALUE" {
+				s += " VALUES LESS THAN MAXVALUE"
+			} else {
+				s += " VALUES LESS THAN (" + strings.Join(values, ",") + ")"
+			}
+		case len(pd.InValues) > 0:
+			values := make([]string, 0, len(pd.InValues))
+			for _, vs := range pd.InValues {
+				if len(vs) == 1 {
+					values = append(values, vs[0])
+				} else {
+					values = append(values, "("+strings.Join(vs, ",")+")")
+				}
+			}
+			s += " VALUES IN (" + strings.Join(values, ",") + ")"
+		}
+		if pd.Comment != "" {
+			s += " COMMENT = " + stringLiteral(pd.Comment)
+		}
+		defs = append(defs, s+" ENGINE = "+engine)
+	}
+	sb.WriteString("\n(" + strings.Join(defs, ",\n ") + ") */")
+	return sb.String()
+}
+
+// partitionName returns the name of a partition or a partitioning column, which is
+// quoted only if it's not a plain identifier.
+func partitionName(name string) string {
+	plain := name != ""
+	digits := true
+	for _, r := range name {
+		switch {
+		case r >= '0' && r <= '9':
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r == '$':
+			digits = false
+		default:
+			plain = false
+		}
+	}
+	if plain && !digits {
+		return name
+	}
+	return quoteName(name)
+}
+
+// wrapExpr returns the text of the expression, which is in parentheses unless it's a
+// column, a literal or a function call, like MySQL shows the expressions.
+func wrapExpr(text string) string {
+	expr, err := parseExpr(text)
+	if err != nil {
+		return text
+	}
+	switch expr.(type) {
+	case *ast.ColumnNameExpr, ast.ValueExpr, *ast.FuncCallExpr, *ast.AggregateFuncExpr, *ast.ParenthesesExpr:
+		return text
+	}
+	return "(" + text + ")"
+}
+
+// quoteName returns the name quoted by backquotes.
+func quoteName(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+// stringLiteral returns the string literal of s escaped like MySQL shows it.
+func stringLiteral(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", "''", "\x00", `\0`, "\n", `\n`, "\r", `\r`).Replace(s) + "'"
+}
@@ -0,0 +1,150 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog_test
+
+import (
+	. "github.com/pingcap/check"
+	. "github.com/pingcap/parser/catalog"
+)
+
+var _ = Suite(&testShowSuite{})
+
+type testShowSuite struct {
+}
+
+func (s *testShowSuite) TestShowCreateDatabase(c *C) {
+	cat := newCatalog(c, "create database d1 charset latin1; create database d2 collate utf8mb4_0900_ai_ci; create database d3 charset latin1 collate latin1_general_ci")
+	c.Assert(ShowCreateDatabase(cat.Database("test")), Equals, "CREATE DATABASE `test` /*!40100 DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci */ /*!80016 DEFAULT ENCRYPTION='N' */")
+	c.Assert(ShowCreateDatabase(cat.Database("d1")), Equals, "CREATE DATABASE `d1` /*!40100 DEFAULT CHARACTER SET latin1 */ /*!80016 DEFAULT ENCRYPTION='N' */")
+	c.Assert(ShowCreateDatabase(cat.Database("d2")), Equals, "CREATE DATABASE `d2` /*!40100 DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci */ /*!80016 DEFAULT ENCRYPTION='N' */")
+	c.Assert(ShowCreateDatabase(cat.Database("d3")), Equals, "CREATE DATABASE `d3` /*!40100 DEFAULT CHARACTER SET latin1 COLLATE latin1_general_ci */ /*!80016 DEFAULT ENCRYPTION='N' */")
+}
+
+func (s *testShowSuite) TestShowCreateTable(c *C) {
+	cases := []struct {
+		sql      string
+		expected string
+	}{
+		{"create table t (id int, a bigint(20) unsigned not null, b int(5) zerofill, c tinyint(1) default 1, d year(4))",
+			"CREATE TABLE `t` (\n" +
+				"  `id` int DEFAULT NULL,\n" +
+				"  `a` bigint unsigned NOT NULL,\n" +
+				"  `b` int(5) unsigned zerofill DEFAULT NULL,\n" +
+				"  `c` tinyint(1) DEFAULT '1',\n" +
+				"  `d` year DEFAULT NULL\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci"},
+		{"create table t (a bit(3) default b'101', b bit(10) default 1, c bit)",
+			"CREATE TABLE `t` (\n" +
+				"  `a` bit(3) DEFAULT b'101',\n" +
+				"  `b` bit(10) DEFAULT b'1',\n" +
+				"  `c` bit(1) DEFAULT NULL\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci"},
+		{"create table t (a varchar(10), b text, c enum('x') not null, d char(2) charset latin1)",
+			"CREATE TABLE `t` (\n" +
+				"  `a` varchar(10) DEFAULT NULL,\n" +
+				"  `b` text,\n" +
+				"  `c` enum('x') NOT NULL,\n" +
+				"  `d` char(2) CHARACTER SET latin1 DEFAULT NULL\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci"},
+		{"create table t (a varchar(10), b varchar(10) charset utf8mb4) charset latin1",
+			"CREATE TABLE `t` (\n" +
+				"  `a` varchar(10) DEFAULT NULL,\n" +
+				"  `b` varchar(10) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci DEFAULT NULL\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=latin1"},
+		{"create table t (a varchar(10), b char(2) charset latin1, c text collate utf8mb4_0900_ai_ci, d varbinary(5), e blob, f json) charset utf8mb4 collate utf8mb4_0900_ai_ci",
+			"CREATE TABLE `t` (\n" +
+				"  `a` varchar(10) DEFAULT NULL,\n" +
+				"  `b` char(2) CHARACTER SET latin1 DEFAULT NULL,\n" +
+				"  `c` text,\n" +
+				"  `d` varbinary(5) DEFAULT NULL,\n" +
+				"  `e` blob,\n" +
+				"  `f` json DEFAULT NULL\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci"},
+		{"create table t (a varchar(10) collate utf8mb4_bin, b varchar(10) charset latin1 collate latin1_swedish_ci) charset latin1 collate latin1_swedish_ci",
+			"CREATE TABLE `t` (\n" +
+				"  `a` varchar(10) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin DEFAULT NULL,\n" +
+				"  `b` varchar(10) DEFAULT NULL\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=latin1"},
+		{"create table t (id bigint auto_increment primary key comment 'it''s', p decimal(10,2) not null default 1.5, f float(7,3), g double, " +
+			"ts timestamp(3) default current_timestamp(3) on update current_timestamp(3), t2 timestamp, e enum('x','y') default 'x', " +
+			"v int as (id + 1) virtual, s int as (abs(id)) stored not null)",
+			"CREATE TABLE `t` (\n" +
+				"  `id` bigint NOT NULL AUTO_INCREMENT COMMENT 'it''s',\n" +
+				"  `p` decimal(10,2) NOT NULL DEFAULT '1.50',\n" +
+				"  `f` float(7,3) DEFAULT NULL,\n" +
+				"  `g` double DEFAULT NULL,\n" +
+				"  `ts` timestamp(3) NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),\n" +
+				"  `t2` timestamp NULL DEFAULT NULL,\n" +
+				"  `e` enum('x','y') DEFAULT 'x',\n" +
+				"  `v` int GENERATED ALWAYS AS ((`id` + 1)) VIRTUAL,\n" +
+				"  `s` int GENERATED ALWAYS AS (abs(`id`)) STORED NOT NULL,\n" +
+				"  PRIMARY KEY (`id`)\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci"},
+		{"create table t (id int, a int not null, b varchar(10), c text, key ab (a, b(5)) comment 'k', unique key (b), unique key (a), fulltext key (c), " +
+			"primary key (id, a), key (c(10)) invisible, constraint fk foreign key (a) references p (id) on delete cascade on update no action, constraint ck check (a > 0) not enforced, check (b is not null)) " +
+			"engine myisam auto_increment 10 row_format compressed key_block_size 8 comment 'c'",
+			"CREATE TABLE `t` (\n" +
+				"  `id` int NOT NULL,\n" +
+				"  `a` int NOT NULL,\n" +
+				"  `b` varchar(10) DEFAULT NULL,\n" +
+				"  `c` text,\n" +
+				"  PRIMARY KEY (`id`,`a`),\n" +
+				"  UNIQUE KEY `a` (`a`),\n" +
+				"  UNIQUE KEY `b` (`b`),\n" +
+				"  KEY `ab` (`a`,`b`(5)) COMMENT 'k',\n" +
+				"  KEY `c_2` (`c`(10)) /*!80000 INVISIBLE */,\n" +
+				"  FULLTEXT KEY `c` (`c`),\n" +
+				"  CONSTRAINT `fk` FOREIGN KEY (`a`) REFERENCES `p` (`id`) ON DELETE CASCADE,\n" +
+				"  CONSTRAINT `ck` CHECK ((`a` > 0)) /*!80016 NOT ENFORCED */,\n" +
+				"  CONSTRAINT `t_chk_2` CHECK ((`b` is not null))\n" +
+				") ENGINE=MyISAM AUTO_INCREMENT=10 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci ROW_FORMAT=COMPRESSED KEY_BLOCK_SIZE=8 COMMENT='c'"},
+		{"create table t (id int) engine innodb charset latin1 partition by range (id) (partition p0 values less than (10) comment 'x', partition p1 values less than maxvalue)",
+			"CREATE TABLE `t` (\n" +
+				"  `id` int DEFAULT NULL\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=latin1\n" +
+				"/*!50100 PARTITION BY RANGE (`id`)\n" +
+				"(PARTITION p0 VALUES LESS THAN (10) COMMENT = 'x' ENGINE = InnoDB,\n" +
+				" PARTITION p1 VALUES LESS THAN MAXVALUE ENGINE = InnoDB) */"},
+		{"create table t (a int, b varchar(10)) charset latin1 collate latin1_swedish_ci partition by range columns (a, b) (partition p0 values less than (1, 'x'), partition p1 values less than (maxvalue, maxvalue))",
+			"CREATE TABLE `t` (\n" +
+				"  `a` int DEFAULT NULL,\n" +
+				"  `b` varchar(10) DEFAULT NULL\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=latin1\n" +
+				"/*!50500 PARTITION BY RANGE  COLUMNS(a,b)\n" +
+				"(PARTITION p0 VALUES LESS THAN (1,'x') ENGINE = InnoDB,\n" +
+				" PARTITION p1 VALUES LESS THAN (MAXVALUE,MAXVALUE) ENGINE = InnoDB) */"},
+		{"create table t (id int) charset latin1 collate latin1_swedish_ci partition by list (id) (partition `p-0` values in (1, 2), partition p1 values in (3))",
+			"CREATE TABLE `t` (\n" +
+				"  `id` int DEFAULT NULL\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=latin1\n" +
+				"/*!50100 PARTITION BY LIST (`id`)\n" +
+				"(PARTITION `p-0` VALUES IN (1,2) ENGINE = InnoDB,\n" +
+				" PARTITION p1 VALUES IN (3) ENGINE = InnoDB) */"},
+		{"create table t (id int) charset latin1 collate latin1_swedish_ci partition by hash (id) partitions 4",
+			"CREATE TABLE `t` (\n" +
+				"  `id` int DEFAULT NULL\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=latin1\n" +
+				"/*!50100 PARTITION BY HASH (`id`)\n" +
+				"PARTITIONS 4 */"},
+	}
+	for _, ca := range cases {
+		cat := newCatalog(c, ca.sql)
+		c.Assert(ShowCreateTable(cat.Table("test", "t")), Equals, ca.expected, Commentf("sql: %s", ca.sql))
+	}
+
+	cat := newCatalog(c, "create table t (id int); create view v (x) as select id from t where id > 1 with local check option; create definer = 'u'@'%' view v2 as select 1 as one")
+	c.Assert(ShowCreateTable(cat.Table("test", "v")), Equals,
+		"CREATE ALGORITHM=UNDEFINED SQL SECURITY DEFINER VIEW `v` (`x`) AS select `id` from `t` where `id` > 1 WITH LOCAL CHECK OPTION")
+	c.Assert(ShowCreateTable(cat.Table("test", "v2")), Equals, "CREATE ALGORITHM=UNDEFINED DEFINER=`u`@`%` SQL SECURITY DEFINER VIEW `v2` AS select 1 as `one`")
+}
@@ -0,0 +1,650 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pingcap/errors"
+)
+
+// IdentifierKind is the kind of object an identifier refers to.
+type IdentifierKind int
+
+// IdentifierKind values.
+const (
+	IdentifierTable IdentifierKind = iota + 1
+	IdentifierColumn
+	IdentifierDatabase
+	IdentifierIndex
+	IdentifierFunction
+	IdentifierVariable
+)
+
+var identifierKindNames = []string{
+	IdentifierTable:    "table",
+	IdentifierColumn:   "column",
+	IdentifierDatabase: "database",
+	IdentifierIndex:    "index",
+	IdentifierFunction: "function",
+	IdentifierVariable: "variable",
+}
+
+// String implements fmt.Stringer interface.
+func (k IdentifierKind) String() string {
+	if k > 0 && int(k) < len(identifierKindNames) {
+		return identifierKindNames[k]
+	}
+	return "unknown"
+}
+
+// CompletionTable is a table reference visible at the cursor.
+type CompletionTable struct {
+	Schema string
+	Name   string
+	// Alias is the alias of the table, it is "" if the table has no alias.
+	Alias string
+}
+
+// Completion is the result of Parser.Complete.
+type Completion struct {
+	// Prefix is the partial word before the cursor, candidates are filtered by it.
+	Prefix string
+	// Qualifier is the name before the '.' ahead of the cursor, such as `t` in `t.|`.
+	Qualifier string
+	// Keywords are the keywords acceptable at the cursor, in upper case.
+	Keywords []string
+	// Punctuations are the operators and punctuations acceptable at the cursor.
+	Punctuations []string
+	// IdentifierKinds are the kinds of identifier acceptable at the cursor.
+	IdentifierKinds []IdentifierKind
+	// Tables are the tables of the enclosing statement visible at the cursor.
+	Tables []CompletionTable
+}
+
+// HasIdentifierKind reports whether the kind of identifier is acceptable.
+func (c *Completion) HasIdentifierKind(kind IdentifierKind) bool {
+	for _, k := range c.IdentifierKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Complete returns the candidates acceptable at the cursor, which is a byte offset of sql.
+// The tokens of the statement before the cursor are fed to the parse table to find out the
+// acceptable tokens, so it works with incomplete statements. An error is returned if the
+// text before the cursor has a syntax error, or the cursor is out of sql.
+func (parser *Parser) Complete(sql string, cursor int) (*Completion, error) {
+	if cursor < 0 || cursor > len(sql) {
+		return nil, errors.Errorf("cursor %d is out of the SQL text of %d bytes", cursor, len(sql))
+	}
+	res := &Completion{}
+	mode := parser.lexer.GetSQLMode()
+	tokens := Tokenize(sql, mode)
+	stmtStart, stmtEnd, parseEnd := 0, len(sql), cursor
+	for i, tok := range tokens {
+		end := tok.Pos.Offset + len(tok.Text)
+		if tok.Kind == TokenOperator && tok.Text == ";" {
+			if end <= cursor {
+				stmtStart = end
+			} else {
+				stmtEnd = tok.Pos.Offset
+				tokens = tokens[:i]
+				break
+			}
+		}
+		if tok.Pos.Offset >= cursor || end < cursor || (end == cursor && !isWordToken(tok.Kind) && tok.Kind != TokenInvalid) {
+			continue
+		}
+		switch tok.Kind {
+		case TokenIdentifier, TokenKeyword, TokenReservedKeyword, TokenVariable:
+			res.Prefix = sql[tok.Pos.Offset:cursor]
+			parseEnd = tok.Pos.Offset
+			if tok.Kind == TokenVariable {
+				res.IdentifierKinds = []IdentifierKind{IdentifierVariable}
+				return res, nil
+			}
+		case TokenQuotedIdentifier:
+			res.Prefix = sql[tok.Pos.Offset+1 : cursor]
+			parseEnd = tok.Pos.Offset
+		case TokenInvalid:
+			// the cursor is in an unterminated quoted identifier, string or comment,
+			// which runs to the end of sql.
+			switch {
+			case tok.Text[0] == '`' || tok.Text[0] == '"' && mode.HasANSIQuotesMode():
+				res.Prefix = sql[tok.Pos.Offset+1 : cursor]
+				parseEnd = tok.Pos.Offset
+			case tok.Text[0] == '\'' || tok.Text[0] == '"' || strings.HasPrefix(tok.Text, "/*"):
+				return res, nil
+			}
+		default:
+			// the cursor is in a string, a number or a comment.
+			return res, nil
+		}
+	}
+
+	stack, err := parser.feedLRTokens(sql[stmtStart:parseEnd])
+	if err != nil {
+		return nil, err
+	}
+	collectCandidates(res, stack)
+	if res.HasIdentifierKind(IdentifierColumn) || res.HasIdentifierKind(IdentifierTable) {
+		res.Qualifier = completionQualifier(sql[:parseEnd], tokens)
+	}
+	if isIndexContext(sql[:parseEnd], tokens) {
+		res.IdentifierKinds = appendIdentifierKind(res.IdentifierKinds, IdentifierIndex)
+	}
+	sort.Slice(res.IdentifierKinds, func(i, j int) bool { return res.IdentifierKinds[i] < res.IdentifierKinds[j] })
+	if res.Prefix != "" {
+		res.Keywords = filterByPrefix(res.Keywords, res.Prefix)
+		res.Punctuations = nil
+	}
+	res.Tables = visibleTables(tokens, stmtStart, stmtEnd, parseEnd, cursor)
+	return res, nil
+}
+
+func isWordToken(kind TokenKind) bool {
+	switch kind {
+	case TokenIdentifier, TokenKeyword, TokenReservedKeyword, TokenVariable:
+		return true
+	}
+	return false
+}
+
+// feedLRTokens feeds the tokens of sql to the parse table and returns the state stack.
+func (parser *Parser) feedLRTokens(sql string) ([]int, error) {
+	l := parser.lexer.InheritScanner(sql)
+	l.r.p = Pos{Line: 1}
+	stack := []int{0}
+	var v yySymType
+	for {
+		tok := l.Lex(&v)
+		if tok <= 0 {
+			return stack, nil
+		}
+		col, ok := yyXLAT[tok]
+		if !ok {
+			return nil, ErrSyntax
+		}
+		if stack, ok = lrFeed(stack, col, nil); !ok {
+			return nil, ParseErrorWith(sql[l.lastScanOffset:], l.r.p.Line)
+		}
+	}
+}
+
+// lrFeed feeds the symbol in column col of the parse table to the state stack, all
+// reductions happened are reported to onReduce. It returns the stack after the symbol
+// is shifted, ok is false if the symbol causes a syntax error.
+// The stack may be modified, copy it before feeding if it is shared.
+func lrFeed(stack []int, col int, onReduce func(xsym int)) (_ []int, ok bool) {
+	for {
+		state := stack[len(stack)-1]
+		action := lrAction(state, col)
+		switch {
+		case action > 0:
+			return append(stack, action), true
+		case action < 0:
+			r := yyReductions[-action]
+			if onReduce != nil {
+				onReduce(r.xsym)
+			}
+			stack = stack[:len(stack)-r.components]
+			if len(stack) == 0 {
+				return stack, false
+			}
+			next := lrAction(stack[len(stack)-1], r.xsym)
+			if next <= 0 {
+				return stack, false
+			}
+			stack = append(stack, next)
+		case state == 1:
+			// accept
+			return stack, true
+		default:
+			return stack, false
+		}
+	}
+}
+
+func lrAction(state, col int) int {
+	row := yyParseTab[state]
+	if col >= len(row) || row[col] == 0 {
+		return 0
+	}
+	return int(row[col]) + yyTabOfs
+}
+
+func copyStack(stack []int) []int {
+	return append(make([]int, 0, len(stack)+16), stack...)
+}
+
+type completionTables struct {
+	// tokenTexts maps a terminal column to the text of its keyword or punctuation.
+	tokenTexts map[int]string
+	// keywordCols are columns of keyword tokens.
+	keywordCols map[int]bool
+	// terminals maps terminal tokens to their columns.
+	terminals map[int]int
+	// symbols maps a nonterminal name to its column.
+	symbols map[string]int
+}
+
+var (
+	completionTablesOnce sync.Once
+	completionTabs       completionTables
+)
+
+// punctuationTexts is the texts of multiple-character operators.
+var punctuationTexts = map[int]string{
+	eq:           "=",
+	ge:           ">=",
+	le:           "<=",
+	neq:          "!=",
+	neqSynonym:   "<>",
+	nulleq:       "<=>",
+	lsh:          "<<",
+	rsh:          ">>",
+	pipes:        "||",
+	pipesAsOr:    "||",
+	andand:       "&&",
+	assignmentEq: ":=",
+	jss:          "->",
+	juss:         "->>",
+	paramMarker:  "?",
+}
+
+func getCompletionTables() *completionTables {
+	completionTablesOnce.Do(func() {
+		t := &completionTabs
+		t.tokenTexts = make(map[int]string)
+		t.keywordCols = make(map[int]bool)
+		t.terminals = make(map[int]int)
+		t.symbols = make(map[string]int)
+		addKeywords := func(m map[string]int) {
+			for str, tok := range m {
+				if _, ok := aliases[str]; ok {
+					continue
+				}
+				if col, ok := yyXLAT[tok]; ok {
+					t.tokenTexts[col] = str
+					t.keywordCols[col] = true
+				}
+			}
+		}
+		addKeywords(tokenMap)
+		addKeywords(btFuncTokenMap)
+		addKeywords(windowFuncTokenMap)
+		for tok, str := range punctuationTexts {
+			if col, ok := yyXLAT[tok]; ok {
+				t.tokenTexts[col] = str
+			}
+		}
+		for tok, col := range yyXLAT {
+			if tok > 0 && tok < 128 {
+				t.tokenTexts[col] = string(rune(tok))
+			}
+		}
+		// goyacc numbers nonterminals after all terminals and $default.
+		for tok, col := range yyXLAT {
+			if tok < yyDefault {
+				t.terminals[tok] = col
+			} else if tok > yyDefault {
+				t.symbols[yySymNames[col]] = col
+			}
+		}
+	})
+	return &completionTabs
+}
+
+// identifierKindSymbols maps nonterminals to the kind of identifier they are made of.
+var identifierKindSymbols = map[string]IdentifierKind{
+	"TableName":     IdentifierTable,
+	"TableNameList": IdentifierTable,
+	"ViewName":      IdentifierTable,
+	"ColumnName":    IdentifierColumn,
+	"SimpleIdent":   IdentifierColumn,
+	"DBName":        IdentifierDatabase,
+	"IndexName":     IdentifierIndex,
+	"IndexNameList": IdentifierIndex,
+}
+
+// identifierKeywordSymbols are the nonterminals that make keywords be used as identifiers.
+var identifierKeywordSymbols = []string{"UnReservedKeyword", "NotKeywordToken", "TiDBKeyword"}
+
+func collectCandidates(res *Completion, stack []int) {
+	tabs := getCompletionTables()
+	state := stack[len(stack)-1]
+	identKeywordSyms := make(map[int]bool, len(identifierKeywordSymbols))
+	for _, name := range identifierKeywordSymbols {
+		identKeywordSyms[tabs.symbols[name]] = true
+	}
+	for tok, col := range tabs.terminals {
+		if lrAction(state, col) == 0 && state != 1 {
+			continue
+		}
+		next, ok := lrFeed(copyStack(stack), col, nil)
+		if !ok || len(next) == 0 {
+			continue
+		}
+		switch {
+		case tok == identifier:
+			collectIdentifierKinds(res, next)
+		case tok == singleAtIdentifier || tok == doubleAtIdentifier:
+			res.IdentifierKinds = appendIdentifierKind(res.IdentifierKinds, IdentifierVariable)
+		case tabs.keywordCols[col]:
+			if !onlyReducesTo(next[len(next)-1], identKeywordSyms) {
+				res.Keywords = append(res.Keywords, tabs.tokenTexts[col])
+			}
+		case tabs.tokenTexts[col] != "":
+			res.Punctuations = append(res.Punctuations, tabs.tokenTexts[col])
+		}
+	}
+	res.Keywords = dedupSorted(res.Keywords)
+	res.Punctuations = dedupSorted(res.Punctuations)
+}
+
+// onlyReducesTo reports whether the only actions of the state are reductions to the symbols.
+func onlyReducesTo(state int, symbols map[int]bool) bool {
+	for _, col := range getCompletionTables().terminals {
+		action := lrAction(state, col)
+		if action == 0 {
+			continue
+		}
+		if action > 0 || !symbols[yyReductions[-action].xsym] {
+			return false
+		}
+	}
+	return true
+}
+
+// collectIdentifierKinds finds out the kinds of identifier by the nonterminals the
+// identifier is reduced to, stack is the state stack after the identifier is shifted.
+func collectIdentifierKinds(res *Completion, stack []int) {
+	tabs := getCompletionTables()
+	kindSyms := make(map[int]IdentifierKind, len(identifierKindSymbols))
+	for name, kind := range identifierKindSymbols {
+		kindSyms[tabs.symbols[name]] = kind
+	}
+	state := stack[len(stack)-1]
+	var kinds []IdentifierKind
+	for tok, col := range tabs.terminals {
+		if lrAction(state, col) == 0 {
+			continue
+		}
+		if tok == '(' && lrAction(state, col) > 0 {
+			kinds = appendIdentifierKind(kinds, IdentifierFunction)
+		}
+		var found []IdentifierKind
+		_, ok := lrFeed(copyStack(stack), col, func(xsym int) {
+			if kind, ok := kindSyms[xsym]; ok {
+				found = appendIdentifierKind(found, kind)
+			}
+		})
+		if ok {
+			for _, kind := range found {
+				kinds = appendIdentifierKind(kinds, kind)
+			}
+		}
+	}
+	// a generic function call is only possible where a column is.
+	if !containsIdentifierKind(kinds, IdentifierColumn) {
+		kinds = removeIdentifierKind(kinds, IdentifierFunction)
+	}
+	for _, kind := range kinds {
+		res.IdentifierKinds = appendIdentifierKind(res.IdentifierKinds, kind)
+	}
+}
+
+func containsIdentifierKind(kinds []IdentifierKind, kind IdentifierKind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func appendIdentifierKind(kinds []IdentifierKind, kind IdentifierKind) []IdentifierKind {
+	if containsIdentifierKind(kinds, kind) {
+		return kinds
+	}
+	return append(kinds, kind)
+}
+
+func removeIdentifierKind(kinds []IdentifierKind, kind IdentifierKind) []IdentifierKind {
+	for i, k := range kinds {
+		if k == kind {
+			return append(kinds[:i], kinds[i+1:]...)
+		}
+	}
+	return kinds
+}
+
+func dedupSorted(strs []string) []string {
+	sort.Strings(strs)
+	res := strs[:0]
+	for i, str := range strs {
+		if i == 0 || str != strs[i-1] {
+			res = append(res, str)
+		}
+	}
+	return res
+}
+
+func filterByPrefix(strs []string, prefix string) []string {
+	prefix = strings.ToUpper(prefix)
+	res := strs[:0]
+	for _, str := range strs {
+		if strings.HasPrefix(str, prefix) {
+			res = append(res, str)
+		}
+	}
+	return res
+}
+
+// tokensBefore returns the tokens ending before offset, comments are skipped.
+func tokensBefore(tokens []Token, offset int) []Token {
+	res := make([]Token, 0, len(tokens))
+	for _, tok := range tokens {
+		if tok.Pos.Offset+len(tok.Text) > offset {
+			break
+		}
+		switch tok.Kind {
+		case TokenComment, TokenVersionComment, TokenHint:
+			continue
+		}
+		res = append(res, tok)
+	}
+	return res
+}
+
+// completionQualifier returns the name before `name.` at the end of text.
+func completionQualifier(text string, tokens []Token) string {
+	before := tokensBefore(tokens, len(text))
+	n := len(before)
+	if n < 2 || before[n-1].Text != "." || before[n-1].Pos.Offset+1 != len(strings.TrimRight(text, " \t\r\n")) {
+		return ""
+	}
+	switch before[n-2].Kind {
+	case TokenIdentifier, TokenKeyword, TokenQuotedIdentifier:
+		return before[n-2].Value
+	}
+	return ""
+}
+
+// isIndexContext reports whether an index name is expected at the end of text, it
+// is used because most index names are plain identifiers in the grammar.
+func isIndexContext(text string, tokens []Token) bool {
+	before := tokensBefore(tokens, len(text))
+	n := len(before)
+	isIndexKeyword := func(tok Token) bool {
+		return strings.EqualFold(tok.Text, "INDEX") || strings.EqualFold(tok.Text, "KEY")
+	}
+	switch {
+	case n >= 1 && isIndexKeyword(before[n-1]):
+		// DROP INDEX |, ALTER TABLE t RENAME INDEX |
+		return true
+	case n >= 2 && before[n-1].Text == "(" && isIndexKeyword(before[n-2]):
+		// USE INDEX (|
+		return true
+	}
+	// USE INDEX (a, |
+	for i := n - 1; i >= 1; i-- {
+		switch {
+		case before[i].Text == ",":
+			continue
+		case before[i].Kind == TokenIdentifier || before[i].Kind == TokenQuotedIdentifier:
+			continue
+		case before[i].Text == "(":
+			return isIndexKeyword(before[i-1]) && before[n-1].Text == ","
+		}
+		return false
+	}
+	return false
+}
+
+// tableRefKeywords are the keywords followed by table references.
+var tableRefKeywords = map[string]bool{
+	"FROM":   true,
+	"JOIN":   true,
+	"UPDATE": true,
+	"INTO":   true,
+	"TABLE":  true,
+}
+
+// visibleTables extracts the table references of the statement in [stmtStart, stmtEnd),
+// tables in subqueries not enclosing the cursor are invisible. The word being typed in
+// [prefixStart, cursor) is ignored.
+func visibleTables(tokens []Token, stmtStart, stmtEnd, prefixStart, cursor int) []CompletionTable {
+	var (
+		stmtTokens []Token
+		// groups[i] is the index of the parenthesis group where stmtTokens[i] is in.
+		groups  []int
+		parents = []int{-1}
+		stack   = []int{0}
+	)
+	cursorGroup := -1
+	for _, tok := range tokens {
+		if tok.Pos.Offset < stmtStart || tok.Pos.Offset >= stmtEnd ||
+			(tok.Pos.Offset >= prefixStart && tok.Pos.Offset < cursor) {
+			continue
+		}
+		switch tok.Kind {
+		case TokenComment, TokenVersionComment, TokenHint:
+			continue
+		}
+		if cursorGroup < 0 && tok.Pos.Offset >= cursor {
+			cursorGroup = stack[len(stack)-1]
+		}
+		switch tok.Text {
+		case "(":
+			parents = append(parents, stack[len(stack)-1])
+			stack = append(stack, len(parents)-1)
+		case ")":
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+		stmtTokens = append(stmtTokens, tok)
+		groups = append(groups, stack[len(stack)-1])
+	}
+	if cursorGroup < 0 {
+		cursorGroup = stack[len(stack)-1]
+	}
+	visible := make(map[int]bool)
+	for g := cursorGroup; g >= 0; g = parents[g] {
+		visible[g] = true
+	}
+
+	var (
+		tables      []CompletionTable
+		inTableRefs bool
+		// saved keeps inTableRefs of the outer groups.
+		saved []bool
+	)
+	for i := 0; i < len(stmtTokens); i++ {
+		tok := stmtTokens[i]
+		switch {
+		case tok.Text == "(" && tok.Kind == TokenOperator:
+			saved = append(saved, inTableRefs)
+			inTableRefs = false
+			continue
+		case tok.Text == ")" && tok.Kind == TokenOperator:
+			if len(saved) == 0 {
+				continue
+			}
+			inTableRefs = saved[len(saved)-1]
+			saved = saved[:len(saved)-1]
+			if !inTableRefs || !visible[groups[i]] {
+				continue
+			}
+			// the alias of a derived table.
+			j := i + 1
+			if j < len(stmtTokens) && strings.EqualFold(stmtTokens[j].Text, "AS") {
+				j++
+			}
+			if j < len(stmtTokens) && isNameToken(stmtTokens[j]) {
+				tables = append(tables, CompletionTable{Alias: stmtTokens[j].Value})
+				i = j
+			}
+			continue
+		case tok.Kind == TokenKeyword && inTableRefs && i > 0 &&
+			(stmtTokens[i-1].Text == "," || tableRefKeywords[strings.ToUpper(stmtTokens[i-1].Text)]):
+			// an unreserved keyword used as table name.
+		case tok.Kind == TokenReservedKeyword || tok.Kind == TokenKeyword:
+			upper := strings.ToUpper(tok.Text)
+			if isRef, ok := tableRefKeywords[upper]; ok || upper != "AS" {
+				inTableRefs = isRef
+			}
+			continue
+		case !isNameToken(tok):
+			continue
+		}
+		if !inTableRefs || !visible[groups[i]] {
+			continue
+		}
+		if i > 0 && (stmtTokens[i-1].Text == "." || isNameToken(stmtTokens[i-1])) {
+			continue
+		}
+		table := CompletionTable{Name: tok.Value}
+		j := i + 1
+		if j < len(stmtTokens) && stmtTokens[j].Text == "." {
+			if j+1 == len(stmtTokens) || !isNameToken(stmtTokens[j+1]) {
+				// the table name is not typed yet.
+				i = j
+				continue
+			}
+			table.Schema, table.Name = table.Name, stmtTokens[j+1].Value
+			j += 2
+		}
+		if j < len(stmtTokens) && strings.EqualFold(stmtTokens[j].Text, "AS") {
+			j++
+		}
+		i = j - 1
+		if j < len(stmtTokens) && isNameToken(stmtTokens[j]) && groups[j] == groups[i] {
+			table.Alias = stmtTokens[j].Value
+			i = j
+		}
+		tables = append(tables, table)
+	}
+	return tables
+}
+
+func isNameToken(tok Token) bool {
+	return tok.Kind == TokenIdentifier || tok.Kind == TokenQuotedIdentifier
+}
@@ -0,0 +1,157 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser_test
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+)
+
+var _ = Suite(&testCompletionSuite{})
+
+type testCompletionSuite struct {
+}
+
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
+
+func complete(c *C, sql string) *parser.Completion {
+	res, err := parser.New().Complete(sql, len(sql))
+	c.Assert(err, IsNil, Commentf("sql: %s", sql))
+	return res
+}
+
+func (s *testCompletionSuite) TestKeywords(c *C) {
+	res := complete(c, "")
+	c.Assert(containsString(res.Keywords, "SELECT"), IsTrue)
+	c.Assert(containsString(res.Keywords, "CREATE"), IsTrue)
+	c.Assert(containsString(res.Keywords, "WHERE"), IsFalse)
+	c.Assert(res.IdentifierKinds, HasLen, 0)
+
+	res = complete(c, "SELECT * FROM t WH")
+	c.Assert(res.Prefix, Equals, "WH")
+	c.Assert(res.Keywords, DeepEquals, []string{"WHERE"})
+	c.Assert(res.Punctuations, HasLen, 0)
+
+	// unreserved keywords which can only be identifiers here are not listed.
+	res = complete(c, "SELECT * FROM ")
+	c.Assert(res.Keywords, DeepEquals, []string{"DUAL"})
+	c.Assert(containsString(res.Punctuations, "("), IsTrue)
+
+	res = complete(c, "SELECT * FROM t ORDER BY a ")
+	c.Assert(containsString(res.Keywords, "DESC"), IsTrue)
+	c.Assert(containsString(res.Keywords, "LIMIT"), IsTrue)
+	c.Assert(containsString(res.Punctuations, ","), IsTrue)
+
+	// the cursor is in the middle of sql.
+	sql := "SELECT * FR t; SELECT 1"
+	res, err := parser.New().Complete(sql, len("SELECT * FR"))
+	c.Assert(err, IsNil)
+	c.Assert(res.Prefix, Equals, "FR")
+	c.Assert(res.Keywords, DeepEquals, []string{"FROM"})
+	res, err = parser.New().Complete(sql, len(sql))
+	c.Assert(err, IsNil)
+	c.Assert(containsString(res.Keywords, "FROM"), IsTrue)
+
+	// the cursor is in a string.
+	sql = "SELECT 'abc'"
+	res, err = parser.New().Complete(sql, 9)
+	c.Assert(err, IsNil)
+	c.Assert(res.Keywords, HasLen, 0)
+
+	// the cursor is in an unterminated string.
+	res, err = parser.New().Complete("SELECT 'ab", 10)
+	c.Assert(err, IsNil)
+	c.Assert(res.Keywords, HasLen, 0)
+
+	_, err = parser.New().Complete("SELECT * FROM WHERE ", 20)
+	c.Assert(err, NotNil)
+	_, err = parser.New().Complete("SELECT", 7)
+	c.Assert(err, ErrorMatches, "cursor 7 is out of .*")
+	_, err = parser.New().Complete("SELECT", -1)
+	c.Assert(err, NotNil)
+}
+
+func (s *testCompletionSuite) TestIdentifierKinds(c *C) {
+	kinds := func(sql string) []parser.IdentifierKind {
+		return complete(c, sql).IdentifierKinds
+	}
+	c.Assert(kinds("SELECT * FROM "), DeepEquals, []parser.IdentifierKind{parser.IdentifierTable})
+	c.Assert(kinds("INSERT INTO "), DeepEquals, []parser.IdentifierKind{parser.IdentifierTable})
+	c.Assert(kinds("SELECT * FROM t WHERE "), DeepEquals,
+		[]parser.IdentifierKind{parser.IdentifierColumn, parser.IdentifierFunction, parser.IdentifierVariable})
+	c.Assert(kinds("USE "), DeepEquals, []parser.IdentifierKind{parser.IdentifierDatabase})
+	c.Assert(kinds("DROP INDEX "), DeepEquals, []parser.IdentifierKind{parser.IdentifierIndex})
+	c.Assert(kinds("SELECT * FROM t USE INDEX (a, "), DeepEquals, []parser.IdentifierKind{parser.IdentifierIndex})
+	c.Assert(kinds("INSERT INTO t ("), DeepEquals, []parser.IdentifierKind{parser.IdentifierColumn})
+	c.Assert(kinds("SELECT @"), DeepEquals, []parser.IdentifierKind{parser.IdentifierVariable})
+	c.Assert(parser.IdentifierDatabase.String(), Equals, "database")
+
+	res := complete(c, "SELECT t.")
+	c.Assert(res.Qualifier, Equals, "t")
+	c.Assert(res.IdentifierKinds, DeepEquals, []parser.IdentifierKind{parser.IdentifierColumn})
+	c.Assert(res.Punctuations, DeepEquals, []string{"*"})
+
+	res = complete(c, "SELECT * FROM db.")
+	c.Assert(res.Qualifier, Equals, "db")
+	c.Assert(res.IdentifierKinds, DeepEquals, []parser.IdentifierKind{parser.IdentifierTable})
+
+	// the cursor is in an unterminated quoted identifier.
+	res = complete(c, "SELECT * FROM `db`.`t")
+	c.Assert(res.Prefix, Equals, "t")
+	c.Assert(res.Qualifier, Equals, "db")
+	c.Assert(res.IdentifierKinds, DeepEquals, []parser.IdentifierKind{parser.IdentifierTable})
+
+	res = complete(c, "SELECT * FROM t1 a JOIN t2 AS b ON a.id = b.i")
+	c.Assert(res.Prefix, Equals, "i")
+	c.Assert(res.Qualifier, Equals, "b")
+	c.Assert(res.HasIdentifierKind(parser.IdentifierColumn), IsTrue)
+}
+
+func (s *testCompletionSuite) TestTables(c *C) {
+	res := complete(c, "SELECT * FROM t1 a JOIN db.t2 AS b ON a.id = b.")
+	c.Assert(res.Tables, DeepEquals, []parser.CompletionTable{
+		{Name: "t1", Alias: "a"},
+		{Schema: "db", Name: "t2", Alias: "b"},
+	})
+
+	// the tables of the enclosing query are visible in a subquery.
+	res = complete(c, "SELECT a FROM t WHERE x IN (SELECT y FROM u WHERE ")
+	c.Assert(res.Tables, DeepEquals, []parser.CompletionTable{{Name: "t"}, {Name: "u"}})
+
+	// the tables of a subquery are invisible outside.
+	res = complete(c, "SELECT a FROM t WHERE x IN (SELECT y FROM u) AND ")
+	c.Assert(res.Tables, DeepEquals, []parser.CompletionTable{{Name: "t"}})
+
+	// tables after the cursor are visible too.
+	sql := "SELECT  FROM t1, `t 2` x WHERE a = 1"
+	res, err := parser.New().Complete(sql, len("SELECT "))
+	c.Assert(err, IsNil)
+	c.Assert(res.Tables, DeepEquals, []parser.CompletionTable{{Name: "t1"}, {Name: "t 2", Alias: "x"}})
+
+	res = complete(c, "SELECT * FROM (SELECT a FROM t1) AS d, t2 WHERE ")
+	c.Assert(res.Tables, DeepEquals, []parser.CompletionTable{{Alias: "d"}, {Name: "t2"}})
+
+	res = complete(c, "UPDATE t SET a = 1 WHERE ")
+	c.Assert(res.Tables, DeepEquals, []parser.CompletionTable{{Name: "t"}})
+
+	res = complete(c, "SELECT * FROM t WH")
+	c.Assert(res.Tables, DeepEquals, []parser.CompletionTable{{Name: "t"}})
+}
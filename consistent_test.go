@@ -74,6 +74,22 @@ func (s *testConsistentSuite) TestKeywordConsistent(c *C) {
 	c.Assert(s.tidbKeywords, DeepEquals, tidbKeywordsCollectionDef)
 }
 
+func (s *testConsistentSuite) TestReservedKeywordTokens(c *C) {
+	c.Assert(reservedKeywordTokens, HasLen, len(s.reservedKeywords))
+	for _, kw := range s.reservedKeywords {
+		tok, ok := tokenMap[kw]
+		if !ok {
+			tok, ok = windowFuncTokenMap[kw]
+		}
+		if !ok {
+			// the token is not mapped from its name, such as TIDB_RANK.
+			continue
+		}
+		_, ok = reservedKeywordTokens[tok]
+		c.Assert(ok, IsTrue, Commentf("%s is not in reservedKeywordTokens", kw))
+	}
+}
+
 func extractMiddle(str, startMarker, endMarker string) string {
 	startIdx := strings.Index(str, startMarker)
 	if startIdx == -1 {
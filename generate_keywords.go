@@ -0,0 +1,102 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build ignore
+// +build ignore
+
+// generate_keywords.go generates reserved_keywords.go, which lists the tokens
+// declared in the ReservedKeyword section of parser.y.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	goformat "go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+)
+
+const header = `// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by generate_keywords.go. DO NOT EDIT.
+
+package parser
+
+// reservedKeywordTokens is the set of tokens belong to ReservedKeyword in parser.y,
+// they can't be used as identifiers without quoting.
+var reservedKeywordTokens = map[int]struct{}{
+`
+
+const (
+	sectionPrefix  = "/* The following tokens belong to "
+	reservedMarker = sectionPrefix + "ReservedKeyword."
+)
+
+func main() {
+	tokens, err := reservedTokens("parser.y")
+	if err != nil {
+		log.Fatal(err)
+	}
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	for _, tok := range tokens {
+		buf.WriteString("\t" + tok + ": {},\n")
+	}
+	buf.WriteString("}\n")
+	src, err := goformat.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = ioutil.WriteFile("reserved_keywords.go", src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// reservedTokens returns the names of the tokens declared in the ReservedKeyword section of the grammar.
+func reservedTokens(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var (
+		tokens  []string
+		section bool
+	)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, reservedMarker):
+			section = true
+		case strings.HasPrefix(line, sectionPrefix), strings.HasPrefix(line, "%"):
+			section = false
+		case section && line != "":
+			tokens = append(tokens, strings.Fields(line)[0])
+		}
+	}
+	return tokens, scanner.Err()
+}
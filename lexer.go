@@ -81,6 +81,14 @@ type Scanner struct {
 
 	// true if a dot follows an identifier
 	identifierDot bool
+
+	// keepComments indicates comments and version comment markers are returned
+	// as tokens instead of being skipped, it is used by Tokenizer.
+	keepComments bool
+
+	// startToken is returned before any other token if it isn't 0,
+	// it makes the parser start from a rule other than statements.
+	startToken int
 }
 
 // Errors returns the errors and warns during a scan.
@@ -176,6 +184,12 @@ func (s *Scanner) getNextToken() int {
 // return 0 tells parser that scanner meets EOF,
 // return invalid tells parser that scanner meets illegal character.
 func (s *Scanner) Lex(v *yySymType) int {
+	if s.startToken != 0 {
+		tok := s.startToken
+		s.startToken = 0
+		v.offset = 0
+		return tok
+	}
 	tok, pos, lit := s.scan()
 	s.lastScanOffset = pos.Offset
 	s.lastKeyword3 = s.lastKeyword2
@@ -387,9 +401,13 @@ func startWithBb(s *Scanner) (tok int, pos Pos, lit string) {
 }
 
 func startWithSharp(s *Scanner) (tok int, pos Pos, lit string) {
+	pos = s.r.pos()
 	s.r.incAsLongAs(func(ch rune) bool {
 		return ch != '\n'
 	})
+	if s.keepComments {
+		return commentTok, pos, s.r.data(&pos)
+	}
 	return s.scan()
 }
 
@@ -401,6 +419,9 @@ func startWithDash(s *Scanner) (tok int, pos Pos, lit string) {
 			s.r.incAsLongAs(func(ch rune) bool {
 				return ch != '\n'
 			})
+			if s.keepComments {
+				return commentTok, pos, s.r.data(&pos)
+			}
 			return s.scan()
 		}
 	}
@@ -439,6 +460,9 @@ func startWithSlash(s *Scanner) (tok int, pos Pos, lit string) {
 		// in '/*!', which we always recognize regardless of version.
 		s.scanVersionDigits(5, 5)
 		s.inBangComment = true
+		if s.keepComments {
+			return versionCommentStart, pos, s.r.data(&pos)
+		}
 		return s.scan()
 
 	case 'T': // '/*T' maybe TiDB-specific comments
@@ -451,6 +475,9 @@ func startWithSlash(s *Scanner) (tok int, pos Pos, lit string) {
 		features := s.scanFeatureIDs()
 		if tidbfeature.CanParseFeature(features...) {
 			s.inBangComment = true
+			if s.keepComments {
+				return versionCommentStart, pos, s.r.data(&pos)
+			}
 			return s.scan()
 		}
 	case 'M': // '/*M' maybe MariaDB-specific comments
@@ -491,6 +518,8 @@ func startWithSlash(s *Scanner) (tok int, pos Pos, lit string) {
 				if isOptimizerHint {
 					s.lastHintPos = pos
 					return hintComment, pos, s.r.data(&pos)
+				} else if s.keepComments {
+					return commentTok, pos, s.r.data(&pos)
 				} else {
 					return s.scan()
 				}
@@ -518,6 +547,9 @@ func startWithStar(s *Scanner) (tok int, pos Pos, lit string) {
 	if s.inBangComment && s.r.peek() == '/' {
 		s.inBangComment = false
 		s.r.inc()
+		if s.keepComments {
+			return versionCommentEnd, pos, s.r.data(&pos)
+		}
 		return s.scan()
 	}
 	// otherwise it is just a normal star.
@@ -320,6 +320,11 @@ type TableInfo struct {
 
 	Compression string `json:"compression"`
 
+	// Engine, RowFormat and KeyBlockSize are the storage options of MySQL.
+	Engine       string `json:"engine,omitempty"`
+	RowFormat    string `json:"row_format,omitempty"`
+	KeyBlockSize uint64 `json:"key_block_size,omitempty"`
+
 	View *ViewInfo `json:"view"`
 
 	Sequence *SequenceInfo `json:"sequence"`
@@ -958,12 +963,13 @@ type IndexInfo struct {
 	Table     CIStr          `json:"tbl_name"` // Table name.
 	Columns   []*IndexColumn `json:"idx_cols"` // Index columns.
 	State     SchemaState    `json:"state"`
-	Comment   string         `json:"comment"`      // Comment
-	Tp        IndexType      `json:"index_type"`   // Index type: Btree, Hash or Rtree
-	Unique    bool           `json:"is_unique"`    // Whether the index is unique.
-	Primary   bool           `json:"is_primary"`   // Whether the index is primary key.
-	Invisible bool           `json:"is_invisible"` // Whether the index is invisible.
-	Global    bool           `json:"is_global"`    // Whether the index is global.
+	Comment   string         `json:"comment"`               // Comment
+	Tp        IndexType      `json:"index_type"`            // Index type: Btree, Hash or Rtree
+	Unique    bool           `json:"is_unique"`             // Whether the index is unique.
+	Primary   bool           `json:"is_primary"`            // Whether the index is primary key.
+	Invisible bool           `json:"is_invisible"`          // Whether the index is invisible.
+	Global    bool           `json:"is_global"`             // Whether the index is global.
+	Fulltext  bool           `json:"is_fulltext,omitempty"` // Whether the index is a FULLTEXT index, which has no index type.
 }
 
 // Clone clones IndexInfo.
@@ -62,6 +62,8 @@ import (
 	doubleAtIdentifier "identifier with double leading at"
 	invalid            "a special token never used by parser, used by lexer to indicate error"
 	hintComment        "an optimizer hint"
+	exprStart          "a special token to start parsing an expression"
+	fieldTypeStart     "a special token to start parsing a field type"
 	andand             "&&"
 	pipes              "||"
 
@@ -1476,6 +1478,18 @@ import (
 
 Start:
 	StatementList
+|	exprStart Expression
+	{
+		parser.exprResult = $2
+	}
+|	fieldTypeStart Type OptCollate
+	{
+		tp := $2.(*types.FieldType)
+		if $3 != "" {
+			tp.Collate = $3
+		}
+		parser.fieldTypeResult = tp
+	}
 
 /**************************************AlterTableStmt***************************************
  * See https://dev.mysql.com/doc/refman/5.7/en/alter-table.html
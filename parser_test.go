@@ -6438,3 +6438,92 @@ func (g *gbkEncodingChecker) Enter(n ast.Node) (node ast.Node, skipChildren bool
 func (g *gbkEncodingChecker) Leave(n ast.Node) (node ast.Node, ok bool) {
 	return n, true
 }
+
+func (s *testParserSuite) TestParseExpr(c *C) {
+	p := parser.New()
+	expr, warns, err := p.ParseExpr("a + 1 > b")
+	c.Assert(err, IsNil)
+	c.Assert(warns, IsNil)
+	cmp, ok := expr.(*ast.BinaryOperationExpr)
+	c.Assert(ok, IsTrue)
+	c.Assert(cmp.Op, Equals, opcode.GT)
+	c.Assert(cmp.R.(*ast.ColumnNameExpr).Name.Name.O, Equals, "b")
+
+	var sb strings.Builder
+	expr, _, err = p.ParseExpr("json_extract(`doc`, _utf8mb4'$.id')")
+	c.Assert(err, IsNil)
+	c.Assert(expr.Restore(NewRestoreCtx(DefaultRestoreFlags, &sb)), IsNil)
+	c.Assert(sb.String(), Equals, "JSON_EXTRACT(`doc`, _UTF8MB4'$.id')")
+
+	expr, _, err = p.ParseExpr("'abc'")
+	c.Assert(err, IsNil)
+	c.Assert(expr.(ast.ValueExpr).GetString(), Equals, "abc")
+
+	_, _, err = p.ParseExpr("a +")
+	c.Assert(err, ErrorMatches, `line 1 column 3 near "".*`)
+	_, _, err = p.ParseExpr("select 1")
+	c.Assert(err, NotNil)
+	_, _, err = p.ParseExpr("1; 2")
+	c.Assert(err, NotNil)
+	_, _, err = p.ParseExpr("")
+	c.Assert(err, NotNil)
+
+	// the parser still works for statements.
+	stmt, err := p.ParseOneStmt("select a from t", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmt, FitsTypeOf, &ast.SelectStmt{})
+}
+
+func (s *testParserSuite) TestParseFieldType(c *C) {
+	p := parser.New()
+	tp, warns, err := p.ParseFieldType("int(10) unsigned zerofill")
+	c.Assert(err, IsNil)
+	c.Assert(warns, IsNil)
+	c.Assert(tp.Tp, Equals, mysql.TypeLong)
+	c.Assert(tp.Flen, Equals, 10)
+	c.Assert(mysql.HasUnsignedFlag(tp.Flag), IsTrue)
+	c.Assert(mysql.HasZerofillFlag(tp.Flag), IsTrue)
+
+	tp, _, err = p.ParseFieldType("varchar(20) character set utf8mb4 collate utf8mb4_general_ci")
+	c.Assert(err, IsNil)
+	c.Assert(tp.Tp, Equals, mysql.TypeVarchar)
+	c.Assert(tp.Flen, Equals, 20)
+	c.Assert(tp.Charset, Equals, "utf8mb4")
+	c.Assert(tp.Collate, Equals, "utf8mb4_general_ci")
+
+	tp, _, err = p.ParseFieldType("char(3) charset latin1")
+	c.Assert(err, IsNil)
+	c.Assert(tp.Collate, Equals, "latin1_bin")
+
+	tp, _, err = p.ParseFieldType("text collate utf8_bin")
+	c.Assert(err, IsNil)
+	c.Assert(tp.Charset, Equals, "utf8")
+
+	tp, _, err = p.ParseFieldType("varchar(10)")
+	c.Assert(err, IsNil)
+	c.Assert(tp.Charset, Equals, "")
+	c.Assert(tp.Collate, Equals, "")
+
+	tp, _, err = p.ParseFieldType("enum('a','b')")
+	c.Assert(err, IsNil)
+	c.Assert(tp.Elems, DeepEquals, []string{"a", "b"})
+
+	tp, _, err = p.ParseFieldType("varbinary(16)")
+	c.Assert(err, IsNil)
+	c.Assert(mysql.HasBinaryFlag(tp.Flag), IsTrue)
+	c.Assert(tp.Charset, Equals, charset.CharsetBin)
+
+	tp, _, err = p.ParseFieldType("decimal(10,2)")
+	c.Assert(err, IsNil)
+	c.Assert(tp.Flen, Equals, 10)
+	c.Assert(tp.Decimal, Equals, 2)
+
+	_, _, err = p.ParseFieldType("varchar(10) charset utf8mb4 collate latin1_bin")
+	c.Assert(charset.ErrCollationCharsetMismatch.Equal(err), IsTrue)
+	_, _, err = p.ParseFieldType("varchar(10) collate unknown")
+	c.Assert(err, NotNil)
+	_, _, err = p.ParseFieldType("varchar")
+	c.Assert(err, ErrorMatches, `line 1 column 7 near "".*`)
+	_, _, err = p.ParseFieldType("a int")
+	c.Assert(err, NotNil)
+}
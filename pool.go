@@ -0,0 +1,159 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/mysql"
+)
+
+// PoolConfig is the configuration shared by all Parsers of a Pool.
+type PoolConfig struct {
+	// SQLMode is the SQL mode of the parsers.
+	SQLMode mysql.SQLMode
+	// ParserConfig is applied to every parser created by the pool.
+	ParserConfig ParserConfig
+	// Charset and Collation are passed to Parser.Parse,
+	// if they are "", default charset and collation will be used.
+	Charset   string
+	Collation string
+}
+
+// DefaultPoolConfig returns the PoolConfig which configures parsers the same as New.
+func DefaultPoolConfig() PoolConfig {
+	mode, _ := mysql.GetSQLMode(mysql.DefaultSQLMode)
+	return PoolConfig{
+		SQLMode: mode,
+		ParserConfig: ParserConfig{
+			EnableWindowFunction:        true,
+			EnableStrictDoubleTypeCheck: true,
+		},
+	}
+}
+
+// Pool is a concurrency-safe entry for parsing, it keeps a pool of Parsers
+// which are configured by the same PoolConfig.
+// Parser is not safe for concurrent use, use Pool instead when parsing from
+// multiple goroutines.
+type Pool struct {
+	config  PoolConfig
+	parsers sync.Pool
+}
+
+// NewPool returns a Pool whose parsers are configured by config.
+func NewPool(config PoolConfig) *Pool {
+	p := &Pool{config: config}
+	p.parsers.New = func() interface{} {
+		parser := New()
+		parser.SetParserConfig(config.ParserConfig)
+		parser.SetSQLMode(config.SQLMode)
+		return parser
+	}
+	return p
+}
+
+// Config returns the configuration of the pool.
+func (p *Pool) Config() PoolConfig {
+	return p.config
+}
+
+// Parse parses a query string to raw ast.StmtNode like Parser.Parse.
+// It is safe to be called concurrently. The returned statements are owned
+// by the caller and don't share any buffer with the pooled parsers.
+func (p *Pool) Parse(sql string) (stmts []ast.StmtNode, warns []error, err error) {
+	parser := p.parsers.Get().(*Parser)
+	stmts, warns, err = parser.Parse(sql, p.config.Charset, p.config.Collation)
+	if len(stmts) > 0 {
+		stmts = append([]ast.StmtNode(nil), stmts...)
+	}
+	parser.release()
+	p.parsers.Put(parser)
+	return stmts, warns, err
+}
+
+// ParseOneStmt parses a query and returns an ast.StmtNode like Parser.ParseOneStmt.
+// It is safe to be called concurrently.
+func (p *Pool) ParseOneStmt(sql string) (ast.StmtNode, error) {
+	stmts, _, err := p.Parse(sql)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(stmts) != 1 {
+		return nil, ErrSyntax
+	}
+	return stmts[0], nil
+}
+
+// BatchResult is the result of parsing one query in Pool.ParseBatch.
+type BatchResult struct {
+	Stmts []ast.StmtNode
+	Warns []error
+	Err   error
+}
+
+// ParseBatch parses independent queries in parallel.
+// concurrency is the number of goroutines used, GOMAXPROCS is used if it is not positive.
+// The i-th result corresponds to the i-th query.
+func (p *Pool) ParseBatch(sqls []string, concurrency int) []BatchResult {
+	results := make([]BatchResult, len(sqls))
+	if concurrency <= 0 {
+		concurrency = runtime.GOMAXPROCS(0)
+	}
+	if concurrency > len(sqls) {
+		concurrency = len(sqls)
+	}
+	var (
+		next int64 = -1
+		wg   sync.WaitGroup
+	)
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for {
+				idx := int(atomic.AddInt64(&next, 1))
+				if idx >= len(sqls) {
+					return
+				}
+				r := &results[idx]
+				r.Stmts, r.Warns, r.Err = p.Parse(sqls[idx])
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// release drops the references to the last parsed query and its AST,
+// so a pooled parser doesn't keep them alive.
+func (parser *Parser) release() {
+	for i := range parser.result {
+		parser.result[i] = nil
+	}
+	parser.result = parser.result[:0]
+	parser.exprResult = nil
+	parser.fieldTypeResult = nil
+	for i := range parser.cache {
+		parser.cache[i] = yySymType{}
+	}
+	parser.yylval = yySymType{}
+	parser.yyVAL = nil
+	parser.src = ""
+	parser.lexer.reset("")
+}
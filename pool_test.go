@@ -0,0 +1,115 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser_test
+
+import (
+	"fmt"
+	"sync"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/mysql"
+)
+
+var _ = Suite(&testPoolSuite{})
+
+type testPoolSuite struct {
+}
+
+func (s *testPoolSuite) TestParse(c *C) {
+	pool := parser.NewPool(parser.DefaultPoolConfig())
+	stmts, warns, err := pool.Parse("select 1; select 2")
+	c.Assert(err, IsNil)
+	c.Assert(warns, IsNil)
+	c.Assert(stmts, HasLen, 2)
+
+	// The statements returned before must not be touched by later parsing.
+	for i := 0; i < 10; i++ {
+		_, _, err = pool.Parse("insert into t values (1)")
+		c.Assert(err, IsNil)
+	}
+	c.Assert(stmts[0], FitsTypeOf, &ast.SelectStmt{})
+	c.Assert(stmts[1], FitsTypeOf, &ast.SelectStmt{})
+	c.Assert(stmts[1].Text(), Equals, " select 2")
+
+	stmt, err := pool.ParseOneStmt("delete from t")
+	c.Assert(err, IsNil)
+	c.Assert(stmt, FitsTypeOf, &ast.DeleteStmt{})
+	_, err = pool.ParseOneStmt("select 1; select 2")
+	c.Assert(parser.ErrSyntax.Equal(err), IsTrue)
+	_, _, err = pool.Parse("selec 1")
+	c.Assert(err, NotNil)
+}
+
+func (s *testPoolSuite) TestConfig(c *C) {
+	config := parser.DefaultPoolConfig()
+	config.SQLMode = mysql.ModeANSIQuotes
+	pool := parser.NewPool(config)
+	stmt, err := pool.ParseOneStmt(`select "a" from t`)
+	c.Assert(err, IsNil)
+	field := stmt.(*ast.SelectStmt).Fields.Fields[0]
+	c.Assert(field.Expr, FitsTypeOf, &ast.ColumnNameExpr{})
+
+	config.ParserConfig.EnableWindowFunction = false
+	pool = parser.NewPool(config)
+	_, err = pool.ParseOneStmt("select row_number() over () from t")
+	c.Assert(err, NotNil)
+}
+
+func (s *testPoolSuite) TestParseBatch(c *C) {
+	pool := parser.NewPool(parser.DefaultPoolConfig())
+	sqls := make([]string, 0, 200)
+	for i := 0; i < 200; i++ {
+		if i%10 == 0 {
+			sqls = append(sqls, fmt.Sprintf("selec %d", i))
+			continue
+		}
+		sqls = append(sqls, fmt.Sprintf("select %d from t%d where a = 'v%d'", i, i, i))
+	}
+	for _, concurrency := range []int{0, 1, 8, 1000} {
+		results := pool.ParseBatch(sqls, concurrency)
+		c.Assert(results, HasLen, len(sqls))
+		for i, r := range results {
+			if i%10 == 0 {
+				c.Assert(r.Err, NotNil)
+				continue
+			}
+			c.Assert(r.Err, IsNil)
+			c.Assert(r.Stmts, HasLen, 1)
+			c.Assert(r.Stmts[0].Text(), Equals, sqls[i])
+			tbl := r.Stmts[0].(*ast.SelectStmt).From.TableRefs.Left.(*ast.TableSource).Source.(*ast.TableName)
+			c.Assert(tbl.Name.O, Equals, fmt.Sprintf("t%d", i))
+		}
+	}
+	c.Assert(pool.ParseBatch(nil, 4), HasLen, 0)
+}
+
+func (s *testPoolSuite) TestConcurrentParse(c *C) {
+	pool := parser.NewPool(parser.DefaultPoolConfig())
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				sql := fmt.Sprintf("update t%d set a = %d", i, j)
+				stmt, err := pool.ParseOneStmt(sql)
+				c.Check(err, IsNil)
+				c.Check(stmt.Text(), Equals, sql)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,415 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package review
+
+import (
+	"strings"
+
+	"github.com/pingcap/parser/analysis"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/charset"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/types"
+)
+
+// DDLRules returns the rules of the table conventions, which check CREATE TABLE,
+// ALTER TABLE and CREATE INDEX. They are separate from BuiltinRules, and can be
+// used together with them by a Reviewer.
+func DDLRules() []Rule {
+	return []Rule{
+		primaryKey{},
+		tableCharset{},
+		tableEngine{},
+		tableComment{},
+		columnComment{},
+		indexName{},
+		moneyFloat{},
+		noEnum{},
+		varcharLength{},
+		noForeignKey{},
+		timestampDefault{},
+	}
+}
+
+// columnSpan returns the span of the definition of col.
+func columnSpan(ctx *Context, col *ast.ColumnDef) analysis.Span {
+	return ctx.clause(ctx.spans.Of(col.Name).Start, 0)
+}
+
+// constraintSpan returns the span of the definition of cons, whose position is
+// only known by the columns in its parentheses.
+func constraintSpan(ctx *Context, cons *ast.Constraint) analysis.Span {
+	span := ctx.spans.Of(cons)
+	if span.End <= span.Start {
+		return ctx.stmtSpan
+	}
+	return ctx.clause(span.Start, 1)
+}
+
+// columnOption returns the first option of col in the type.
+func columnOption(col *ast.ColumnDef, tp ast.ColumnOptionType) *ast.ColumnOption {
+	for _, opt := range col.Options {
+		if opt.Tp == tp {
+			return opt
+		}
+	}
+	return nil
+}
+
+// tableOptions returns the options of CREATE TABLE or ALTER TABLE at the cursor,
+// and the name of the table.
+func tableOptions(c *ast.Cursor) ([]*ast.TableOption, *ast.TableName, bool) {
+	switch x := c.Node().(type) {
+	case *ast.CreateTableStmt:
+		return x.Options, x.Table, true
+	case *ast.AlterTableStmt:
+		var opts []*ast.TableOption
+		for _, spec := range x.Specs {
+			if spec.Tp == ast.AlterTableOption {
+				opts = append(opts, spec.Options...)
+			}
+		}
+		return opts, x.Table, true
+	}
+	return nil, nil, false
+}
+
+// isCreate returns whether stmt creates a table from the definitions of the columns,
+// rather than CREATE TABLE ... LIKE.
+func isCreate(stmt *ast.CreateTableStmt) bool {
+	return stmt.ReferTable == nil
+}
+
+type primaryKey struct{}
+
+func (primaryKey) Info() RuleInfo {
+	return RuleInfo{
+		ID:       "ddl-primary-key",
+		Severity: analysis.SeverityHigh,
+		Summary:  "tables must have a primary key",
+	}
+}
+
+func (primaryKey) Check(ctx *Context, c *ast.Cursor) {
+	switch x := c.Node().(type) {
+	case *ast.CreateTableStmt:
+		if !isCreate(x) {
+			return
+		}
+		for _, cons := range x.Constraints {
+			if cons.Tp == ast.ConstraintPrimaryKey {
+				return
+			}
+		}
+		for _, col := range x.Cols {
+			if columnOption(col, ast.ColumnOptionPrimaryKey) != nil {
+				return
+			}
+		}
+		ctx.Report(x.Table, "table %s has no primary key", x.Table.Name.O)
+	case *ast.AlterTableStmt:
+		var drop *ast.AlterTableSpec
+		for _, spec := range x.Specs {
+			switch {
+			case spec.Tp == ast.AlterTableDropPrimaryKey:
+				drop = spec
+			case spec.Tp == ast.AlterTableAddConstraint && spec.Constraint.Tp == ast.ConstraintPrimaryKey:
+				return
+			}
+		}
+		if drop != nil {
+			ctx.Report(x.Table, "the primary key of table %s is dropped", x.Table.Name.O)
+		}
+	}
+}
+
+type tableCharset struct{}
+
+func (tableCharset) Info() RuleInfo {
+	return RuleInfo{
+		ID:       "ddl-charset",
+		Severity: analysis.SeverityMedium,
+		Summary:  "tables and columns must use the allowed charsets",
+		Params:   map[string]string{"charsets": charset.CharsetUTF8MB4},
+	}
+}
+
+// charsetOf returns the charset of the option, which is the charset of the collation for COLLATE.
+func charsetOf(opt *ast.TableOption) string {
+	if opt.Tp == ast.TableOptionCharset {
+		return opt.StrValue
+	}
+	if coll, err := charset.GetCollationByName(opt.StrValue); err == nil {
+		return coll.CharsetName
+	}
+	return ""
+}
+
+func (tableCharset) Check(ctx *Context, c *ast.Cursor) {
+	allowed := ctx.ListParam("charsets")
+	if col, ok := c.Node().(*ast.ColumnDef); ok {
+		// the binary strings and the types such as JSON have no charset to check.
+		if !types.HasCharset(col.Tp) || col.Tp.Charset == charset.CharsetBin {
+			return
+		}
+		cs := col.Tp.Charset
+		if opt := columnOption(col, ast.ColumnOptionCollate); opt != nil {
+			if coll, err := charset.GetCollationByName(opt.StrValue); err == nil {
+				cs = coll.CharsetName
+			}
+		}
+		if cs != "" && !allowed[strings.ToLower(cs)] {
+			ctx.ReportSpan(columnSpan(ctx, col), "column %s uses charset %s", col.Name.Name.O, cs)
+		}
+		return
+	}
+	opts, table, ok := tableOptions(c)
+	if !ok {
+		return
+	}
+	found := false
+	for _, opt := range opts {
+		if opt.Tp != ast.TableOptionCharset && opt.Tp != ast.TableOptionCollate {
+			continue
+		}
+		found = true
+		if cs := charsetOf(opt); cs != "" && !allowed[strings.ToLower(cs)] {
+			ctx.ReportSpan(ctx.option("charset", "character", "collate"), "table %s uses charset %s", table.Name.O, cs)
+		}
+	}
+	if stmt, ok := c.Node().(*ast.CreateTableStmt); ok && isCreate(stmt) && !found {
+		ctx.Report(table, "table %s has no explicit charset", table.Name.O)
+	}
+}
+
+type tableEngine struct{}
+
+func (tableEngine) Info() RuleInfo {
+	return RuleInfo{
+		ID:       "ddl-engine",
+		Severity: analysis.SeverityMedium,
+		Summary:  "tables must use the allowed engines",
+		Params:   map[string]string{"engines": "InnoDB"},
+	}
+}
+
+func (tableEngine) Check(ctx *Context, c *ast.Cursor) {
+	opts, table, ok := tableOptions(c)
+	if !ok {
+		return
+	}
+	allowed := ctx.ListParam("engines")
+	found := false
+	for _, opt := range opts {
+		if opt.Tp != ast.TableOptionEngine {
+			continue
+		}
+		found = true
+		if !allowed[strings.ToLower(opt.StrValue)] {
+			ctx.ReportSpan(ctx.option("engine"), "table %s uses engine %s", table.Name.O, opt.StrValue)
+		}
+	}
+	if stmt, ok := c.Node().(*ast.CreateTableStmt); ok && isCreate(stmt) && !found {
+		ctx.Report(table, "table %s has no explicit engine", table.Name.O)
+	}
+}
+
+type tableComment struct{}
+
+func (tableComment) Info() RuleInfo {
+	return RuleInfo{
+		ID:       "ddl-table-comment",
+		Severity: analysis.SeverityLow,
+		Summary:  "tables must have a comment",
+	}
+}
+
+func (tableComment) Check(ctx *Context, c *ast.Cursor) {
+	stmt, ok := c.Node().(*ast.CreateTableStmt)
+	if !ok || !isCreate(stmt) {
+		return
+	}
+	for _, opt := range stmt.Options {
+		if opt.Tp == ast.TableOptionComment && opt.StrValue != "" {
+			return
+		}
+	}
+	ctx.Report(stmt.Table, "table %s has no comment", stmt.Table.Name.O)
+}
+
+type columnComment struct{}
+
+func (columnComment) Info() RuleInfo {
+	return RuleInfo{
+		ID:       "ddl-column-comment",
+		Severity: analysis.SeverityLow,
+		Summary:  "columns must have a comment",
+	}
+}
+
+func (columnComment) Check(ctx *Context, c *ast.Cursor) {
+	if col, ok := c.Node().(*ast.ColumnDef); ok && columnOption(col, ast.ColumnOptionComment) == nil {
+		ctx.ReportSpan(columnSpan(ctx, col), "column %s has no comment", col.Name.Name.O)
+	}
+}
+
+type indexName struct{}
+
+func (indexName) Info() RuleInfo {
+	return RuleInfo{
+		ID:       "ddl-index-name",
+		Severity: analysis.SeverityLow,
+		Summary:  "the names of the indexes must have the prefixes of the index types",
+		Params:   map[string]string{"index_prefix": "idx_", "unique_prefix": "uk_"},
+	}
+}
+
+func (indexName) Check(ctx *Context, c *ast.Cursor) {
+	check := func(name string, unique bool, span analysis.Span) {
+		prefix := ctx.Param("index_prefix")
+		if unique {
+			prefix = ctx.Param("unique_prefix")
+		}
+		switch {
+		case name == "":
+			ctx.ReportSpan(span, "the index has no name, the name must start with %s", prefix)
+		case !strings.HasPrefix(strings.ToLower(name), strings.ToLower(prefix)):
+			ctx.ReportSpan(span, "the name of index %s doesn't start with %s", name, prefix)
+		}
+	}
+	switch x := c.Node().(type) {
+	case *ast.Constraint:
+		switch x.Tp {
+		case ast.ConstraintKey, ast.ConstraintIndex, ast.ConstraintFulltext:
+			check(x.Name, false, constraintSpan(ctx, x))
+		case ast.ConstraintUniq, ast.ConstraintUniqKey, ast.ConstraintUniqIndex:
+			check(x.Name, true, constraintSpan(ctx, x))
+		}
+	case *ast.ColumnDef:
+		if columnOption(x, ast.ColumnOptionUniqKey) != nil {
+			check("", true, columnSpan(ctx, x))
+		}
+	case *ast.CreateIndexStmt:
+		check(x.IndexName, x.KeyType == ast.IndexKeyTypeUnique, ctx.stmtSpan)
+	case *ast.AlterTableSpec:
+		if x.Tp == ast.AlterTableRenameIndex {
+			// the type of the index is unknown, so the name can have either prefix.
+			name := x.ToKey.L
+			if !strings.HasPrefix(name, strings.ToLower(ctx.Param("index_prefix"))) && !strings.HasPrefix(name, strings.ToLower(ctx.Param("unique_prefix"))) {
+				ctx.ReportSpan(ctx.stmtSpan, "the name of index %s doesn't start with %s or %s", x.ToKey.O, ctx.Param("index_prefix"), ctx.Param("unique_prefix"))
+			}
+		}
+	}
+}
+
+type moneyFloat struct{}
+
+func (moneyFloat) Info() RuleInfo {
+	return RuleInfo{
+		ID:       "ddl-money-float",
+		Severity: analysis.SeverityHigh,
+		Summary:  "the columns of money must not be FLOAT or DOUBLE, use DECIMAL instead",
+		Params:   map[string]string{"columns": "price,amount,money,cost,fee,balance"},
+	}
+}
+
+func (moneyFloat) Check(ctx *Context, c *ast.Cursor) {
+	col, ok := c.Node().(*ast.ColumnDef)
+	if !ok || col.Tp == nil || col.Tp.Tp != mysql.TypeFloat && col.Tp.Tp != mysql.TypeDouble {
+		return
+	}
+	for word := range ctx.ListParam("columns") {
+		if strings.Contains(col.Name.Name.L, word) {
+			ctx.ReportSpan(columnSpan(ctx, col), "column %s of money is %s", col.Name.Name.O, strings.ToUpper(types.TypeStr(col.Tp.Tp)))
+			return
+		}
+	}
+}
+
+type noEnum struct{}
+
+func (noEnum) Info() RuleInfo {
+	return RuleInfo{
+		ID:       "ddl-enum",
+		Severity: analysis.SeverityMedium,
+		Summary:  "ENUM columns are not allowed",
+	}
+}
+
+func (noEnum) Check(ctx *Context, c *ast.Cursor) {
+	if col, ok := c.Node().(*ast.ColumnDef); ok && col.Tp != nil && col.Tp.Tp == mysql.TypeEnum {
+		ctx.ReportSpan(columnSpan(ctx, col), "column %s is ENUM", col.Name.Name.O)
+	}
+}
+
+type varcharLength struct{}
+
+func (varcharLength) Info() RuleInfo {
+	return RuleInfo{
+		ID:       "ddl-varchar-length",
+		Severity: analysis.SeverityMedium,
+		Summary:  "the length of VARCHAR columns is limited",
+		Params:   map[string]string{"max_length": "1024"},
+	}
+}
+
+func (varcharLength) Check(ctx *Context, c *ast.Cursor) {
+	col, ok := c.Node().(*ast.ColumnDef)
+	if !ok || col.Tp == nil || col.Tp.Tp != mysql.TypeVarchar {
+		return
+	}
+	if max := ctx.IntParam("max_length"); col.Tp.Flen > max {
+		ctx.ReportSpan(columnSpan(ctx, col), "the length %d of column %s is more than %d", col.Tp.Flen, col.Name.Name.O, max)
+	}
+}
+
+type noForeignKey struct{}
+
+func (noForeignKey) Info() RuleInfo {
+	return RuleInfo{
+		ID:       "ddl-foreign-key",
+		Severity: analysis.SeverityHigh,
+		Summary:  "foreign keys are not allowed",
+	}
+}
+
+func (noForeignKey) Check(ctx *Context, c *ast.Cursor) {
+	switch x := c.Node().(type) {
+	case *ast.Constraint:
+		if x.Tp == ast.ConstraintForeignKey {
+			ctx.ReportSpan(constraintSpan(ctx, x), "foreign key is used")
+		}
+	case *ast.ColumnDef:
+		if columnOption(x, ast.ColumnOptionReference) != nil {
+			ctx.ReportSpan(columnSpan(ctx, x), "column %s references a foreign key", x.Name.Name.O)
+		}
+	}
+}
+
+type timestampDefault struct{}
+
+func (timestampDefault) Info() RuleInfo {
+	return RuleInfo{
+		ID:       "ddl-timestamp-default",
+		Severity: analysis.SeverityMedium,
+		Summary:  "TIMESTAMP columns must have an explicit default",
+	}
+}
+
+func (timestampDefault) Check(ctx *Context, c *ast.Cursor) {
+	col, ok := c.Node().(*ast.ColumnDef)
+	if ok && col.Tp != nil && col.Tp.Tp == mysql.TypeTimestamp && columnOption(col, ast.ColumnOptionDefaultValue) == nil {
+		ctx.ReportSpan(columnSpan(ctx, col), "TIMESTAMP column %s has no explicit default", col.Name.Name.O)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package review_test
+
+import (
+	"fmt"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	. "github.com/pingcap/parser/review"
+)
+
+var _ = Suite(&testDDLSuite{})
+
+type testDDLSuite struct {
+}
+
+// reviewDDL reviews sql by the DDL rules, and formats the violations as `<rule>: <text of span>`.
+func reviewDDL(c *C, sql string, config Config) []string {
+	stmts, _, err := parser.New().Parse(sql, "", "")
+	c.Assert(err, IsNil, Commentf("sql: %s", sql))
+	reviewer, err := NewReviewer(DDLRules(), config)
+	c.Assert(err, IsNil)
+	var strs []string
+	for _, v := range reviewer.Review(sql, stmts, 0).Violations {
+		strs = append(strs, fmt.Sprintf("%s: %s", v.Rule, sql[v.Span.Start:v.Span.End]))
+	}
+	return strs
+}
+
+func (s *testDDLSuite) TestDDLRules(c *C) {
+	const options = " engine=InnoDB default charset=utf8mb4 comment='t'"
+	cases := []struct {
+		sql      string
+		expected []string
+	}{
+		{"create table t (id bigint primary key comment 'id', uk int comment 'k', unique key uk_k (uk), index idx_k (uk))" + options, nil},
+		{
+			"create table t (a int comment 'a')",
+			[]string{"ddl-primary-key: t", "ddl-charset: t", "ddl-engine: t", "ddl-table-comment: t"},
+		},
+		{
+			"create table t (id int comment 'id', b varchar(10) charset latin1 comment 'b', primary key (id))" + options,
+			[]string{"ddl-charset: b varchar(10) charset latin1 comment 'b'"},
+		},
+		{
+			"create table t (id int primary key comment 'id', b blob comment 'b', vb varbinary(8) comment 'vb', " +
+				"bn binary(4) comment 'bn', j json comment 'j', l text charset latin1 comment 'l')" + options,
+			[]string{"ddl-charset: l text charset latin1 comment 'l'"},
+		},
+		{
+			"create table t (id int primary key comment 'id') engine=MyISAM charset=utf8 comment 'x'",
+			[]string{"ddl-engine: engine=MyISAM", "ddl-charset: charset=utf8"},
+		},
+		{
+			"create table t (id int primary key comment 'id') collate=latin1_bin engine=InnoDB comment 'x'",
+			[]string{"ddl-charset: collate=latin1_bin"},
+		},
+		{
+			"create table t (id int primary key, price double comment 'p')" + options,
+			[]string{"ddl-column-comment: id int primary key", "ddl-money-float: price double comment 'p'"},
+		},
+		{
+			"create table t (id int primary key comment 'id', a int comment 'a', key k_a (a), unique (a), fulltext index idx_f (a))" + options,
+			[]string{"ddl-index-name: key k_a (a)", "ddl-index-name: unique (a)"},
+		},
+		{
+			"create table t (id int primary key comment 'id', a int unique comment 'a')" + options,
+			[]string{"ddl-index-name: a int unique comment 'a'"},
+		},
+		{
+			"create table t (id int primary key comment 'id', s enum('a', 'b') comment 's', v varchar(2000) comment 'v', ts timestamp comment 'ts', ts2 timestamp default current_timestamp comment 'ts2')" + options,
+			[]string{"ddl-enum: s enum('a', 'b') comment 's'", "ddl-varchar-length: v varchar(2000) comment 'v'", "ddl-timestamp-default: ts timestamp comment 'ts'"},
+		},
+		{
+			"create table t (id int primary key comment 'id', pid int comment 'p', foreign key (pid) references p (id), qid int references q (id) comment 'q')" + options,
+			[]string{"ddl-foreign-key: foreign key (pid) references p (id)", "ddl-foreign-key: qid int references q (id) comment 'q'"},
+		},
+		{"create table t like s", nil},
+		{
+			"alter table t add column c int, add index i (c), modify d timestamp comment 'd'",
+			[]string{"ddl-column-comment: add column c int", "ddl-index-name: add index i (c)", "ddl-timestamp-default: modify d timestamp comment 'd'"},
+		},
+		{"alter table t engine = MyISAM, convert to charset latin1", []string{"ddl-engine: engine = MyISAM", "ddl-charset: charset latin1"}},
+		{"alter table t drop primary key", []string{"ddl-primary-key: t"}},
+		{"alter table t drop primary key, add primary key (a, b)", nil},
+		{"alter table t rename index idx_a to a", []string{"ddl-index-name: alter table t rename index idx_a to a"}},
+		{"create unique index idx_a on t (a)", []string{"ddl-index-name: create unique index idx_a on t (a)"}},
+		{"create index idx_a on t (a)", nil},
+	}
+	for _, ca := range cases {
+		c.Assert(reviewDDL(c, ca.sql, Config{}), DeepEquals, ca.expected, Commentf("sql: %s", ca.sql))
+	}
+}
+
+func (s *testDDLSuite) TestDDLConfig(c *C) {
+	sql := "create table t (id int primary key comment 'id', v varchar(2000) comment 'v', total float comment 't', key key_v (v)) engine=MyISAM charset=latin1 comment 't'"
+	config := Config{Rules: map[string]RuleConfig{
+		"ddl-charset":        {Params: map[string]string{"charsets": "utf8mb4, latin1"}},
+		"ddl-engine":         {Params: map[string]string{"engines": "InnoDB,MyISAM"}},
+		"ddl-varchar-length": {Params: map[string]string{"max_length": "4096"}},
+		"ddl-money-float":    {Params: map[string]string{"columns": "total"}},
+		"ddl-index-name":     {Params: map[string]string{"index_prefix": "key_"}},
+	}}
+	c.Assert(reviewDDL(c, sql, config), DeepEquals, []string{"ddl-money-float: total float comment 't'"})
+}
@@ -0,0 +1,368 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package review checks statements against review rules, such as the built-in
+// rules of queries returned by BuiltinRules and the table conventions returned
+// by DDLRules, and reports the violations.
+//
+// A violation can be suppressed by a comment in the statement, such as
+// `/* review:ignore select-star */`. The comment suppresses the violations of
+// the rules listed in it, separated by commas or spaces, or all the rules if
+// none is listed.
+package review
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/analysis"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/mysql"
+)
+
+// RuleInfo is the information of a Rule.
+type RuleInfo struct {
+	// ID is the unique ID of the rule, such as "select-star".
+	ID string
+	// Severity is the default severity of the violations.
+	Severity analysis.Severity
+	Summary  string
+	// Params are the parameters of the rule with the default values.
+	Params map[string]string
+}
+
+// Rule is a review rule.
+type Rule interface {
+	// Info returns the information of the rule.
+	Info() RuleInfo
+	// Check is called for every node of the statements in depth-first order,
+	// and reports the violations by ctx.
+	Check(ctx *Context, c *ast.Cursor)
+}
+
+// RuleConfig is the configuration of a rule.
+type RuleConfig struct {
+	Disabled bool `json:"disabled,omitempty"`
+	// Severity overrides the default severity if it's not zero.
+	Severity analysis.Severity `json:"severity,omitempty"`
+	// Params override the default parameters.
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// Config is the configuration of a Reviewer, the rules are configured by the IDs.
+type Config struct {
+	Rules map[string]RuleConfig `json:"rules,omitempty"`
+}
+
+// Violation is a violation of a rule.
+type Violation struct {
+	Rule     string            `json:"rule"`
+	Severity analysis.Severity `json:"severity"`
+	// Statement is the index of the statement.
+	Statement int           `json:"statement"`
+	Span      analysis.Span `json:"span"`
+	Message   string        `json:"message"`
+}
+
+// Report is the result of Reviewer.Review.
+type Report struct {
+	Violations []Violation `json:"violations"`
+	// Suppressed is the number of the violations suppressed by the comments.
+	Suppressed int `json:"suppressed"`
+}
+
+// Reviewer reviews statements by rules.
+type Reviewer struct {
+	rules   []Rule
+	configs []RuleConfig
+}
+
+// NewReviewer returns a Reviewer of the rules configured by config. It returns
+// an error if the IDs of the rules are duplicated, or config has an unknown rule
+// or parameter.
+func NewReviewer(rules []Rule, config Config) (*Reviewer, error) {
+	r := &Reviewer{}
+	ids := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		info := rule.Info()
+		if ids[info.ID] {
+			return nil, errors.Errorf("duplicate rule %s", info.ID)
+		}
+		ids[info.ID] = true
+		cfg := config.Rules[info.ID]
+		for name := range cfg.Params {
+			if _, ok := info.Params[name]; !ok {
+				return nil, errors.Errorf("unknown parameter %s of rule %s", name, info.ID)
+			}
+		}
+		if cfg.Disabled {
+			continue
+		}
+		if cfg.Severity == 0 {
+			cfg.Severity = info.Severity
+		}
+		r.rules = append(r.rules, rule)
+		r.configs = append(r.configs, cfg)
+	}
+	for id := range config.Rules {
+		if !ids[id] {
+			return nil, errors.Errorf("unknown rule %s", id)
+		}
+	}
+	return r, nil
+}
+
+// Review checks stmts parsed from sql in the SQL mode, and returns the violations
+// ordered by the statements and the spans.
+func (r *Reviewer) Review(sql string, stmts []ast.StmtNode, mode mysql.SQLMode) *Report {
+	ctx := &Context{spans: analysis.NewSpans(sql, mode)}
+	stmtSpans := ctx.spans.Statements()
+	ignores := ignoredRules(ctx.spans.Tokens(), stmtSpans)
+	report := &Report{Violations: []Violation{}}
+	infos := make([]RuleInfo, len(r.rules))
+	for j, rule := range r.rules {
+		infos[j] = rule.Info()
+	}
+	// the violations of every rule, so they are reported in the order of the rules for the same span.
+	violations := make([][]Violation, len(r.rules))
+	for i, stmt := range stmts {
+		ctx.stmt, ctx.stmtNode = i, stmt
+		ctx.stmtSpan = analysis.Span{}
+		if i < len(stmtSpans) {
+			ctx.stmtSpan = stmtSpans[i]
+		}
+		ctx.spans.Locate(stmt, ctx.stmtSpan)
+		for j := range violations {
+			violations[j] = violations[j][:0]
+		}
+		// every node is checked by all the rules in a single traversal.
+		ast.Walk(stmt, func(c *ast.Cursor) bool {
+			for j, rule := range r.rules {
+				ctx.rule, ctx.config = infos[j], r.configs[j]
+				ctx.violations = violations[j]
+				rule.Check(ctx, c)
+				violations[j] = ctx.violations
+			}
+			return true
+		}, nil)
+		for _, vs := range violations {
+			for _, v := range vs {
+				if ignored, ok := ignores[i]; ok && (ignored == nil || ignored[v.Rule]) {
+					report.Suppressed++
+					continue
+				}
+				report.Violations = append(report.Violations, v)
+			}
+		}
+	}
+	sort.SliceStable(report.Violations, func(i, j int) bool {
+		vi, vj := report.Violations[i], report.Violations[j]
+		if vi.Statement != vj.Statement {
+			return vi.Statement < vj.Statement
+		}
+		return vi.Span.Start < vj.Span.Start
+	})
+	return report
+}
+
+// ignoredRules returns the rules ignored by the comments of the statements, which
+// are nil if all the rules are ignored. A comment between two statements is of
+// the latter statement.
+func ignoredRules(tokens []parser.Token, stmtSpans []analysis.Span) map[int]map[string]bool {
+	ignores := make(map[int]map[string]bool)
+	for _, tok := range tokens {
+		if tok.Kind != parser.TokenComment {
+			continue
+		}
+		idx := strings.Index(tok.Text, "review:ignore")
+		if idx < 0 {
+			continue
+		}
+		stmt := sort.Search(len(stmtSpans), func(i int) bool { return stmtSpans[i].End > tok.Pos.Offset })
+		if stmt == len(stmtSpans) && stmt > 0 {
+			stmt--
+		}
+		body := strings.TrimSuffix(tok.Text[idx+len("review:ignore"):], "*/")
+		ids := strings.FieldsFunc(body, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\n' })
+		if len(ids) == 0 {
+			ignores[stmt] = nil
+			continue
+		}
+		ignored, ok := ignores[stmt]
+		if ok && ignored == nil {
+			continue
+		}
+		if ignored == nil {
+			ignored = make(map[string]bool)
+			ignores[stmt] = ignored
+		}
+		for _, id := range ids {
+			ignored[id] = true
+		}
+	}
+	return ignores
+}
+
+// Context is the context of a rule checking a statement.
+type Context struct {
+	spans      *analysis.Spans
+	stmt       int
+	stmtNode   ast.StmtNode
+	stmtSpan   analysis.Span
+	rule       RuleInfo
+	config     RuleConfig
+	violations []Violation
+}
+
+// Param returns the parameter of the rule, which is configured or the default.
+func (ctx *Context) Param(name string) string {
+	if value, ok := ctx.config.Params[name]; ok {
+		return value
+	}
+	return ctx.rule.Params[name]
+}
+
+// BoolParam returns the parameter of the rule as a bool, which is true for "true" and "1".
+func (ctx *Context) BoolParam(name string) bool {
+	value := strings.ToLower(ctx.Param(name))
+	return value == "true" || value == "1"
+}
+
+// IntParam returns the parameter of the rule as an int, the default value is used
+// if the configured value isn't an int.
+func (ctx *Context) IntParam(name string) int {
+	if n, err := strconv.Atoi(ctx.Param(name)); err == nil {
+		return n
+	}
+	n, _ := strconv.Atoi(ctx.rule.Params[name])
+	return n
+}
+
+// ListParam returns the parameter of the rule as a set of lowercase items separated by commas.
+func (ctx *Context) ListParam(name string) map[string]bool {
+	items := make(map[string]bool)
+	for _, item := range strings.Split(ctx.Param(name), ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			items[item] = true
+		}
+	}
+	return items
+}
+
+// Span returns the span of n, which is the span of the statement if the span of n is unknown.
+func (ctx *Context) Span(n ast.Node) analysis.Span {
+	if _, ok := n.(ast.StmtNode); !ok {
+		if span := ctx.spans.Of(n); span.End > span.Start {
+			return span
+		}
+	}
+	return ctx.stmtSpan
+}
+
+// Report reports a violation of the rule at n.
+func (ctx *Context) Report(n ast.Node, format string, args ...interface{}) {
+	ctx.ReportSpan(ctx.Span(n), format, args...)
+}
+
+// ReportSpan reports a violation of the rule at span.
+func (ctx *Context) ReportSpan(span analysis.Span, format string, args ...interface{}) {
+	ctx.violations = append(ctx.violations, Violation{
+		Rule:      ctx.rule.ID,
+		Severity:  ctx.config.Severity,
+		Statement: ctx.stmt,
+		Span:      span,
+		Message:   fmt.Sprintf(format, args...),
+	})
+}
+
+// stmtTokens returns the tokens of the statement, with the depths in the parentheses.
+// The depth of a parenthesis is the depth outside it.
+func (ctx *Context) stmtTokens() ([]parser.Token, []int) {
+	tokens := ctx.spans.Tokens()
+	first := sort.Search(len(tokens), func(i int) bool { return tokens[i].Pos.Offset >= ctx.stmtSpan.Start })
+	last := sort.Search(len(tokens), func(i int) bool { return tokens[i].Pos.Offset >= ctx.stmtSpan.End })
+	tokens = tokens[first:last]
+	depths := make([]int, len(tokens))
+	depth := 0
+	for i, tok := range tokens {
+		if tok.Text == ")" {
+			depth--
+		}
+		depths[i] = depth
+		if tok.Text == "(" {
+			depth++
+		}
+	}
+	return tokens, depths
+}
+
+// clause returns the span of the item of a list separated by commas, such as a
+// column definition of CREATE TABLE or a spec of ALTER TABLE. The item contains
+// the token at offset, which is in up levels of parentheses of the item.
+func (ctx *Context) clause(offset, up int) analysis.Span {
+	tokens, depths := ctx.stmtTokens()
+	k := sort.Search(len(tokens), func(i int) bool { return tokens[i].Pos.Offset > offset }) - 1
+	if k < 0 {
+		return ctx.stmtSpan
+	}
+	bound := 0
+	if stmt, ok := ctx.stmtNode.(*ast.AlterTableStmt); ok {
+		// the first spec starts after the table name.
+		bound = ctx.spans.Of(stmt.Table).End
+	}
+	level := depths[k] - up
+	separator := func(i int) bool {
+		return depths[i] < level || depths[i] == level && tokens[i].Text == ","
+	}
+	start, end := k, k
+	for start > 0 && !separator(start-1) && tokens[start-1].Pos.Offset >= bound {
+		start--
+	}
+	for end+1 < len(tokens) && !separator(end+1) {
+		end++
+	}
+	return analysis.Span{Start: tokens[start].Pos.Offset, End: tokens[end].Pos.Offset + len(tokens[end].Text)}
+}
+
+// option returns the span of the table option starting with one of the keywords,
+// which is the span of the statement if it's not found.
+func (ctx *Context) option(keywords ...string) analysis.Span {
+	tokens, depths := ctx.stmtTokens()
+	for i, tok := range tokens {
+		if depths[i] != 0 || tok.Kind != parser.TokenKeyword && tok.Kind != parser.TokenReservedKeyword {
+			continue
+		}
+		for _, keyword := range keywords {
+			if !strings.EqualFold(tok.Text, keyword) {
+				continue
+			}
+			// skip SET of CHARACTER SET and the optional =.
+			j := i + 1
+			if j < len(tokens) && strings.EqualFold(tokens[j].Text, "set") {
+				j++
+			}
+			if j < len(tokens) && tokens[j].Text == "=" {
+				j++
+			}
+			if j >= len(tokens) {
+				j = len(tokens) - 1
+			}
+			return analysis.Span{Start: tok.Pos.Offset, End: tokens[j].Pos.Offset + len(tokens[j].Text)}
+		}
+	}
+	return ctx.stmtSpan
+}
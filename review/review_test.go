@@ -0,0 +1,164 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package review_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/analysis"
+	"github.com/pingcap/parser/ast"
+	. "github.com/pingcap/parser/review"
+	_ "github.com/pingcap/parser/test_driver"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testReviewSuite{})
+
+type testReviewSuite struct {
+}
+
+// review reviews sql by the built-in rules, and formats the violations as `<rule>: <text of span>`.
+func review(c *C, sql string, config Config) ([]string, *Report) {
+	stmts, _, err := parser.New().Parse(sql, "", "")
+	c.Assert(err, IsNil, Commentf("sql: %s", sql))
+	reviewer, err := NewReviewer(BuiltinRules(), config)
+	c.Assert(err, IsNil)
+	report := reviewer.Review(sql, stmts, 0)
+	var strs []string
+	for _, v := range report.Violations {
+		strs = append(strs, fmt.Sprintf("%s: %s", v.Rule, sql[v.Span.Start:v.Span.End]))
+	}
+	return strs, report
+}
+
+func (s *testReviewSuite) TestBuiltinRules(c *C) {
+	cases := []struct {
+		sql      string
+		expected []string
+	}{
+		{"select a from t where b = 1", nil},
+		{"select * from t", []string{"select-star: *"}},
+		{"select t.* from t", []string{"select-star: t.*"}},
+		{"select a from t where exists (select * from s where s.id = t.id)", nil},
+		{"update t set a = 1", []string{"dml-without-where: update t set a = 1"}},
+		{"delete from t", []string{"dml-without-where: delete from t"}},
+		{"delete from t where a = 1 limit 10", []string{"dml-limit-without-order: delete from t where a = 1 limit 10"}},
+		{"update t set a = 1 where b = 2 order by c limit 10", nil},
+		{"insert into t values (1, 2)", []string{"insert-without-columns: t"}},
+		{"insert into db.t select a, b from s", []string{"insert-without-columns: db.t"}},
+		{"insert into t (a, b) values (1, 2)", nil},
+		{"insert into t set a = 1", nil},
+		{"select t.a from t, s", []string{"implicit-cross-join: t, s"}},
+		{"select t.a from t join s where t.b = 1", nil},
+		{"select t.a from t cross join s", nil},
+		{"select t.a from t, (s) where t.b = 1", []string{"implicit-cross-join: t, (s)"}},
+		{"select t.a from t join s on t.id = s.id, u", []string{"implicit-cross-join: t join s on t.id = s.id, u"}},
+		{"select x.a from t x, s y where x.id = y.id", nil},
+		{"select t.a from t join s on t.id = s.id", nil},
+		{"select a from t order by rand() limit 1", []string{"order-by-rand: rand()"}},
+		{"select a from t group by rand()", nil},
+		{"select a from t where b not in (select b from s)", []string{"not-in-subquery: b not in (select b from s)"}},
+		{"select a from t where b <> all (select b from s)", []string{"not-in-subquery: b <> all (select b from s)"}},
+		{"select a from t where b not in (1, 2)", nil},
+		{"select * from t; delete from s", []string{"select-star: *", "dml-without-where: delete from s"}},
+	}
+	for _, ca := range cases {
+		violations, _ := review(c, ca.sql, Config{})
+		c.Assert(violations, DeepEquals, ca.expected, Commentf("sql: %s", ca.sql))
+	}
+}
+
+func (s *testReviewSuite) TestConfig(c *C) {
+	sql := "select a from t where exists (select * from s); delete from t"
+	violations, report := review(c, sql, Config{Rules: map[string]RuleConfig{
+		"select-star":       {Params: map[string]string{"allow_in_exists": "false"}},
+		"dml-without-where": {Severity: analysis.SeverityCritical},
+	}})
+	c.Assert(violations, DeepEquals, []string{"select-star: *", "dml-without-where: delete from t"})
+	c.Assert(report.Violations[0].Statement, Equals, 0)
+	c.Assert(report.Violations[1].Statement, Equals, 1)
+	c.Assert(report.Violations[1].Severity, Equals, analysis.SeverityCritical)
+
+	violations, _ = review(c, sql, Config{Rules: map[string]RuleConfig{"dml-without-where": {Disabled: true}}})
+	c.Assert(violations, IsNil)
+
+	_, err := NewReviewer(BuiltinRules(), Config{Rules: map[string]RuleConfig{"no-such-rule": {}}})
+	c.Assert(err, ErrorMatches, "unknown rule no-such-rule")
+	_, err = NewReviewer(BuiltinRules(), Config{Rules: map[string]RuleConfig{"select-star": {Params: map[string]string{"x": "1"}}}})
+	c.Assert(err, ErrorMatches, "unknown parameter x of rule select-star")
+	_, err = NewReviewer(append(BuiltinRules(), BuiltinRules()[0]), Config{})
+	c.Assert(err, ErrorMatches, "duplicate rule select-star")
+}
+
+func (s *testReviewSuite) TestSuppression(c *C) {
+	cases := []struct {
+		sql        string
+		expected   []string
+		suppressed int
+	}{
+		{"select * from t /* review:ignore select-star */", nil, 1},
+		{"/* review:ignore */ delete from t limit 1", nil, 2},
+		{"delete from t limit 1 -- review:ignore dml-without-where", []string{"dml-limit-without-order: delete from t limit 1"}, 1},
+		{"select * from t; -- review:ignore\nselect * from s", []string{"select-star: *"}, 1},
+		{"select * from t # review:ignore order-by-rand, not-in-subquery", []string{"select-star: *"}, 0},
+	}
+	for _, ca := range cases {
+		violations, report := review(c, ca.sql, Config{})
+		c.Assert(violations, DeepEquals, ca.expected, Commentf("sql: %s", ca.sql))
+		c.Assert(report.Suppressed, Equals, ca.suppressed, Commentf("sql: %s", ca.sql))
+	}
+}
+
+func (s *testReviewSuite) TestReportJSON(c *C) {
+	_, report := review(c, "update t set a = 1", Config{})
+	data, err := json.Marshal(report)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, `{"violations":[{"rule":"dml-without-where","severity":"high","statement":0,"span":{"start":0,"end":18},"message":"UPDATE without WHERE"}],"suppressed":0}`)
+
+	var config Config
+	c.Assert(json.Unmarshal([]byte(`{"rules":{"select-star":{"severity":"critical"}}}`), &config), IsNil)
+	c.Assert(config.Rules["select-star"].Severity, Equals, analysis.SeverityCritical)
+}
+
+type forbidDrop struct{}
+
+func (forbidDrop) Info() RuleInfo {
+	return RuleInfo{ID: "forbid-drop", Severity: analysis.SeverityHigh, Params: map[string]string{"message": "DROP TABLE"}}
+}
+
+func (forbidDrop) Check(ctx *Context, c *ast.Cursor) {
+	if x, ok := c.Node().(*ast.DropTableStmt); ok {
+		ctx.Report(x.Tables[0], "%s is forbidden", ctx.Param("message"))
+	}
+}
+
+func (s *testReviewSuite) TestCustomRule(c *C) {
+	sql := "drop table db.t"
+	stmts, _, err := parser.New().Parse(sql, "", "")
+	c.Assert(err, IsNil)
+	reviewer, err := NewReviewer([]Rule{forbidDrop{}}, Config{Rules: map[string]RuleConfig{"forbid-drop": {Params: map[string]string{"message": "DROP"}}}})
+	c.Assert(err, IsNil)
+	report := reviewer.Review(sql, stmts, 0)
+	c.Assert(report.Violations, HasLen, 1)
+	c.Assert(report.Violations[0].Message, Equals, "DROP is forbidden")
+	c.Assert(sql[report.Violations[0].Span.Start:report.Violations[0].Span.End], Equals, "db.t")
+}
@@ -0,0 +1,271 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package review
+
+import (
+	"sort"
+
+	"github.com/pingcap/parser/analysis"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/opcode"
+)
+
+// BuiltinRules returns the built-in rules.
+func BuiltinRules() []Rule {
+	return []Rule{
+		selectStar{},
+		dmlWithoutWhere{},
+		dmlLimitWithoutOrder{},
+		insertWithoutColumns{},
+		implicitCrossJoin{},
+		orderByRand{},
+		notInSubquery{},
+	}
+}
+
+type selectStar struct{}
+
+func (selectStar) Info() RuleInfo {
+	return RuleInfo{
+		ID:       "select-star",
+		Severity: analysis.SeverityLow,
+		Summary:  "SELECT * depends on the columns of the tables, list the columns instead",
+		Params:   map[string]string{"allow_in_exists": "true"},
+	}
+}
+
+func (selectStar) Check(ctx *Context, c *ast.Cursor) {
+	sel, ok := c.Node().(*ast.SelectStmt)
+	if !ok || sel.Fields == nil {
+		return
+	}
+	if ctx.BoolParam("allow_in_exists") && c.Parent() != nil && c.Parent().Parent() != nil {
+		if _, ok := c.Parent().Parent().Node().(*ast.ExistsSubqueryExpr); ok {
+			return
+		}
+	}
+	for _, field := range sel.Fields.Fields {
+		if field.WildCard != nil {
+			ctx.Report(field, "SELECT * is used")
+		}
+	}
+}
+
+type dmlWithoutWhere struct{}
+
+func (dmlWithoutWhere) Info() RuleInfo {
+	return RuleInfo{
+		ID:       "dml-without-where",
+		Severity: analysis.SeverityHigh,
+		Summary:  "UPDATE or DELETE without WHERE changes all the rows",
+	}
+}
+
+func (dmlWithoutWhere) Check(ctx *Context, c *ast.Cursor) {
+	switch x := c.Node().(type) {
+	case *ast.UpdateStmt:
+		if x.Where == nil {
+			ctx.Report(x, "UPDATE without WHERE")
+		}
+	case *ast.DeleteStmt:
+		if x.Where == nil {
+			ctx.Report(x, "DELETE without WHERE")
+		}
+	}
+}
+
+type dmlLimitWithoutOrder struct{}
+
+func (dmlLimitWithoutOrder) Info() RuleInfo {
+	return RuleInfo{
+		ID:       "dml-limit-without-order",
+		Severity: analysis.SeverityMedium,
+		Summary:  "UPDATE or DELETE with LIMIT but no ORDER BY changes nondeterministic rows",
+	}
+}
+
+func (dmlLimitWithoutOrder) Check(ctx *Context, c *ast.Cursor) {
+	switch x := c.Node().(type) {
+	case *ast.UpdateStmt:
+		if x.Limit != nil && x.Order == nil {
+			ctx.Report(x, "UPDATE with LIMIT but no ORDER BY")
+		}
+	case *ast.DeleteStmt:
+		if x.Limit != nil && x.Order == nil {
+			ctx.Report(x, "DELETE with LIMIT but no ORDER BY")
+		}
+	}
+}
+
+type insertWithoutColumns struct{}
+
+func (insertWithoutColumns) Info() RuleInfo {
+	return RuleInfo{
+		ID:       "insert-without-columns",
+		Severity: analysis.SeverityMedium,
+		Summary:  "INSERT without a column list depends on the order of the columns",
+	}
+}
+
+func (insertWithoutColumns) Check(ctx *Context, c *ast.Cursor) {
+	if x, ok := c.Node().(*ast.InsertStmt); ok && len(x.Columns) == 0 && len(x.Setlist) == 0 {
+		ctx.Report(x.Table, "INSERT without a column list")
+	}
+}
+
+type implicitCrossJoin struct{}
+
+func (implicitCrossJoin) Info() RuleInfo {
+	return RuleInfo{
+		ID:       "implicit-cross-join",
+		Severity: analysis.SeverityMedium,
+		Summary:  "tables are joined by a comma without a join condition in WHERE",
+	}
+}
+
+func (implicitCrossJoin) Check(ctx *Context, c *ast.Cursor) {
+	join, ok := c.Node().(*ast.Join)
+	if !ok || join.Right == nil || join.Tp != ast.CrossJoin || join.On != nil || join.Using != nil || join.NaturalJoin {
+		return
+	}
+	// JOIN and CROSS JOIN without ON are explicit, they are cross joins in the AST too.
+	if !commaJoin(ctx, join) {
+		return
+	}
+	var where ast.ExprNode
+parents:
+	for p := c.Parent(); p != nil; p = p.Parent() {
+		switch x := p.Node().(type) {
+		case *ast.SelectStmt:
+			where = x.Where
+			break parents
+		case *ast.UpdateStmt:
+			where = x.Where
+			break parents
+		case *ast.DeleteStmt:
+			where = x.Where
+			break parents
+		}
+	}
+	left, right := tableNames(join.Left), tableNames(join.Right)
+	if where == nil || !joinedBy(where, left, right) {
+		ctx.Report(join, "tables are joined without a join condition")
+	}
+}
+
+// commaJoin reports whether the right table of join follows a comma.
+func commaJoin(ctx *Context, join *ast.Join) bool {
+	span := ctx.spans.Of(join.Right)
+	if span.End <= span.Start {
+		return false
+	}
+	tokens, _ := ctx.stmtTokens()
+	i := sort.Search(len(tokens), func(i int) bool { return tokens[i].Pos.Offset >= span.Start }) - 1
+	// the right table may be in parentheses.
+	for i >= 0 && tokens[i].Text == "(" {
+		i--
+	}
+	return i >= 0 && tokens[i].Text == ","
+}
+
+// tableNames returns the names of the tables in rs, which are the aliases if they're aliased.
+func tableNames(rs ast.ResultSetNode) map[string]bool {
+	names := make(map[string]bool)
+	var collect func(rs ast.ResultSetNode)
+	collect = func(rs ast.ResultSetNode) {
+		switch x := rs.(type) {
+		case *ast.Join:
+			collect(x.Left)
+			if x.Right != nil {
+				collect(x.Right)
+			}
+		case *ast.TableSource:
+			if x.AsName.L != "" {
+				names[x.AsName.L] = true
+			} else if tn, ok := x.Source.(*ast.TableName); ok {
+				names[tn.Name.L] = true
+			} else {
+				collect(x.Source)
+			}
+		}
+	}
+	collect(rs)
+	return names
+}
+
+// joinedBy returns whether where has an equality of a column of left and a column of right.
+// An unqualified column is assumed to be of either side.
+func joinedBy(where ast.ExprNode, left, right map[string]bool) bool {
+	joined := false
+	ast.Inspect(where, func(n ast.Node) bool {
+		x, ok := n.(*ast.BinaryOperationExpr)
+		if !ok || joined || x.Op != opcode.EQ && x.Op != opcode.NullEQ {
+			return !joined
+		}
+		l, lok := x.L.(*ast.ColumnNameExpr)
+		r, rok := x.R.(*ast.ColumnNameExpr)
+		if lok && rok {
+			lt, rt := l.Name.Table.L, r.Name.Table.L
+			joined = lt == "" || rt == "" || left[lt] && right[rt] || left[rt] && right[lt]
+		}
+		return !joined
+	})
+	return joined
+}
+
+type orderByRand struct{}
+
+func (orderByRand) Info() RuleInfo {
+	return RuleInfo{
+		ID:       "order-by-rand",
+		Severity: analysis.SeverityMedium,
+		Summary:  "ORDER BY RAND() sorts all the rows",
+	}
+}
+
+func (orderByRand) Check(ctx *Context, c *ast.Cursor) {
+	item, ok := c.Node().(*ast.ByItem)
+	if !ok {
+		return
+	}
+	if _, ok := c.Parent().Node().(*ast.OrderByClause); !ok {
+		return
+	}
+	if fn, ok := item.Expr.(*ast.FuncCallExpr); ok && fn.FnName.L == ast.Rand {
+		ctx.Report(item.Expr, "ORDER BY RAND()")
+	}
+}
+
+type notInSubquery struct{}
+
+func (notInSubquery) Info() RuleInfo {
+	return RuleInfo{
+		ID:       "not-in-subquery",
+		Severity: analysis.SeverityMedium,
+		Summary:  "NOT IN over a subquery is empty if the subquery returns NULL, use NOT EXISTS instead",
+	}
+}
+
+func (notInSubquery) Check(ctx *Context, c *ast.Cursor) {
+	switch x := c.Node().(type) {
+	case *ast.PatternInExpr:
+		if x.Not && x.Sel != nil {
+			ctx.Report(x, "NOT IN over a subquery")
+		}
+	case *ast.CompareSubqueryExpr:
+		if x.All && x.Op == opcode.NE {
+			ctx.Report(x, "<> ALL over a subquery")
+		}
+	}
+}
@@ -0,0 +1,340 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bufio"
+	// io is a token name in this package.
+	goio "io"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser/ast"
+)
+
+const (
+	defaultDelimiter  = ";"
+	delimiterCommand  = "delimiter"
+	streamBufferBytes = 64 * 1024
+)
+
+// StreamStmt is a statement read by StmtStream.
+type StreamStmt struct {
+	// Stmt is the parsed statement, it is nil if the text can't be parsed.
+	Stmt ast.StmtNode
+	// Text is the statement text without the trailing delimiter.
+	Text string
+	// Offset is the byte offset of Text in the stream.
+	// Positions recorded in Stmt are relative to Text.
+	Offset int64
+	// Line is the line number of the first byte of Text in the stream.
+	Line int
+	// Warns holds the warnings reported while parsing Text.
+	Warns []error
+}
+
+// EndOffset returns the byte offset right after the statement text in the stream.
+func (s *StreamStmt) EndOffset() int64 {
+	return s.Offset + int64(len(s.Text))
+}
+
+// StmtStream reads statements one by one from an io.Reader, such as a mysqldump file.
+// Only the text of the statement being parsed is kept in memory, so the memory
+// usage is bounded by the largest single statement instead of the whole input.
+//
+// Statements are split by the delimiter outside of strings, quoted identifiers and
+// comments, the content of version comments like `/*!40101 ... */` is scanned as SQL.
+// The client side `DELIMITER` command is recognized to support stored programs in dumps.
+//
+// StmtStream uses the Parser it was created from, so the Parser must not be used
+// by others until the stream is drained.
+type StmtStream struct {
+	parser    *Parser
+	charset   string
+	collation string
+
+	r         *bufio.Reader
+	buf       []byte
+	delimiter string
+	offset    int64
+	line      int
+	err       error
+
+	stmtOffset int64
+	stmtLine   int
+	pending    []*StreamStmt
+}
+
+// ParseStream returns a StmtStream which parses statements read from r.
+// If charset or collation is "", default charset and collation will be used.
+func (parser *Parser) ParseStream(r goio.Reader, charset, collation string) *StmtStream {
+	return &StmtStream{
+		parser:    parser,
+		charset:   charset,
+		collation: collation,
+		r:         bufio.NewReaderSize(r, streamBufferBytes),
+		delimiter: defaultDelimiter,
+		line:      1,
+	}
+}
+
+// Next returns the next statement in the stream, it returns io.EOF when the stream is drained.
+// If the statement text can't be parsed, the returned StreamStmt carries the text and
+// its position together with the parse error, and Next can be called again to continue
+// with the following statement. Errors from the underlying reader are returned as is and
+// stop the stream.
+func (ss *StmtStream) Next() (*StreamStmt, error) {
+	for len(ss.pending) == 0 {
+		if ss.err != nil {
+			return nil, ss.err
+		}
+		found, err := ss.readStmt()
+		if err != nil {
+			// the text read before the error may be a part of a statement, so it isn't parsed.
+			ss.err = err
+			return nil, err
+		}
+		if !found {
+			ss.err = goio.EOF
+			continue
+		}
+		stmt, err := ss.parse()
+		if err != nil {
+			return stmt, err
+		}
+	}
+	stmt := ss.pending[0]
+	ss.pending[0] = nil
+	ss.pending = ss.pending[1:]
+	return stmt, nil
+}
+
+// Delimiter returns the current statement delimiter.
+func (ss *StmtStream) Delimiter() string {
+	return ss.delimiter
+}
+
+func (ss *StmtStream) parse() (*StreamStmt, error) {
+	text := string(ss.buf)
+	stmts, warns, err := ss.parser.Parse(text, ss.charset, ss.collation)
+	if err != nil {
+		return &StreamStmt{
+			Text:   text,
+			Offset: ss.stmtOffset,
+			Line:   ss.stmtLine,
+			Warns:  warns,
+		}, errors.Trace(err)
+	}
+	if len(stmts) == 1 {
+		ss.pending = append(ss.pending, &StreamStmt{
+			Stmt:   stmts[0],
+			Text:   text,
+			Offset: ss.stmtOffset,
+			Line:   ss.stmtLine,
+			Warns:  warns,
+		})
+		return nil, nil
+	}
+	// The text contains several statements when the delimiter is not ';'.
+	from := 0
+	for _, stmt := range stmts {
+		s := &StreamStmt{
+			Stmt:   stmt,
+			Text:   text,
+			Offset: ss.stmtOffset,
+			Line:   ss.stmtLine,
+			Warns:  warns,
+		}
+		stmtText := strings.Trim(stmt.Text(), "; \t\r\n")
+		if idx := strings.Index(text[from:], stmtText); idx >= 0 && len(stmtText) > 0 {
+			start := from + idx
+			s.Text = stmtText
+			s.Offset += int64(start)
+			s.Line += strings.Count(text[:start], "\n")
+			from = start + len(stmtText)
+		}
+		ss.pending = append(ss.pending, s)
+	}
+	return nil, nil
+}
+
+// readStmt reads the text of next statement into ss.buf.
+// Whitespaces and comments before a statement are dropped.
+func (ss *StmtStream) readStmt() (found bool, err error) {
+	blank := true
+	ss.buf = ss.buf[:0]
+	for {
+		if blank {
+			ss.buf = ss.buf[:0]
+			ss.stmtOffset, ss.stmtLine = ss.offset, ss.line
+		}
+		c, err := ss.readByte()
+		if err == nil {
+			switch {
+			case c == ss.delimiter[0] && ss.peekIs(ss.delimiter[1:]):
+				if _, err = ss.r.Discard(len(ss.delimiter) - 1); err != nil {
+					break
+				}
+				ss.offset += int64(len(ss.delimiter) - 1)
+				if blank {
+					continue
+				}
+				ss.buf = ss.buf[:len(ss.buf)-1]
+				ss.trimBuf()
+				return true, nil
+			case c == '\'' || c == '"' || c == '`':
+				err = ss.skipQuoted(c)
+			case c == '#' || (c == '-' && ss.isDashComment()):
+				if err = ss.skipUntil("\n"); err == nil && blank {
+					continue
+				}
+			case c == '/' && ss.peekIs("*!"):
+				// Version comments are executed by MySQL, so they are kept as a part of the statement.
+			case c == '/' && ss.peekIs("*"):
+				// Read the '*' first, so that `/*/` doesn't close the comment.
+				if _, err = ss.readByte(); err == nil {
+					err = ss.skipUntil("*/")
+				}
+				if err == nil && blank {
+					continue
+				}
+			case blank && isStreamSpace(c):
+				continue
+			case blank && (c == 'd' || c == 'D') && ss.isDelimiterCommand():
+				if err = ss.readDelimiter(); err == nil {
+					continue
+				}
+				blank = true
+			}
+		}
+		if err != nil {
+			if err == goio.EOF {
+				err = nil
+			}
+			ss.trimBuf()
+			return !blank, err
+		}
+		blank = false
+	}
+}
+
+func (ss *StmtStream) readByte() (byte, error) {
+	c, err := ss.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	ss.offset++
+	if c == '\n' {
+		ss.line++
+	}
+	ss.buf = append(ss.buf, c)
+	return c, nil
+}
+
+// peekIs reports whether the unread bytes start with s.
+func (ss *StmtStream) peekIs(s string) bool {
+	if len(s) == 0 {
+		return true
+	}
+	b, _ := ss.r.Peek(len(s))
+	return string(b) == s
+}
+
+// isDashComment reports whether the '-' just read starts a `-- ` comment.
+func (ss *StmtStream) isDashComment() bool {
+	b, _ := ss.r.Peek(2)
+	switch len(b) {
+	case 0:
+		return false
+	case 1:
+		return b[0] == '-'
+	default:
+		return b[0] == '-' && isStreamSpace(b[1])
+	}
+}
+
+// isDelimiterCommand reports whether the 'd' just read starts a `DELIMITER xx` command.
+func (ss *StmtStream) isDelimiterCommand() bool {
+	b, _ := ss.r.Peek(len(delimiterCommand))
+	if len(b) != len(delimiterCommand) {
+		return false
+	}
+	return strings.EqualFold(string(b[:len(b)-1]), delimiterCommand[1:]) && isStreamSpace(b[len(b)-1])
+}
+
+// readDelimiter reads the rest of a `DELIMITER xx` command line and sets the new delimiter.
+func (ss *StmtStream) readDelimiter() error {
+	err := ss.skipUntil("\n")
+	if err != nil && err != goio.EOF {
+		return err
+	}
+	fields := strings.Fields(string(ss.buf[len(delimiterCommand):]))
+	if len(fields) == 0 {
+		return errors.Errorf("DELIMITER must be followed by a 'delimiter' character or string at line %d", ss.stmtLine)
+	}
+	ss.delimiter = fields[0]
+	return err
+}
+
+// skipQuoted reads until the closing quote of a string or quoted identifier.
+func (ss *StmtStream) skipQuoted(quote byte) error {
+	// Keep the same as Scanner.scanString, `"` quoted identifiers in ANSI_QUOTES mode are escaped too.
+	escapable := quote != '`' && !ss.parser.lexer.GetSQLMode().HasNoBackslashEscapesMode()
+	for {
+		c, err := ss.readByte()
+		if err != nil {
+			return err
+		}
+		switch {
+		case c == quote:
+			return nil
+		case c == '\\' && escapable:
+			if _, err = ss.readByte(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// skipUntil reads until the terminator has been read.
+func (ss *StmtStream) skipUntil(terminator string) error {
+	last := terminator[len(terminator)-1]
+	start := len(ss.buf)
+	for {
+		c, err := ss.readByte()
+		if err != nil {
+			return err
+		}
+		if c == last && len(ss.buf)-start >= len(terminator) &&
+			string(ss.buf[len(ss.buf)-len(terminator):]) == terminator {
+			return nil
+		}
+	}
+}
+
+func (ss *StmtStream) trimBuf() {
+	i := len(ss.buf)
+	for i > 0 && isStreamSpace(ss.buf[i-1]) {
+		i--
+	}
+	ss.buf = ss.buf[:i]
+}
+
+func isStreamSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\r', '\f', '\v':
+		return true
+	}
+	return false
+}
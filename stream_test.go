@@ -0,0 +1,133 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing/iotest"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/mysql"
+)
+
+var _ = Suite(&testStreamSuite{})
+
+type testStreamSuite struct {
+}
+
+// errReader always fails with err.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func readAllStmts(c *C, ss *parser.StmtStream) (stmts []*parser.StreamStmt, errs []error) {
+	for {
+		stmt, err := ss.Next()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+		c.Assert(stmt, NotNil)
+		stmts = append(stmts, stmt)
+	}
+}
+
+func (s *testStreamSuite) TestStmtStream(c *C) {
+	dump := "-- MySQL dump\n" +
+		"/*!40101 SET NAMES utf8mb4 */;\n" +
+		"/*/ x; */ /**/ /* a ; comment */ # another ; comment\n" +
+		"CREATE TABLE `t;1` (a int COMMENT 'x;\\'y', b varchar(10) DEFAULT \"-- ;\");\n" +
+		"INSERT INTO `t;1` VALUES (1,'a''; b'),(2,'/* ;');\n" +
+		"DELIMITER ;;\n" +
+		"SELECT 1; SELECT 2;;\n" +
+		"delimiter ;\n" +
+		";;\n" +
+		"SELECT a --comment\n FROM t"
+	expected := []struct {
+		text string
+		line int
+		tp   interface{}
+	}{
+		{"/*!40101 SET NAMES utf8mb4 */", 2, &ast.SetStmt{}},
+		{"CREATE TABLE `t;1` (a int COMMENT 'x;\\'y', b varchar(10) DEFAULT \"-- ;\")", 4, &ast.CreateTableStmt{}},
+		{"INSERT INTO `t;1` VALUES (1,'a''; b'),(2,'/* ;')", 5, &ast.InsertStmt{}},
+		{"SELECT 1", 7, &ast.SelectStmt{}},
+		{"SELECT 2", 7, &ast.SelectStmt{}},
+		{"SELECT a --comment\n FROM t", 10, &ast.SelectStmt{}},
+	}
+
+	for _, r := range []io.Reader{strings.NewReader(dump), iotest.OneByteReader(strings.NewReader(dump))} {
+		stmts, errs := readAllStmts(c, parser.New().ParseStream(r, "", ""))
+		c.Assert(errs, HasLen, 0)
+		c.Assert(stmts, HasLen, len(expected))
+		for i, stmt := range stmts {
+			comment := Commentf("statement %d", i)
+			c.Assert(stmt.Text, Equals, expected[i].text, comment)
+			c.Assert(stmt.Line, Equals, expected[i].line, comment)
+			c.Assert(stmt.Stmt, FitsTypeOf, expected[i].tp, comment)
+			c.Assert(dump[stmt.Offset:stmt.EndOffset()], Equals, stmt.Text, comment)
+		}
+		c.Assert(stmts[1].Stmt.(*ast.CreateTableStmt).Table.Name.O, Equals, "t;1")
+	}
+}
+
+func (s *testStreamSuite) TestStmtStreamError(c *C) {
+	ss := parser.New().ParseStream(strings.NewReader("SELECT 1; SELEC 2;\nSELECT 3; SELECT 'unclosed"), "", "")
+	stmts, errs := readAllStmts(c, ss)
+	c.Assert(stmts, HasLen, 4)
+	c.Assert(errs, HasLen, 2)
+	c.Assert(stmts[1].Stmt, IsNil)
+	c.Assert(stmts[1].Text, Equals, "SELEC 2")
+	c.Assert(stmts[1].Offset, Equals, int64(10))
+	c.Assert(stmts[2].Stmt, NotNil)
+	c.Assert(stmts[2].Line, Equals, 2)
+	c.Assert(stmts[3].Text, Equals, "SELECT 'unclosed")
+
+	ss = parser.New().ParseStream(strings.NewReader("DELIMITER\nSELECT 1"), "", "")
+	_, err := ss.Next()
+	c.Assert(err, ErrorMatches, "DELIMITER must be followed.*")
+	_, err = ss.Next()
+	c.Assert(err, ErrorMatches, "DELIMITER must be followed.*")
+
+	// the statement cut by the error of the reader isn't parsed.
+	r := io.MultiReader(strings.NewReader("SELECT 1; INSERT INTO t VALUES (1),(2)"), errReader{errors.New("disk")})
+	ss = parser.New().ParseStream(r, "", "")
+	stmt, err := ss.Next()
+	c.Assert(err, IsNil)
+	c.Assert(stmt.Text, Equals, "SELECT 1")
+	for i := 0; i < 2; i++ {
+		stmt, err = ss.Next()
+		c.Assert(err, ErrorMatches, "disk")
+		c.Assert(stmt, IsNil)
+	}
+}
+
+func (s *testStreamSuite) TestStmtStreamSQLMode(c *C) {
+	p := parser.New()
+	p.SetSQLMode(mysql.ModeNoBackslashEscapes)
+	stmts, errs := readAllStmts(c, p.ParseStream(strings.NewReader(`SELECT 'a\'; SELECT 1`), "", ""))
+	c.Assert(errs, HasLen, 0)
+	c.Assert(stmts, HasLen, 2)
+	c.Assert(stmts[0].Text, Equals, `SELECT 'a\'`)
+}
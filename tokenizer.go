@@ -0,0 +1,224 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:generate go run generate_keywords.go
+
+package parser
+
+import (
+	"unicode"
+
+	"github.com/pingcap/parser/mysql"
+)
+
+// The following tokens are only returned by Scanner when keepComments is set.
+// They are negative so they never conflict with tokens generated from parser.y.
+const (
+	commentTok          = -1000
+	versionCommentStart = -1001
+	versionCommentEnd   = -1002
+)
+
+// TokenKind is the kind of a token returned by Tokenizer.
+// The values are stable and can be persisted.
+type TokenKind int
+
+// TokenKind values.
+const (
+	// TokenInvalid is an illegal character or an unterminated string, quoted identifier or comment.
+	TokenInvalid TokenKind = iota
+	// TokenKeyword is an unreserved keyword or a charset introducer like `_utf8mb4`.
+	TokenKeyword
+	// TokenReservedKeyword is a keyword which can't be used as identifier without quoting.
+	TokenReservedKeyword
+	// TokenIdentifier is an unquoted identifier.
+	TokenIdentifier
+	// TokenQuotedIdentifier is an identifier quoted by '`', or by '"' in ANSI_QUOTES mode.
+	TokenQuotedIdentifier
+	// TokenString is a string literal.
+	TokenString
+	// TokenNumber is a numeric, hexadecimal or bit literal.
+	TokenNumber
+	// TokenOperator is an operator or a punctuation.
+	TokenOperator
+	// TokenComment is a `#`, `-- ` or `/* */` comment.
+	TokenComment
+	// TokenHint is an optimizer hint comment like `/*+ ... */`.
+	TokenHint
+	// TokenVersionComment is the opening `/*!50100` or closing `*/` of a version comment,
+	// tokens inside the version comment are returned as usual.
+	TokenVersionComment
+	// TokenVariable is a user variable like `@a` or a system variable like `@@global.a`.
+	TokenVariable
+	// TokenParamMarker is the parameter marker `?`.
+	TokenParamMarker
+)
+
+var tokenKindNames = []string{
+	TokenInvalid:          "invalid",
+	TokenKeyword:          "keyword",
+	TokenReservedKeyword:  "reserved keyword",
+	TokenIdentifier:       "identifier",
+	TokenQuotedIdentifier: "quoted identifier",
+	TokenString:           "string",
+	TokenNumber:           "number",
+	TokenOperator:         "operator",
+	TokenComment:          "comment",
+	TokenHint:             "hint",
+	TokenVersionComment:   "version comment",
+	TokenVariable:         "variable",
+	TokenParamMarker:      "param marker",
+}
+
+// String implements fmt.Stringer interface.
+func (k TokenKind) String() string {
+	if k >= 0 && int(k) < len(tokenKindNames) {
+		return tokenKindNames[k]
+	}
+	return "unknown"
+}
+
+// Token is a token returned by Tokenizer.
+type Token struct {
+	Kind TokenKind
+	// Text is the original text of the token.
+	Text string
+	// Value is the unescaped content of strings and quoted identifiers,
+	// and the same as Text for other tokens.
+	Value string
+	// Pos is the start position of the token.
+	Pos Pos
+}
+
+// Tokenizer splits a SQL text into tokens, keeping comments. It is used for
+// syntax highlighting and lightweight analysis which don't need a full parse.
+type Tokenizer struct {
+	s Scanner
+}
+
+// NewTokenizer returns a Tokenizer of sql with default SQL mode.
+func NewTokenizer(sql string) *Tokenizer {
+	t := &Tokenizer{}
+	t.s.reset(sql)
+	t.s.keepComments = true
+	t.s.supportWindowFunc = true
+	mode, _ := mysql.GetSQLMode(mysql.DefaultSQLMode)
+	t.s.SetSQLMode(mode)
+	return t
+}
+
+// SetSQLMode sets the SQL mode for tokenizer, ANSI_QUOTES, NO_BACKSLASH_ESCAPES,
+// IGNORE_SPACE and PIPES_AS_CONCAT affect the result.
+func (t *Tokenizer) SetSQLMode(mode mysql.SQLMode) {
+	t.s.SetSQLMode(mode)
+}
+
+// EnableWindowFunc controls whether window function names are recognized as keywords.
+func (t *Tokenizer) EnableWindowFunc(val bool) {
+	t.s.EnableWindowFunc(val)
+}
+
+// Next returns the next token, ok is false when all tokens are returned.
+func (t *Tokenizer) Next() (tok Token, ok bool) {
+	s := &t.s
+	id, pos, lit := s.scan()
+	end := s.r.pos().Offset
+	if end <= pos.Offset {
+		if s.r.eof() {
+			return tok, false
+		}
+		// an illegal character, skip it to make progress.
+		s.r.peek()
+		s.r.inc()
+		end = s.r.pos().Offset
+	}
+	tok = Token{
+		Text: s.r.s[pos.Offset:end],
+		Pos:  pos,
+	}
+	switch id {
+	case commentTok:
+		tok.Kind = TokenComment
+	case versionCommentStart, versionCommentEnd:
+		tok.Kind = TokenVersionComment
+	case hintComment:
+		tok.Kind = TokenHint
+	default:
+		s.lastKeyword3 = s.lastKeyword2
+		s.lastKeyword2 = s.lastKeyword
+		s.lastKeyword = 0
+		tok.Kind = t.kindOf(id, &tok)
+	}
+	if tok.Kind == TokenString || tok.Kind == TokenQuotedIdentifier {
+		tok.Value = lit
+	} else {
+		tok.Value = tok.Text
+	}
+	return tok, true
+}
+
+func (t *Tokenizer) kindOf(id int, tok *Token) TokenKind {
+	s := &t.s
+	switch id {
+	case identifier:
+		s.identifierDot = s.r.peek() == '.'
+		if s.handleIdent(&yySymType{ident: tok.Text}) == underscoreCS {
+			return TokenKeyword
+		}
+		kw := s.isTokenIdentifier(tok.Text, tok.Pos.Offset)
+		if kw == 0 {
+			return TokenIdentifier
+		}
+		s.lastKeyword = kw
+		if _, ok := reservedKeywordTokens[kw]; ok {
+			return TokenReservedKeyword
+		}
+		return TokenKeyword
+	case quotedIdentifier:
+		s.identifierDot = s.r.peek() == '.'
+		return TokenQuotedIdentifier
+	case stringLit:
+		if s.sqlMode.HasANSIQuotesMode() && tok.Text[0] == '"' {
+			s.identifierDot = s.r.peek() == '.'
+			return TokenQuotedIdentifier
+		}
+		return TokenString
+	case intLit, floatLit, decLit, hexLit, bitLit:
+		return TokenNumber
+	case singleAtIdentifier, doubleAtIdentifier:
+		return TokenVariable
+	case paramMarker:
+		return TokenParamMarker
+	case underscoreCS:
+		return TokenKeyword
+	case null:
+		return TokenReservedKeyword
+	case invalid, unicode.ReplacementChar, 0:
+		return TokenInvalid
+	}
+	return TokenOperator
+}
+
+// Tokenize returns all tokens of sql in the SQL mode.
+func Tokenize(sql string, mode mysql.SQLMode) []Token {
+	t := NewTokenizer(sql)
+	t.SetSQLMode(mode)
+	var tokens []Token
+	for {
+		tok, ok := t.Next()
+		if !ok {
+			return tokens
+		}
+		tokens = append(tokens, tok)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser_test
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/mysql"
+)
+
+var _ = Suite(&testTokenizerSuite{})
+
+type testTokenizerSuite struct {
+}
+
+type tokenCase struct {
+	kind  parser.TokenKind
+	text  string
+	value string
+}
+
+func checkTokens(c *C, sql string, mode mysql.SQLMode, expected []tokenCase) {
+	tokens := parser.Tokenize(sql, mode)
+	comment := Commentf("%s: %v", sql, tokens)
+	c.Assert(tokens, HasLen, len(expected), comment)
+	for i, tok := range tokens {
+		c.Assert(tok.Kind, Equals, expected[i].kind, comment)
+		c.Assert(tok.Text, Equals, expected[i].text, comment)
+		c.Assert(sql[tok.Pos.Offset:tok.Pos.Offset+len(tok.Text)], Equals, tok.Text, comment)
+		if expected[i].value != "" {
+			c.Assert(tok.Value, Equals, expected[i].value, comment)
+		}
+	}
+}
+
+func (s *testTokenizerSuite) TestTokenize(c *C) {
+	mode, _ := mysql.GetSQLMode(mysql.DefaultSQLMode)
+	checkTokens(c, "SELECT /*+ HASH_JOIN(t) */ a, `b``c`, t.select FROM t WHERE d >= 1.5 AND e = 'x\\'y' -- end", mode, []tokenCase{
+		{parser.TokenReservedKeyword, "SELECT", ""},
+		{parser.TokenHint, "/*+ HASH_JOIN(t) */", ""},
+		{parser.TokenIdentifier, "a", ""},
+		{parser.TokenOperator, ",", ""},
+		{parser.TokenQuotedIdentifier, "`b``c`", "b`c"},
+		{parser.TokenOperator, ",", ""},
+		{parser.TokenIdentifier, "t", ""},
+		{parser.TokenOperator, ".", ""},
+		{parser.TokenIdentifier, "select", ""},
+		{parser.TokenReservedKeyword, "FROM", ""},
+		{parser.TokenIdentifier, "t", ""},
+		{parser.TokenReservedKeyword, "WHERE", ""},
+		{parser.TokenIdentifier, "d", ""},
+		{parser.TokenOperator, ">=", ""},
+		{parser.TokenNumber, "1.5", ""},
+		{parser.TokenReservedKeyword, "AND", ""},
+		{parser.TokenIdentifier, "e", ""},
+		{parser.TokenOperator, "=", ""},
+		{parser.TokenString, "'x\\'y'", "x'y"},
+		{parser.TokenComment, "-- end", ""},
+	})
+	checkTokens(c, "/*!40101 SET @a = @@session.sql_mode */ # c\n/* select */ select ?, _utf8mb4'', 0x1f, null, begin", mode, []tokenCase{
+		{parser.TokenVersionComment, "/*!40101", ""},
+		{parser.TokenReservedKeyword, "SET", ""},
+		{parser.TokenVariable, "@a", ""},
+		{parser.TokenOperator, "=", ""},
+		{parser.TokenVariable, "@@session.sql_mode", ""},
+		{parser.TokenVersionComment, "*/", ""},
+		{parser.TokenComment, "# c", ""},
+		{parser.TokenComment, "/* select */", ""},
+		{parser.TokenReservedKeyword, "select", ""},
+		{parser.TokenParamMarker, "?", ""},
+		{parser.TokenOperator, ",", ""},
+		{parser.TokenKeyword, "_utf8mb4", ""},
+		{parser.TokenString, "''", ""},
+		{parser.TokenOperator, ",", ""},
+		{parser.TokenNumber, "0x1f", ""},
+		{parser.TokenOperator, ",", ""},
+		{parser.TokenReservedKeyword, "null", ""},
+		{parser.TokenOperator, ",", ""},
+		{parser.TokenKeyword, "begin", ""},
+	})
+	// a hint is only recognized after certain keywords.
+	checkTokens(c, "/*+ x */ delete", mode, []tokenCase{
+		{parser.TokenComment, "/*+ x */", ""},
+		{parser.TokenReservedKeyword, "delete", ""},
+	})
+	checkTokens(c, "a 'unclosed", mode, []tokenCase{
+		{parser.TokenIdentifier, "a", ""},
+		{parser.TokenInvalid, "'unclosed", ""},
+	})
+	checkTokens(c, "a \x01 /* unclosed", mode, []tokenCase{
+		{parser.TokenIdentifier, "a", ""},
+		{parser.TokenInvalid, "\x01", ""},
+		{parser.TokenInvalid, "/* unclosed", ""},
+	})
+}
+
+func (s *testTokenizerSuite) TestTokenizeSQLMode(c *C) {
+	checkTokens(c, `"a\"b"`, 0, []tokenCase{
+		{parser.TokenString, `"a\"b"`, `a"b`},
+	})
+	checkTokens(c, `"a\"b"`, mysql.ModeANSIQuotes, []tokenCase{
+		{parser.TokenQuotedIdentifier, `"a\"b"`, `a"b`},
+	})
+	checkTokens(c, `'a\' b`, mysql.ModeNoBackslashEscapes, []tokenCase{
+		{parser.TokenString, `'a\'`, `a\`},
+		{parser.TokenIdentifier, "b", ""},
+	})
+
+	tokenizer := parser.NewTokenizer("select 1")
+	tok, ok := tokenizer.Next()
+	c.Assert(ok, IsTrue)
+	c.Assert(tok.Pos, Equals, parser.Pos{Line: 1, Col: 0, Offset: 0})
+	c.Assert(tok.Kind.String(), Equals, "reserved keyword")
+	tok, ok = tokenizer.Next()
+	c.Assert(ok, IsTrue)
+	c.Assert(tok.Pos.Offset, Equals, 7)
+	_, ok = tokenizer.Next()
+	c.Assert(ok, IsFalse)
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/pingcap/parser/charset"
 	"github.com/pingcap/parser/mysql"
 	"github.com/pingcap/parser/terror"
+	"github.com/pingcap/parser/types"
 )
 
 var (
@@ -88,6 +89,10 @@ type Parser struct {
 	explicitCharset       bool
 	strictDoubleFieldType bool
 
+	// exprResult and fieldTypeResult are the results of ParseExpr and ParseFieldType.
+	exprResult      ast.ExprNode
+	fieldTypeResult *types.FieldType
+
 	// the following fields are used by yyParse to reduce allocation.
 	cache  []yySymType
 	yylval yySymType
@@ -140,6 +145,61 @@ func (parser *Parser) SetParserConfig(config ParserConfig) {
 // Parse parses a query string to raw ast.StmtNode.
 // If charset or collation is "", default charset and collation will be used.
 func (parser *Parser) Parse(sql, charset, collation string) (stmt []ast.StmtNode, warns []error, err error) {
+	warns, err = parser.parse(sql, charset, collation, 0)
+	if err != nil {
+		return nil, warns, err
+	}
+	for _, stmt := range parser.result {
+		ast.SetFlag(stmt)
+	}
+	return parser.result, warns, nil
+}
+
+// ParseExpr parses an expression, such as the expression of a generated column,
+// a CHECK constraint or a default value, to raw ast.ExprNode.
+func (parser *Parser) ParseExpr(text string) (expr ast.ExprNode, warns []error, err error) {
+	warns, err = parser.parse(text, "", "", exprStart)
+	if err != nil {
+		return nil, warns, err
+	}
+	expr = parser.exprResult
+	parser.exprResult = nil
+	ast.SetFlag(expr)
+	return expr, warns, nil
+}
+
+// ParseFieldType parses a data type like `varchar(10) charset utf8mb4 collate utf8mb4_bin`
+// or `int(10) unsigned`, which is the COLUMN_TYPE in information_schema.
+// If only one of the charset and the collation is specified, the other one is filled by it.
+func (parser *Parser) ParseFieldType(text string) (tp *types.FieldType, warns []error, err error) {
+	warns, err = parser.parse(text, "", "", fieldTypeStart)
+	if err != nil {
+		return nil, warns, err
+	}
+	tp = parser.fieldTypeResult
+	parser.fieldTypeResult = nil
+	if tp.Charset == "" && tp.Collate != "" {
+		co, err := charset.GetCollationByName(tp.Collate)
+		if err != nil {
+			return nil, warns, errors.Trace(err)
+		}
+		tp.Charset = co.CharsetName
+	}
+	if tp.Charset != "" && tp.Collate == "" {
+		tp.Collate, err = charset.GetDefaultCollation(tp.Charset)
+		if err != nil {
+			return nil, warns, errors.Trace(err)
+		}
+	}
+	if tp.Charset != "" && !charset.ValidCharsetAndCollation(tp.Charset, tp.Collate) {
+		return nil, warns, charset.ErrCollationCharsetMismatch.GenWithStackByArgs(tp.Collate, tp.Charset)
+	}
+	return tp, warns, nil
+}
+
+// parse runs the parser on sql, startToken selects the start rule of the grammar,
+// statements are parsed if it is 0.
+func (parser *Parser) parse(sql, charset, collation string, startToken int) (warns []error, err error) {
 	sql = parser.lexer.tryDecodeToUTF8String(sql)
 	if charset == "" {
 		charset = mysql.DefaultCharset
@@ -151,9 +211,12 @@ func (parser *Parser) Parse(sql, charset, collation string) (stmt []ast.StmtNode
 	parser.collation = collation
 	parser.src = sql
 	parser.result = parser.result[:0]
+	parser.exprResult = nil
+	parser.fieldTypeResult = nil
 
 	var l yyLexer
 	parser.lexer.reset(sql)
+	parser.lexer.startToken = startToken
 	l = &parser.lexer
 	yyParse(l, parser)
 
@@ -164,12 +227,9 @@ func (parser *Parser) Parse(sql, charset, collation string) (stmt []ast.StmtNode
 		warns = nil
 	}
 	if len(errs) != 0 {
-		return nil, warns, errors.Trace(errs[0])
+		return warns, errors.Trace(errs[0])
 	}
-	for _, stmt := range parser.result {
-		ast.SetFlag(stmt)
-	}
-	return parser.result, warns, nil
+	return warns, nil
 }
 
 func (parser *Parser) lastErrorAsWarn() {